@@ -0,0 +1,209 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound   = errors.New("bingo: api key could not be found")
+	ErrInvalidAPIKey    = errors.New("bingo: api key is invalid or revoked")
+	ErrAPIKeyValidation = NewValErr("bingo: api key is not valid")
+)
+
+// Permission granted to an api key. Keys only give access to the routes requiring one of their scopes.
+type Scope string
+
+const (
+	ScopeGamesRead     Scope = "games:read"
+	ScopeGamesCall     Scope = "games:call"
+	ScopeCardsGenerate Scope = "cards:generate"
+)
+
+// All scopes an api key can be granted
+var Scopes = []Scope{
+	ScopeGamesRead,
+	ScopeGamesCall,
+	ScopeCardsGenerate,
+}
+
+// Prefix of every api key, which tells them apart from access tokens
+const APIKeyPrefix = "bb_"
+
+type APIKeyRepository interface {
+	Save(ctx context.Context, k *APIKey) error
+	Get(ctx context.Context, id string) (*APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	ListByUser(ctx context.Context, userId string) ([]APIKey, error)
+}
+
+type APIKeyService struct {
+	apiKeyRepo APIKeyRepository
+	userRepo   UserRepository
+}
+
+// Create a named api key with the given scopes for the user. The plain key is returned alongside the api key, and is
+// not possible to get again, as only the hash of it is stored.
+func (as *APIKeyService) Create(ctx context.Context, userId, name string, scopes []Scope) (*APIKey, string, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	key := APIKeyPrefix + secret
+
+	k := CreateAPIKey(userId, name, scopes, key)
+	if err := k.Validate(); err != nil {
+		return nil, "", err
+	}
+	if err := as.apiKeyRepo.Save(ctx, k); err != nil {
+		return nil, "", err
+	}
+
+	return k, key, nil
+}
+
+// List the api keys of the user, including revoked ones.
+func (as *APIKeyService) List(ctx context.Context, userId string) ([]APIKey, error) {
+	return as.apiKeyRepo.ListByUser(ctx, userId)
+}
+
+// Revoke the api key of the user, so it can no longer be used. Returns domain error if the user does not own the key.
+func (as *APIKeyService) Revoke(ctx context.Context, userId, id string) (*APIKey, error) {
+	k, err := as.apiKeyRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not reveal keys of other users
+	if k.UserID != userId {
+		return nil, ErrAPIKeyNotFound
+	}
+	if k.Revoked() {
+		return k, nil
+	}
+
+	k.RevokedAt = time.Now()
+	if err := as.apiKeyRepo.Save(ctx, k); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Find the api key and its user from the plain key. Returns domain error if the key is unknown or revoked.
+func (as *APIKeyService) Authenticate(ctx context.Context, key string) (*User, *APIKey, error) {
+	if !IsAPIKey(key) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	k, err := as.apiKeyRepo.GetByHash(ctx, hashToken(key))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if k.Revoked() {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	u, err := as.userRepo.Get(ctx, k.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u, k, nil
+}
+
+// Instantiate a new api key service with an api key repository and a user repository.
+func NewAPIKeyService(apiKeyRepo APIKeyRepository, userRepo UserRepository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// Named credential letting scripts and devices act on behalf of a user, limited to the granted scopes.
+type APIKey struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	Name   string `json:"name"`
+
+	// Beginning of the plain key, so users can recognize their keys
+	Hint string `json:"hint"`
+
+	// Hash of the plain key. Never exposed outside of the domain
+	Hash string `json:"-"`
+
+	Scopes []Scope `json:"scopes"`
+
+	// When the key was revoked. Zero if not revoked
+	RevokedAt time.Time `json:"revokedAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// See if api key object is valid
+func (k *APIKey) Validate() error {
+	if strings.TrimSpace(k.Name) == "" {
+		return ErrAPIKeyValidation.withFieldErr("Name", "empty", "name has to have a value")
+	}
+	if len(k.Scopes) == 0 {
+		return ErrAPIKeyValidation.withFieldErr("Scopes", "empty", "at least one scope has to be granted")
+	}
+	for _, s := range k.Scopes {
+		if !s.Valid() {
+			return ErrAPIKeyValidation.withFieldErr("Scopes", "oneof", "scope %s does not exist", s)
+		}
+	}
+
+	return nil
+}
+
+// Reports whether the key has been granted the scope
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (k *APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// Reports whether the scope is one of the known scopes
+func (s Scope) Valid() bool {
+	for _, known := range Scopes {
+		if s == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reports whether the token looks like an api key rather than an access token
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// API key constructor. Only the hash of the plain key is kept.
+func CreateAPIKey(userId, name string, scopes []Scope, key string) *APIKey {
+	hint := key
+	if len(hint) > len(APIKeyPrefix)+4 {
+		hint = hint[:len(APIKeyPrefix)+4]
+	}
+
+	return &APIKey{
+		UserID:    userId,
+		Name:      strings.TrimSpace(name),
+		Hint:      hint,
+		Hash:      hashToken(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+}
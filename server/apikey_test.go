@@ -0,0 +1,219 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_Create(t *testing.T) {
+	u := MustMakeTestUser(t)
+
+	cases := []struct {
+		caseName     string
+		name         string
+		scopes       []bingo.Scope
+		expectValErr bool
+	}{
+		{
+			caseName: "success",
+			name:     "scoreboard",
+			scopes:   []bingo.Scope{bingo.ScopeGamesRead},
+		},
+		{
+			caseName:     "missing name",
+			name:         " ",
+			scopes:       []bingo.Scope{bingo.ScopeGamesRead},
+			expectValErr: true,
+		},
+		{
+			caseName:     "no scopes",
+			name:         "scoreboard",
+			expectValErr: true,
+		},
+		{
+			caseName:     "unknown scope",
+			name:         "scoreboard",
+			scopes:       []bingo.Scope{"games:delete"},
+			expectValErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			apiKeySvc, mocks := MustCreateAPIKeyService(t)
+			defer mocks.apiKeyRepo.RequireExpectationsMet()
+
+			var saved *bingo.APIKey
+			if !tc.expectValErr {
+				mocks.apiKeyRepo.ExpectSave(func(_ context.Context, k *bingo.APIKey) error {
+					k.ID = requiretest.UUIDv4(t)
+					saved = k
+					return nil
+				})
+			}
+
+			k, key, err := apiKeySvc.Create(context.Background(), u.ID, tc.name, tc.scopes)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				require.Nil(t, k, "api key must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.True(t, strings.HasPrefix(key, bingo.APIKeyPrefix), "plain key must carry api key prefix")
+			require.True(t, strings.HasPrefix(key, k.Hint), "hint must be the beginning of the plain key")
+			require.Equal(t, HashTestToken(key), saved.Hash, "only the hash of the key must be persisted")
+			require.Equal(t, u.ID, k.UserID, "api key must belong to user")
+		})
+	}
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	u := MustMakeTestUser(t)
+	k := &bingo.APIKey{
+		ID:        requiretest.UUIDv4(t),
+		UserID:    u.ID,
+		Name:      "scoreboard",
+		Scopes:    []bingo.Scope{bingo.ScopeGamesRead},
+		CreatedAt: time.Now(),
+	}
+
+	cases := []struct {
+		caseName    string
+		userId      string
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName:   "success",
+			userId:     u.ID,
+			expectSave: true,
+		},
+		{
+			caseName:    "key of other user",
+			userId:      requiretest.UUIDv4(t),
+			expectedErr: bingo.ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			apiKeySvc, mocks := MustCreateAPIKeyService(t)
+			defer mocks.apiKeyRepo.RequireExpectationsMet()
+
+			stored := *k
+			mocks.apiKeyRepo.ExpectGet(func(_ context.Context, _ string) (*bingo.APIKey, error) { return &stored, nil })
+			if tc.expectSave {
+				mocks.apiKeyRepo.ExpectSave(func(_ context.Context, _ *bingo.APIKey) error { return nil })
+			}
+
+			rk, err := apiKeySvc.Revoke(context.Background(), tc.userId, k.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.True(t, rk.Revoked(), "api key must be revoked")
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	u := MustMakeTestUser(t)
+	key := bingo.APIKeyPrefix + "secret"
+	activeKey := bingo.CreateAPIKey(u.ID, "scoreboard", []bingo.Scope{bingo.ScopeGamesRead}, key)
+	revokedKey := *activeKey
+	revokedKey.RevokedAt = time.Now()
+
+	cases := []struct {
+		caseName    string
+		key         string
+		stored      *bingo.APIKey
+		expectGet   bool
+		expectedErr error
+	}{
+		{
+			caseName:  "success",
+			key:       key,
+			stored:    activeKey,
+			expectGet: true,
+		},
+		{
+			caseName:    "revoked",
+			key:         key,
+			stored:      &revokedKey,
+			expectedErr: bingo.ErrInvalidAPIKey,
+		},
+		{
+			caseName:    "unknown",
+			key:         key,
+			expectedErr: bingo.ErrInvalidAPIKey,
+		},
+		{
+			caseName:    "not an api key",
+			key:         "access token",
+			expectedErr: bingo.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			apiKeySvc, mocks := MustCreateAPIKeyService(t)
+			defer mocks.apiKeyRepo.RequireExpectationsMet()
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			if bingo.IsAPIKey(tc.key) {
+				mocks.apiKeyRepo.ExpectGetByHash(func(_ context.Context, hash string) (*bingo.APIKey, error) {
+					if tc.stored == nil || tc.stored.Hash != hash {
+						return nil, bingo.ErrAPIKeyNotFound
+					}
+					return tc.stored, nil
+				})
+			}
+			if tc.expectGet {
+				mocks.userRepo.ExpectGet(MakeSingleUserGetHandler(t, *u))
+			}
+
+			au, ak, err := apiKeySvc.Authenticate(context.Background(), tc.key)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, au, "user must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, u.ID, au.ID, "user must be the owner of the key")
+			require.True(t, ak.HasScope(bingo.ScopeGamesRead), "api key must keep its scopes")
+		})
+	}
+}
+
+type apiKeyServiceMocks struct {
+	apiKeyRepo *mock.APIKeyRepository
+	userRepo   *mock.UserRepository
+}
+
+func MustCreateAPIKeyService(tb testing.TB) (*bingo.APIKeyService, *apiKeyServiceMocks) {
+	tb.Helper()
+
+	apiKeyRepo := mock.NewAPIKeyRepository(tb)
+	userRepo := mock.NewUserRepository(tb)
+
+	apiKeySvc := bingo.NewAPIKeyService(apiKeyRepo, userRepo)
+	mocks := &apiKeyServiceMocks{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+
+	return apiKeySvc, mocks
+}
@@ -0,0 +1,150 @@
+package argon2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ErrUnknownHashFormat = errors.New("argon2: hash is not in a known format")
+	ErrMalformedHash     = errors.New("argon2: hash is malformed")
+)
+
+const prefix = "$argon2id$"
+
+// Tunable argon2id parameters. Memory is in KiB.
+type Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Parameters following the recommendations of RFC 9106 for memory constrained environments
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hashes passwords with argon2id. The hashes are encoded in the PHC string format, recording the algorithm and the
+// parameters used, e.g. $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+type Hasher struct {
+	params Params
+
+	// Hasher used for comparing hashes not made by argon2id, e.g. bcrypt hashes from before argon2id was introduced
+	Legacy bingo.Hasher
+}
+
+// Try to hash the given password.
+func (h *Hasher) Hash(passwd string) ([]byte, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(passwd), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return encode(h.params, salt, key), nil
+}
+
+// Try to match the given password against the hash. Returns domain error if passwords does not match
+func (h *Hasher) Compare(hash []byte, passwd string) error {
+	if !bytes.HasPrefix(hash, []byte(prefix)) {
+		if h.Legacy == nil {
+			return ErrUnknownHashFormat
+		}
+
+		return h.Legacy.Compare(hash, passwd)
+	}
+
+	p, salt, key, err := decode(hash)
+	if err != nil {
+		return err
+	}
+
+	// Derive key with the parameters recorded in the hash, and compare in constant time
+	other := argon2.IDKey([]byte(passwd), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return bingo.ErrPasswordMismatch
+	}
+
+	return nil
+}
+
+// Reports whether the hash is not an argon2id hash, or was made with weaker parameters than the hasher uses
+func (h *Hasher) NeedsRehash(hash []byte) bool {
+	p, _, _, err := decode(hash)
+	if err != nil {
+		return true
+	}
+
+	return p.Memory < h.params.Memory ||
+		p.Iterations < h.params.Iterations ||
+		p.Parallelism < h.params.Parallelism ||
+		p.SaltLength < h.params.SaltLength ||
+		p.KeyLength < h.params.KeyLength
+}
+
+func encode(p Params, salt, key []byte) []byte {
+	return []byte(fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		prefix,
+		argon2.Version,
+		p.Memory,
+		p.Iterations,
+		p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	))
+}
+
+func decode(hash []byte) (p Params, salt, key []byte, err error) {
+	if !bytes.HasPrefix(hash, []byte(prefix)) {
+		return Params{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	// Expected parts after prefix: version, params, salt and key
+	parts := strings.Split(string(hash[len(prefix):]), "$")
+	if len(parts) != 4 {
+		return Params{}, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, ErrMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return Params{}, nil, nil, ErrMalformedHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Params{}, nil, nil, ErrMalformedHash
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return Params{}, nil, nil, ErrMalformedHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
+
+func NewHasher(params Params) *Hasher {
+	return &Hasher{
+		params: params,
+	}
+}
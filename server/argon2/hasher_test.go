@@ -0,0 +1,100 @@
+package argon2_test
+
+import (
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/argon2"
+	"github.com/nohns/bingo-box/server/bcrypt"
+	"github.com/stretchr/testify/require"
+	gobcrypt "golang.org/x/crypto/bcrypt"
+)
+
+var implementsHasher bingo.Hasher = &argon2.Hasher{}
+
+// Cheap parameters to keep the tests fast
+var testParams = argon2.Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestHasher_Compare(t *testing.T) {
+	h := argon2.NewHasher(testParams)
+	h.Legacy = bcrypt.NewHasher()
+
+	hash, err := h.Hash("correct password")
+	require.NoError(t, err, "expected no error from hashing")
+	require.Contains(t, string(hash), "$argon2id$v=19$m=1024,t=1,p=1$", "hash must record algorithm and parameters")
+
+	legacyHash, err := gobcrypt.GenerateFromPassword([]byte("correct password"), gobcrypt.MinCost)
+	require.NoError(t, err, "expected no error from bcrypt hashing")
+
+	cases := []struct {
+		cn            string
+		hash          []byte
+		passwd        string
+		expectedErrIs error
+	}{
+		{
+			cn:     "success",
+			hash:   hash,
+			passwd: "correct password",
+		},
+		{
+			cn:            "fail mismatch",
+			hash:          hash,
+			passwd:        "wrong password",
+			expectedErrIs: bingo.ErrPasswordMismatch,
+		},
+		{
+			cn:     "success legacy bcrypt",
+			hash:   legacyHash,
+			passwd: "correct password",
+		},
+		{
+			cn:            "fail mismatch legacy bcrypt",
+			hash:          legacyHash,
+			passwd:        "wrong password",
+			expectedErrIs: bingo.ErrPasswordMismatch,
+		},
+		{
+			cn:            "fail malformed",
+			hash:          []byte("$argon2id$v=19$m=1024"),
+			passwd:        "correct password",
+			expectedErrIs: argon2.ErrMalformedHash,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			err := h.Compare(c.hash, c.passwd)
+			if c.expectedErrIs != nil {
+				require.ErrorIs(t, err, c.expectedErrIs, "expected different error")
+			} else {
+				require.NoError(t, err, "expected no error")
+			}
+		})
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	h := argon2.NewHasher(testParams)
+
+	weakerParams := testParams
+	weakerParams.Memory = testParams.Memory / 2
+	weakHash, err := argon2.NewHasher(weakerParams).Hash("password")
+	require.NoError(t, err, "expected no error from hashing")
+
+	currentHash, err := h.Hash("password")
+	require.NoError(t, err, "expected no error from hashing")
+
+	legacyHash, err := gobcrypt.GenerateFromPassword([]byte("password"), gobcrypt.MinCost)
+	require.NoError(t, err, "expected no error from bcrypt hashing")
+
+	require.False(t, h.NeedsRehash(currentHash), "hash with current parameters must not need rehash")
+	require.True(t, h.NeedsRehash(weakHash), "hash with weaker parameters must need rehash")
+	require.True(t, h.NeedsRehash(legacyHash), "bcrypt hash must need rehash")
+}
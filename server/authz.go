@@ -0,0 +1,65 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrForbidden = errors.New("bingo: actor is not allowed to access the resource")
+)
+
+// Authorizes actors acting on games. The role of the actor is either given by the game itself, or by the organization
+// owning the game.
+type gameAuthorizer struct {
+	orgRepo OrganizationRepository
+}
+
+// Authorize that the actor, i.e. the authenticated user of the context, has a role in the game granting the
+// permission. Returns domain error if no actor is present or the actor is not allowed.
+func (ga gameAuthorizer) authorize(ctx context.Context, g *Game, perm Permission) error {
+	actor := UserFromContext(ctx)
+	if actor == nil || g == nil {
+		return ErrForbidden
+	}
+
+	if role, ok := g.RoleOf(actor.ID); ok && role.Can(perm) {
+		return nil
+	}
+
+	// Members of the organization owning the game have a role in it as well
+	if g.OrganizationID == "" {
+		return ErrForbidden
+	}
+	org, err := ga.orgRepo.Get(ctx, g.OrganizationID)
+	if errors.Is(err, ErrOrganizationNotFound) {
+		return ErrForbidden
+	} else if err != nil {
+		return err
+	}
+	if orgRole, ok := org.RoleOf(actor.ID); ok && orgRole.GameRole().Can(perm) {
+		return nil
+	}
+
+	return ErrForbidden
+}
+
+// Authorize that the actor is member of the organization. Returns domain error if not.
+func (ga gameAuthorizer) authorizeOrganization(ctx context.Context, orgId string) error {
+	actor := UserFromContext(ctx)
+	if actor == nil {
+		return ErrForbidden
+	}
+
+	org, err := ga.orgRepo.Get(ctx, orgId)
+	if errors.Is(err, ErrOrganizationNotFound) {
+		return ErrForbidden
+	} else if err != nil {
+		return err
+	}
+	if _, ok := org.RoleOf(actor.ID); !ok {
+		return ErrForbidden
+	}
+
+	return nil
+}
@@ -35,6 +35,16 @@ func (h *Hasher) Compare(hash []byte, passwd string) error {
 	return nil
 }
 
+// Reports whether the hash is not a bcrypt hash or was made with a lower cost than the default
+func (h *Hasher) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return true
+	}
+
+	return cost < bcrypt.DefaultCost
+}
+
 func NewHasher() *Hasher {
 	return &Hasher{}
 }
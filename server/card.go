@@ -5,16 +5,33 @@ import (
 	"errors"
 	"math/rand"
 	"sort"
+	"strings"
+	"time"
 )
 
 var (
 	ErrCardNumberExists = errors.New("game: card number already exists in game")
+	ErrCardNotFound     = errors.New("game: card could not be found")
+	ErrCardAssigned     = errors.New("game: card has already been assigned to a player")
+
+	ErrCardRevocationValidation = NewValErr("bingo: card revocation validation failed")
 )
 
 type CardRepository interface {
 	Save(ctx context.Context, card *Card) error
 	SaveAll(ctx context.Context, cards []Card) error
 	GetByNumber(ctx context.Context, cardNum int, gameId string) (*Card, error)
+
+	// Find cards of the game by their numbers, ordered by number. Numbers no card of the game has are left out.
+	FindByNumbers(ctx context.Context, gameId string, numbers []int) ([]Card, error)
+
+	// Assign cards of the game by their numbers to the player atomically, if they are neither assigned nor void.
+	// Returns ErrCardAssigned if any of them could not be assigned, e.g. when sold concurrently, in which case the
+	// transaction it is run within must be aborted.
+	Assign(ctx context.Context, gameId string, numbers []int, playerId string) error
+
+	// Count the cards of the game assigned to no player, which are not void
+	CountUnassigned(ctx context.Context, gameId string) (int, error)
 }
 
 // Card entity / root aggregate for card related data
@@ -30,12 +47,53 @@ type Card struct {
 	Player   *Player `json:"player,omitempty"`
 
 	GridNumbers []CardGridNumber `json:"gridNumbers"`
+
+	// Revocation voiding the card, if it has been revoked. Void cards can not win
+	Revocation *CardRevocation `json:"revocation,omitempty"`
 }
 
 func (c *Card) Validate() error {
 	return nil
 }
 
+// Whether the card has been revoked, and is void
+func (c *Card) Void() bool {
+	return c.Revocation != nil
+}
+
+// Who revoked a card, when and why
+type CardRevocation struct {
+	// User who revoked the card, or empty if the player withdrew from the game themselves
+	RevokedBy string    `json:"revokedBy,omitempty"`
+	Reason    string    `json:"reason"`
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+// Max length of the reason cards are revoked for
+const CardRevocationReasonMaxLength = 500
+
+func (r *CardRevocation) Validate() error {
+	if len(r.Reason) > CardRevocationReasonMaxLength {
+		return ErrCardRevocationValidation.withFieldErr("Reason", "max", "reason can at most be %d characters long", CardRevocationReasonMaxLength)
+	}
+
+	return nil
+}
+
+// Create revocation of cards by the actor of the context for the reason. The actor is empty if players withdraw
+// themselves.
+func NewCardRevocation(ctx context.Context, reason string) CardRevocation {
+	r := CardRevocation{
+		Reason:    strings.TrimSpace(reason),
+		RevokedAt: time.Now(),
+	}
+	if actor := UserFromContext(ctx); actor != nil {
+		r.RevokedBy = actor.ID
+	}
+
+	return r
+}
+
 type CardMatrix [3][9]int
 
 func (c *Card) Matrix() CardMatrix {
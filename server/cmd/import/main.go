@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/config"
+	"github.com/nohns/bingo-box/server/csv"
+	"github.com/nohns/bingo-box/server/jwt"
+	"github.com/nohns/bingo-box/server/mongo"
+)
+
+// Entrypoint for the import binary, which imports players to an invitation from a csv file, e.g. the attendees of a
+// company party. Players are imported as the host of the game, by the same rules as imports over HTTP.
+func main() {
+
+	// Flags of the import are defined on the default flag set, as config.Read calls flag.Parse() for the flags of the app
+	// configuration. They must therefore be defined before the configuration is read, and are not set until it has been
+	invId := flag.String("invitation", "", "Id of the invitation players are imported to")
+	file := flag.String("file", "", "Path of the csv file with the players. The header must name the columns name and email, and optionally cards and language")
+	deliver := flag.Bool("deliver", false, "Mail the cards to the players imported")
+	as := flag.String("as", "", "Email of the user the players are imported as, who must be allowed to manage the game")
+
+	// Read app configuration
+	conf, err := config.Read()
+	if err != nil {
+		fmt.Printf("%v\n\nfailed to run import due to bad configuration\n", err)
+		os.Exit(1)
+	}
+	if *invId == "" || *file == "" || *as == "" {
+		fmt.Println("the flags --invitation, --file and --as are required")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		logFatal("could not open csv file", err)
+	}
+	rows, err := csv.ParsePlayers(f)
+	f.Close()
+	if err != nil {
+		logFatal("could not parse players of csv file", err)
+	}
+
+	ctx := context.Background()
+	invSvc, userRepo, err := bootstrap(ctx, conf)
+	if err != nil {
+		logFatal("could not bootstrap import", err)
+	}
+
+	// Import as the user, so they are authorized as they would be over HTTP
+	u, err := userRepo.GetByEmail(ctx, bingo.NormalizeEmail(*as))
+	if err != nil {
+		logFatal("could not get user to import as", err)
+	}
+	report, err := invSvc.ImportPlayers(bingo.NewContextWithUser(ctx, u), *invId, rows, *deliver)
+	if err != nil {
+		logFatal("could not import players", err)
+	}
+
+	printReport(report)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// Setup the invitation service with its mongodb dependencies
+func bootstrap(ctx context.Context, conf config.Conf) (*bingo.InvitationService, bingo.UserRepository, error) {
+	mongoCtx, mongoCancel := context.WithTimeout(ctx, 5*time.Second)
+	db, err := mongo.New(mongoCtx, conf.ConnURI())
+	mongoCancel()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repos := bingo.Repositories{
+		Users:         mongo.NewUserRepository(db),
+		Organizations: mongo.NewOrganizationRepository(db),
+		Games:         mongo.NewGameRepository(db),
+		Cards:         mongo.NewCardRepository(db),
+		Invitations:   mongo.NewInvitationRepository(db),
+		Players:       mongo.NewPlayerRepository(db),
+		Credits:       mongo.NewCreditRepository(db),
+		Outbox:        mongo.NewOutboxRepository(db),
+	}
+	invSvc := bingo.NewInvitationService(repos, jwt.NewConfirmationSigner(conf.HTTP.JWTSecret), mongo.NewTransactor(db))
+
+	return invSvc, repos.Users, nil
+}
+
+// Print the result of every row, followed by the totals
+func printReport(report *bingo.PlayerImportReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tEMAIL\tRESULT")
+	for _, res := range report.Rows {
+		result := "imported"
+		switch {
+		case res.Err != nil:
+			result = strings.ReplaceAll(strings.TrimSpace(res.Err.Error()), "\n", " ")
+		case res.Player.Status == bingo.PlayerStatusWaitlisted:
+			result = "waitlisted"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", res.Line, res.Email, result)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d imported, %d waitlisted, %d failed\n", report.Imported, report.Waitlisted, report.Failed)
+}
+
+// Log a fatal error to stderr
+func logFatal(msg string, err error) {
+	log.Fatalf("main: %s: \n%v\n\n", msg, err)
+}
@@ -7,26 +7,49 @@ import (
 	"time"
 
 	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/argon2"
 	"github.com/nohns/bingo-box/server/bcrypt"
 	"github.com/nohns/bingo-box/server/config"
 	"github.com/nohns/bingo-box/server/http"
+	"github.com/nohns/bingo-box/server/jwt"
+	"github.com/nohns/bingo-box/server/kratos"
 	"github.com/nohns/bingo-box/server/logger"
 	"github.com/nohns/bingo-box/server/mail"
 	"github.com/nohns/bingo-box/server/mongo"
+	"github.com/nohns/bingo-box/server/pdf"
 )
 
+// Interval the outbox is polled for messages that are due
+const outboxPollInterval = 15 * time.Second
+
 type App struct {
-	Log        logger.Logger
-	HTTPServer *http.Server
-	Conf       config.Conf
-	Mailer     *mail.Mailer
+	Log           logger.Logger
+	HTTPServer    *http.Server
+	Conf          config.Conf
+	Mailer        bingo.Mailer
+	OutboxService *bingo.OutboxService
+
+	// Stops the outbox worker
+	stopOutbox context.CancelFunc
 }
 
 // Boostrap the server application
 func (a *App) Bootstrap(ctx context.Context) error {
 
-	// Setup bcrypt hasher
-	hasher := bcrypt.NewHasher()
+	// Setup argon2id hasher. Passwords hashed with bcrypt before argon2id was introduced can still be compared,
+	// and are rehashed on login
+	hashParams := argon2.DefaultParams
+	hashParams.Memory = uint32(a.Conf.Hash.Argon2Memory)
+	hashParams.Iterations = uint32(a.Conf.Hash.Argon2Iterations)
+	hashParams.Parallelism = uint8(a.Conf.Hash.Argon2Parallelism)
+	hasher := argon2.NewHasher(hashParams)
+	hasher.Legacy = bcrypt.NewHasher()
+
+	// Setup access, confirmation, member invitation and player session token signers
+	signer := jwt.NewSigner(a.Conf.HTTP.JWTSecret)
+	confirmations := jwt.NewConfirmationSigner(a.Conf.HTTP.JWTSecret)
+	memberInvitations := jwt.NewMemberInvitationSigner(a.Conf.HTTP.JWTSecret)
+	playerSessions := jwt.NewPlayerSessionSigner(a.Conf.HTTP.JWTSecret)
 
 	// Setup mongodb dependency
 	mongoCtx, mongoCancel := context.WithTimeout(ctx, 5*time.Second)
@@ -36,8 +59,11 @@ func (a *App) Bootstrap(ctx context.Context) error {
 		return err
 	}
 
-	a.Mailer = mail.NewMailer(a.Conf.Mail.MGDomain, a.Conf.Mail.MGAPIKey)
-	a.Mailer.BaseDownloadLink = a.Conf.Mail.DLLinkBase
+	a.Mailer = newMailer(a.Conf)
+	mailTemplates, err := mail.NewTemplates()
+	if err != nil {
+		return err
+	}
 
 	// Setup repos dependencies
 	userRepo := mongo.NewUserRepository(db)
@@ -45,34 +71,114 @@ func (a *App) Bootstrap(ctx context.Context) error {
 	playerRepo := mongo.NewPlayerRepository(db)
 	gameRepo := mongo.NewGameRepository(db)
 	cardRepo := mongo.NewCardRepository(db)
+	refreshTokenRepo := mongo.NewRefreshTokenRepository(db)
+	apiKeyRepo := mongo.NewAPIKeyRepository(db)
+	orgRepo := mongo.NewOrganizationRepository(db)
+	creditRepo := mongo.NewCreditRepository(db)
+	outboxRepo := mongo.NewOutboxRepository(db)
+	tx := mongo.NewTransactor(db)
+
+	repos := bingo.Repositories{
+		Users:         userRepo,
+		Organizations: orgRepo,
+		Games:         gameRepo,
+		Cards:         cardRepo,
+		Invitations:   invRepo,
+		Players:       playerRepo,
+		Credits:       creditRepo,
+		Outbox:        outboxRepo,
+	}
 
 	// Setup domain services
 	userSvc := bingo.NewUserService(userRepo, hasher)
-	gameSvc := bingo.NewGameService(gameRepo, cardRepo)
-	invSvc := bingo.NewInvitationService(invRepo, playerRepo)
-	playerSvc := bingo.NewPlayerService(playerRepo)
+	tokenSvc := bingo.NewTokenService(userRepo, refreshTokenRepo, signer)
+	apiKeySvc := bingo.NewAPIKeyService(apiKeyRepo, userRepo)
+	orgSvc := bingo.NewOrganizationService(orgRepo, userRepo)
+	creditSvc := bingo.NewCreditService(repos, tx)
+	gameSvc := bingo.NewGameService(repos, memberInvitations, tx)
+	invSvc := bingo.NewInvitationService(repos, confirmations, tx)
+	playerSvc := bingo.NewPlayerService(repos, playerSessions, tx)
+	a.OutboxService = bingo.NewOutboxService(repos, pdf.Renderer{}, mailTemplates, a.Mailer, confirmations, playerSessions, memberInvitations, a.Conf.Mail.DLLinkBase)
 
 	// Setup HTTP rest server
-	a.HTTPServer = http.NewServer(a.Conf.HTTP.APIKey)
+	a.HTTPServer = http.NewServer()
 	a.HTTPServer.UserService = userSvc
+	a.HTTPServer.TokenService = tokenSvc
+	a.HTTPServer.APIKeyService = apiKeySvc
+	a.HTTPServer.OrganizationService = orgSvc
+	a.HTTPServer.CreditService = creditSvc
 	a.HTTPServer.GameService = gameSvc
 	a.HTTPServer.InvitationService = invSvc
 	a.HTTPServer.PlayerService = playerSvc
+	a.HTTPServer.OutboxService = a.OutboxService
+
+	// Accept Kratos sessions if configured
+	if url := a.Conf.Auth.KratosPublicURL; url != "" {
+		a.HTTPServer.SessionVerifier = kratos.NewClient(url)
+	}
 
+	a.HTTPServer.HookSecret = a.Conf.Auth.HookSecret
+	a.HTTPServer.LinkBase = a.Conf.Mail.DLLinkBase
 	a.HTTPServer.Addr = a.Conf.HTTPListenAddr()
 	a.HTTPServer.Log = a.Log
 
 	return nil
 }
 
-// Serve the applicaion. Atm. it is only the http server
+// Create mailer of the configured provider
+func newMailer(conf config.Conf) bingo.Mailer {
+	switch conf.Mail.Provider {
+	case "smtp":
+		m := mail.NewSMTP(conf.Mail.SMTPHost, conf.Mail.SMTPPort, mail.TLSMode(conf.Mail.SMTPTLS))
+		m.Username = conf.Mail.SMTPUser
+		m.Password = conf.Mail.SMTPPass
+		m.InsecureSkipVerify = conf.Mail.SMTPSkipVerify
+		m.From = conf.Mail.From
+		return m
+	case "file":
+		m := mail.NewFile(conf.Mail.FileDir)
+		m.From = conf.Mail.From
+		return m
+	default:
+		m := mail.NewMailgun(conf.Mail.MGDomain, conf.Mail.MGAPIKey)
+		m.From = conf.Mail.From
+		return m
+	}
+}
+
+// Serve the applicaion. That is the http server, and the outbox worker delivering mails in the background
 func (a *App) Run(errChan chan<- error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopOutbox = cancel
+	go a.runOutboxWorker(ctx)
+
 	a.Log.Infof("Now serving http request on address %s...\n", a.HTTPServer.Addr)
 	errChan <- a.HTTPServer.Serve()
 }
 
+// Deliver due outbox messages every poll interval until the context is cancelled
+func (a *App) runOutboxWorker(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.OutboxService.DeliverDue(ctx); err != nil {
+				a.Log.Errf("could not deliver due outbox messages due to error:\n%v\n", err)
+			}
+		}
+	}
+}
+
 // Runs when server application closes
 func (a *App) Close() error {
+	if a.stopOutbox != nil {
+		a.stopOutbox()
+	}
+
 	fmt.Fprint(os.Stdout, "\n")
 	a.Log.Info("App terminated. Goodbye.")
 
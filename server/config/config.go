@@ -12,6 +12,8 @@ type Conf struct {
 	DB   dbConf   `conf:"db" validate:"required"`
 	HTTP httpConf `conf:"http" validate:"required"`
 	Mail mailConf `conf:"mail" validate:"required"`
+	Hash hashConf `conf:"hash" validate:"required"`
+	Auth authConf `conf:"auth"`
 }
 
 type dbConf struct {
@@ -32,15 +34,41 @@ type httpConf struct {
 	Address   string `validate:"required" help:"Address for HTTP server to listen on"`
 	Port      string `validate:"required" help:"Port for HTTP server to listen on"`
 	JWTSecret string `conf:"jwt secret" validate:"required" help:"JWT secret used for signing access tokens"`
-	APIKey    string `conf:"api key" validate:"required" help:"(deprecated) API key used for remote autorized access"`
 }
 
 type mailConf struct {
-	DLLinkBase string `conf:"dl link base" validate:"required" help:""`
+	DLLinkBase string `conf:"dl link base" validate:"required" help:"Base url of the web app, which links in mails and QR codes point to"`
+
+	Provider string `validate:"oneof=mailgun smtp file" help:"Provider mails are sent with. Either mailgun, smtp or file"`
+	From     string `validate:"required" help:"Sender of mails, e.g. 'Bingo Box <info@bingobox.io>'"`
 
 	// Mail gun credentials
-	MGDomain string `conf:"mg domain" validate:"required"`
-	MGAPIKey string `conf:"mg api key" validate:"required"`
+	MGDomain string `conf:"mg domain" validate:"required_if=Provider mailgun"`
+	MGAPIKey string `conf:"mg api key" validate:"required_if=Provider mailgun"`
+
+	// SMTP server
+	SMTPHost       string `conf:"smtp host" validate:"required_if=Provider smtp" help:"Host of the SMTP server"`
+	SMTPPort       int    `conf:"smtp port" validate:"min=1,max=65535" help:"Port of the SMTP server"`
+	SMTPUser       string `conf:"smtp user" help:"Username to authenticate with. No authentication when empty"`
+	SMTPPass       string `conf:"smtp pass" help:"Password to authenticate with"`
+	SMTPTLS        string `conf:"smtp tls" validate:"oneof=starttls implicit none" help:"How the connection is secured. Either starttls, implicit or none"`
+	SMTPSkipVerify bool   `conf:"smtp skip verify" help:"Accept any certificate of the SMTP server, e.g. a self-signed one of a local mail sink"`
+
+	// Directory .eml files are written to by the file provider
+	FileDir string `conf:"file dir" validate:"required_if=Provider file" help:"Directory mails are written to as .eml files"`
+}
+
+type authConf struct {
+	// Ory Kratos
+	KratosPublicURL string `conf:"kratos public url" help:"Base url of the public Kratos API. Kratos sessions are accepted when set"`
+	HookSecret      string `conf:"hook secret" help:"Shared secret Kratos webhook calls must carry in the X-Hook-Secret header"`
+}
+
+type hashConf struct {
+	// Argon2id password hashing parameters
+	Argon2Memory      int `conf:"argon2 memory" validate:"min=1024" help:"Memory in KiB used when hashing passwords with argon2id"`
+	Argon2Iterations  int `conf:"argon2 iterations" validate:"min=1" help:"Number of passes over memory when hashing passwords with argon2id"`
+	Argon2Parallelism int `conf:"argon2 parallelism" validate:"min=1,max=255" help:"Number of threads used when hashing passwords with argon2id"`
 }
 
 func (c Conf) Validate(confNamespaces map[string]string) error {
@@ -58,7 +86,7 @@ func (c Conf) Validate(confNamespaces map[string]string) error {
 		for _, err := range errs {
 			confField := confNamespaces[err.StructNamespace()]
 			switch err.Tag() {
-			case "required":
+			case "required", "required_if":
 				valErr.empty(confField)
 			default:
 				valErr.invalid(confField, "validation with tag '%s' failed", err.Tag())
@@ -149,6 +177,17 @@ func Read() (Conf, error) {
 			Address: "0.0.0.0",
 			Port:    "5001",
 		},
+		Mail: mailConf{
+			Provider: "mailgun",
+			From:     "Bingo Box <info@bingobox.io>",
+			SMTPPort: 587,
+			SMTPTLS:  "starttls",
+		},
+		Hash: hashConf{
+			Argon2Memory:      64 * 1024,
+			Argon2Iterations:  3,
+			Argon2Parallelism: 2,
+		},
 	}
 
 	// Go from least to most specific source. E.g file config is overridden by env, and env is overridden by flags
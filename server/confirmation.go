@@ -0,0 +1,106 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidConfirmationToken = errors.New("bingo: confirmation token is invalid or expired")
+)
+
+// Time players joining invitations requiring confirmation have to confirm their email, before they expire
+const PlayerConfirmationLifetime = 48 * time.Hour
+
+// Signs and verifies the tokens of the links players confirm their email with.
+type ConfirmationTokenSigner interface {
+	Sign(claims ConfirmationClaims) (string, error)
+	Verify(token string) (*ConfirmationClaims, error)
+}
+
+type ConfirmationClaims struct {
+	PlayerID string
+	Email    string
+
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Confirm the email of a player who joined an invitation requiring confirmation, by the token of the link mailed to
+// them. The player then joins as if they had just joined an invitation without, and is waitlisted if it is full.
+// Confirming again once joined returns the player as is, so clicking the link twice is harmless.
+func (is *InvitationService) Confirm(ctx context.Context, token string) (*Player, error) {
+	claims, err := is.confirmations.Verify(token)
+	if err != nil {
+		return nil, ErrInvalidConfirmationToken
+	}
+	now := time.Now()
+	if claims.ExpiresAt.Before(now) {
+		return nil, ErrInvalidConfirmationToken
+	}
+
+	// Players who do not confirm in time are deleted, which leaves their tokens invalid too
+	p, err := is.playerRepo.Get(ctx, claims.PlayerID)
+	if errors.Is(err, ErrPlayerNotFound) {
+		return nil, ErrInvalidConfirmationToken
+	} else if err != nil {
+		return nil, err
+	}
+	if p.Email != claims.Email {
+		return nil, ErrInvalidConfirmationToken
+	}
+
+	inv, err := is.invRepo.Get(ctx, p.InvitationID)
+	if err != nil {
+		return nil, err
+	}
+	if status := inv.StatusAt(now); status != InvitationStatusFull {
+		if err := status.err(); err != nil {
+			return nil, err
+		}
+	}
+	// The player is read again within the transaction, so they are only joined once if confirmed concurrently
+	var g *Game
+	err = is.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := is.playerRepo.Get(ctx, claims.PlayerID)
+		if err != nil {
+			return err
+		}
+		g, err = is.gameRepo.Get(ctx, inv.GameID)
+		if err != nil {
+			return err
+		}
+		p = current
+		switch {
+		case p.Status == PlayerStatusRemoved:
+			return ErrPlayerRemoved
+		case p.Status != PlayerStatusUnconfirmed:
+			return nil
+		case p.confirmationExpired(now):
+			return ErrInvalidConfirmationToken
+		}
+
+		err = is.invRepo.Reserve(ctx, inv, p.RequestedCards)
+		if errors.Is(err, ErrInvitationFull) {
+			position, err := is.invRepo.Waitlist(ctx, inv)
+			if err != nil {
+				return err
+			}
+			p.waitlist(position, p.RequestedCards)
+			return is.playerRepo.Save(ctx, p)
+		}
+		if err != nil {
+			return err
+		}
+
+		return is.roster.admit(ctx, inv, g, p, p.RequestedCards, inv.mailsCards())
+	})
+	if err != nil {
+		return nil, err
+	}
+	inv.Game = g
+	p.Invitation = inv
+
+	return p, nil
+}
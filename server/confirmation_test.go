@@ -0,0 +1,314 @@
+package bingo_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitationService_JoinRequiringConfirmation(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.RequireConfirmation = true
+
+	cases := []struct {
+		caseName      string
+		existing      *bingo.Player
+		cardAmount    int
+		expectedCards int
+	}{
+		{
+			caseName:      "new player awaits confirmation",
+			cardAmount:    2,
+			expectedCards: 2,
+		},
+		{
+			caseName:      "joining again before confirming adds cards",
+			existing:      MustMakeUnconfirmedPlayer(t, testInv, 1, time.Now().Add(time.Hour)),
+			cardAmount:    2,
+			expectedCards: 3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			mocks.playerRepo.ExpectGetByEmail(func(_ context.Context, _ string, _ string) (*bingo.Player, error) {
+				if tc.existing == nil {
+					return nil, bingo.ErrPlayerNotFound
+				}
+				return MustCopyPlayer(t, tc.existing), nil
+			})
+			mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+				if p.ID == "" {
+					p.ID = requiretest.UUIDv4(t)
+				}
+				return nil
+			})
+			var queued *bingo.OutboxMessage
+			mocks.outboxRepo.ExpectSave(func(_ context.Context, msg *bingo.OutboxMessage) error {
+				queued = msg
+				return nil
+			})
+
+			p, err := invSvc.Join(context.Background(), testInv.ID, "Player Name", "player@test.com", tc.cardAmount, "")
+			require.NoError(t, err, "no error is expected")
+
+			require.Equal(t, bingo.PlayerStatusUnconfirmed, p.Status, "player must await confirmation")
+			require.Equal(t, tc.expectedCards, p.RequestedCards, "player must await the cards asked for")
+			require.Empty(t, p.Cards, "no cards must be generated before the player confirms")
+			require.WithinDuration(t, time.Now().Add(bingo.PlayerConfirmationLifetime), p.ConfirmBy, time.Minute, "player must have the lifetime to confirm")
+			require.Equal(t, bingo.MessageKindPlayerConfirmation, queued.Kind, "confirmation link must be queued for mailing")
+			require.Equal(t, p.ID, queued.PlayerID, "confirmation link must be mailed to the player")
+		})
+	}
+}
+
+func TestInvitationService_Confirm(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.RequireConfirmation = true
+	fullInv := MustCopyInvitation(t, testInv)
+	fullInv.MaxPlayers = 1
+	fullInv.PlayerCount = 1
+
+	unconfirmed := MustMakeUnconfirmedPlayer(t, testInv, 2, time.Now().Add(time.Hour))
+	lapsed := MustMakeUnconfirmedPlayer(t, testInv, 2, time.Now().Add(-time.Minute))
+	joined := MustMakeTestPlayer(t, testInv)
+	validToken := MustSignConfirmationToken(t, unconfirmed, time.Now().Add(time.Hour))
+
+	cases := []struct {
+		caseName       string
+		inv            *bingo.Invitation
+		player         *bingo.Player
+		token          string
+		expectTx       bool
+		expectJoin     bool
+		expectWaitlist bool
+		expectedStatus bingo.PlayerStatus
+		expectedErr    error
+	}{
+		{
+			caseName:       "success",
+			inv:            testInv,
+			player:         unconfirmed,
+			token:          validToken,
+			expectTx:       true,
+			expectJoin:     true,
+			expectedStatus: bingo.PlayerStatusJoined,
+		},
+		{
+			caseName:       "full invitation waitlists",
+			inv:            fullInv,
+			player:         unconfirmed,
+			token:          validToken,
+			expectTx:       true,
+			expectWaitlist: true,
+			expectedStatus: bingo.PlayerStatusWaitlisted,
+		},
+		{
+			caseName:       "already confirmed",
+			inv:            testInv,
+			player:         joined,
+			token:          MustSignConfirmationToken(t, joined, time.Now().Add(time.Hour)),
+			expectTx:       true,
+			expectedStatus: bingo.PlayerStatusJoined,
+		},
+		{
+			caseName:    "malformed token",
+			token:       "malformed",
+			expectedErr: bingo.ErrInvalidConfirmationToken,
+		},
+		{
+			caseName:    "expired token",
+			token:       MustSignConfirmationToken(t, unconfirmed, time.Now().Add(-time.Minute)),
+			expectedErr: bingo.ErrInvalidConfirmationToken,
+		},
+		{
+			caseName:    "player expired",
+			inv:         testInv,
+			player:      unconfirmed,
+			token:       MustSignConfirmationToken(t, &bingo.Player{ID: requiretest.UUIDv4(t), Email: unconfirmed.Email}, time.Now().Add(time.Hour)),
+			expectedErr: bingo.ErrInvalidConfirmationToken,
+		},
+		{
+			caseName:    "other email",
+			inv:         testInv,
+			player:      unconfirmed,
+			token:       MustSignConfirmationToken(t, &bingo.Player{ID: unconfirmed.ID, Email: "other@test.com"}, time.Now().Add(time.Hour)),
+			expectedErr: bingo.ErrInvalidConfirmationToken,
+		},
+		{
+			caseName:    "confirmed too late",
+			inv:         testInv,
+			player:      lapsed,
+			token:       MustSignConfirmationToken(t, lapsed, time.Now().Add(time.Hour)),
+			expectTx:    true,
+			expectedErr: bingo.ErrInvalidConfirmationToken,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+
+			// Player is looked up whenever the token itself is valid
+			if tc.player != nil {
+				mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.player))
+			}
+			if tc.expectTx {
+				mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *tc.inv))
+				mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+				mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.player))
+			}
+			if tc.expectJoin {
+				mocks.invRepo.ExpectReserve(func(_ context.Context, _ *bingo.Invitation, cardAmount int) error {
+					require.Equal(t, tc.player.RequestedCards, cardAmount, "room must be reserved for the cards asked for")
+					return nil
+				})
+				mocks.playerRepo.ExpectSave(func(_ context.Context, _ *bingo.Player) error { return nil })
+				mocks.cardRepo.ExpectSaveAll(func(_ context.Context, _ []bingo.Card) error { return nil })
+				mocks.gameRepo.ExpectAllocateCardNumbers(MakeCardNumbersAllocateHandler(t, *testGame))
+			}
+			if tc.expectWaitlist {
+				mocks.invRepo.ExpectReserve(func(_ context.Context, _ *bingo.Invitation, _ int) error { return bingo.ErrInvitationFull })
+				mocks.invRepo.ExpectWaitlist(func(_ context.Context, _ *bingo.Invitation) (int, error) { return 1, nil })
+				mocks.playerRepo.ExpectSave(func(_ context.Context, _ *bingo.Player) error { return nil })
+			}
+
+			p, err := invSvc.Confirm(context.Background(), tc.token)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, tc.expectedStatus, p.Status, "player must have the expected status")
+			require.True(t, p.ConfirmBy.IsZero(), "confirmed players must not expire")
+			if tc.expectJoin {
+				require.Len(t, p.Cards, tc.player.RequestedCards, "cards asked for must be generated")
+			}
+		})
+	}
+}
+
+func TestOutboxService_DeliverPlayerConfirmation(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.RequireConfirmation = true
+
+	cases := []struct {
+		caseName   string
+		player     *bingo.Player
+		expectMail bool
+	}{
+		{
+			caseName:   "sent",
+			player:     MustMakeUnconfirmedPlayer(t, testInv, 1, time.Now().Add(time.Hour)),
+			expectMail: true,
+		},
+		{
+			caseName: "confirmed since queued",
+			player:   MustMakeTestPlayer(t, testInv),
+		},
+		{
+			caseName: "expired since queued",
+			player:   MustMakeUnconfirmedPlayer(t, testInv, 1, time.Now().Add(-time.Minute)),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			outboxSvc, mocks := MustCreateOutboxService(t)
+			defer mocks.outboxRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.templates.RequireExpectationsMet()
+			defer mocks.mailer.RequireExpectationsMet()
+
+			msg := bingo.NewPlayerConfirmationMessage(tc.player)
+			msg.ID = requiretest.UUIDv4(t)
+
+			claimed := false
+			claimHandler := func(_ context.Context, _ time.Time, _ time.Duration) (*bingo.OutboxMessage, error) {
+				if claimed {
+					return nil, bingo.ErrOutboxEmpty
+				}
+				claimed = true
+				return msg, nil
+			}
+			mocks.outboxRepo.ExpectClaimDue(claimHandler)
+			mocks.outboxRepo.ExpectClaimDue(claimHandler)
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.player))
+			if tc.expectMail {
+				mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+				mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+				var link string
+				mocks.templates.ExpectRenderMail(func(tmpl bingo.MailTemplate, _ bingo.Language, data interface{}) (*bingo.Mail, error) {
+					require.Equal(t, bingo.MailTemplatePlayerConfirmation, tmpl, "confirmation template must be rendered")
+					link = data.(bingo.PlayerConfirmationMailData).ConfirmLink
+					return &bingo.Mail{}, nil
+				})
+				mocks.mailer.ExpectSend(func(_ context.Context, m *bingo.Mail) error {
+					require.Equal(t, tc.player.Email, m.To, "link must be sent to the player")
+					require.True(t, strings.HasPrefix(link, testDownloadLinkBase+"/join/confirm?token="), "link must point to the confirmation page")
+					return nil
+				})
+			}
+			var savedMsg *bingo.OutboxMessage
+			mocks.outboxRepo.ExpectSave(func(_ context.Context, m *bingo.OutboxMessage) error {
+				savedMsg = m
+				return nil
+			})
+
+			_, err := outboxSvc.DeliverDue(context.Background())
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.OutboxStatusSent, savedMsg.Status, "message must be done, whether or not the link was needed")
+		})
+	}
+}
+
+func MustMakeUnconfirmedPlayer(tb testing.TB, inv *bingo.Invitation, cardAmount int, confirmBy time.Time) *bingo.Player {
+	tb.Helper()
+
+	p := MustMakeTestPlayer(tb, inv)
+	p.Status = bingo.PlayerStatusUnconfirmed
+	p.RequestedCards = cardAmount
+	p.ConfirmBy = confirmBy
+	return p
+}
+
+// Sign a token confirming the email of the player, as the fake signer of the services under test does
+func MustSignConfirmationToken(tb testing.TB, p *bingo.Player, expiresAt time.Time) string {
+	tb.Helper()
+
+	token, err := mock.ConfirmationTokenSigner{}.Sign(bingo.ConfirmationClaims{
+		PlayerID:  p.ID,
+		Email:     p.Email,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(tb, err, "token must be signed")
+
+	return token
+}
@@ -0,0 +1,44 @@
+package bingo
+
+import "context"
+
+type contextKey int
+
+const (
+	userContextKey contextKey = iota
+	apiKeyContextKey
+	playerContextKey
+)
+
+// Return a new context carrying the authenticated user.
+func NewContextWithUser(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, userContextKey, u)
+}
+
+// Get the authenticated user from the context. Returns nil if no user is present.
+func UserFromContext(ctx context.Context) *User {
+	u, _ := ctx.Value(userContextKey).(*User)
+	return u
+}
+
+// Return a new context carrying the api key the request was authenticated with.
+func NewContextWithAPIKey(ctx context.Context, k *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey, k)
+}
+
+// Get the api key the request was authenticated with. Returns nil if the request was not authenticated by api key.
+func APIKeyFromContext(ctx context.Context) *APIKey {
+	k, _ := ctx.Value(apiKeyContextKey).(*APIKey)
+	return k
+}
+
+// Return a new context carrying the player authenticated by their session.
+func NewContextWithPlayer(ctx context.Context, p *Player) context.Context {
+	return context.WithValue(ctx, playerContextKey, p)
+}
+
+// Get the player authenticated by their session from the context. Returns nil if no player is present.
+func PlayerFromContext(ctx context.Context) *Player {
+	p, _ := ctx.Value(playerContextKey).(*Player)
+	return p
+}
@@ -0,0 +1,242 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInsufficientCredits = errors.New("bingo: insufficient game credits")
+	ErrCreditValidation    = NewValErr("bingo: credit entry validation failed")
+)
+
+const (
+	// Cards every game may generate without debiting credits
+	FreeCardsPerGame = 30
+
+	// Cards generated per credit debited, once the free allowance of the game is used
+	CardsPerCredit = 10
+
+	// Credits debited for creating a game
+	GameCreationCost = 1
+)
+
+// Reason credits were debited from, or credited to, an account
+type CreditReason string
+
+const (
+	CreditReasonGameCreated    CreditReason = "GAME_CREATED"
+	CreditReasonCardsGenerated CreditReason = "CARDS_GENERATED"
+	CreditReasonGrant          CreditReason = "GRANT"
+)
+
+type CreditRepository interface {
+	// Append entry to the ledger. Entries are never changed once appended
+	Append(ctx context.Context, e *CreditEntry) error
+
+	// Sum of the amounts of all entries of the account
+	Balance(ctx context.Context, acc CreditAccount) (int, error)
+
+	// List entries of the account, newest first
+	List(ctx context.Context, acc CreditAccount) ([]CreditEntry, error)
+}
+
+type CreditService struct {
+	ledger creditLedger
+	authz  gameAuthorizer
+	tx     Transactor
+}
+
+// Grant credits to the account. Only admins are allowed to grant credits.
+func (cs *CreditService) Grant(ctx context.Context, acc CreditAccount, amount int, note string) (*CreditEntry, error) {
+	actor := UserFromContext(ctx)
+	if actor == nil || !actor.Admin {
+		return nil, ErrForbidden
+	}
+	if amount <= 0 {
+		return nil, ErrCreditValidation.withFieldErr("Amount", "min", "granted amount must be positive")
+	}
+
+	e := &CreditEntry{
+		UserID:         acc.UserID,
+		OrganizationID: acc.OrganizationID,
+		Reason:         CreditReasonGrant,
+		Amount:         amount,
+		Note:           strings.TrimSpace(note),
+		CreatedBy:      actor.ID,
+		CreatedAt:      time.Now(),
+	}
+	err := cs.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return cs.ledger.post(ctx, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Get the statement of the account the actor is acting on behalf of, i.e. the active organization or the actor. The
+// balance is summed from the ledger, which is the source of truth, rather than read from the cached balance.
+func (cs *CreditService) Statement(ctx context.Context) (*CreditStatement, error) {
+	actor := UserFromContext(ctx)
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+
+	acc := CreditAccount{UserID: actor.ID}
+	if actor.ActiveOrganizationID != "" {
+		if err := cs.authz.authorizeOrganization(ctx, actor.ActiveOrganizationID); err != nil {
+			return nil, err
+		}
+		acc = CreditAccount{OrganizationID: actor.ActiveOrganizationID}
+	}
+
+	balance, err := cs.ledger.creditRepo.Balance(ctx, acc)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := cs.ledger.creditRepo.List(ctx, acc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreditStatement{Balance: balance, Entries: entries}, nil
+}
+
+func NewCreditService(repos Repositories, tx Transactor) *CreditService {
+	return &CreditService{
+		ledger: newCreditLedger(repos),
+		authz:  gameAuthorizer{orgRepo: repos.Organizations},
+		tx:     tx,
+	}
+}
+
+// Ledger of game credits. The balance of an account is the sum of its entries, and kept on the user or organization
+// owning the account along with every entry posted.
+type creditLedger struct {
+	creditRepo CreditRepository
+	userRepo   UserRepository
+	orgRepo    OrganizationRepository
+}
+
+func newCreditLedger(repos Repositories) creditLedger {
+	return creditLedger{
+		creditRepo: repos.Credits,
+		userRepo:   repos.Users,
+		orgRepo:    repos.Organizations,
+	}
+}
+
+// Post entry to the ledger. Debits exceeding the balance of the account are refused with a domain error. The balance
+// is changed atomically, and only if it covers the debit, so concurrent debits can not overdraw the account. Use a
+// transactor to post entries atomically.
+func (l creditLedger) post(ctx context.Context, e *CreditEntry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	var err error
+	if acc := e.Account(); acc.OrganizationID != "" {
+		err = l.orgRepo.AddCredits(ctx, acc.OrganizationID, e.Amount)
+	} else {
+		err = l.userRepo.AddCredits(ctx, acc.UserID, e.Amount)
+	}
+	if err != nil {
+		return err
+	}
+
+	return l.creditRepo.Append(ctx, e)
+}
+
+// Debit the cost from the account paying for the game, for the reason given.
+func (l creditLedger) debit(ctx context.Context, g *Game, cost int, reason CreditReason) error {
+	acc := CreditAccountOf(g)
+	e := &CreditEntry{
+		UserID:         acc.UserID,
+		OrganizationID: acc.OrganizationID,
+		GameID:         g.ID,
+		Reason:         reason,
+		Amount:         -cost,
+		CreatedAt:      time.Now(),
+	}
+	if actor := UserFromContext(ctx); actor != nil {
+		e.CreatedBy = actor.ID
+	}
+
+	return l.post(ctx, e)
+}
+
+// Balance of a credit account along with the entries of its ledger, newest first
+type CreditStatement struct {
+	Balance int           `json:"balance"`
+	Entries []CreditEntry `json:"entries"`
+}
+
+// Account holding credits. Either a user or an organization
+type CreditAccount struct {
+	UserID         string `json:"userId,omitempty"`
+	OrganizationID string `json:"organizationId,omitempty"`
+}
+
+// Account paying for the game. Games owned by an organization are paid from its pool, and otherwise by the host
+func CreditAccountOf(g *Game) CreditAccount {
+	if g.OrganizationID != "" {
+		return CreditAccount{OrganizationID: g.OrganizationID}
+	}
+
+	return CreditAccount{UserID: g.HostId}
+}
+
+// Credits debited for generating the amount of cards in a game, which already has generated some. Cards are free
+// until the allowance of the game is used, and from there on cost a credit per started batch of cards.
+func CardGenerationCost(generated, amount int) int {
+	billable := func(cards int) int {
+		if cards <= FreeCardsPerGame {
+			return 0
+		}
+		return (cards - FreeCardsPerGame + CardsPerCredit - 1) / CardsPerCredit
+	}
+
+	return billable(generated+amount) - billable(generated)
+}
+
+// Append-only entry of the credit ledger. Debits have negative amounts
+type CreditEntry struct {
+	ID             string       `json:"id"`
+	UserID         string       `json:"userId,omitempty"`
+	OrganizationID string       `json:"organizationId,omitempty"`
+	GameID         string       `json:"gameId,omitempty"`
+	Reason         CreditReason `json:"reason"`
+	Amount         int          `json:"amount"`
+	Note           string       `json:"note,omitempty"`
+
+	// Id of the user posting the entry, e.g. the admin granting credits
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (e *CreditEntry) Account() CreditAccount {
+	return CreditAccount{
+		UserID:         e.UserID,
+		OrganizationID: e.OrganizationID,
+	}
+}
+
+func (e *CreditEntry) Validate() error {
+	if (e.UserID == "") == (e.OrganizationID == "") {
+		return ErrCreditValidation.withFieldErr("Account", "oneOf", "entry must belong to either a user or an organization")
+	}
+	if e.Amount == 0 {
+		return ErrCreditValidation.withFieldErr("Amount", "empty", "amount has to have a value")
+	}
+	switch e.Reason {
+	case CreditReasonGameCreated, CreditReasonCardsGenerated, CreditReasonGrant:
+	default:
+		return ErrCreditValidation.withFieldErr("Reason", "noMatch", "reason %s does not match any of the available: %s, %s, %s", e.Reason, CreditReasonGameCreated, CreditReasonCardsGenerated, CreditReasonGrant)
+	}
+
+	return nil
+}
@@ -0,0 +1,355 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardGenerationCost(t *testing.T) {
+
+	cases := []struct {
+		caseName  string
+		generated int
+		amount    int
+		expected  int
+	}{
+		{"within allowance", 0, bingo.FreeCardsPerGame, 0},
+		{"one beyond allowance", 0, bingo.FreeCardsPerGame + 1, 1},
+		{"full batch beyond allowance", bingo.FreeCardsPerGame, bingo.CardsPerCredit, 1},
+		{"started batch already paid", bingo.FreeCardsPerGame + 1, bingo.CardsPerCredit - 1, 0},
+		{"several batches", 0, bingo.FreeCardsPerGame + 3*bingo.CardsPerCredit, 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			require.Equal(t, tc.expected, bingo.CardGenerationCost(tc.generated, tc.amount), "unexpected cost of cards")
+		})
+	}
+}
+
+func TestCreditService_Grant(t *testing.T) {
+
+	admin := &bingo.User{ID: requiretest.UUIDv4(t), Admin: true}
+	receiver := bingo.User{ID: requiretest.UUIDv4(t), Email: "receiver@test.com", GameCredits: 2}
+
+	cases := []struct {
+		caseName     string
+		actor        *bingo.User
+		acc          bingo.CreditAccount
+		amount       int
+		expectPost   bool
+		expectValErr bool
+		expectedErr  error
+	}{
+		{
+			caseName:   "success",
+			actor:      admin,
+			acc:        bingo.CreditAccount{UserID: receiver.ID},
+			amount:     10,
+			expectPost: true,
+		},
+		{
+			caseName:    "user not found",
+			actor:       admin,
+			acc:         bingo.CreditAccount{UserID: requiretest.UUIDv4(t)},
+			amount:      10,
+			expectedErr: bingo.ErrUserNotFound,
+		},
+		{
+			caseName:     "negative amount",
+			actor:        admin,
+			acc:          bingo.CreditAccount{UserID: receiver.ID},
+			amount:       -10,
+			expectValErr: true,
+		},
+		{
+			caseName:     "no account",
+			actor:        admin,
+			amount:       10,
+			expectValErr: true,
+		},
+		{
+			caseName:    "forbidden not admin",
+			actor:       &bingo.User{ID: receiver.ID},
+			acc:         bingo.CreditAccount{UserID: receiver.ID},
+			amount:      10,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			creditSvc, mocks := MustCreateCreditService(t)
+			defer mocks.creditRepo.RequireExpectationsMet()
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			var added int
+			if tc.expectPost {
+				mocks.userRepo.ExpectAddCredits(func(_ context.Context, id string, amount int) error {
+					require.Equal(t, receiver.ID, id, "credits must be added to the receiver")
+					added = amount
+					return nil
+				})
+				mocks.creditRepo.ExpectAppend(MakeCreditAppendHandler(t))
+			}
+			if errors.Is(tc.expectedErr, bingo.ErrUserNotFound) {
+				mocks.userRepo.ExpectAddCredits(func(_ context.Context, _ string, _ int) error {
+					return bingo.ErrUserNotFound
+				})
+			}
+
+			ctx := bingo.NewContextWithUser(context.Background(), tc.actor)
+			e, err := creditSvc.Grant(ctx, tc.acc, tc.amount, "welcome")
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, e, "entry must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.CreditReasonGrant, e.Reason, "entry must be a grant")
+			require.Equal(t, admin.ID, e.CreatedBy, "entry must be created by the admin")
+			require.Equal(t, tc.amount, added, "granted amount must be added to the balance of the user")
+		})
+	}
+}
+
+func TestCreditService_Statement(t *testing.T) {
+
+	org := MustMakeTestOrganization(t)
+	orgMember := &bingo.User{ID: org.Members[1].UserID, ActiveOrganizationID: org.ID}
+	user := &bingo.User{ID: requiretest.UUIDv4(t), GameCredits: 99}
+
+	cases := []struct {
+		caseName    string
+		actor       *bingo.User
+		expectedAcc bingo.CreditAccount
+		expectedErr error
+	}{
+		{
+			caseName:    "user",
+			actor:       user,
+			expectedAcc: bingo.CreditAccount{UserID: user.ID},
+		},
+		{
+			caseName:    "active organization",
+			actor:       orgMember,
+			expectedAcc: bingo.CreditAccount{OrganizationID: org.ID},
+		},
+		{
+			caseName:    "forbidden not member of active organization",
+			actor:       &bingo.User{ID: requiretest.UUIDv4(t), ActiveOrganizationID: org.ID},
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			creditSvc, mocks := MustCreateCreditService(t)
+			defer mocks.creditRepo.RequireExpectationsMet()
+			defer mocks.orgRepo.RequireExpectationsMet()
+
+			if tc.actor.ActiveOrganizationID != "" {
+				mocks.orgRepo.ExpectGet(MakeSingleOrganizationGetHandler(t, *org))
+			}
+			entries := []bingo.CreditEntry{
+				{ID: requiretest.UUIDv4(t), Reason: bingo.CreditReasonGameCreated, Amount: -bingo.GameCreationCost},
+				{ID: requiretest.UUIDv4(t), Reason: bingo.CreditReasonGrant, Amount: 5},
+			}
+			if tc.expectedErr == nil {
+				mocks.creditRepo.ExpectBalance(func(_ context.Context, acc bingo.CreditAccount) (int, error) {
+					require.Equal(t, tc.expectedAcc, acc, "balance of the account acted on behalf of must be summed")
+					return 5 - bingo.GameCreationCost, nil
+				})
+				mocks.creditRepo.ExpectList(func(_ context.Context, acc bingo.CreditAccount) ([]bingo.CreditEntry, error) {
+					require.Equal(t, tc.expectedAcc, acc, "entries of the account acted on behalf of must be listed")
+					return entries, nil
+				})
+			}
+
+			statement, err := creditSvc.Statement(bingo.NewContextWithUser(context.Background(), tc.actor))
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, statement, "statement must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, 5-bingo.GameCreationCost, statement.Balance, "balance must be summed from the ledger, not the cached balance")
+			require.Equal(t, entries, statement.Entries, "entries of the ledger must be listed")
+		})
+	}
+}
+
+func TestGameService_GenerateCards_Credits(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	host := bingo.User{ID: testGame.HostId, Email: "host@test.com"}
+	amount := bingo.FreeCardsPerGame + bingo.CardsPerCredit
+
+	cases := []struct {
+		caseName    string
+		balance     int
+		expectedErr error
+	}{
+		{
+			caseName: "success",
+			balance:  1,
+		},
+		{
+			caseName:    "insufficient credits",
+			balance:     0,
+			expectedErr: bingo.ErrInsufficientCredits,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+			defer mocks.creditRepo.RequireExpectationsMet()
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			mocks.gameRepo.ExpectAllocateCardNumbers(MakeCardNumbersAllocateHandler(t, *testGame))
+			mocks.userRepo.ExpectAddCredits(MakeUserAddCreditsHandler(t, host.ID, tc.balance))
+
+			var debit *bingo.CreditEntry
+			if tc.expectedErr == nil {
+				mocks.creditRepo.ExpectAppend(func(_ context.Context, e *bingo.CreditEntry) error {
+					debit = e
+					return nil
+				})
+				mocks.cardRepo.ExpectSaveAll(func(_ context.Context, cards []bingo.Card) error { return nil })
+			}
+
+			cards, err := gameSvc.GenerateCards(NewActorContext(t, testGame.HostId), testGame.ID, amount)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, cards, "cards must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Len(t, cards, amount, "all cards must be generated")
+			require.Equal(t, -1, debit.Amount, "credits beyond the allowance must be debited")
+			require.Equal(t, testGame.ID, debit.GameID, "debit must reference the game")
+			require.Equal(t, bingo.CreditReasonCardsGenerated, debit.Reason, "debit must be for generated cards")
+		})
+	}
+}
+
+func TestGameService_Create_Credits(t *testing.T) {
+
+	hostId := requiretest.UUIDv4(t)
+
+	cases := []struct {
+		caseName    string
+		balance     int
+		expectedErr error
+	}{
+		{
+			caseName: "success",
+			balance:  bingo.GameCreationCost,
+		},
+		{
+			caseName:    "insufficient credits",
+			balance:     bingo.GameCreationCost - 1,
+			expectedErr: bingo.ErrInsufficientCredits,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.creditRepo.RequireExpectationsMet()
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectSave(MakeGameSaveHandler(t))
+			mocks.userRepo.ExpectAddCredits(MakeUserAddCreditsHandler(t, hostId, tc.balance))
+
+			var debit *bingo.CreditEntry
+			if tc.expectedErr == nil {
+				mocks.creditRepo.ExpectAppend(func(_ context.Context, e *bingo.CreditEntry) error {
+					debit = e
+					return nil
+				})
+			}
+
+			g, err := gameSvc.Create(NewActorContext(t, hostId), hostId, "paid game")
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, g, "game must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, -bingo.GameCreationCost, debit.Amount, "cost of creating the game must be debited")
+			require.Equal(t, g.ID, debit.GameID, "debit must reference the game")
+			require.Equal(t, bingo.CreditReasonGameCreated, debit.Reason, "debit must be for the created game")
+		})
+	}
+}
+
+type creditServiceMocks struct {
+	creditRepo *mock.CreditRepository
+	userRepo   *mock.UserRepository
+	orgRepo    *mock.OrganizationRepository
+}
+
+func MustCreateCreditService(tb testing.TB) (*bingo.CreditService, *creditServiceMocks) {
+	tb.Helper()
+
+	creditRepo := mock.NewCreditRepository(tb)
+	userRepo := mock.NewUserRepository(tb)
+	orgRepo := mock.NewOrganizationRepository(tb)
+
+	creditSvc := bingo.NewCreditService(bingo.Repositories{
+		Users:         userRepo,
+		Organizations: orgRepo,
+		Credits:       creditRepo,
+	}, mock.NewTransactor(tb))
+	mocks := &creditServiceMocks{
+		creditRepo: creditRepo,
+		userRepo:   userRepo,
+		orgRepo:    orgRepo,
+	}
+
+	return creditSvc, mocks
+}
+
+// Make handler adding credits to the balance of the user, refusing debits exceeding it
+func MakeUserAddCreditsHandler(tb testing.TB, userId string, balance int) mock.UserAddCreditsHandler {
+	tb.Helper()
+
+	return func(_ context.Context, id string, amount int) error {
+		require.Equal(tb, userId, id, "credits must be added to the expected user")
+		if balance+amount < 0 {
+			return bingo.ErrInsufficientCredits
+		}
+		balance += amount
+		return nil
+	}
+}
+
+func MakeCreditAppendHandler(tb testing.TB) mock.CreditAppendHandler {
+	tb.Helper()
+
+	return func(_ context.Context, e *bingo.CreditEntry) error {
+		e.ID = requiretest.UUIDv4(tb)
+		return nil
+	}
+}
@@ -0,0 +1,209 @@
+package bingo
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Max depth criteria may be nested in groups
+const maxCriteriaDepth = 4
+
+type InvitationCriterionKind string
+
+const (
+	// Field must match the regular expression of the value
+	InvitationCriterionKindRegex InvitationCriterionKind = "REGEX"
+
+	// Domain of the email must be one of the values
+	InvitationCriterionKindEmailDomain InvitationCriterionKind = "EMAIL_DOMAIN"
+
+	// Field must equal one of the values, ignoring case
+	InvitationCriterionKindOneOf InvitationCriterionKind = "ONE_OF"
+
+	// Field must start with the value, ignoring case
+	InvitationCriterionKindPrefix InvitationCriterionKind = "PREFIX"
+
+	// Groups met when all, any or none of the grouped criteria are met. NOT groups exactly one criterion
+	InvitationCriterionKindAnd InvitationCriterionKind = "AND"
+	InvitationCriterionKindOr  InvitationCriterionKind = "OR"
+	InvitationCriterionKindNot InvitationCriterionKind = "NOT"
+)
+
+var invitationCriterionKinds = []InvitationCriterionKind{
+	InvitationCriterionKindRegex,
+	InvitationCriterionKindEmailDomain,
+	InvitationCriterionKindOneOf,
+	InvitationCriterionKindPrefix,
+	InvitationCriterionKindAnd,
+	InvitationCriterionKindOr,
+	InvitationCriterionKindNot,
+}
+
+type InvitationCriterionField string
+
+const (
+	InvitationCriterionFieldEmail InvitationCriterionField = "EMAIL"
+	InvitationCriterionFieldName  InvitationCriterionField = "NAME"
+)
+
+// Reports whether the player meets a criterion
+type playerMatcher func(p *Player) bool
+
+// Value object
+type InvitationCriterion struct {
+	Kind  InvitationCriterionKind  `json:"kind"`
+	Field InvitationCriterionField `json:"field"`
+	Value string                   `json:"value"`
+
+	// Values of list kinds, e.g. the allowed email domains
+	Values []string `json:"values,omitempty"`
+
+	// Criteria grouped by AND, OR and NOT kinds
+	Criteria []InvitationCriterion `json:"criteria,omitempty"`
+}
+
+// Validate the criterion and any criteria it groups, including that regular expressions compile.
+func (ic InvitationCriterion) Validate() error {
+	_, err := ic.compile(1)
+	return err
+}
+
+// Compile the criterion into a matcher, validating it on the way
+func (ic InvitationCriterion) compile(depth int) (playerMatcher, error) {
+	if depth > maxCriteriaDepth {
+		return nil, ErrInvitationValidation.withFieldErr("Criteria", "max", "criteria must not be nested deeper than %d", maxCriteriaDepth)
+	}
+
+	// Groups of criteria
+	switch ic.Kind {
+	case InvitationCriterionKindAnd, InvitationCriterionKindOr:
+		if len(ic.Criteria) == 0 {
+			return nil, ErrInvitationValidation.withFieldErr("Criteria", "empty", "%s criterion has to group at least one criterion", ic.Kind)
+		}
+		matchers := make([]playerMatcher, 0, len(ic.Criteria))
+		for _, c := range ic.Criteria {
+			m, err := c.compile(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, m)
+		}
+
+		// AND is met unless a criterion is not, while OR is not met unless a criterion is
+		all := ic.Kind == InvitationCriterionKindAnd
+		return func(p *Player) bool {
+			for _, m := range matchers {
+				if m(p) != all {
+					return !all
+				}
+			}
+			return all
+		}, nil
+	case InvitationCriterionKindNot:
+		if len(ic.Criteria) != 1 {
+			return nil, ErrInvitationValidation.withFieldErr("Criteria", "len", "%s criterion has to group exactly one criterion", ic.Kind)
+		}
+		m, err := ic.Criteria[0].compile(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return func(p *Player) bool { return !m(p) }, nil
+	}
+
+	// Criteria on a field of the player
+	var valueOf func(p *Player) string
+	switch ic.Field {
+	case InvitationCriterionFieldEmail:
+		valueOf = func(p *Player) string { return p.Email }
+	case InvitationCriterionFieldName:
+		valueOf = func(p *Player) string { return p.Name }
+	default:
+		return nil, ErrInvitationValidation.withFieldErr("field", "noMatch", "invitation criterium field %s does not match any of the available: %s, %s", ic.Field, InvitationCriterionFieldEmail, InvitationCriterionFieldName)
+	}
+
+	switch ic.Kind {
+	case InvitationCriterionKindRegex:
+		regex, err := regexp.Compile(ic.Value)
+		if err != nil {
+			return nil, ErrInvitationValidation.withFieldErr("value", "malformedRegex", "given regex %s is invalid", ic.Value)
+		}
+		return func(p *Player) bool { return regex.MatchString(valueOf(p)) }, nil
+	case InvitationCriterionKindEmailDomain:
+		if ic.Field != InvitationCriterionFieldEmail {
+			return nil, ErrInvitationValidation.withFieldErr("field", "noMatch", "%s criterion only applies to field %s", ic.Kind, InvitationCriterionFieldEmail)
+		}
+		domains, err := ic.valueSet(func(v string) string { return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(v)), "@") })
+		if err != nil {
+			return nil, err
+		}
+		return func(p *Player) bool {
+			at := strings.LastIndex(p.Email, "@")
+			if at < 0 {
+				return false
+			}
+			_, ok := domains[strings.ToLower(p.Email[at+1:])]
+			return ok
+		}, nil
+	case InvitationCriterionKindOneOf:
+		values, err := ic.valueSet(func(v string) string { return strings.ToLower(strings.TrimSpace(v)) })
+		if err != nil {
+			return nil, err
+		}
+		return func(p *Player) bool {
+			_, ok := values[strings.ToLower(strings.TrimSpace(valueOf(p)))]
+			return ok
+		}, nil
+	case InvitationCriterionKindPrefix:
+		if ic.Value == "" {
+			return nil, ErrInvitationValidation.withFieldErr("value", "empty", "%s criterion has to have a value", ic.Kind)
+		}
+		prefix := strings.ToLower(ic.Value)
+		return func(p *Player) bool { return strings.HasPrefix(strings.ToLower(valueOf(p)), prefix) }, nil
+	}
+
+	kinds := make([]string, 0, len(invitationCriterionKinds))
+	for _, k := range invitationCriterionKinds {
+		kinds = append(kinds, string(k))
+	}
+	return nil, ErrInvitationValidation.withFieldErr("kind", "noMatch", "invitation criterium kind %s does not match any of the available: %s", ic.Kind, strings.Join(kinds, ", "))
+}
+
+// Set of the normalized values of a list kind. Fails if there are no values
+func (ic InvitationCriterion) valueSet(normalize func(v string) string) (map[string]struct{}, error) {
+	set := make(map[string]struct{}, len(ic.Values))
+	for _, v := range ic.Values {
+		if v := normalize(v); v != "" {
+			set[v] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		return nil, ErrInvitationValidation.withFieldErr("values", "empty", "%s criterion has to have at least one value", ic.Kind)
+	}
+
+	return set, nil
+}
+
+// Error telling the player what criterion they did not meet. The values of lists are not disclosed, as they may be the
+// emails of other players
+func (ic InvitationCriterion) failedErr() error {
+	field := "Criteria"
+	switch ic.Field {
+	case InvitationCriterionFieldEmail:
+		field = "Email"
+	case InvitationCriterionFieldName:
+		field = "Name"
+	}
+
+	switch ic.Kind {
+	case InvitationCriterionKindRegex:
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr(field, "failedCriteria", "did not match regex value %s", ic.Value)
+	case InvitationCriterionKindEmailDomain:
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr(field, "failedCriteria", "email domain is not allowed")
+	case InvitationCriterionKindOneOf:
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr(field, "failedCriteria", "is not one of the allowed values")
+	case InvitationCriterionKindPrefix:
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr(field, "failedCriteria", "did not start with %s", ic.Value)
+	default:
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr("Criteria", "failedCriteria", "did not meet the criteria of the invitation")
+	}
+}
@@ -0,0 +1,202 @@
+package bingo_test
+
+import (
+	"errors"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitationCriterion_Validate(t *testing.T) {
+
+	emailRegex := bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindRegex, Field: bingo.InvitationCriterionFieldEmail, Value: `@test\.com$`}
+
+	// Nest the criterion in NOT groups the given amount of times
+	nest := func(c bingo.InvitationCriterion, times int) bingo.InvitationCriterion {
+		for i := 0; i < times; i++ {
+			c = bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindNot, Criteria: []bingo.InvitationCriterion{c}}
+		}
+		return c
+	}
+
+	cases := []struct {
+		caseName     string
+		criterion    bingo.InvitationCriterion
+		expectValErr bool
+	}{
+		{
+			caseName:  "regex",
+			criterion: emailRegex,
+		},
+		{
+			caseName:     "malformed regex",
+			criterion:    bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindRegex, Field: bingo.InvitationCriterionFieldName, Value: "(unclosed"},
+			expectValErr: true,
+		},
+		{
+			caseName:     "unknown kind",
+			criterion:    bingo.InvitationCriterion{Kind: "GLOB", Field: bingo.InvitationCriterionFieldName, Value: "*"},
+			expectValErr: true,
+		},
+		{
+			caseName:     "unknown field",
+			criterion:    bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindPrefix, Field: "PHONE", Value: "+45"},
+			expectValErr: true,
+		},
+		{
+			caseName:  "email domain",
+			criterion: bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindEmailDomain, Field: bingo.InvitationCriterionFieldEmail, Values: []string{"test.com"}},
+		},
+		{
+			caseName:     "email domain of name",
+			criterion:    bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindEmailDomain, Field: bingo.InvitationCriterionFieldName, Values: []string{"test.com"}},
+			expectValErr: true,
+		},
+		{
+			caseName:     "one of without values",
+			criterion:    bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindOneOf, Field: bingo.InvitationCriterionFieldEmail, Values: []string{" "}},
+			expectValErr: true,
+		},
+		{
+			caseName:     "prefix without value",
+			criterion:    bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindPrefix, Field: bingo.InvitationCriterionFieldName},
+			expectValErr: true,
+		},
+		{
+			caseName:     "empty group",
+			criterion:    bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindOr},
+			expectValErr: true,
+		},
+		{
+			caseName:     "not grouping two",
+			criterion:    bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindNot, Criteria: []bingo.InvitationCriterion{emailRegex, emailRegex}},
+			expectValErr: true,
+		},
+		{
+			caseName: "malformed regex in group",
+			criterion: bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindAnd, Criteria: []bingo.InvitationCriterion{
+				emailRegex,
+				{Kind: bingo.InvitationCriterionKindRegex, Field: bingo.InvitationCriterionFieldName, Value: "[a-"},
+			}},
+			expectValErr: true,
+		},
+		{
+			caseName:  "nested at max depth",
+			criterion: nest(emailRegex, 3),
+		},
+		{
+			caseName:     "nested too deep",
+			criterion:    nest(emailRegex, 4),
+			expectValErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			err := tc.criterion.Validate()
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+		})
+	}
+}
+
+func TestInvitation_ValidatePlayerCriteria(t *testing.T) {
+
+	domain := bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindEmailDomain, Field: bingo.InvitationCriterionFieldEmail, Values: []string{"@Test.com", "example.org"}}
+	guests := bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindOneOf, Field: bingo.InvitationCriterionFieldEmail, Values: []string{"Guest@Other.com"}}
+	staff := bingo.InvitationCriterion{Kind: bingo.InvitationCriterionKindPrefix, Field: bingo.InvitationCriterionFieldName, Value: "staff "}
+
+	cases := []struct {
+		caseName     string
+		criteria     []bingo.InvitationCriterion
+		email        string
+		name         string
+		expectValErr bool
+	}{
+		{
+			caseName: "email domain allowed",
+			criteria: []bingo.InvitationCriterion{domain},
+			email:    "player@test.com",
+		},
+		{
+			caseName:     "email domain not allowed",
+			criteria:     []bingo.InvitationCriterion{domain},
+			email:        "player@nottest.com",
+			expectValErr: true,
+		},
+		{
+			caseName: "one of ignoring case",
+			criteria: []bingo.InvitationCriterion{guests},
+			email:    "guest@other.com",
+		},
+		{
+			caseName: "prefix ignoring case",
+			criteria: []bingo.InvitationCriterion{staff},
+			email:    "player@other.com",
+			name:     "Staff Jane",
+		},
+		{
+			caseName: "or met by any",
+			criteria: []bingo.InvitationCriterion{
+				{Kind: bingo.InvitationCriterionKindOr, Criteria: []bingo.InvitationCriterion{domain, guests}},
+			},
+			email: "guest@other.com",
+		},
+		{
+			caseName: "or met by none",
+			criteria: []bingo.InvitationCriterion{
+				{Kind: bingo.InvitationCriterionKindOr, Criteria: []bingo.InvitationCriterion{domain, guests}},
+			},
+			email:        "player@other.com",
+			expectValErr: true,
+		},
+		{
+			caseName: "and not met by all",
+			criteria: []bingo.InvitationCriterion{
+				{Kind: bingo.InvitationCriterionKindAnd, Criteria: []bingo.InvitationCriterion{domain, staff}},
+			},
+			email:        "player@test.com",
+			name:         "Jane",
+			expectValErr: true,
+		},
+		{
+			caseName: "not excluding staff",
+			criteria: []bingo.InvitationCriterion{
+				domain,
+				{Kind: bingo.InvitationCriterionKindNot, Criteria: []bingo.InvitationCriterion{staff}},
+			},
+			email:        "player@test.com",
+			name:         "Staff Jane",
+			expectValErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			inv := MustMakeTestInvitation(t, MustMakeTestGame(t))
+			inv.Criteria = tc.criteria
+			require.NoError(t, inv.Validate(), "invitation must be valid")
+
+			name := tc.name
+			if name == "" {
+				name = "Jane"
+			}
+			p := bingo.NewPlayer(inv.ID, name, tc.email, "")
+
+			err := inv.ValidatePlayer(p)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Byte order mark spreadsheet applications put at the start of csv files saved as utf-8
+const bom = "\ufeff"
+
+// Columns of player imports. Other columns are ignored, so spreadsheets can be imported as they are
+const (
+	columnName     = "name"
+	columnEmail    = "email"
+	columnCards    = "cards"
+	columnLanguage = "language"
+)
+
+var (
+	ErrMissingHeader     = errors.New("csv: header is missing")
+	ErrMissingColumn     = errors.New("csv: a required column is missing from the header")
+	ErrInvalidCardAmount = errors.New("csv: cards must be a whole number")
+)
+
+// Parse players of an import. The first line is a header naming the columns name and email, and optionally cards and
+// language, in any order and case. Imports of more players than can be imported at once fail with
+// bingo.ErrPlayerImportTooLarge. Players get one card if they have no cards. Columns are separated by commas, or by
+// semicolons as spreadsheet applications save them in locales using commas as the decimal separator. Rows that can not
+// be read have the error set, so they fail on their own when imported.
+func ParsePlayers(r io.Reader) ([]bingo.PlayerImportRow, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimPrefix(b, []byte(bom))
+
+	cr := csv.NewReader(bytes.NewReader(b))
+	cr.Comma = delimiter(b)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrMissingHeader
+	} else if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{columnName, columnEmail} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, required)
+		}
+	}
+
+	rows := make([]bingo.PlayerImportRow, 0)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// Spreadsheet applications save the blank rows below the attendees as rows of empty columns
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == bingo.PlayerImportMaxRows {
+			return nil, bingo.ErrPlayerImportTooLarge
+		}
+
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, parsePlayer(line, columns, record))
+	}
+
+	return rows, nil
+}
+
+// Player of the record by the columns of the header
+func parsePlayer(line int, columns map[string]int, record []string) bingo.PlayerImportRow {
+	field := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row := bingo.PlayerImportRow{
+		Line:       line,
+		Name:       field(columnName),
+		Email:      field(columnEmail),
+		CardAmount: 1,
+		Language:   bingo.Language(strings.ToLower(field(columnLanguage))),
+	}
+	if cards := field(columnCards); cards != "" {
+		n, err := strconv.Atoi(cards)
+		if err != nil {
+			row.Err = ErrInvalidCardAmount
+		}
+		row.CardAmount = n
+	}
+
+	return row
+}
+
+// Delimiter of the columns, by which of commas and semicolons the header is separated by the most
+func delimiter(b []byte) rune {
+	header := b
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		header = b[:i]
+	}
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		return ';'
+	}
+
+	return ','
+}
@@ -0,0 +1,92 @@
+package csv_test
+
+import (
+	"strings"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/csv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlayers(t *testing.T) {
+	cases := []struct {
+		caseName    string
+		input       string
+		expected    []bingo.PlayerImportRow
+		expectedErr error
+	}{
+		{
+			caseName: "success",
+			input:    "name,email,cards,language\nFirst Player,first@test.com,2,da\nSecond Player,second@test.com,1,en\n",
+			expected: []bingo.PlayerImportRow{
+				{Line: 2, Name: "First Player", Email: "first@test.com", CardAmount: 2, Language: bingo.LanguageDanish},
+				{Line: 3, Name: "Second Player", Email: "second@test.com", CardAmount: 1, Language: bingo.LanguageEnglish},
+			},
+		},
+		{
+			caseName: "columns in any order and case with others ignored",
+			input:    "Department,E-mail,Email, Name \nSales,,first@test.com,First Player\n",
+			expected: []bingo.PlayerImportRow{
+				{Line: 2, Name: "First Player", Email: "first@test.com", CardAmount: 1},
+			},
+		},
+		{
+			caseName: "semicolons saved by spreadsheets",
+			input:    "\ufeffName;Email;Cards\r\n\"Player, First\";first@test.com;3\r\n;;\r\n",
+			expected: []bingo.PlayerImportRow{
+				{Line: 2, Name: "Player, First", Email: "first@test.com", CardAmount: 3},
+			},
+		},
+		{
+			caseName: "missing and short columns",
+			input:    "name,email,cards\nFirst Player,first@test.com,\nSecond Player\n",
+			expected: []bingo.PlayerImportRow{
+				{Line: 2, Name: "First Player", Email: "first@test.com", CardAmount: 1},
+				{Line: 3, Name: "Second Player", CardAmount: 1},
+			},
+		},
+		{
+			caseName: "invalid cards",
+			input:    "name,email,cards\nFirst Player,first@test.com,two\n",
+			expected: []bingo.PlayerImportRow{
+				{Line: 2, Name: "First Player", Email: "first@test.com", Err: csv.ErrInvalidCardAmount},
+			},
+		},
+		{
+			caseName:    "missing header",
+			input:       "",
+			expectedErr: csv.ErrMissingHeader,
+		},
+		{
+			caseName:    "missing email column",
+			input:       "name,cards\nFirst Player,2\n",
+			expectedErr: csv.ErrMissingColumn,
+		},
+		{
+			caseName:    "too many players",
+			input:       "name,email\n" + strings.Repeat("Player,player@test.com\n", bingo.PlayerImportMaxRows+1),
+			expectedErr: bingo.ErrPlayerImportTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			rows, err := csv.ParsePlayers(strings.NewReader(tc.input))
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, rows, "rows must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, tc.expected, rows, "rows must be parsed by the columns of the header")
+		})
+	}
+}
+
+func TestParsePlayersMalformed(t *testing.T) {
+	_, err := csv.ParsePlayers(strings.NewReader("name,email\n\"First Player,first@test.com\n"))
+	require.Error(t, err, "unterminated quotes must fail the parse")
+	require.Contains(t, err.Error(), "line", "error must refer to the line")
+}
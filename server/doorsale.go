@@ -0,0 +1,164 @@
+package bingo
+
+import (
+	"context"
+)
+
+var (
+	ErrDoorSaleValidation = NewValErr("bingo: door sale validation failed")
+)
+
+// Max amount of cards sold to a player at the door at once
+const DoorSaleMaxCards = 100
+
+// Stock of the cards of a game, e.g. cards printed in advance to sell at the door
+type CardStock struct {
+	GameID string `json:"gameId"`
+
+	// Cards generated for the game, and the ones of them assigned to no player yet
+	Generated  int `json:"generated"`
+	Unassigned int `json:"unassigned"`
+}
+
+// Range of card numbers, from and to including both, e.g. a stack of cards printed in advance
+type CardNumberRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// Numbers of the range in increasing order. Ranges ending before they start have none
+func (r CardNumberRange) Numbers() []int {
+	if r.To < r.From {
+		return []int{}
+	}
+
+	nums := make([]int, 0, r.To-r.From+1)
+	for n := r.From; n <= r.To; n++ {
+		nums = append(nums, n)
+	}
+	return nums
+}
+
+// Amount of numbers in the range
+func (r CardNumberRange) Len() int {
+	if r.To < r.From {
+		return 0
+	}
+
+	return r.To - r.From + 1
+}
+
+// Assign cards printed in advance, and sold at the door, to a new player who walks in. The player joins by the
+// invitation, e.g. one the host keeps for door sales, and may go without name and email. Players with an email can
+// get their cards by a magic link as any other player. The cards must be generated for the game with no player, and
+// cards can not be assigned twice, also when sold at the same time. Only members allowed to manage the game can sell
+// cards.
+func (ps *PlayerService) AssignCards(ctx context.Context, invId string, name, email string, numbers []int) (*Player, error) {
+	inv, err := ps.invRepo.Get(ctx, invId)
+	if err != nil {
+		return nil, err
+	}
+	g, err := ps.gameRepo.Get(ctx, inv.GameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.authz.authorize(ctx, g, PermissionManageGame); err != nil {
+		return nil, err
+	}
+	if !inv.Active {
+		return nil, ErrInvitationInactive
+	}
+	if err := validateCardNumbers(numbers); err != nil {
+		return nil, err
+	}
+
+	p := NewPlayer(inv.ID, name, email, "")
+	err = ps.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		cards, err := ps.cardRepo.FindByNumbers(ctx, g.ID, numbers)
+		if err != nil {
+			return err
+		}
+		if len(cards) != len(numbers) {
+			return ErrCardNotFound
+		}
+		for _, c := range cards {
+			if c.Void() {
+				return ErrCardVoid
+			}
+			if c.PlayerID != "" {
+				return ErrCardAssigned
+			}
+		}
+
+		// The cards are counted on the invitation as those of players joining online
+		if err := ps.invRepo.Reserve(ctx, inv, len(cards)); err != nil {
+			return err
+		}
+		p.join()
+		if err := ps.playerRepo.Save(ctx, p); err != nil {
+			return err
+		}
+		if err := ps.cardRepo.Assign(ctx, g.ID, numbers, p.ID); err != nil {
+			return err
+		}
+
+		for i := range cards {
+			cards[i].PlayerID = p.ID
+		}
+		p.Cards = cards
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	inv.Game = g
+	p.Invitation = inv
+
+	return p, nil
+}
+
+// Get the stock of cards of the game, with the amount of cards left to sell at the door. Only members of the game
+// can view the stock.
+func (ps *PlayerService) CardStock(ctx context.Context, gameId string) (*CardStock, error) {
+	g, err := ps.gameRepo.Get(ctx, gameId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.authz.authorize(ctx, g, PermissionViewGame); err != nil {
+		return nil, err
+	}
+
+	unassigned, err := ps.cardRepo.CountUnassigned(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CardStock{
+		GameID:     g.ID,
+		Generated:  g.NextCardNumber - 1,
+		Unassigned: unassigned,
+	}, nil
+}
+
+// Check cards are sold by numbers of cards, each number once
+func validateCardNumbers(numbers []int) error {
+	if len(numbers) == 0 {
+		return ErrDoorSaleValidation.withFieldErr("Numbers", "empty", "at least one card number has to be given")
+	}
+	if len(numbers) > DoorSaleMaxCards {
+		return ErrDoorSaleValidation.withFieldErr("Numbers", "max", "at most %d cards can be sold at once", DoorSaleMaxCards)
+	}
+
+	seen := make(map[int]bool, len(numbers))
+	for _, n := range numbers {
+		if n < 1 {
+			return ErrDoorSaleValidation.withFieldErr("Numbers", "min", "card numbers must be greater than 0")
+		}
+		if seen[n] {
+			return ErrDoorSaleValidation.withFieldErr("Numbers", "unique", "card number %d is given more than once", n)
+		}
+		seen[n] = true
+	}
+
+	return nil
+}
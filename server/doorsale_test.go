@@ -0,0 +1,247 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardNumberRange_Numbers(t *testing.T) {
+	require.Equal(t, []int{4, 5, 6}, bingo.CardNumberRange{From: 4, To: 6}.Numbers(), "range must include both ends")
+	require.Equal(t, []int{4}, bingo.CardNumberRange{From: 4, To: 4}.Numbers(), "range of one card must have its number")
+	require.Empty(t, bingo.CardNumberRange{From: 6, To: 4}.Numbers(), "range ending before it starts must have none")
+	require.Equal(t, 3, bingo.CardNumberRange{From: 4, To: 6}.Len(), "length must count both ends")
+}
+
+func TestPlayerService_AssignCards(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testGame.NextCardNumber = 51
+	testInv := MustMakeTestInvitation(t, testGame)
+	inactive := MustMakeTestInvitation(t, testGame)
+	inactive.Active = false
+	assigned := MustMakeStockCard(t, testGame, 12)
+	assigned.PlayerID = requiretest.UUIDv4(t)
+	void := MustMakeStockCard(t, testGame, 12)
+	void.Revocation = &bingo.CardRevocation{Reason: "misprint"}
+
+	cases := []struct {
+		caseName     string
+		actorId      string
+		inv          *bingo.Invitation
+		numbers      []int
+		stock        map[int]*bingo.Card
+		expectFind   bool
+		expectAssign bool
+		assignErr    error
+		expectValErr bool
+		expectedErr  error
+	}{
+		{
+			caseName:     "success",
+			actorId:      testGame.HostId,
+			inv:          testInv,
+			numbers:      []int{12, 13},
+			expectFind:   true,
+			expectAssign: true,
+		},
+		{
+			caseName:    "card does not exist",
+			actorId:     testGame.HostId,
+			inv:         testInv,
+			numbers:     []int{12, 99},
+			stock:       map[int]*bingo.Card{99: nil},
+			expectFind:  true,
+			expectedErr: bingo.ErrCardNotFound,
+		},
+		{
+			caseName:    "card already assigned",
+			actorId:     testGame.HostId,
+			inv:         testInv,
+			numbers:     []int{12, 13},
+			stock:       map[int]*bingo.Card{12: assigned},
+			expectFind:  true,
+			expectedErr: bingo.ErrCardAssigned,
+		},
+		{
+			caseName:    "card void",
+			actorId:     testGame.HostId,
+			inv:         testInv,
+			numbers:     []int{12, 13},
+			stock:       map[int]*bingo.Card{12: void},
+			expectFind:  true,
+			expectedErr: bingo.ErrCardVoid,
+		},
+		{
+			caseName:     "card assigned concurrently",
+			actorId:      testGame.HostId,
+			inv:          testInv,
+			numbers:      []int{12, 13},
+			expectFind:   true,
+			expectAssign: true,
+			assignErr:    bingo.ErrCardAssigned,
+			expectedErr:  bingo.ErrCardAssigned,
+		},
+		{
+			caseName:     "no numbers",
+			actorId:      testGame.HostId,
+			inv:          testInv,
+			numbers:      []int{},
+			expectValErr: true,
+		},
+		{
+			caseName:     "same number twice",
+			actorId:      testGame.HostId,
+			inv:          testInv,
+			numbers:      []int{12, 12},
+			expectValErr: true,
+		},
+		{
+			caseName:    "invitation inactive",
+			actorId:     testGame.HostId,
+			inv:         inactive,
+			numbers:     []int{12},
+			expectedErr: bingo.ErrInvitationInactive,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			inv:         testInv,
+			numbers:     []int{12},
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *tc.inv))
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			if tc.expectFind {
+				mocks.cardRepo.ExpectFindByNumbers(func(_ context.Context, gameId string, numbers []int) ([]bingo.Card, error) {
+					require.Equal(t, testGame.ID, gameId, "cards must be found in the game")
+					cards := make([]bingo.Card, 0, len(numbers))
+					for _, n := range numbers {
+						c, ok := tc.stock[n]
+						if !ok {
+							c = MustMakeStockCard(t, testGame, n)
+						}
+						if c == nil {
+							continue
+						}
+						cards = append(cards, *c)
+					}
+					return cards, nil
+				})
+			}
+			var saved *bingo.Player
+			if tc.expectAssign {
+				mocks.invRepo.ExpectReserve(func(_ context.Context, _ *bingo.Invitation, cardAmount int) error {
+					require.Equal(t, len(tc.numbers), cardAmount, "room for the cards sold must be reserved")
+					return nil
+				})
+				mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+					p.ID = requiretest.UUIDv4(t)
+					saved = p
+					return nil
+				})
+				mocks.cardRepo.ExpectAssign(func(_ context.Context, _ string, numbers []int, playerId string) error {
+					require.Equal(t, tc.numbers, numbers, "cards sold must be assigned")
+					require.Equal(t, saved.ID, playerId, "cards must be assigned to the player saved")
+					return tc.assignErr
+				})
+			}
+
+			p, err := playerSvc.AssignCards(NewActorContext(t, tc.actorId), tc.inv.ID, "", "", tc.numbers)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.PlayerStatusJoined, p.Status, "player must have joined")
+			require.Empty(t, p.Email, "player may go without email")
+			require.Equal(t, tc.inv.ID, p.InvitationID, "player must have joined by the invitation")
+			require.Empty(t, p.DeliveryStatus, "cards sold at the door are not delivered")
+			require.Len(t, p.Cards, len(tc.numbers), "player must have the cards sold")
+			for i, c := range p.Cards {
+				require.Equal(t, tc.numbers[i], c.Number, "player must have the cards of the numbers")
+				require.Equal(t, p.ID, c.PlayerID, "cards must be owned by the player")
+			}
+		})
+	}
+}
+
+func TestPlayerService_CardStock(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testGame.NextCardNumber = 51
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		expectedErr error
+	}{
+		{
+			caseName: "success",
+			actorId:  testGame.HostId,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			if tc.expectedErr == nil {
+				mocks.cardRepo.ExpectCountUnassigned(func(_ context.Context, gameId string) (int, error) {
+					require.Equal(t, testGame.ID, gameId, "cards of the game must be counted")
+					return 38, nil
+				})
+			}
+
+			stock, err := playerSvc.CardStock(NewActorContext(t, tc.actorId), testGame.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, stock, "stock must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, 50, stock.Generated, "all cards generated for the game must be in stock")
+			require.Equal(t, 38, stock.Unassigned, "cards assigned to no player must be left")
+		})
+	}
+}
+
+// Make card of the game printed in advance, assigned to no player
+func MustMakeStockCard(tb testing.TB, g *bingo.Game, number int) *bingo.Card {
+	tb.Helper()
+
+	c := g.CreateRandomCard(rand.NewSource(int64(number)), number)
+	c.ID = requiretest.UUIDv4(tb)
+	return c
+}
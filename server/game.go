@@ -12,62 +12,117 @@ import (
 )
 
 var (
-	ErrGameNotFound = errors.New("game: game could not be found")
+	ErrGameNotFound   = errors.New("game: game could not be found")
+	ErrGameValidation = NewValErr("bingo: game validation failed")
 )
 
 type GameRepository interface {
 	Save(ctx context.Context, game *Game) error
 	Get(ctx context.Context, id string) (*Game, error)
+	Find(ctx context.Context, filter GameFilter) ([]Game, error)
+
+	// Allocate the amount of card numbers in the game atomically, and return the first of them. Saving the game does
+	// not change the next card number, so numbers are never handed out twice
+	AllocateCardNumbers(ctx context.Context, id string, amount int) (int, error)
+}
+
+// Filter games by their owner. Zero value fields are not filtered by
+type GameFilter struct {
+	HostID         string
+	OrganizationID string
 }
 
 type GameService struct {
-	gameRepo GameRepository
-	cardRepo CardRepository
+	gameRepo   GameRepository
+	cardRepo   CardRepository
+	outboxRepo OutboxRepository
+	authz      gameAuthorizer
+	ledger     creditLedger
+	cards      cardGenerator
+	tx         Transactor
+
+	// Verifies the tokens of the links members accept their invitations with
+	invitations MemberInvitationSigner
+}
+
+// List the games of the organization the actor is acting on behalf of, or the personal games of the actor if none.
+func (gs *GameService) List(ctx context.Context) ([]Game, error) {
+	actor := UserFromContext(ctx)
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+
+	if actor.ActiveOrganizationID == "" {
+		return gs.gameRepo.Find(ctx, GameFilter{HostID: actor.ID})
+	}
+	if err := gs.authz.authorizeOrganization(ctx, actor.ActiveOrganizationID); err != nil {
+		return nil, err
+	}
+
+	return gs.gameRepo.Find(ctx, GameFilter{OrganizationID: actor.ActiveOrganizationID})
+}
+
+// Get game by its id. Every member of the game is allowed to get it.
+func (gs *GameService) Get(ctx context.Context, id string) (*Game, error) {
+	return gs.getAuthorized(ctx, id, PermissionViewGame)
 }
 
-// Creates a new game and saves it.
+// Creates a new game and saves it, debiting the cost of creating it. Games can only be created with the actor as host,
+// and are owned by the organization the actor is acting on behalf of, if any.
 func (gs *GameService) Create(ctx context.Context, hostId string, name string) (*Game, error) {
-	g := CreateGame(hostId, name)
+	g, err := gs.newGame(ctx, hostId, name)
+	if err != nil {
+		return nil, err
+	}
 
-	// Try saving the new game
-	if err := gs.gameRepo.Save(ctx, g); err != nil {
+	err = gs.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return gs.saveNew(ctx, g)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return g, nil
 }
 
-// Creates a new game, saves it, and generates cards for it.
+// Creates a new game, saves it, and generates cards for it. The game is only created if the cost of creating it, and
+// of the cards, can be debited.
 func (gs *GameService) CreateWithCards(ctx context.Context, hostId, name string, cardAmount int) (*Game, []Card, error) {
-	g, err := gs.Create(ctx, hostId, name)
+	g, err := gs.newGame(ctx, hostId, name)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	cards, err := gs.GenerateCards(ctx, g.ID, cardAmount)
-
-	return g, cards, nil
-}
+	var cards []Card
+	err = gs.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := gs.saveNew(ctx, g); err != nil {
+			return err
+		}
 
-func (gs *GameService) GenerateCards(ctx context.Context, id string, amount int) ([]Card, error) {
-	// Try to get game from id
-	g, err := gs.gameRepo.Get(ctx, id)
+		cards, err = gs.cards.generate(ctx, g, cardAmount, CreditReasonCardsGenerated, "")
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Generate random cards for game
-	cards, nextCardNum := g.GenerateBulkRandomCards(amount)
+	return g, cards, nil
+}
 
-	// Save all the new cards that has been generated
-	err = gs.cardRepo.SaveAll(ctx, cards)
+// Generates cards for the game and saves them. Cards beyond the free allowance of the game are debited from the credits
+// of the account paying for the game, and refused with a domain error if the balance is insufficient.
+func (gs *GameService) GenerateCards(ctx context.Context, id string, amount int) ([]Card, error) {
+	// Try to get game from id, and make sure the actor may manage it
+	g, err := gs.getAuthorized(ctx, id, PermissionManageGame)
 	if err != nil {
 		return nil, err
 	}
 
-	// Save new next card number for game
-	g.NextCardNumber = nextCardNum
-	err = gs.gameRepo.Save(ctx, g)
+	var cards []Card
+	err = gs.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		cards, err = gs.cards.generate(ctx, g, amount, CreditReasonCardsGenerated, "")
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -77,8 +132,8 @@ func (gs *GameService) GenerateCards(ctx context.Context, id string, amount int)
 
 // Calls a new number in the game identified by the id and saves it.
 func (gs *GameService) CallNumber(ctx context.Context, id string, num int) (*Game, error) {
-	// Try to get game from id
-	g, err := gs.gameRepo.Get(ctx, id)
+	// Try to get game from id, and make sure the actor may call numbers
+	g, err := gs.getAuthorized(ctx, id, PermissionCallNumbers)
 	if err != nil {
 		return nil, err
 	}
@@ -96,10 +151,29 @@ func (gs *GameService) CallNumber(ctx context.Context, id string, num int) (*Gam
 	return g, nil
 }
 
+// Set the language mails to players of the game are written in, unless the players chose their own.
+func (gs *GameService) SetLanguage(ctx context.Context, id string, lang Language) (*Game, error) {
+	g, err := gs.getAuthorized(ctx, id, PermissionManageGame)
+	if err != nil {
+		return nil, err
+	}
+
+	g.Language = lang
+	g.UpdatedAt = time.Now()
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	if err := gs.gameRepo.Save(ctx, g); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
 // Matches winning card patterns against the card identified by cardId in game identified by gameId.
 func (gs *GameService) MatchWinningCardPattern(ctx context.Context, cardNum int, gameId string) ([]int, error) {
-	// Try to get game from id
-	g, err := gs.gameRepo.Get(ctx, gameId)
+	// Try to get game from id, and make sure the actor may check cards
+	g, err := gs.getAuthorized(ctx, gameId, PermissionCheckCards)
 	if err != nil {
 		return nil, err
 	}
@@ -119,14 +193,106 @@ func (gs *GameService) MatchWinningCardPattern(ctx context.Context, cardNum int,
 	return matches, nil
 }
 
+// Instantiate a new game hosted by the actor, owned by the organization the actor is acting on behalf of, if any
+func (gs *GameService) newGame(ctx context.Context, hostId string, name string) (*Game, error) {
+	g := CreateGame(hostId, name)
+	if err := gs.authz.authorize(ctx, g, PermissionManageGame); err != nil {
+		return nil, err
+	}
+	if orgId := UserFromContext(ctx).ActiveOrganizationID; orgId != "" {
+		if err := gs.authz.authorizeOrganization(ctx, orgId); err != nil {
+			return nil, err
+		}
+		g.OrganizationID = orgId
+	}
+
+	return g, nil
+}
+
+// Save the new game and debit the cost of creating it. Must be run within a transaction
+func (gs *GameService) saveNew(ctx context.Context, g *Game) error {
+	if err := gs.gameRepo.Save(ctx, g); err != nil {
+		return err
+	}
+
+	return gs.ledger.debit(ctx, g, GameCreationCost, CreditReasonGameCreated)
+}
+
+// Get game by id and authorize that the actor has a role in it granting the permission
+func (gs *GameService) getAuthorized(ctx context.Context, id string, perm Permission) (*Game, error) {
+	g, err := gs.gameRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := gs.authz.authorize(ctx, g, perm); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
 // Instantiate new game service with dependencies
-func NewGameService(gameRepo GameRepository, cardRepo CardRepository) *GameService {
+func NewGameService(repos Repositories, invitations MemberInvitationSigner, tx Transactor) *GameService {
 	return &GameService{
-		gameRepo: gameRepo,
-		cardRepo: cardRepo,
+		gameRepo:    repos.Games,
+		cardRepo:    repos.Cards,
+		outboxRepo:  repos.Outbox,
+		authz:       gameAuthorizer{orgRepo: repos.Organizations},
+		ledger:      newCreditLedger(repos),
+		cards:       newCardGenerator(repos),
+		tx:          tx,
+		invitations: invitations,
 	}
 }
 
+// Generates cards for games, and debits the credits for cards beyond the free allowance of the game. Use a transactor
+// to generate cards atomically.
+type cardGenerator struct {
+	gameRepo GameRepository
+	cardRepo CardRepository
+	ledger   creditLedger
+}
+
+func newCardGenerator(repos Repositories) cardGenerator {
+	return cardGenerator{
+		gameRepo: repos.Games,
+		cardRepo: repos.Cards,
+		ledger:   newCreditLedger(repos),
+	}
+}
+
+// Generate amount of cards for the game, owned by the player if the id is given, and save them. The card numbers are
+// allocated in the game atomically, so cards generated concurrently never share a number, and the next card number of
+// the game is updated to match.
+func (cg cardGenerator) generate(ctx context.Context, g *Game, amount int, reason CreditReason, playerId string) ([]Card, error) {
+	first, err := cg.gameRepo.AllocateCardNumbers(ctx, g.ID, amount)
+	if err != nil {
+		return nil, err
+	}
+	g.NextCardNumber = first
+
+	// Debit the credits for the cards before generating them
+	if cost := CardGenerationCost(first-1, amount); cost > 0 {
+		if err := cg.ledger.debit(ctx, g, cost, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	// Generate random cards for game
+	cards, nextCardNum := g.GenerateBulkRandomCards(amount)
+	for i := range cards {
+		cards[i].PlayerID = playerId
+	}
+
+	// Save all the new cards that has been generated
+	if err := cg.cardRepo.SaveAll(ctx, cards); err != nil {
+		return nil, err
+	}
+	g.NextCardNumber = nextCardNum
+
+	return cards, nil
+}
+
 // Game entity containing information about games created.
 type Game struct {
 	ID   string `json:"id"`
@@ -136,15 +302,28 @@ type Game struct {
 	HostId string `json:"hostId"`
 	Host   *User  `json:"host"`
 
+	// Organization owning the game, if created on behalf of one. Its members have a role in the game as well
+	OrganizationID string `json:"organizationId"`
+
+	// Helpers of the host, e.g. co-hosts and callers
+	Members []GameMember `json:"members"`
+
 	NextCardNumber int
 
 	CalledNumbers []Ball `json:"calledNumbers"`
 
+	// Language of mails to players, unless they chose their own
+	Language Language `json:"language"`
+
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
 func (g *Game) Validate() error {
+	if g.Language != "" && !g.Language.Valid() {
+		return ErrGameValidation.withFieldErr("Language", "noMatch", "language %s does not match any of the available: %s, %s", g.Language, LanguageDanish, LanguageEnglish)
+	}
+
 	return nil
 }
 
@@ -244,6 +423,7 @@ func (g *Game) CallNumber(num int) error {
 
 var (
 	ErrCardDoesNotBelongToGame = errors.New("game: card does not belong to this game")
+	ErrCardVoid                = errors.New("game: card has been revoked and is void")
 )
 
 // Matches winning card patterns and return the rows that matched.
@@ -253,6 +433,11 @@ func (g *Game) MatchWinningCardPatterns(card *Card) ([]int, error) {
 		return nil, ErrCardDoesNotBelongToGame
 	}
 
+	// Void cards are invalid, however many of their numbers have been called
+	if card.Void() {
+		return nil, ErrCardVoid
+	}
+
 	// Register the called numbers in a map so it is easy and cheap to check if a given number has been called
 	calledNumMap := make(map[int]bool)
 	for _, cn := range g.CalledNumbers {
@@ -288,6 +473,7 @@ func CreateGame(hostId string, name string) *Game {
 		HostId:         hostId,
 		NextCardNumber: 1,
 		Name:           name,
+		Language:       DefaultLanguage,
 		UpdatedAt:      now,
 		CreatedAt:      now,
 	}
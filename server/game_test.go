@@ -108,8 +108,11 @@ func TestGameService_Create(t *testing.T) {
 
 	var ErrGameRepo = errors.New("repo: error occurred")
 
+	hostId := requiretest.UUIDv4(t)
+
 	cases := []struct {
 		caseName    string
+		actorId     string
 		hostId      string
 		gameName    string
 		saveHandler mock.GameSaveHandler
@@ -118,7 +121,8 @@ func TestGameService_Create(t *testing.T) {
 	}{
 		{
 			"success",
-			requiretest.UUIDv4(t),
+			hostId,
+			hostId,
 			"success game",
 			func(_ context.Context, g *bingo.Game) error {
 				g.ID = requiretest.UUIDv4(t)
@@ -132,7 +136,8 @@ func TestGameService_Create(t *testing.T) {
 		},
 		{
 			"repo error",
-			requiretest.UUIDv4(t),
+			hostId,
+			hostId,
 			"failing game",
 			func(_ context.Context, g *bingo.Game) error {
 				return ErrGameRepo
@@ -140,16 +145,33 @@ func TestGameService_Create(t *testing.T) {
 			true,
 			ErrGameRepo,
 		},
+		{
+			"forbidden other host",
+			requiretest.UUIDv4(t),
+			hostId,
+			"forbidden game",
+			nil,
+			true,
+			bingo.ErrForbidden,
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.caseName, func(t *testing.T) {
 			gameSvc, mocks := MustCreateGameService(t)
 			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.userRepo.RequireExpectationsMet()
+			defer mocks.creditRepo.RequireExpectationsMet()
 
-			mocks.gameRepo.ExpectSave(tc.saveHandler)
+			if tc.saveHandler != nil {
+				mocks.gameRepo.ExpectSave(tc.saveHandler)
+			}
+			if !tc.expectErr {
+				mocks.userRepo.ExpectAddCredits(MakeUserAddCreditsHandler(t, tc.hostId, bingo.GameCreationCost))
+				mocks.creditRepo.ExpectAppend(MakeCreditAppendHandler(t))
+			}
 
-			g, err := gameSvc.Create(context.Background(), tc.hostId, tc.gameName)
+			g, err := gameSvc.Create(NewActorContext(t, tc.actorId), tc.hostId, tc.gameName)
 			if tc.expectErr {
 				require.Nil(t, g, "game must be nil when error is expected")
 				require.Error(t, err, "error must be set when error is expected")
@@ -190,6 +212,7 @@ func TestGameService_CallNumber(t *testing.T) {
 
 	cases := []struct {
 		caseName     string
+		actorId      string
 		gameId       string
 		calledNumber int
 		getHandler   mock.GameGetHandler
@@ -233,6 +256,15 @@ func TestGameService_CallNumber(t *testing.T) {
 			expectErr:    true,
 			expectedErr:  ErrGameRepo,
 		},
+		{
+			caseName:     "forbidden other host",
+			actorId:      requiretest.UUIDv4(t),
+			gameId:       testGame.ID,
+			calledNumber: 3,
+			getHandler:   testGameGetHandler,
+			expectErr:    true,
+			expectedErr:  bingo.ErrForbidden,
+		},
 	}
 
 	for _, tc := range cases {
@@ -245,7 +277,12 @@ func TestGameService_CallNumber(t *testing.T) {
 				mocks.gameRepo.ExpectSave(tc.saveHandler)
 			}
 
-			g, err := gameSvc.CallNumber(context.Background(), tc.gameId, tc.calledNumber)
+			actorId := testGame.HostId
+			if tc.actorId != "" {
+				actorId = tc.actorId
+			}
+
+			g, err := gameSvc.CallNumber(NewActorContext(t, actorId), tc.gameId, tc.calledNumber)
 			if tc.expectErr {
 				require.Nil(t, g, "game must be nil when error is expected")
 				require.Error(t, err, "error must be set when error is expected")
@@ -266,6 +303,64 @@ func TestGameService_CallNumber(t *testing.T) {
 	}
 }
 
+func TestGameService_SetLanguage(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+
+	cases := []struct {
+		caseName     string
+		actorId      string
+		lang         bingo.Language
+		expectValErr bool
+		expectedErr  error
+	}{
+		{
+			caseName: "success",
+			actorId:  testGame.HostId,
+			lang:     bingo.LanguageDanish,
+		},
+		{
+			caseName:     "unknown language",
+			actorId:      testGame.HostId,
+			lang:         bingo.Language("xx"),
+			expectValErr: true,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			lang:        bingo.LanguageDanish,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+			if !tc.expectValErr && tc.expectedErr == nil {
+				mocks.gameRepo.ExpectSave(MakeGameSaveHandler(t))
+			}
+
+			g, err := gameSvc.SetLanguage(NewActorContext(t, tc.actorId), testGame.ID, tc.lang)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, g, "game must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, tc.lang, g.Language, "language must be set on the game")
+		})
+	}
+}
+
 func TestGameService_GenerateCards(t *testing.T) {
 
 	var ErrGameRepo = errors.New("repo: error occurred")
@@ -273,7 +368,7 @@ func TestGameService_GenerateCards(t *testing.T) {
 	testGame := MustMakeTestGame(t)
 
 	gameGetHandler := MakeSingleGameGetHandler(t, *testGame)
-	gameSaveHandler := MakeGameSaveHandler(t)
+	allocateHandler := MakeCardNumbersAllocateHandler(t, *testGame)
 	cardSaveAllHandler := func(ctx context.Context, cards []bingo.Card) error {
 		for _, c := range cards {
 			require.NotEmpty(t, c.GameID, "cardsaveallhandler: a game id must be present before cards can be saved")
@@ -283,10 +378,11 @@ func TestGameService_GenerateCards(t *testing.T) {
 
 	cases := []struct {
 		caseName           string
+		actorId            string
 		gameId             string
 		cardAmount         int
 		gameGetHandler     mock.GameGetHandler
-		gameSaveHandler    mock.GameSaveHandler
+		allocateHandler    mock.GameAllocateCardNumbersHandler
 		cardSaveAllHandler mock.CardSaveAllHandler
 		expectErr          bool
 		expectedErr        error
@@ -296,7 +392,7 @@ func TestGameService_GenerateCards(t *testing.T) {
 			gameId:             testGame.ID,
 			cardAmount:         3,
 			gameGetHandler:     gameGetHandler,
-			gameSaveHandler:    gameSaveHandler,
+			allocateHandler:    allocateHandler,
 			cardSaveAllHandler: cardSaveAllHandler,
 			expectErr:          false,
 			expectedErr:        nil,
@@ -307,7 +403,7 @@ func TestGameService_GenerateCards(t *testing.T) {
 			cardAmount:         3,
 			gameGetHandler:     gameGetHandler,
 			cardSaveAllHandler: nil,
-			gameSaveHandler:    nil,
+			allocateHandler:    nil,
 			expectErr:          true,
 			expectedErr:        bingo.ErrGameNotFound,
 		},
@@ -316,19 +412,28 @@ func TestGameService_GenerateCards(t *testing.T) {
 			gameId:             testGame.ID,
 			cardAmount:         3,
 			gameGetHandler:     gameGetHandler,
+			allocateHandler:    allocateHandler,
 			cardSaveAllHandler: func(ctx context.Context, cards []bingo.Card) error { return bingo.ErrCardNumberExists },
 			expectErr:          true,
 			expectedErr:        bingo.ErrCardNumberExists,
 		},
 		{
-			caseName:           "save game error",
-			gameId:             testGame.ID,
-			cardAmount:         3,
-			gameGetHandler:     gameGetHandler,
-			cardSaveAllHandler: cardSaveAllHandler,
-			gameSaveHandler:    func(ctx context.Context, game *bingo.Game) error { return ErrGameRepo },
-			expectErr:          true,
-			expectedErr:        ErrGameRepo,
+			caseName:        "allocate card numbers error",
+			gameId:          testGame.ID,
+			cardAmount:      3,
+			gameGetHandler:  gameGetHandler,
+			allocateHandler: func(ctx context.Context, id string, amount int) (int, error) { return 0, ErrGameRepo },
+			expectErr:       true,
+			expectedErr:     ErrGameRepo,
+		},
+		{
+			caseName:       "forbidden other host",
+			actorId:        requiretest.UUIDv4(t),
+			gameId:         testGame.ID,
+			cardAmount:     3,
+			gameGetHandler: gameGetHandler,
+			expectErr:      true,
+			expectedErr:    bingo.ErrForbidden,
 		},
 	}
 
@@ -338,14 +443,19 @@ func TestGameService_GenerateCards(t *testing.T) {
 			defer mocks.gameRepo.RequireExpectationsMet()
 
 			mocks.gameRepo.ExpectGet(tc.gameGetHandler)
+			if tc.allocateHandler != nil {
+				mocks.gameRepo.ExpectAllocateCardNumbers(tc.allocateHandler)
+			}
 			if tc.cardSaveAllHandler != nil {
 				mocks.cardRepo.ExpectSaveAll(tc.cardSaveAllHandler)
 			}
-			if tc.gameSaveHandler != nil {
-				mocks.gameRepo.ExpectSave(tc.gameSaveHandler)
+
+			actorId := testGame.HostId
+			if tc.actorId != "" {
+				actorId = tc.actorId
 			}
 
-			cards, err := gameSvc.GenerateCards(context.Background(), tc.gameId, tc.cardAmount)
+			cards, err := gameSvc.GenerateCards(NewActorContext(t, actorId), tc.gameId, tc.cardAmount)
 			if tc.expectErr {
 				require.Nil(t, cards, "game must be nil when error is expected")
 				require.Error(t, err, "error must be set when error is expected")
@@ -370,9 +480,163 @@ func TestGameService_GenerateCards(t *testing.T) {
 	}
 }
 
+func TestGameService_GenerateCardsAllocatesNumbers(t *testing.T) {
+	testGame := MustMakeTestGame(t)
+
+	// Cards generated concurrently since the game was read have taken the numbers up to 10
+	allocated := *testGame
+	allocated.NextCardNumber = 11
+
+	gameSvc, mocks := MustCreateGameService(t)
+	defer mocks.gameRepo.RequireExpectationsMet()
+	defer mocks.cardRepo.RequireExpectationsMet()
+
+	mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+	mocks.gameRepo.ExpectAllocateCardNumbers(MakeCardNumbersAllocateHandler(t, allocated))
+	mocks.cardRepo.ExpectSaveAll(func(_ context.Context, _ []bingo.Card) error { return nil })
+
+	cards, err := gameSvc.GenerateCards(NewActorContext(t, testGame.HostId), testGame.ID, 3)
+	require.NoError(t, err, "no error is expected")
+	for i, c := range cards {
+		require.Equal(t, 11+i, c.Number, "cards must be numbered from the numbers allocated, not the game read")
+	}
+}
+
+func TestGameService_Get(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testGameGetHandler := MakeSingleGameGetHandler(t, *testGame)
+
+	cases := []struct {
+		caseName    string
+		ctx         context.Context
+		gameId      string
+		expectedErr error
+	}{
+		{
+			caseName: "success",
+			ctx:      NewActorContext(t, testGame.HostId),
+			gameId:   testGame.ID,
+		},
+		{
+			caseName:    "game not found",
+			ctx:         NewActorContext(t, testGame.HostId),
+			gameId:      "",
+			expectedErr: bingo.ErrGameNotFound,
+		},
+		{
+			caseName:    "forbidden other host",
+			ctx:         NewActorContext(t, requiretest.UUIDv4(t)),
+			gameId:      testGame.ID,
+			expectedErr: bingo.ErrForbidden,
+		},
+		{
+			caseName:    "forbidden no actor",
+			ctx:         context.Background(),
+			gameId:      testGame.ID,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(testGameGetHandler)
+
+			g, err := gameSvc.Get(tc.ctx, tc.gameId)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, g, "game must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.Equal(t, testGame.ID, g.ID, "game must be the one asked for")
+			}
+		})
+	}
+}
+
+func TestGameService_MatchWinningCardPattern(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testGameGetHandler := MakeSingleGameGetHandler(t, *testGame)
+	testCard := testGame.CreateRandomCard(rand.NewSource(1), 1)
+	voidCard := testGame.CreateRandomCard(rand.NewSource(2), 2)
+	voidCard.Revocation = &bingo.CardRevocation{RevokedBy: testGame.HostId, Reason: "paid with a fake note", RevokedAt: time.Now()}
+	cardGetByNumberHandler := func(_ context.Context, cardNum int, gameId string) (*bingo.Card, error) {
+		for _, c := range []*bingo.Card{testCard, voidCard} {
+			if cardNum == c.Number && gameId == c.GameID {
+				return c, nil
+			}
+		}
+		return nil, bingo.ErrCardNotFound
+	}
+
+	cases := []struct {
+		caseName          string
+		actorId           string
+		cardNumber        int
+		expectGetByNumber bool
+		expectedErr       error
+	}{
+		{
+			caseName:          "success",
+			actorId:           testGame.HostId,
+			cardNumber:        testCard.Number,
+			expectGetByNumber: true,
+		},
+		{
+			caseName:          "card void",
+			actorId:           testGame.HostId,
+			cardNumber:        voidCard.Number,
+			expectGetByNumber: true,
+			expectedErr:       bingo.ErrCardVoid,
+		},
+		{
+			caseName:          "card not found",
+			actorId:           testGame.HostId,
+			cardNumber:        voidCard.Number + 1,
+			expectGetByNumber: true,
+			expectedErr:       bingo.ErrCardNotFound,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			cardNumber:  testCard.Number,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(testGameGetHandler)
+			if tc.expectGetByNumber {
+				mocks.cardRepo.ExpectGetByNumber(cardGetByNumberHandler)
+			}
+
+			rows, err := gameSvc.MatchWinningCardPattern(NewActorContext(t, tc.actorId), tc.cardNumber, testGame.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, rows, "rows must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.NotNil(t, rows, "rows must be set when no error is expected")
+			}
+		})
+	}
+}
+
 type gameServiceMocks struct {
-	gameRepo *mock.GameRepository
-	cardRepo *mock.CardRespository
+	gameRepo   *mock.GameRepository
+	cardRepo   *mock.CardRespository
+	orgRepo    *mock.OrganizationRepository
+	userRepo   *mock.UserRepository
+	creditRepo *mock.CreditRepository
+	outboxRepo *mock.OutboxRepository
 }
 
 func MustCreateGameService(tb testing.TB) (*bingo.GameService, *gameServiceMocks) {
@@ -380,11 +644,26 @@ func MustCreateGameService(tb testing.TB) (*bingo.GameService, *gameServiceMocks
 
 	gameRepo := mock.NewGameRepository(tb)
 	cardRepo := mock.NewCardRepository(tb)
-
-	gameSvc := bingo.NewGameService(gameRepo, cardRepo)
+	orgRepo := mock.NewOrganizationRepository(tb)
+	userRepo := mock.NewUserRepository(tb)
+	creditRepo := mock.NewCreditRepository(tb)
+	outboxRepo := mock.NewOutboxRepository(tb)
+
+	gameSvc := bingo.NewGameService(bingo.Repositories{
+		Users:         userRepo,
+		Organizations: orgRepo,
+		Games:         gameRepo,
+		Cards:         cardRepo,
+		Credits:       creditRepo,
+		Outbox:        outboxRepo,
+	}, mock.MemberInvitationSigner{}, mock.NewTransactor(tb))
 	mocks := &gameServiceMocks{
-		gameRepo: gameRepo,
-		cardRepo: cardRepo,
+		gameRepo:   gameRepo,
+		cardRepo:   cardRepo,
+		orgRepo:    orgRepo,
+		userRepo:   userRepo,
+		creditRepo: creditRepo,
+		outboxRepo: outboxRepo,
 	}
 
 	return gameSvc, mocks
@@ -404,6 +683,21 @@ func MakeGameSaveHandler(tb testing.TB) mock.GameSaveHandler {
 	}
 }
 
+// Make handler allocating card numbers in the game, starting from its next card number
+func MakeCardNumbersAllocateHandler(tb testing.TB, game bingo.Game) mock.GameAllocateCardNumbersHandler {
+	tb.Helper()
+
+	return func(_ context.Context, id string, amount int) (int, error) {
+		if id != game.ID {
+			return 0, bingo.ErrGameNotFound
+		}
+
+		first := game.NextCardNumber
+		game.NextCardNumber += amount
+		return first, nil
+	}
+}
+
 func MakeSingleGameGetHandler(tb testing.TB, game bingo.Game) mock.GameGetHandler {
 	tb.Helper()
 
@@ -416,6 +710,13 @@ func MakeSingleGameGetHandler(tb testing.TB, game bingo.Game) mock.GameGetHandle
 	}
 }
 
+// Make context with a user acting as the actor
+func NewActorContext(tb testing.TB, userId string) context.Context {
+	tb.Helper()
+
+	return bingo.NewContextWithUser(context.Background(), &bingo.User{ID: userId})
+}
+
 func MustMakeTestGame(tb testing.TB) *bingo.Game {
 	tb.Helper()
 
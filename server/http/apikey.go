@@ -0,0 +1,142 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Response data for a newly created api key. The plain key is only ever shown here
+type createdAPIKeyResponse struct {
+	APIKey *bingo.APIKey `json:"apiKey"`
+	Key    string        `json:"key"`
+}
+
+func (s *Server) getAPIKeys() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		user := bingo.UserFromContext(r.Context())
+		keys, err := s.APIKeyService.List(r.Context(), user.ID)
+		if err != nil {
+			s.Log.Errf("could not list api keys for user id %s due to error:\n%v\n", user.ID, err)
+
+			status = http.StatusInternalServerError
+			message = "Unknown error occured"
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = keys
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) postAPIKey() http.HandlerFunc {
+	type requestBody struct {
+		Name   string   `json:"name" validate:"required"`
+		Scopes []string `json:"scopes" validate:"required,min=1"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		scopes := make([]bingo.Scope, 0, len(body.Scopes))
+		for _, sc := range body.Scopes {
+			scopes = append(scopes, bingo.Scope(sc))
+		}
+
+		user := bingo.UserFromContext(r.Context())
+		k, key, err := s.APIKeyService.Create(r.Context(), user.ID, body.Name, scopes)
+		if err != nil {
+			s.Log.Errf("could not create api key for user id %s due to error:\n%v\n", user.ID, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusCreated
+		data = createdAPIKeyResponse{
+			APIKey: k,
+			Key:    key,
+		}
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) deleteAPIKey() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get api key id from url
+		keyId, ok := s.requireParam(rw, r, "apiKeyID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		user := bingo.UserFromContext(r.Context())
+		k, err := s.APIKeyService.Revoke(r.Context(), user.ID, keyId)
+		if err != nil {
+			s.Log.Errf("could not revoke api key for given api key id %s due to error:\n%v\n", keyId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrAPIKeyNotFound):
+				status = http.StatusNotFound
+				message = "API key could not be found"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = k
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) registerAPIKeyRoutes(r *mux.Router, middleware ...mux.MiddlewareFunc) {
+
+	r.Use(middleware...)
+
+	r.HandleFunc("/", s.getAPIKeys()).Methods(http.MethodGet)
+	r.HandleFunc("/", s.postAPIKey()).Methods(http.MethodPost)
+	r.HandleFunc("/{apiKeyID}", s.deleteAPIKey()).Methods(http.MethodDelete)
+}
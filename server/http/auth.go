@@ -8,6 +8,12 @@ import (
 	bingo "github.com/nohns/bingo-box/server"
 )
 
+// Response data for successful authentication
+type authResponse struct {
+	User *bingo.User `json:"user"`
+	*bingo.AuthTokens
+}
+
 func (s *Server) getAuthenticate() http.HandlerFunc {
 	type requestBody struct {
 		Email    string `json:"email" validate:"required,email"`
@@ -45,16 +51,110 @@ func (s *Server) getAuthenticate() http.HandlerFunc {
 			return
 		}
 
+		// Issue access and refresh tokens for the authenticated user
+		tokens, err := s.TokenService.Issue(r.Context(), user)
+		if err != nil {
+			s.Log.Errf("could not issue tokens for user id %s due to error:\n%v\n", user.ID, err)
+
+			status = http.StatusInternalServerError
+			message = "Unknown error occured"
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
 		// Set response payload
 		status = http.StatusOK
-		data = user
+		data = authResponse{
+			User:       user,
+			AuthTokens: tokens,
+		}
 		s.writeJsonPayload(rw, status, message, data)
 	}
 }
 
 func (s *Server) postRefresh() http.HandlerFunc {
+	type requestBody struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		user, tokens, err := s.TokenService.Refresh(r.Context(), body.RefreshToken)
+		if err != nil {
+			s.Log.Errf("could not refresh tokens due to error:\n%v\n", err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case
+				errors.Is(err, bingo.ErrInvalidRefreshToken),
+				errors.Is(err, bingo.ErrRefreshTokenReused):
+				status = http.StatusUnauthorized
+				message = "Refresh token is invalid"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = authResponse{
+			User:       user,
+			AuthTokens: tokens,
+		}
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) postLogout() http.HandlerFunc {
+	type requestBody struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
+	}
 	return func(rw http.ResponseWriter, r *http.Request) {
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
 
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		if err := s.TokenService.Revoke(r.Context(), body.RefreshToken); err != nil {
+			s.Log.Errf("could not revoke refresh token due to error:\n%v\n", err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrInvalidRefreshToken):
+				status = http.StatusUnauthorized
+				message = "Refresh token is invalid"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		message = "Logged out"
+		s.writeJsonPayload(rw, status, message, data)
 	}
 }
 
@@ -83,7 +183,12 @@ func (s *Server) postRegister() http.HandlerFunc {
 			s.Log.Errf("could not register user due to error:\n%v\n", err)
 
 			// Try to check what kind of error we are dealing withs
+			var valErr bingo.ValidationErr
 			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
 			case errors.Is(err, bingo.ErrUserAlreadyExists):
 				status = http.StatusConflict
 				data = validationData{
@@ -120,4 +225,5 @@ func (s *Server) registerAuthRoutes(r *mux.Router) {
 	r.HandleFunc("/login", s.getAuthenticate()).Methods(http.MethodGet)
 	r.HandleFunc("/register", s.postRegister()).Methods(http.MethodPost)
 	r.HandleFunc("/refresh", s.postRefresh()).Methods(http.MethodPost)
+	r.HandleFunc("/logout", s.postLogout()).Methods(http.MethodPost)
 }
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Get the credit balance and ledger of the active organization, or the authenticated user if none
+func (s *Server) getCredits() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		statement, err := s.CreditService.Statement(r.Context())
+		if err != nil {
+			s.Log.Errf("could not get credit statement due to error:\n%v\n", err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access credits of the active organization"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = statement
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Grant credits to a user or an organization. Admins only
+func (s *Server) postCreditGrant() http.HandlerFunc {
+	type requestBody struct {
+		UserID         string `json:"userId"`
+		OrganizationID string `json:"organizationId"`
+		Amount         int    `json:"amount" validate:"required,min=1"`
+		Note           string `json:"note"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		acc := bingo.CreditAccount{
+			UserID:         body.UserID,
+			OrganizationID: body.OrganizationID,
+		}
+		e, err := s.CreditService.Grant(r.Context(), acc, body.Amount, body.Note)
+		if err != nil {
+			s.Log.Errf("could not grant %d credits to account %+v due to error:\n%v\n", body.Amount, acc, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrUserNotFound):
+				status = http.StatusNotFound
+				message = "User could not be found"
+			case errors.Is(err, bingo.ErrOrganizationNotFound):
+				status = http.StatusNotFound
+				message = "Organization could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to grant credits"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusCreated
+		data = e
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) registerCreditRoutes(r *mux.Router, middleware ...mux.MiddlewareFunc) {
+
+	r.Use(middleware...)
+
+	r.HandleFunc("/", s.getCredits()).Methods(http.MethodGet)
+	r.HandleFunc("/grants", s.postCreditGrant()).Methods(http.MethodPost)
+}
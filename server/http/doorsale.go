@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Sell cards printed in advance to a player walking in at the door. Cards are given by their numbers, by ranges of
+// numbers, or both
+func (s *Server) postInvitationDoorSale() http.HandlerFunc {
+	type requestBody struct {
+		Name    string                  `json:"name"`
+		Email   string                  `json:"email" validate:"omitempty,email"`
+		Numbers []int                   `json:"numbers"`
+		Ranges  []bingo.CardNumberRange `json:"ranges"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		invId, ok := s.requireParam(rw, r, "invID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Ranges are counted before they are expanded, so huge ranges are rejected without allocating them
+		amount := len(body.Numbers)
+		for _, rng := range body.Ranges {
+			if rng.From < 1 || rng.Len() == 0 {
+				s.writeJsonPayload(rw, http.StatusBadRequest, "Ranges must start at a card number and not end before they start", nil)
+				return
+			}
+			amount += rng.Len()
+			if amount > bingo.DoorSaleMaxCards {
+				s.writeJsonPayload(rw, http.StatusBadRequest, fmt.Sprintf("At most %d cards can be sold at once", bingo.DoorSaleMaxCards), nil)
+				return
+			}
+		}
+		numbers := body.Numbers
+		for _, rng := range body.Ranges {
+			numbers = append(numbers, rng.Numbers()...)
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		player, err := s.PlayerService.AssignCards(r.Context(), invId, body.Name, body.Email, numbers)
+		if err != nil {
+			s.Log.Errf("could not sell cards at the door for given inv id %s due to error:\n%v\n", invId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "Invitation could not be found"
+			case errors.Is(err, bingo.ErrCardNotFound):
+				status = http.StatusNotFound
+				message = "Not all cards exist in the game"
+			case errors.Is(err, bingo.ErrInvitationInactive):
+				status = http.StatusGone
+				message = "Invitation is no longer active"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to sell cards"
+			case errors.Is(err, bingo.ErrCardAssigned):
+				status = http.StatusConflict
+				message = "A card has already been sold"
+			case errors.Is(err, bingo.ErrCardVoid):
+				status = http.StatusConflict
+				message = "A card has been revoked and is void"
+			case errors.Is(err, bingo.ErrPlayerExists):
+				status = http.StatusConflict
+				message = "A player has already joined with the email"
+			case errors.Is(err, bingo.ErrInvitationFull):
+				status = http.StatusConflict
+				message = "Invitation is sold out"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusCreated
+		data = player
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) getCardStock() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		stock, err := s.PlayerService.CardStock(r.Context(), gameId)
+		if err != nil {
+			s.Log.Errf("could not get card stock for given game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to view the cards of the game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = stock
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
@@ -1,20 +1,85 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
 )
 
 func (s *Server) getGames() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		games, err := s.GameService.List(r.Context())
+		if err != nil {
+			s.Log.Errf("could not list games due to error:\n%v\n", err)
 
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to list games of the active organization"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = games
+		s.writeJsonPayload(rw, status, message, data)
 	}
 }
 
 func (s *Server) getGame() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
 
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		game, err := s.GameService.Get(r.Context(), gameId)
+		if err != nil {
+			s.Log.Errf("could not get game for given game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = game
+		s.writeJsonPayload(rw, status, message, data)
 	}
 }
 
@@ -36,9 +101,221 @@ func (s *Server) deleteGame() http.HandlerFunc {
 	}
 }
 
+func (s *Server) postCalledNumber() http.HandlerFunc {
+	type requestBody struct {
+		Number int `json:"number" validate:"required,min=1,max=90"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		game, err := s.GameService.CallNumber(r.Context(), gameId, body.Number)
+		if err != nil {
+			s.Log.Errf("could not call number %d in game id %s due to error:\n%v\n", body.Number, gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access game"
+			case errors.Is(err, bingo.ErrCalledNumberExists):
+				status = http.StatusConflict
+				message = "Number has already been called"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = game
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Set the language mails to players of the game are written in
+func (s *Server) putGameLanguage() http.HandlerFunc {
+	type requestBody struct {
+		Language string `json:"language" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		game, err := s.GameService.SetLanguage(r.Context(), gameId, bingo.Language(body.Language))
+		if err != nil {
+			s.Log.Errf("could not set language %s of game id %s due to error:\n%v\n", body.Language, gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to manage game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = game
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) postCards() http.HandlerFunc {
+	type requestBody struct {
+		Amount int `json:"amount" validate:"required,min=1,max=1000"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		cards, err := s.GameService.GenerateCards(r.Context(), gameId, body.Amount)
+		if err != nil {
+			s.Log.Errf("could not generate cards for game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access game"
+			case errors.Is(err, bingo.ErrInsufficientCredits):
+				status = http.StatusPaymentRequired
+				message = "Insufficient game credits"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusCreated
+		data = cards
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
 func (s *Server) getCardMatch() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id and card number from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+		cardNumParam, ok := s.requireParam(rw, r, "cardNumber")
+		if !ok {
+			return
+		}
+		cardNum, err := strconv.Atoi(cardNumParam)
+		if err != nil {
+			s.writeJsonPayload(rw, http.StatusBadRequest, "Bad url parameters", map[string]string{"paramName": "cardNumber"})
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
 
+		rows, err := s.GameService.MatchWinningCardPattern(r.Context(), cardNum, gameId)
+		if err != nil {
+			s.Log.Errf("could not match card number %d in game id %s due to error:\n%v\n", cardNum, gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access game"
+			case errors.Is(err, bingo.ErrCardNotFound):
+				status = http.StatusNotFound
+				message = "Card could not be found"
+			case errors.Is(err, bingo.ErrCardVoid):
+				status = http.StatusConflict
+				message = "Card has been revoked and is void"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = map[string][]int{"matchedRows": rows}
+		s.writeJsonPayload(rw, status, message, data)
 	}
 }
 
@@ -46,12 +323,25 @@ func (s *Server) registerGameRoutes(r *mux.Router, middleware ...mux.MiddlewareF
 
 	r.Use(middleware...)
 
-	r.HandleFunc("/", s.getGames()).Methods(http.MethodGet)
-	r.HandleFunc("/", s.postGame()).Methods(http.MethodPost)
-	r.HandleFunc("/:gameId", s.getGame()).Methods(http.MethodGet)
-	r.HandleFunc("/:gameId", s.patchGame()).Methods(http.MethodPatch)
-	r.HandleFunc("/:gameId", s.deleteGame()).Methods(http.MethodDelete)
+	r.HandleFunc("/", s.requireScope(bingo.ScopeGamesRead, s.getGames())).Methods(http.MethodGet)
+	r.Handle("/", s.requireUserSession(s.postGame())).Methods(http.MethodPost)
+	r.HandleFunc("/{gameID}", s.requireScope(bingo.ScopeGamesRead, s.getGame())).Methods(http.MethodGet)
+	r.Handle("/{gameID}", s.requireUserSession(s.patchGame())).Methods(http.MethodPatch)
+	r.Handle("/{gameID}", s.requireUserSession(s.deleteGame())).Methods(http.MethodDelete)
 
 	// Actions methods
-	r.HandleFunc("/:gameId/matchCard/:cardNumber", s.getCardMatch()).Methods(http.MethodGet)
+	r.HandleFunc("/{gameID}/numbers", s.requireScope(bingo.ScopeGamesCall, s.postCalledNumber())).Methods(http.MethodPost)
+	r.HandleFunc("/{gameID}/cards", s.requireScope(bingo.ScopeCardsGenerate, s.postCards())).Methods(http.MethodPost)
+	r.HandleFunc("/{gameID}/cards/{cardNumber}/match", s.requireScope(bingo.ScopeGamesRead, s.getCardMatch())).Methods(http.MethodGet)
+	r.Handle("/{gameID}/cards/stock", s.requireUserSession(s.getCardStock())).Methods(http.MethodGet)
+	r.Handle("/{gameID}/language", s.requireUserSession(s.putGameLanguage())).Methods(http.MethodPut)
+
+	// Previews of mails sent to players of the game
+	r.Handle("/{gameID}/mails/player-cards/preview", s.requireUserSession(s.getPlayerCardsMailPreview())).Methods(http.MethodGet)
+
+	// Players who joined the game
+	r.Handle("/{gameID}/players", s.requireUserSession(s.getGamePlayers())).Methods(http.MethodGet)
+
+	// Members of the game are only managed by users themselves
+	s.registerMemberRoutes(r.PathPrefix("/{gameID}/members").Subrouter(), s.requireUserSession)
 }
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Header carrying the shared secret of webhook calls
+const hookSecretHeader = "X-Hook-Secret"
+
+// Only let webhook calls carrying the shared hook secret through. All calls are rejected if no secret is configured
+func (s *Server) authenticateHook(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(hookSecretHeader)
+		if s.HookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(s.HookSecret)) != 1 {
+			s.Log.Errf("rejected webhook call to %s with missing or wrong secret\n", r.URL.Path)
+			s.writeJsonPayload(rw, http.StatusUnauthorized, "Hook secret is invalid", nil)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Called by Kratos after registration and identity updates. The payload is made by kratos/kratos.hook.jsonnet
+func (s *Server) postKratosIdentityHook() http.HandlerFunc {
+	type requestBody struct {
+		IdentityID string `json:"identityId" validate:"required"`
+		Email      string `json:"email" validate:"required,email"`
+		Name       struct {
+			First string `json:"first"`
+			Last  string `json:"last"`
+		} `json:"name"`
+		TOS           bool `json:"tos"`
+		EmailVerified bool `json:"emailVerified"`
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload. Defer writing payload to response writer until handler has returned
+		var status int
+		var message string
+		var data interface{}
+
+		user, err := s.UserService.SyncIdentity(r.Context(), &bingo.Identity{
+			ID:            body.IdentityID,
+			Email:         body.Email,
+			FirstName:     body.Name.First,
+			LastName:      body.Name.Last,
+			EmailVerified: body.EmailVerified,
+			TOSAccepted:   body.TOS,
+		})
+		if err != nil {
+			s.Log.Errf("could not sync user with identity id %s due to error:\n%v\n", body.IdentityID, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrUserAlreadyExists):
+				status = http.StatusConflict
+				message = "Email belongs to another user"
+			case errors.Is(err, bingo.ErrIdentityEmailUnverified):
+				status = http.StatusConflict
+				message = "Email belongs to another user and has not been verified"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = user
+
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) registerHookRoutes(r *mux.Router, middleware ...mux.MiddlewareFunc) {
+
+	r.Use(middleware...)
+
+	r.HandleFunc("/kratos/identity", s.postKratosIdentityHook()).Methods(http.MethodPost)
+}
@@ -1,24 +1,56 @@
 package http
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/pdf"
+	"github.com/nohns/bingo-box/server/qr"
 )
 
+// Criterion of a request body. Values depend on the kind, which the domain validates
+type invitationCriterionBody struct {
+	Kind     string                    `json:"kind" validate:"required"`
+	Field    string                    `json:"field"`
+	Value    string                    `json:"value"`
+	Values   []string                  `json:"values"`
+	Criteria []invitationCriterionBody `json:"criteria"`
+}
+
+// Convert criteria of a request body, including the criteria they group
+func criteriaFromBody(body []invitationCriterionBody) []bingo.InvitationCriterion {
+	criteria := make([]bingo.InvitationCriterion, 0, len(body))
+	for _, c := range body {
+		criteria = append(criteria, bingo.InvitationCriterion{
+			Kind:     bingo.InvitationCriterionKind(c.Kind),
+			Field:    bingo.InvitationCriterionField(c.Field),
+			Value:    c.Value,
+			Values:   c.Values,
+			Criteria: criteriaFromBody(c.Criteria),
+		})
+	}
+
+	return criteria
+}
+
 func (s *Server) postInvitation() http.HandlerFunc {
 	type requestBody struct {
-		GameID         string `json:"gameId" validate:"required"`
-		DeliveryMethod string `json:"deliveryMethod" validate:"required"`
-		MaxCardAmount  int    `json:"maxCardAmount" validate:"required"`
-		Active         bool   `json:"active" validate:"required"`
-		Criteria       []struct {
-			Field string `json:"field" validate:"required"`
-			Kind  string `json:"kind" validate:"required"`
-			Value string `json:"value" validate:"required"`
-		}
+		GameID              string                    `json:"gameId" validate:"required"`
+		DeliveryMethod      string                    `json:"deliveryMethod" validate:"required"`
+		MaxCardAmount       int                       `json:"maxCardAmount" validate:"required"`
+		Active              bool                      `json:"active" validate:"required"`
+		Criteria            []invitationCriterionBody `json:"criteria"`
+		OpensAt             time.Time                 `json:"opensAt"`
+		ExpiresAt           time.Time                 `json:"expiresAt"`
+		MaxPlayers          int                       `json:"maxPlayers"`
+		MaxTotalCards       int                       `json:"maxTotalCards"`
+		RequireConfirmation bool                      `json:"requireConfirmation"`
 	}
 	return func(rw http.ResponseWriter, r *http.Request) {
 		// Parse request json body
@@ -32,16 +64,14 @@ func (s *Server) postInvitation() http.HandlerFunc {
 		var message string
 		var data interface{}
 
-		criteria := make([]bingo.InvitationCriterion, 0, len(body.Criteria))
-		for _, c := range body.Criteria {
-			criteria = append(criteria, bingo.InvitationCriterion{
-				Field: bingo.InvitationCriterionField(c.Field),
-				Kind:  bingo.InvitationCriterionKind(c.Kind),
-				Value: c.Value,
-			})
+		criteria := criteriaFromBody(body.Criteria)
+		limits := bingo.InvitationLimits{
+			OpensAt:       body.OpensAt,
+			ExpiresAt:     body.ExpiresAt,
+			MaxPlayers:    body.MaxPlayers,
+			MaxTotalCards: body.MaxTotalCards,
 		}
-
-		inv, err := s.InvitationService.Create(r.Context(), body.GameID, bingo.InvitationDeliveryMethod(body.DeliveryMethod), body.MaxCardAmount, criteria)
+		inv, err := s.InvitationService.Create(r.Context(), body.GameID, bingo.InvitationDeliveryMethod(body.DeliveryMethod), body.MaxCardAmount, criteria, limits, body.RequireConfirmation)
 		if err != nil {
 			s.Log.Errf("could not create invitation for given game id %s due to error:\n%v\n", body.GameID, err)
 
@@ -52,6 +82,12 @@ func (s *Server) postInvitation() http.HandlerFunc {
 				status = http.StatusBadRequest
 				message = "Validation failed"
 				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to invite to game"
 			default:
 				status = http.StatusInternalServerError
 				message = "Unknown error occured"
@@ -68,6 +104,48 @@ func (s *Server) postInvitation() http.HandlerFunc {
 	}
 }
 
+// Invitation along with whether it can be joined, and the time of the server, so the sign-up page can count down to
+// the opening regardless of the clock of the client
+type invitationStatusData struct {
+	*bingo.Invitation
+	Status     bingo.InvitationStatus `json:"status"`
+	ServerTime time.Time              `json:"serverTime"`
+}
+
+func newInvitationStatusData(inv *bingo.Invitation) invitationStatusData {
+	now := time.Now()
+	return invitationStatusData{
+		Invitation: newPublicInvitation(inv),
+		Status:     inv.StatusAt(now),
+		ServerTime: now,
+	}
+}
+
+// Copy of the invitation shown to players and the public. The members of the game are left out, as their emails and
+// roles are only for the host and the members themselves
+func newPublicInvitation(inv *bingo.Invitation) *bingo.Invitation {
+	if inv == nil || inv.Game == nil {
+		return inv
+	}
+
+	g := *inv.Game
+	g.Members = nil
+	pub := *inv
+	pub.Game = &g
+	return &pub
+}
+
+// Copy of the player shown to the player themselves, with the invitation they joined shown as to the public
+func newPublicPlayer(p *bingo.Player) *bingo.Player {
+	if p == nil {
+		return nil
+	}
+
+	pub := *p
+	pub.Invitation = newPublicInvitation(p.Invitation)
+	return &pub
+}
+
 func (s *Server) getInvitation() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 
@@ -88,6 +166,9 @@ func (s *Server) getInvitation() http.HandlerFunc {
 
 			// Try to check what kind of error we are dealing withs
 			switch {
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "Invitation could not be found"
 			default:
 				status = http.StatusInternalServerError
 				message = "Unknown error occured"
@@ -99,11 +180,175 @@ func (s *Server) getInvitation() http.HandlerFunc {
 
 		// Set response payload
 		status = http.StatusOK
-		data = inv
+		data = newInvitationStatusData(inv)
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) getInvitationByJoinCode() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		// Get join code from url
+		code, ok := s.requireParam(rw, r, "code")
+		if !ok {
+			return
+		}
+
+		inv, err := s.InvitationService.GetByJoinCode(r.Context(), code)
+		if err != nil {
+			s.Log.Errf("could not get invitation for given join code %s due to error:\n%v\n", code, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "No invitation has the join code"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = newInvitationStatusData(inv)
 		s.writeJsonPayload(rw, status, message, data)
 	}
 }
 
+// Url of the page players join the invitation on by its join code
+func (s *Server) joinURL(inv *bingo.Invitation) string {
+	return fmt.Sprintf("%s/join/%s", s.LinkBase, inv.JoinCode)
+}
+
+// Get invitation with a join code for handing it out, e.g. as a QR code. Writes the error response if it fails
+func (s *Server) requireJoinCode(rw http.ResponseWriter, r *http.Request, invId string) (*bingo.Invitation, bool) {
+	inv, err := s.InvitationService.EnsureJoinCode(r.Context(), invId)
+	if err == nil {
+		return inv, true
+	}
+	s.Log.Errf("could not get join code of invitation for given invitation id %s due to error:\n%v\n", invId, err)
+
+	// Try to check what kind of error we are dealing with
+	var status int
+	var message string
+	switch {
+	case errors.Is(err, bingo.ErrInvitationNotFound):
+		status = http.StatusNotFound
+		message = "Invitation could not be found"
+	case errors.Is(err, bingo.ErrForbidden):
+		status = http.StatusForbidden
+		message = "Not allowed to hand out invitation"
+	default:
+		status = http.StatusInternalServerError
+		message = "Unknown error occured"
+	}
+	s.writeJsonPayload(rw, status, message, nil)
+
+	return nil, false
+}
+
+func (s *Server) getInvitationQRCode() http.HandlerFunc {
+	const (
+		defaultSize = 512
+		maxSize     = 4096
+	)
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get invitation id from url
+		invId, ok := s.requireParam(rw, r, "invID")
+		if !ok {
+			return
+		}
+
+		// Image is a png of the size in pixels by default, or an svg
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "png"
+		}
+		size := defaultSize
+		if raw := r.URL.Query().Get("size"); raw != "" {
+			var err error
+			size, err = strconv.Atoi(raw)
+			if err != nil || size < 1 || size > maxSize {
+				s.writeJsonPayload(rw, http.StatusBadRequest, fmt.Sprintf("Size must be a number of pixels between 1 and %d", maxSize), nil)
+				return
+			}
+		}
+		if format != "png" && format != "svg" {
+			s.writeJsonPayload(rw, http.StatusBadRequest, "Format must be either png or svg", nil)
+			return
+		}
+
+		inv, ok := s.requireJoinCode(rw, r, invId)
+		if !ok {
+			return
+		}
+		code, err := qr.Encode(s.joinURL(inv))
+		if err != nil {
+			s.Log.Errf("could not encode QR code for invitation id %s due to error:\n%v\n", invId, err)
+			s.writeJsonPayload(rw, http.StatusInternalServerError, "QR code could not be generated", nil)
+			return
+		}
+
+		// Render the image to a buffer first, so errors can still be written as json
+		var buf bytes.Buffer
+		contentType := "image/png"
+		if format == "svg" {
+			contentType = "image/svg+xml"
+			err = code.WriteSVG(&buf, size)
+		} else {
+			err = code.WritePNG(&buf, size)
+		}
+		if errors.Is(err, qr.ErrSizeTooSmall) {
+			s.writeJsonPayload(rw, http.StatusBadRequest, "Size is too small to fit the QR code", nil)
+			return
+		}
+		if err != nil {
+			s.Log.Errf("could not render QR code for invitation id %s due to error:\n%v\n", invId, err)
+			s.writeJsonPayload(rw, http.StatusInternalServerError, "QR code could not be generated", nil)
+			return
+		}
+
+		// Write image to response
+		rw.Header().Set("Content-Type", contentType)
+		buf.WriteTo(rw)
+	}
+}
+
+func (s *Server) getInvitationPoster() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get invitation id from url
+		invId, ok := s.requireParam(rw, r, "invID")
+		if !ok {
+			return
+		}
+
+		inv, ok := s.requireJoinCode(rw, r, invId)
+		if !ok {
+			return
+		}
+		// Generate poster pdf
+		var buf bytes.Buffer
+		if err := (pdf.Renderer{}).RenderPoster(&buf, inv.Game, s.joinURL(inv), inv.JoinCode); err != nil {
+			s.Log.Errf("could not generate poster pdf for invitation id %s due to error:\n%v\n", invId, err)
+			s.writeJsonPayload(rw, http.StatusInternalServerError, "Pdf file could not be generated", nil)
+			return
+		}
+
+		// Write pdf to response
+		rw.Header().Set("Content-Type", "application/pdf")
+		buf.WriteTo(rw)
+	}
+}
+
 func (s *Server) patchDisableInvitation() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		// Get invitation id from url
@@ -123,6 +368,79 @@ func (s *Server) patchDisableInvitation() http.HandlerFunc {
 
 			// Try to check what kind of error we are dealing with
 			switch {
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "Invitation could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to disable invitation"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = inv
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) putInvitationLimits() http.HandlerFunc {
+	type requestBody struct {
+		OpensAt       time.Time `json:"opensAt"`
+		ExpiresAt     time.Time `json:"expiresAt"`
+		MaxPlayers    int       `json:"maxPlayers"`
+		MaxTotalCards int       `json:"maxTotalCards"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get invitation id from url
+		invId, ok := s.requireParam(rw, r, "invID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		limits := bingo.InvitationLimits{
+			OpensAt:       body.OpensAt,
+			ExpiresAt:     body.ExpiresAt,
+			MaxPlayers:    body.MaxPlayers,
+			MaxTotalCards: body.MaxTotalCards,
+		}
+		inv, err := s.InvitationService.SetLimits(r.Context(), invId, limits)
+		if err != nil {
+			s.Log.Errf("could not set limits of invitation for given invitation id %s due to error:\n%v\n", invId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "Invitation could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to change invitation"
+			case errors.Is(err, bingo.ErrInsufficientCredits):
+				status = http.StatusServiceUnavailable
+				message = "Limits were set, but the game can not hand out cards to the waitlist right now"
 			default:
 				status = http.StatusInternalServerError
 				message = "Unknown error occured"
@@ -143,7 +461,8 @@ func (s *Server) joinInvitation() http.HandlerFunc {
 	type requestBody struct {
 		Email      string `json:"email" validate:"required,email"`
 		Name       string `json:"name" validate:"required"`
-		CardAmount int    `json:"cardAmount" validate:"required"`
+		CardAmount int    `json:"cardAmount" validate:"required,min=1"`
+		Language   string `json:"language"`
 	}
 	return func(rw http.ResponseWriter, r *http.Request) {
 		invId, ok := s.requireParam(rw, r, "invID")
@@ -162,7 +481,7 @@ func (s *Server) joinInvitation() http.HandlerFunc {
 		var message string
 		var data interface{}
 
-		player, err := s.InvitationService.Join(r.Context(), invId, body.Name, body.Email, body.CardAmount)
+		player, err := s.InvitationService.Join(r.Context(), invId, body.Name, body.Email, body.CardAmount, bingo.Language(body.Language))
 		if err != nil {
 			s.Log.Errf("could not join invitation for given inv id %s due to error:\n%v\n", invId, err)
 
@@ -173,6 +492,33 @@ func (s *Server) joinInvitation() http.HandlerFunc {
 				status = http.StatusBadRequest
 				message = "Validation failed"
 				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "Invitation could not be found"
+			case errors.Is(err, bingo.ErrInvitationInactive):
+				status = http.StatusGone
+				message = "Invitation is no longer active"
+			case errors.Is(err, bingo.ErrInvitationNotOpen):
+				status = http.StatusForbidden
+				message = "Invitation is not open yet"
+			case errors.Is(err, bingo.ErrInvitationExpired):
+				status = http.StatusGone
+				message = "Invitation has expired"
+			case errors.Is(err, bingo.ErrInvitationFull):
+				status = http.StatusConflict
+				message = "Invitation is sold out"
+			case errors.Is(err, bingo.ErrPlayerCardLimit):
+				status = http.StatusConflict
+				message = "Already joined with the max amount of cards"
+			case errors.Is(err, bingo.ErrPlayerExists):
+				status = http.StatusConflict
+				message = "Already joined with the email"
+			case errors.Is(err, bingo.ErrPlayerRemoved):
+				status = http.StatusForbidden
+				message = "Removed from the game"
+			case errors.Is(err, bingo.ErrInsufficientCredits):
+				status = http.StatusServiceUnavailable
+				message = "The game can not hand out more cards right now"
 			default:
 				status = http.StatusInternalServerError
 				message = "Unknown error occured"
@@ -182,9 +528,126 @@ func (s *Server) joinInvitation() http.HandlerFunc {
 			return
 		}
 
-		// Set response payload
+		// Set response payload. Players joining full invitations are accepted on the waitlist, and players joining
+		// invitations requiring confirmation are accepted once they confirm
+		status = http.StatusCreated
+		switch player.Status {
+		case bingo.PlayerStatusWaitlisted:
+			status = http.StatusAccepted
+			message = "Invitation is full, player is on the waitlist"
+		case bingo.PlayerStatusUnconfirmed:
+			status = http.StatusAccepted
+			message = "Confirm the email to get the cards"
+		}
+		data = newPublicPlayer(player)
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) confirmInvitationPlayer() http.HandlerFunc {
+	type requestBody struct {
+		Token string `json:"token" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		player, err := s.InvitationService.Confirm(r.Context(), body.Token)
+		if err != nil {
+			s.Log.Errf("could not confirm player due to error:\n%v\n", err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrInvalidConfirmationToken):
+				status = http.StatusGone
+				message = "Confirmation link is invalid or has expired"
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "Invitation could not be found"
+			case errors.Is(err, bingo.ErrInvitationInactive):
+				status = http.StatusGone
+				message = "Invitation is no longer active"
+			case errors.Is(err, bingo.ErrInvitationExpired):
+				status = http.StatusGone
+				message = "Invitation has expired"
+			case errors.Is(err, bingo.ErrPlayerRemoved):
+				status = http.StatusForbidden
+				message = "Removed from the game"
+			case errors.Is(err, bingo.ErrInsufficientCredits):
+				status = http.StatusServiceUnavailable
+				message = "The game can not hand out more cards right now"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload. Players confirming when the invitation is full are accepted on the waitlist
 		status = http.StatusCreated
-		data = player
+		if player.Status == bingo.PlayerStatusWaitlisted {
+			status = http.StatusAccepted
+			message = "Invitation is full, player is on the waitlist"
+		}
+		data = newPublicPlayer(player)
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Mail a magic link to the player who joined the invitation with the email. The response is the same whether or not
+// a player joined with the email, so the emails of players can not be probed for
+func (s *Server) postInvitationMagicLink() http.HandlerFunc {
+	type requestBody struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		invId, ok := s.requireParam(rw, r, "invID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		err := s.PlayerService.RequestMagicLink(r.Context(), invId, body.Email)
+		if err != nil {
+			s.Log.Errf("could not request magic link for inv id %s due to error:\n%v\n", invId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "Invitation could not be found"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusAccepted
+		message = "A link has been mailed, if a player joined with the email"
 		s.writeJsonPayload(rw, status, message, data)
 	}
 }
@@ -194,11 +657,19 @@ func (s *Server) registerInvitationRoutes(r *mux.Router, middleware ...mux.Middl
 
 	unauthedRtr := r.PathPrefix("/").Subrouter()
 	authedRtr := r.PathPrefix("/").Subrouter()
-	authedRtr.Use(s.authMiddleware)
+	authedRtr.Use(s.authMiddleware, s.requireUserSession)
 
 	authedRtr.HandleFunc("/", s.postInvitation()).Methods(http.MethodPost)
-	unauthedRtr.HandleFunc("/{invID}/disable", s.patchDisableInvitation()).Methods(http.MethodPatch)
-
+	authedRtr.HandleFunc("/{invID}/disable", s.patchDisableInvitation()).Methods(http.MethodPatch)
+	authedRtr.HandleFunc("/{invID}/limits", s.putInvitationLimits()).Methods(http.MethodPut)
+	authedRtr.HandleFunc("/{invID}/qr", s.getInvitationQRCode()).Methods(http.MethodGet)
+	authedRtr.HandleFunc("/{invID}/poster", s.getInvitationPoster()).Methods(http.MethodGet)
+	authedRtr.HandleFunc("/{invID}/import", s.postInvitationImport()).Methods(http.MethodPost)
+	authedRtr.HandleFunc("/{invID}/door-sales", s.postInvitationDoorSale()).Methods(http.MethodPost)
+
+	unauthedRtr.HandleFunc("/confirm", s.confirmInvitationPlayer()).Methods(http.MethodPost)
+	unauthedRtr.HandleFunc("/codes/{code}", s.getInvitationByJoinCode()).Methods(http.MethodGet)
 	unauthedRtr.HandleFunc("/{invID}", s.getInvitation()).Methods(http.MethodGet)
-	unauthedRtr.HandleFunc("/{invID}/join", s.joinInvitation()).Methods(http.MethodGet)
+	unauthedRtr.HandleFunc("/{invID}/join", s.joinInvitation()).Methods(http.MethodPost)
+	unauthedRtr.HandleFunc("/{invID}/magic-link", s.postInvitationMagicLink()).Methods(http.MethodPost)
 }
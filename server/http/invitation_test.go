@@ -0,0 +1,105 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	bingohttp "github.com/nohns/bingo-box/server/http"
+	"github.com/nohns/bingo-box/server/logger"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+// Invitations are public, so the members of the game must not be shown along with them
+func TestServer_PublicInvitation(t *testing.T) {
+
+	g := &bingo.Game{
+		ID:     requiretest.UUIDv4(t),
+		Name:   "company party",
+		HostId: requiretest.UUIDv4(t),
+		Members: []bingo.GameMember{
+			{UserID: requiretest.UUIDv4(t), Email: "cohost@test.com", Role: bingo.GameRoleCohost, InvitedAt: time.Now(), AcceptedAt: time.Now()},
+			{Email: "checker@test.com", Role: bingo.GameRoleChecker, InvitedAt: time.Now()},
+		},
+	}
+	inv := &bingo.Invitation{
+		ID:       requiretest.UUIDv4(t),
+		Active:   true,
+		JoinCode: strings.Repeat("A", bingo.JoinCodeLength),
+		GameID:   g.ID,
+		Game:     g,
+	}
+
+	cases := []struct {
+		caseName  string
+		path      string
+		expectGet func(invRepo *mock.InvitationRepository)
+	}{
+		{
+			caseName: "by id",
+			path:     "/invitations/" + inv.ID,
+			expectGet: func(invRepo *mock.InvitationRepository) {
+				invRepo.ExpectGet(makeInvitationGetHandler(inv))
+			},
+		},
+		{
+			caseName: "by join code",
+			path:     "/invitations/codes/" + inv.JoinCode,
+			expectGet: func(invRepo *mock.InvitationRepository) {
+				invRepo.ExpectGetByJoinCode(func(_ context.Context, _ string) (*bingo.Invitation, error) {
+					cp := *inv
+					return &cp, nil
+				})
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			srv, invRepo := MustCreateInvitationRouteServer(t)
+			defer invRepo.RequireExpectationsMet()
+			tc.expectGet(invRepo)
+
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, http.StatusOK, rec.Code, "invitation must be shown to the public: %s", rec.Body.String())
+			require.Contains(t, rec.Body.String(), g.Name, "game of the invitation must be shown")
+			for _, m := range g.Members {
+				require.NotContains(t, rec.Body.String(), m.Email, "emails of members must not be shown to the public")
+			}
+			require.Len(t, g.Members, 2, "members of the game must not be changed when hidden")
+		})
+	}
+}
+
+func MustCreateInvitationRouteServer(tb testing.TB) (*bingohttp.Server, *mock.InvitationRepository) {
+	tb.Helper()
+
+	invRepo := mock.NewInvitationRepository(tb)
+
+	srv := bingohttp.NewServer()
+	srv.Log = logger.New()
+	srv.InvitationService = bingo.NewInvitationService(
+		bingo.Repositories{
+			Users:         mock.NewUserRepository(tb),
+			Organizations: mock.NewOrganizationRepository(tb),
+			Games:         mock.NewGameRepository(tb),
+			Cards:         mock.NewCardRepository(tb),
+			Invitations:   invRepo,
+			Players:       mock.NewPlayerRepository(tb),
+			Credits:       mock.NewCreditRepository(tb),
+			Outbox:        mock.NewOutboxRepository(tb),
+		},
+		mock.ConfirmationTokenSigner{},
+		mock.NewTransactor(tb),
+	)
+
+	return srv, invRepo
+}
@@ -0,0 +1,267 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+func (s *Server) postMember() http.HandlerFunc {
+	type requestBody struct {
+		Email string `json:"email" validate:"required,email"`
+		Role  string `json:"role" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		game, err := s.GameService.InviteMember(r.Context(), gameId, body.Email, bingo.GameRole(body.Role))
+		if err != nil {
+			s.Log.Errf("could not invite member to game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrMemberNotFound):
+				status = http.StatusNotFound
+				message = "Member could not be found"
+			case errors.Is(err, bingo.ErrMemberAlreadyExists):
+				status = http.StatusConflict
+				message = "Member already exists"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to manage members of game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusCreated
+		data = game
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Accept the invitation to become member, by the token of the link mailed to the member
+func (s *Server) postAcceptMembership() http.HandlerFunc {
+	type requestBody struct {
+		Token string `json:"token" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		game, err := s.GameService.AcceptMembership(r.Context(), gameId, body.Token)
+		if err != nil {
+			s.Log.Errf("could not accept membership of game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrInvalidMemberInvitation):
+				status = http.StatusGone
+				message = "Invitation link is invalid or has expired"
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrMemberNotFound):
+				status = http.StatusNotFound
+				message = "Member could not be found"
+			case errors.Is(err, bingo.ErrMemberAlreadyExists):
+				status = http.StatusConflict
+				message = "Member already exists"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to manage members of game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = game
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) patchMember() http.HandlerFunc {
+	type requestBody struct {
+		Role string `json:"role" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+		email, ok := s.requireParam(rw, r, "email")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		game, err := s.GameService.ChangeMemberRole(r.Context(), gameId, email, bingo.GameRole(body.Role))
+		if err != nil {
+			s.Log.Errf("could not change member role in game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrMemberNotFound):
+				status = http.StatusNotFound
+				message = "Member could not be found"
+			case errors.Is(err, bingo.ErrMemberAlreadyExists):
+				status = http.StatusConflict
+				message = "Member already exists"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to manage members of game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = game
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) deleteMember() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+		email, ok := s.requireParam(rw, r, "email")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		game, err := s.GameService.RemoveMember(r.Context(), gameId, email)
+		if err != nil {
+			s.Log.Errf("could not remove member from game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrMemberNotFound):
+				status = http.StatusNotFound
+				message = "Member could not be found"
+			case errors.Is(err, bingo.ErrMemberAlreadyExists):
+				status = http.StatusConflict
+				message = "Member already exists"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to manage members of game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = game
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) registerMemberRoutes(r *mux.Router, middleware ...mux.MiddlewareFunc) {
+
+	r.Use(middleware...)
+
+	r.HandleFunc("/", s.postMember()).Methods(http.MethodPost)
+	r.HandleFunc("/accept", s.postAcceptMembership()).Methods(http.MethodPost)
+	r.HandleFunc("/{email}", s.patchMember()).Methods(http.MethodPatch)
+	r.HandleFunc("/{email}", s.deleteMember()).Methods(http.MethodDelete)
+}
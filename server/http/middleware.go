@@ -1,18 +1,225 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
-	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/kratos"
 )
 
-// Construct auth middleware using api key
-func apiKeyMiddlewareFactory(apiKey string) mux.MiddlewareFunc {
-	// Create a factory for middleware, so we can pass in dependencies to it.
+// Authenticate requests and put the authenticated user into the request context. Kratos sessions are accepted,
+// when a session verifier is configured, and otherwise the bearer token in the Authorization header is used. The
+// bearer token is either an access token or an api key, in which case the api key is put into the context as well.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var user *bingo.User
+		var key *bingo.APIKey
+		var ok bool
+		if s.SessionVerifier != nil && hasKratosSession(r) {
+			user, ok = s.authenticateKratosSession(rw, r)
+		} else if token, hasToken := bearerToken(r); hasToken && bingo.IsAPIKey(token) {
+			user, key, ok = s.authenticateAPIKey(rw, r, token)
+		} else {
+			user, ok = s.authenticateAccessToken(rw, r)
+		}
+		if !ok {
+			return
+		}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-			next.ServeHTTP(rw, r)
-		})
+		ctx := bingo.NewContextWithUser(r.Context(), user)
+		if key != nil {
+			ctx = bingo.NewContextWithAPIKey(ctx, key)
+		}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// Authenticate requests by the token of a player session and put the player into the request context. Links mailed to
+// players carry the token in the query, while the web app sends it in the X-Player-Token header.
+func (s *Server) authenticatePlayer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		token, ok := playerToken(r)
+		if !ok {
+			s.writeJsonPayload(rw, http.StatusUnauthorized, "Missing player token", nil)
+			return
+		}
+
+		player, err := s.PlayerService.Authenticate(r.Context(), token)
+		if err != nil {
+			s.Log.Errf("could not authenticate player session due to error:\n%v\n", err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrInvalidPlayerSession):
+				s.writeJsonPayload(rw, http.StatusUnauthorized, "Player session is invalid or has expired", nil)
+			case errors.Is(err, bingo.ErrPlayerRemoved):
+				s.writeJsonPayload(rw, http.StatusForbidden, "Removed from the game", nil)
+			default:
+				s.writeJsonPayload(rw, http.StatusInternalServerError, "Unknown error occured", nil)
+			}
+			return
+		}
+
+		next.ServeHTTP(rw, r.WithContext(bingo.NewContextWithPlayer(r.Context(), player)))
+	})
+}
+
+// Authenticate requests carrying the token of a player session as the player, and all other requests as users. Users
+// must be authenticated by themselves, as no scope of api keys grants access to the cards of players.
+func (s *Server) authenticatePlayerOrUser(next http.Handler) http.Handler {
+	asPlayer := s.authenticatePlayer(next)
+	asUser := s.authMiddleware(s.requireUserSession(next))
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := playerToken(r); ok {
+			asPlayer.ServeHTTP(rw, r)
+			return
+		}
+
+		asUser.ServeHTTP(rw, r)
+	})
+}
+
+// Only let requests through which are authenticated by api key granted the scope, or by the user themselves.
+func (s *Server) requireScope(scope bingo.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if key := bingo.APIKeyFromContext(r.Context()); key != nil && !key.HasScope(scope) {
+			s.writeJsonPayload(rw, http.StatusForbidden, "API key lacks scope "+string(scope), nil)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
 	}
 }
+
+// Only let requests through which are authenticated by the user themselves, and not by an api key.
+func (s *Server) requireUserSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if key := bingo.APIKeyFromContext(r.Context()); key != nil {
+			s.writeJsonPayload(rw, http.StatusForbidden, "API keys are not allowed here", nil)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Authenticate request by bearer access token. If authentication fails, the response is written and ok is false.
+func (s *Server) authenticateAccessToken(rw http.ResponseWriter, r *http.Request) (*bingo.User, bool) {
+	token, ok := bearerToken(r)
+	if !ok {
+		s.writeJsonPayload(rw, http.StatusUnauthorized, "Missing bearer token", nil)
+		return nil, false
+	}
+
+	user, err := s.TokenService.Verify(r.Context(), token)
+	if err != nil {
+		s.Log.Errf("could not verify access token due to error:\n%v\n", err)
+
+		// Try to check what kind of error we are dealing with
+		switch {
+		case
+			errors.Is(err, bingo.ErrInvalidAccessToken),
+			errors.Is(err, bingo.ErrUserNotFound):
+			s.writeJsonPayload(rw, http.StatusUnauthorized, "Access token is invalid", nil)
+		default:
+			s.writeJsonPayload(rw, http.StatusInternalServerError, "Unknown error occured", nil)
+		}
+		return nil, false
+	}
+
+	return user, true
+}
+
+// Authenticate request by api key. If authentication fails, the response is written and ok is false.
+func (s *Server) authenticateAPIKey(rw http.ResponseWriter, r *http.Request, key string) (*bingo.User, *bingo.APIKey, bool) {
+	user, k, err := s.APIKeyService.Authenticate(r.Context(), key)
+	if err != nil {
+		s.Log.Errf("could not authenticate api key due to error:\n%v\n", err)
+
+		// Try to check what kind of error we are dealing with
+		switch {
+		case
+			errors.Is(err, bingo.ErrInvalidAPIKey),
+			errors.Is(err, bingo.ErrUserNotFound):
+			s.writeJsonPayload(rw, http.StatusUnauthorized, "API key is invalid", nil)
+		default:
+			s.writeJsonPayload(rw, http.StatusInternalServerError, "Unknown error occured", nil)
+		}
+		return nil, nil, false
+	}
+
+	return user, k, true
+}
+
+// Authenticate request by Kratos session cookie or token. The identity of the session is mapped to a user, which
+// is created on first sight. If authentication fails, the response is written and ok is false.
+func (s *Server) authenticateKratosSession(rw http.ResponseWriter, r *http.Request) (*bingo.User, bool) {
+	id, err := s.SessionVerifier.Whoami(r.Context(), r.Header.Get("Cookie"), r.Header.Get("X-Session-Token"))
+	if err != nil {
+		s.Log.Errf("could not verify kratos session due to error:\n%v\n", err)
+
+		// Try to check what kind of error we are dealing with
+		switch {
+		case errors.Is(err, bingo.ErrInvalidSession):
+			s.writeJsonPayload(rw, http.StatusUnauthorized, "Session is invalid", nil)
+		default:
+			s.writeJsonPayload(rw, http.StatusInternalServerError, "Unknown error occured", nil)
+		}
+		return nil, false
+	}
+
+	user, err := s.UserService.ProvisionIdentity(r.Context(), id)
+	if err != nil {
+		s.Log.Errf("could not provision user for identity id %s due to error:\n%v\n", id.ID, err)
+
+		// Try to check what kind of error we are dealing with
+		switch {
+		case errors.Is(err, bingo.ErrIdentityEmailUnverified):
+			s.writeJsonPayload(rw, http.StatusConflict, "Email belongs to another user and has not been verified", nil)
+		case errors.Is(err, bingo.ErrUserAlreadyExists):
+			s.writeJsonPayload(rw, http.StatusConflict, "Email belongs to another user", nil)
+		default:
+			s.writeJsonPayload(rw, http.StatusInternalServerError, "Unknown error occured", nil)
+		}
+		return nil, false
+	}
+
+	return user, true
+}
+
+// Check whether the request carries a Kratos session cookie or session token
+func hasKratosSession(r *http.Request) bool {
+	if r.Header.Get("X-Session-Token") != "" {
+		return true
+	}
+
+	_, err := r.Cookie(kratos.SessionCookieName)
+	return err == nil
+}
+
+// Get token from an Authorization header using the bearer scheme
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+
+	h := r.Header.Get("Authorization")
+	if len(h) <= len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	return strings.TrimSpace(h[len(prefix):]), true
+}
+
+// Get token of a player session from the X-Player-Token header, or from the token query parameter
+func playerToken(r *http.Request) (string, bool) {
+	if token := r.Header.Get("X-Player-Token"); token != "" {
+		return token, true
+	}
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token, true
+	}
+
+	return "", false
+}
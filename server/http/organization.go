@@ -0,0 +1,288 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+func (s *Server) getOrganizations() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		orgs, err := s.OrganizationService.List(r.Context())
+		if err != nil {
+			s.Log.Errf("could not list organizations due to error:\n%v\n", err)
+
+			status = http.StatusInternalServerError
+			message = "Unknown error occured"
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = orgs
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) getOrganization() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get organization id from url
+		orgId, ok := s.requireParam(rw, r, "orgID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		org, err := s.OrganizationService.Get(r.Context(), orgId)
+		if err != nil {
+			s.Log.Errf("could not get organization for given organization id %s due to error:\n%v\n", orgId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrOrganizationNotFound):
+				status = http.StatusNotFound
+				message = "Organization could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access organization"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = org
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) postOrganization() http.HandlerFunc {
+	type requestBody struct {
+		Name string `json:"name" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		org, err := s.OrganizationService.Create(r.Context(), body.Name)
+		if err != nil {
+			s.Log.Errf("could not create organization due to error:\n%v\n", err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusCreated
+		data = org
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) postOrganizationMember() http.HandlerFunc {
+	type requestBody struct {
+		Email string `json:"email" validate:"required,email"`
+		Role  string `json:"role" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get organization id from url
+		orgId, ok := s.requireParam(rw, r, "orgID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		org, err := s.OrganizationService.AddMember(r.Context(), orgId, body.Email, bingo.OrganizationRole(body.Role))
+		if err != nil {
+			s.Log.Errf("could not add member to organization id %s due to error:\n%v\n", orgId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrOrganizationNotFound):
+				status = http.StatusNotFound
+				message = "Organization could not be found"
+			case errors.Is(err, bingo.ErrUserNotFound):
+				status = http.StatusNotFound
+				message = "User could not be found"
+			case errors.Is(err, bingo.ErrOrganizationMemberExists):
+				status = http.StatusConflict
+				message = "Member already exists"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to manage members of organization"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusCreated
+		data = org
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) deleteOrganizationMember() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get organization and user id from url
+		orgId, ok := s.requireParam(rw, r, "orgID")
+		if !ok {
+			return
+		}
+		userId, ok := s.requireParam(rw, r, "userID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		org, err := s.OrganizationService.RemoveMember(r.Context(), orgId, userId)
+		if err != nil {
+			s.Log.Errf("could not remove member %s from organization id %s due to error:\n%v\n", userId, orgId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrOrganizationNotFound):
+				status = http.StatusNotFound
+				message = "Organization could not be found"
+			case errors.Is(err, bingo.ErrOrganizationMemberMissing):
+				status = http.StatusNotFound
+				message = "Member could not be found"
+			case errors.Is(err, bingo.ErrLastOrganizationAdmin):
+				status = http.StatusConflict
+				message = "Organization must have at least one admin"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to manage members of organization"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = org
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Switch the organization the authenticated user is acting on behalf of. An empty id switches to the personal account
+func (s *Server) putActiveOrganization() http.HandlerFunc {
+	type requestBody struct {
+		OrganizationID string `json:"organizationId"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		user, err := s.OrganizationService.SwitchActive(r.Context(), body.OrganizationID)
+		if err != nil {
+			s.Log.Errf("could not switch active organization to id %s due to error:\n%v\n", body.OrganizationID, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrOrganizationNotFound):
+				status = http.StatusNotFound
+				message = "Organization could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to act on behalf of organization"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = user
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) registerOrganizationRoutes(r *mux.Router, middleware ...mux.MiddlewareFunc) {
+
+	r.Use(middleware...)
+
+	r.HandleFunc("/", s.getOrganizations()).Methods(http.MethodGet)
+	r.HandleFunc("/", s.postOrganization()).Methods(http.MethodPost)
+	r.HandleFunc("/active", s.putActiveOrganization()).Methods(http.MethodPut)
+	r.HandleFunc("/{orgID}", s.getOrganization()).Methods(http.MethodGet)
+	r.HandleFunc("/{orgID}/members", s.postOrganizationMember()).Methods(http.MethodPost)
+	r.HandleFunc("/{orgID}/members/{userID}", s.deleteOrganizationMember()).Methods(http.MethodDelete)
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// List outbox messages with the status given by the query, dead ones by default. Admins only
+func (s *Server) getOutboxMessages() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		msgStatus := bingo.OutboxStatus(r.URL.Query().Get("status"))
+		if msgStatus == "" {
+			msgStatus = bingo.OutboxStatusDead
+		}
+		msgs, err := s.OutboxService.List(r.Context(), msgStatus)
+		if err != nil {
+			s.Log.Errf("could not list outbox messages with status %s due to error:\n%v\n", msgStatus, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to inspect the outbox"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = msgs
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Queue a dead outbox message for delivery again. Admins only
+func (s *Server) postOutboxResend() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Get message id from url
+		msgId, ok := s.requireParam(rw, r, "messageID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		msg, err := s.OutboxService.Resend(r.Context(), msgId)
+		if err != nil {
+			s.Log.Errf("could not resend outbox message id %s due to error:\n%v\n", msgId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrOutboxMessageNotFound):
+				status = http.StatusNotFound
+				message = "Outbox message could not be found"
+			case errors.Is(err, bingo.ErrOutboxMessageNotDead):
+				status = http.StatusConflict
+				message = "Only dead messages can be resent"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to resend outbox messages"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = msg
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Preview the mail players of the game get their cards by, in the language of the query or else the one of the game
+func (s *Server) getPlayerCardsMailPreview() http.HandlerFunc {
+	type mailPreview struct {
+		Subject string `json:"subject"`
+		HTML    string `json:"html"`
+		Text    string `json:"text"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		lang := bingo.Language(r.URL.Query().Get("lang"))
+		m, err := s.OutboxService.PreviewPlayerCardsMail(r.Context(), gameId, lang)
+		if err != nil {
+			s.Log.Errf("could not preview player cards mail of game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to manage game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = mailPreview{
+			Subject: m.Subject,
+			HTML:    m.HTML,
+			Text:    m.Text,
+		}
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) registerOutboxRoutes(r *mux.Router, middleware ...mux.MiddlewareFunc) {
+
+	r.Use(middleware...)
+
+	r.HandleFunc("/", s.getOutboxMessages()).Methods(http.MethodGet)
+	r.HandleFunc("/{messageID}/resend", s.postOutboxResend()).Methods(http.MethodPost)
+}
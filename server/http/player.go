@@ -1,13 +1,15 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	bingo "github.com/nohns/bingo-box/server"
 	"github.com/nohns/bingo-box/server/pdf"
 )
 
-func (s *Server) getCardsPdf() http.HandlerFunc {
+func (s *Server) getPlayer() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 
 		// Get player id from url
@@ -22,11 +24,381 @@ func (s *Server) getCardsPdf() http.HandlerFunc {
 		var data interface{}
 
 		player, err := s.PlayerService.Get(r.Context(), playerId)
+		if err != nil {
+			s.Log.Errf("could not get player for player id %s due to error:\n%v\n", playerId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrPlayerNotFound):
+				status = http.StatusNotFound
+				message = "Player could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access player"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = player
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Remove the player from the game, letting the first player on the waitlist take their place
+func (s *Server) deletePlayer() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get player id from url
+		playerId, ok := s.requireParam(rw, r, "playerID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		player, err := s.InvitationService.RemovePlayer(r.Context(), playerId)
+		if err != nil {
+			s.Log.Errf("could not remove player for player id %s due to error:\n%v\n", playerId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrPlayerNotFound):
+				status = http.StatusNotFound
+				message = "Player could not be found"
+			case errors.Is(err, bingo.ErrPlayerRemoved):
+				status = http.StatusGone
+				message = "Player has already been removed"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to remove player"
+			case errors.Is(err, bingo.ErrInsufficientCredits):
+				status = http.StatusServiceUnavailable
+				message = "Player was removed, but the game can not hand out cards to the waitlist right now"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = player
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// List players of the game, optionally only those with the given status or card delivery status
+func (s *Server) getGamePlayers() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get game id from url
+		gameId, ok := s.requireParam(rw, r, "gameID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		filter := bingo.PlayerFilter{
+			GameID:         gameId,
+			Status:         bingo.PlayerStatus(r.URL.Query().Get("status")),
+			DeliveryStatus: bingo.DeliveryStatus(r.URL.Query().Get("deliveryStatus")),
+		}
+		players, err := s.PlayerService.List(r.Context(), filter)
+		if err != nil {
+			s.Log.Errf("could not list players for game id %s due to error:\n%v\n", gameId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrGameNotFound):
+				status = http.StatusNotFound
+				message = "Game could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to view players of game"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = players
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Player as seen by themselves in their session, with the status of the invitation and game they joined
+type playerSessionData struct {
+	*bingo.Player
+	Invitation invitationStatusData `json:"invitation"`
+}
+
+// Get the player of the session with their cards, and the game they joined for following it live
+func (s *Server) getPlayerSession() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		player, err := s.PlayerService.Me(r.Context())
+		if err != nil {
+			s.Log.Errf("could not get player of session due to error:\n%v\n", err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrPlayerNotFound):
+				status = http.StatusNotFound
+				message = "Player could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access player"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = playerSessionData{
+			Player:     player,
+			Invitation: newInvitationStatusData(player.Invitation),
+		}
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Deliver the cards of the player by mail again
+func (s *Server) postResendPlayerCards() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get player id from url
+		playerId, ok := s.requireParam(rw, r, "playerID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		player, err := s.PlayerService.ResendCards(r.Context(), playerId)
+		if err != nil {
+			s.Log.Errf("could not resend cards for player id %s due to error:\n%v\n", playerId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrPlayerNotFound):
+				status = http.StatusNotFound
+				message = "Player could not be found"
+			case errors.Is(err, bingo.ErrPlayerWaitlisted):
+				status = http.StatusConflict
+				message = "Player is on the waitlist, and has no cards yet"
+			case errors.Is(err, bingo.ErrPlayerUnconfirmed):
+				status = http.StatusConflict
+				message = "Player has not confirmed their email, and has no cards yet"
+			case errors.Is(err, bingo.ErrPlayerRemoved):
+				status = http.StatusGone
+				message = "Player has been removed"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to resend cards of player"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload. The cards are mailed in the background
+		status = http.StatusAccepted
+		data = newPublicPlayer(player)
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Withdraw the player of the session from the game, voiding their cards
+func (s *Server) postWithdrawPlayer() http.HandlerFunc {
+	type requestBody struct {
+		Reason string `json:"reason"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get player id from url
+		playerId, ok := s.requireParam(rw, r, "playerID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		player, err := s.PlayerService.Withdraw(r.Context(), playerId, body.Reason)
+		if err != nil {
+			s.Log.Errf("could not withdraw player for player id %s due to error:\n%v\n", playerId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrPlayerNotFound):
+				status = http.StatusNotFound
+				message = "Player could not be found"
+			case errors.Is(err, bingo.ErrPlayerRemoved):
+				status = http.StatusGone
+				message = "Player has already been removed"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to withdraw player"
+			case errors.Is(err, bingo.ErrInsufficientCredits):
+				status = http.StatusServiceUnavailable
+				message = "Player has withdrawn, but the game can not hand out cards to the waitlist right now"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = newPublicPlayer(player)
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Revoke the cards of the player for a reason, voiding them and removing the player from the game
+func (s *Server) postRevokePlayerCards() http.HandlerFunc {
+	type requestBody struct {
+		Reason string `json:"reason" validate:"required"`
+	}
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Get player id from url
+		playerId, ok := s.requireParam(rw, r, "playerID")
+		if !ok {
+			return
+		}
+
+		// Parse request json body
+		var body requestBody
+		if !s.jsonBody(rw, r, &body) {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		player, err := s.PlayerService.RevokeCards(r.Context(), playerId, body.Reason)
+		if err != nil {
+			s.Log.Errf("could not revoke cards for player id %s due to error:\n%v\n", playerId, err)
+
+			// Try to check what kind of error we are dealing with
+			var valErr bingo.ValidationErr
+			switch {
+			case errors.As(err, &valErr):
+				status = http.StatusBadRequest
+				message = "Validation failed"
+				data = translateBingoValidationErr(valErr)
+			case errors.Is(err, bingo.ErrPlayerNotFound):
+				status = http.StatusNotFound
+				message = "Player could not be found"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to revoke cards of player"
+			case errors.Is(err, bingo.ErrInsufficientCredits):
+				status = http.StatusServiceUnavailable
+				message = "Cards were revoked, but the game can not hand out cards to the waitlist right now"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = player
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+func (s *Server) getCardsPdf() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+
+		// Get player id from url
+		playerId, ok := s.requireParam(rw, r, "playerID")
+		if !ok {
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		player, err := s.PlayerService.GetCards(r.Context(), playerId)
 		if err != nil {
 			s.Log.Errf("could not find cards for player id %s due to error:\n%v\n", playerId, err)
 
 			// Try to check what kind of error we are dealing with
 			switch {
+			case errors.Is(err, bingo.ErrPlayerNotFound):
+				status = http.StatusNotFound
+				message = "Player could not be found"
+			case errors.Is(err, bingo.ErrPlayerWaitlisted):
+				status = http.StatusConflict
+				message = "Player is on the waitlist, and has no cards yet"
+			case errors.Is(err, bingo.ErrPlayerUnconfirmed):
+				status = http.StatusConflict
+				message = "Player has not confirmed their email, and has no cards yet"
+			case errors.Is(err, bingo.ErrPlayerRemoved):
+				status = http.StatusGone
+				message = "Player has been removed"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to access cards of player"
 			default:
 				status = http.StatusInternalServerError
 				message = "Unknown error occured"
@@ -39,8 +411,11 @@ func (s *Server) getCardsPdf() http.HandlerFunc {
 		// Generate cards pdf
 		cardsPdf, err := pdf.GenFromCards(player.Invitation.Game, player.Cards)
 		if err != nil {
+			s.Log.Errf("could not generate cards pdf for player id %s due to error:\n%v\n", playerId, err)
+
 			status = http.StatusInternalServerError
 			message = "Pdf file could not be generated"
+			s.writeJsonPayload(rw, status, message, data)
 			return
 		}
 
@@ -53,5 +428,22 @@ func (s *Server) getCardsPdf() http.HandlerFunc {
 func (s *Server) RegisterPlayerRoutes(r *mux.Router, mw ...mux.MiddlewareFunc) {
 	r.Use(mw...)
 
-	r.HandleFunc("/{playerID}/cards", s.getCardsPdf()).Methods(http.MethodGet)
+	// Routes of players by their session are registered first, so /me is not taken for a player id
+	sessionRtr := r.PathPrefix("/").Subrouter()
+	sessionRtr.Use(s.authenticatePlayer)
+	playerOrUserRtr := r.PathPrefix("/").Subrouter()
+	playerOrUserRtr.Use(s.authenticatePlayerOrUser)
+	authedRtr := r.PathPrefix("/").Subrouter()
+	authedRtr.Use(s.authMiddleware, s.requireUserSession)
+
+	sessionRtr.HandleFunc("/me", s.getPlayerSession()).Methods(http.MethodGet)
+	sessionRtr.HandleFunc("/{playerID}/withdraw", s.postWithdrawPlayer()).Methods(http.MethodPost)
+
+	// Players get their own cards by their session, and hosts the cards of their players
+	playerOrUserRtr.HandleFunc("/{playerID}/cards", s.getCardsPdf()).Methods(http.MethodGet)
+	playerOrUserRtr.HandleFunc("/{playerID}/resend", s.postResendPlayerCards()).Methods(http.MethodPost)
+
+	authedRtr.HandleFunc("/{playerID}", s.getPlayer()).Methods(http.MethodGet)
+	authedRtr.HandleFunc("/{playerID}", s.deletePlayer()).Methods(http.MethodDelete)
+	authedRtr.HandleFunc("/{playerID}/revoke", s.postRevokePlayerCards()).Methods(http.MethodPost)
 }
@@ -0,0 +1,265 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	bingohttp "github.com/nohns/bingo-box/server/http"
+	"github.com/nohns/bingo-box/server/logger"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+// The session of a player opens the routes of the player themselves, but never those of the members managing the game
+func TestServer_PlayerRoutes(t *testing.T) {
+
+	inv := &bingo.Invitation{ID: requiretest.UUIDv4(t), GameID: requiretest.UUIDv4(t)}
+
+	cases := []struct {
+		caseName       string
+		method         string
+		path           string
+		body           string
+		playerStatus   bingo.PlayerStatus
+		expectService  func(mocks *playerRouteMocks, p *bingo.Player)
+		expectedStatus int
+	}{
+		{
+			caseName:     "me",
+			method:       http.MethodGet,
+			path:         "/players/me",
+			playerStatus: bingo.PlayerStatusJoined,
+			expectService: func(mocks *playerRouteMocks, p *bingo.Player) {
+				mocks.playerRepo.ExpectGet(makePlayerGetHandler(p))
+				mocks.invRepo.ExpectGet(makeInvitationGetHandler(inv))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			caseName:     "withdraw",
+			method:       http.MethodPost,
+			path:         "/players/%s/withdraw",
+			body:         `{"reason": "ill"}`,
+			playerStatus: bingo.PlayerStatusJoined,
+			expectService: func(mocks *playerRouteMocks, p *bingo.Player) {
+				mocks.playerRepo.ExpectGet(makePlayerGetHandler(p))
+				mocks.invRepo.ExpectGet(makeInvitationGetHandler(inv))
+				mocks.playerRepo.ExpectGet(makePlayerGetHandler(p))
+				mocks.invRepo.ExpectRelease(func(_ context.Context, _ *bingo.Invitation, _ *bingo.Player) error { return nil })
+				mocks.playerRepo.ExpectSave(func(_ context.Context, _ *bingo.Player) error { return nil })
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			// Waitlisted players have no cards yet, which is only found out once the session is let through
+			caseName:     "cards",
+			method:       http.MethodGet,
+			path:         "/players/%s/cards",
+			playerStatus: bingo.PlayerStatusWaitlisted,
+			expectService: func(mocks *playerRouteMocks, p *bingo.Player) {
+				mocks.playerRepo.ExpectGet(makePlayerGetHandler(p))
+				mocks.invRepo.ExpectGet(makeInvitationGetHandler(inv))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			caseName:     "resend",
+			method:       http.MethodPost,
+			path:         "/players/%s/resend",
+			playerStatus: bingo.PlayerStatusJoined,
+			expectService: func(mocks *playerRouteMocks, p *bingo.Player) {
+				mocks.playerRepo.ExpectGet(makePlayerGetHandler(p))
+				mocks.invRepo.ExpectGet(makeInvitationGetHandler(inv))
+				mocks.playerRepo.ExpectGet(makePlayerGetHandler(p))
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			caseName:       "get player",
+			method:         http.MethodGet,
+			path:           "/players/%s",
+			playerStatus:   bingo.PlayerStatusJoined,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			caseName:       "remove player",
+			method:         http.MethodDelete,
+			path:           "/players/%s",
+			playerStatus:   bingo.PlayerStatusJoined,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			caseName:       "revoke cards",
+			method:         http.MethodPost,
+			path:           "/players/%s/revoke",
+			body:           `{"reason": "cheating"}`,
+			playerStatus:   bingo.PlayerStatusJoined,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			srv, mocks := MustCreatePlayerRouteServer(t)
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.invRepo.RequireExpectationsMet()
+
+			p := &bingo.Player{
+				ID:             requiretest.UUIDv4(t),
+				Name:           "test player",
+				Email:          "player@test.com",
+				InvitationID:   inv.ID,
+				Status:         tc.playerStatus,
+				DeliveryStatus: bingo.DeliveryStatusPending,
+				CreatedAt:      time.Now(),
+			}
+
+			// Players are authenticated by their session before the routes of players are served
+			if tc.expectService != nil {
+				mocks.playerRepo.ExpectGet(makePlayerGetHandler(p))
+				tc.expectService(mocks, p)
+			}
+
+			path := tc.path
+			if strings.Contains(path, "%s") {
+				path = fmt.Sprintf(path, p.ID)
+			}
+			req := httptest.NewRequest(tc.method, path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Player-Token", MustSignPlayerSession(t, p))
+			rec := httptest.NewRecorder()
+
+			srv.ServeHTTP(rec, req)
+			require.Equal(t, tc.expectedStatus, rec.Code, "unexpected status of %s %s by player session: %s", tc.method, tc.path, rec.Body.String())
+		})
+	}
+}
+
+// API keys are not granted access to the cards of players by any scope, so only users themselves get them
+func TestServer_PlayerRoutes_APIKey(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		method   string
+		path     string
+	}{
+		{"cards", http.MethodGet, "/players/%s/cards"},
+		{"resend", http.MethodPost, "/players/%s/resend"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			srv, mocks := MustCreatePlayerRouteServer(t)
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.invRepo.RequireExpectationsMet()
+
+			u := &bingo.User{ID: requiretest.UUIDv4(t), Email: "host@test.com"}
+			key := bingo.APIKeyPrefix + "readonly"
+			k := bingo.CreateAPIKey(u.ID, "read only", []bingo.Scope{bingo.ScopeGamesRead}, key)
+			k.ID = requiretest.UUIDv4(t)
+
+			apiKeyRepo := mock.NewAPIKeyRepository(t)
+			userRepo := mock.NewUserRepository(t)
+			defer apiKeyRepo.RequireExpectationsMet()
+			defer userRepo.RequireExpectationsMet()
+			srv.APIKeyService = bingo.NewAPIKeyService(apiKeyRepo, userRepo)
+
+			apiKeyRepo.ExpectGetByHash(func(_ context.Context, hash string) (*bingo.APIKey, error) {
+				require.Equal(t, k.Hash, hash, "api key must be looked up by its hash")
+				return k, nil
+			})
+			userRepo.ExpectGet(func(_ context.Context, id string) (*bingo.User, error) {
+				require.Equal(t, u.ID, id, "owner of the api key must be authenticated")
+				return u, nil
+			})
+
+			req := httptest.NewRequest(tc.method, fmt.Sprintf(tc.path, requiretest.UUIDv4(t)), nil)
+			req.Header.Set("Authorization", "Bearer "+key)
+			rec := httptest.NewRecorder()
+
+			srv.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusForbidden, rec.Code, "api key must not open %s %s: %s", tc.method, tc.path, rec.Body.String())
+		})
+	}
+}
+
+type playerRouteMocks struct {
+	playerRepo *mock.PlayerRepository
+	invRepo    *mock.InvitationRepository
+}
+
+func MustCreatePlayerRouteServer(tb testing.TB) (*bingohttp.Server, *playerRouteMocks) {
+	tb.Helper()
+
+	playerRepo := mock.NewPlayerRepository(tb)
+	invRepo := mock.NewInvitationRepository(tb)
+
+	srv := bingohttp.NewServer()
+	srv.Log = logger.New()
+	srv.PlayerService = bingo.NewPlayerService(
+		bingo.Repositories{
+			Users:         mock.NewUserRepository(tb),
+			Organizations: mock.NewOrganizationRepository(tb),
+			Games:         mock.NewGameRepository(tb),
+			Cards:         mock.NewCardRepository(tb),
+			Invitations:   invRepo,
+			Players:       playerRepo,
+			Credits:       mock.NewCreditRepository(tb),
+			Outbox:        mock.NewOutboxRepository(tb),
+		},
+		mock.PlayerSessionSigner{},
+		mock.NewTransactor(tb),
+	)
+	mocks := &playerRouteMocks{
+		playerRepo: playerRepo,
+		invRepo:    invRepo,
+	}
+
+	return srv, mocks
+}
+
+// Sign token of a session of the player valid for an hour
+func MustSignPlayerSession(tb testing.TB, p *bingo.Player) string {
+	tb.Helper()
+
+	token, err := mock.PlayerSessionSigner{}.Sign(bingo.PlayerSessionClaims{
+		PlayerID:  p.ID,
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(tb, err, "expected no error when signing player session")
+	require.Equal(tb, p.ID+":"+strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10), token)
+
+	return token
+}
+
+// Make handler returning a copy of the player, so changes made by the service do not leak into later calls
+func makePlayerGetHandler(p *bingo.Player) mock.PlayerGetHandler {
+	return func(_ context.Context, id string) (*bingo.Player, error) {
+		if id != p.ID {
+			return nil, bingo.ErrPlayerNotFound
+		}
+
+		cp := *p
+		return &cp, nil
+	}
+}
+
+func makeInvitationGetHandler(inv *bingo.Invitation) mock.InvitationGetHandler {
+	return func(_ context.Context, id string) (*bingo.Invitation, error) {
+		if id != inv.ID {
+			return nil, bingo.ErrInvitationNotFound
+		}
+
+		cp := *inv
+		return &cp, nil
+	}
+}
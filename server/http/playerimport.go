@@ -0,0 +1,156 @@
+package http
+
+import (
+	"bytes"
+	encsv "encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/csv"
+)
+
+// Max size of csv files imported, which fits the max amount of players with plenty of room for other columns
+const maxPlayerImportSize = 1 << 20
+
+// Result of an imported row. Rows failing have the error, and the fields failing validation if any
+type playerImportRowData struct {
+	Line   int            `json:"line"`
+	Email  string         `json:"email"`
+	Player *bingo.Player  `json:"player,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Fields validationData `json:"fields,omitempty"`
+}
+
+type playerImportData struct {
+	Imported   int                   `json:"imported"`
+	Waitlisted int                   `json:"waitlisted"`
+	Failed     int                   `json:"failed"`
+	Rows       []playerImportRowData `json:"rows"`
+}
+
+// Import players to the invitation from a csv file in the request body. Cards are mailed to the players imported if
+// asked for by the deliver query parameter. The import succeeds even if rows fail, and reports the result of each row
+func (s *Server) postInvitationImport() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		invId, ok := s.requireParam(rw, r, "invID")
+		if !ok {
+			return
+		}
+
+		var deliver bool
+		if raw := r.URL.Query().Get("deliver"); raw != "" {
+			var err error
+			deliver, err = strconv.ParseBool(raw)
+			if err != nil {
+				s.writeJsonPayload(rw, http.StatusBadRequest, "Deliver must be either true or false", nil)
+				return
+			}
+		}
+
+		// Read the file first, so files too large are rejected before any player is imported
+		b, err := io.ReadAll(io.LimitReader(r.Body, maxPlayerImportSize+1))
+		if err != nil {
+			s.writeJsonPayload(rw, http.StatusBadRequest, "Could not read the csv file", nil)
+			return
+		}
+		if len(b) > maxPlayerImportSize {
+			s.writeJsonPayload(rw, http.StatusRequestEntityTooLarge, "Csv file is too large", nil)
+			return
+		}
+
+		rows, err := csv.ParsePlayers(bytes.NewReader(b))
+		var parseErr *encsv.ParseError
+		switch {
+		case errors.As(err, &parseErr), errors.Is(err, csv.ErrMissingHeader), errors.Is(err, csv.ErrMissingColumn):
+			s.writeJsonPayload(rw, http.StatusBadRequest, "Csv file is malformed. The header must name the columns name and email", nil)
+			return
+		case errors.Is(err, bingo.ErrPlayerImportTooLarge):
+			s.writeJsonPayload(rw, http.StatusRequestEntityTooLarge, "Csv file has more players than can be imported at once", nil)
+			return
+		case err != nil:
+			s.Log.Errf("could not parse players to import for given inv id %s due to error:\n%v\n", invId, err)
+			s.writeJsonPayload(rw, http.StatusBadRequest, "Could not read the csv file", nil)
+			return
+		}
+
+		// Response payload
+		var status int
+		var message string
+		var data interface{}
+
+		report, err := s.InvitationService.ImportPlayers(r.Context(), invId, rows, deliver)
+		if err != nil {
+			s.Log.Errf("could not import players for given inv id %s due to error:\n%v\n", invId, err)
+
+			// Try to check what kind of error we are dealing with
+			switch {
+			case errors.Is(err, bingo.ErrPlayerImportEmpty):
+				status = http.StatusBadRequest
+				message = "Csv file has no players"
+			case errors.Is(err, bingo.ErrPlayerImportTooLarge):
+				status = http.StatusRequestEntityTooLarge
+				message = "Csv file has more players than can be imported at once"
+			case errors.Is(err, bingo.ErrInvitationNotFound):
+				status = http.StatusNotFound
+				message = "Invitation could not be found"
+			case errors.Is(err, bingo.ErrInvitationInactive):
+				status = http.StatusGone
+				message = "Invitation is no longer active"
+			case errors.Is(err, bingo.ErrForbidden):
+				status = http.StatusForbidden
+				message = "Not allowed to import players"
+			default:
+				status = http.StatusInternalServerError
+				message = "Unknown error occured"
+			}
+
+			s.writeJsonPayload(rw, status, message, data)
+			return
+		}
+
+		// Set response payload
+		status = http.StatusOK
+		data = s.newPlayerImportData(invId, report)
+		s.writeJsonPayload(rw, status, message, data)
+	}
+}
+
+// Translate the report of an import, with the errors of failed rows as messages
+func (s *Server) newPlayerImportData(invId string, report *bingo.PlayerImportReport) playerImportData {
+	data := playerImportData{
+		Imported:   report.Imported,
+		Waitlisted: report.Waitlisted,
+		Failed:     report.Failed,
+		Rows:       make([]playerImportRowData, 0, len(report.Rows)),
+	}
+	for _, res := range report.Rows {
+		row := playerImportRowData{
+			Line:   res.Line,
+			Email:  res.Email,
+			Player: res.Player,
+		}
+
+		var valErr bingo.ValidationErr
+		switch {
+		case res.Err == nil:
+		case errors.As(res.Err, &valErr):
+			row.Error = "Validation failed"
+			row.Fields = translateBingoValidationErr(valErr)
+		case errors.Is(res.Err, csv.ErrInvalidCardAmount):
+			row.Error = "Cards must be a whole number"
+		case errors.Is(res.Err, bingo.ErrPlayerExists):
+			row.Error = "Already joined with the email"
+		case errors.Is(res.Err, bingo.ErrInsufficientCredits):
+			row.Error = "The game can not hand out more cards right now"
+		default:
+			s.Log.Errf("could not import player on line %d for given inv id %s due to error:\n%v\n", res.Line, invId, res.Err)
+			row.Error = "Unknown error occured"
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	return data
+}
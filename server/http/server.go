@@ -18,11 +18,25 @@ type Server struct {
 	Log logger.Logger
 
 	// Exposed dependencies
-	Addr              string
-	UserService       *bingo.UserService
-	GameService       *bingo.GameService
-	InvitationService *bingo.InvitationService
-	PlayerService     *bingo.PlayerService
+	Addr                string
+	UserService         *bingo.UserService
+	TokenService        *bingo.TokenService
+	APIKeyService       *bingo.APIKeyService
+	OrganizationService *bingo.OrganizationService
+	CreditService       *bingo.CreditService
+	GameService         *bingo.GameService
+	InvitationService   *bingo.InvitationService
+	PlayerService       *bingo.PlayerService
+	OutboxService       *bingo.OutboxService
+
+	// Optional verifier of external identity provider sessions. When set, these sessions are accepted as authentication
+	SessionVerifier bingo.SessionVerifier
+
+	// Shared secret webhook calls, e.g. from Kratos, must carry. Webhooks are rejected when empty
+	HookSecret string
+
+	// Base url of the web app, which links handed out by the server point to, e.g. the join urls of QR codes
+	LinkBase string
 }
 
 // Start to listen on http address and serve http request. Block until error occurs.
@@ -39,35 +53,46 @@ func (s *Server) Serve() error {
 	return s.http.Serve(s.ln)
 }
 
-func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+// Serve the http request by the routes of the server, so it can be used as a http.Handler, e.g. in tests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Pass handling to mux router
 	s.router.ServeHTTP(w, r)
 }
 
-func NewServer(apiKey string) *Server {
+func NewServer() *Server {
 	s := &Server{
 		http:   &http.Server{},
 		router: mux.NewRouter(),
 	}
 
 	// Setup designated handler and listener
-	s.http.Handler = http.HandlerFunc(s.serveHTTP)
+	s.http.Handler = s
 
 	// Create resource routers
 	authRtr := s.router.PathPrefix("/auth").Subrouter()
 	gameRtr := s.router.PathPrefix("/games").Subrouter()
 	invRtr := s.router.PathPrefix("/invitations").Subrouter()
 	playerRtr := s.router.PathPrefix("/players").Subrouter()
+	hookRtr := s.router.PathPrefix("/hooks").Subrouter()
+	apiKeyRtr := s.router.PathPrefix("/apikeys").Subrouter()
+	orgRtr := s.router.PathPrefix("/organizations").Subrouter()
+	creditRtr := s.router.PathPrefix("/credits").Subrouter()
+	outboxRtr := s.router.PathPrefix("/admin/outbox").Subrouter()
 
 	// Register shared middleware
-	s.authMiddleware = apiKeyMiddlewareFactory(apiKey)
+	s.authMiddleware = s.authenticate
 
 	// Register resource routes. Some with middleware
 	s.registerAuthRoutes(authRtr)
 	s.registerGameRoutes(gameRtr, s.authMiddleware)
 	s.registerInvitationRoutes(invRtr)
 	s.RegisterPlayerRoutes(playerRtr)
+	s.registerHookRoutes(hookRtr, s.authenticateHook)
+	s.registerAPIKeyRoutes(apiKeyRtr, s.authMiddleware, s.requireUserSession)
+	s.registerOrganizationRoutes(orgRtr, s.authMiddleware, s.requireUserSession)
+	s.registerCreditRoutes(creditRtr, s.authMiddleware, s.requireUserSession)
+	s.registerOutboxRoutes(outboxRtr, s.authMiddleware, s.requireUserSession)
 
 	return s
 }
@@ -0,0 +1,44 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidSession = errors.New("bingo: session is invalid or expired")
+
+	// Identities are only linked to users registered with the same email if the identity has verified the email
+	ErrIdentityEmailUnverified = errors.New("bingo: email of identity belongs to another user and has not been verified")
+)
+
+// Verifies sessions issued by an external identity provider, e.g. Ory Kratos, and returns the identity behind it.
+// Either a session cookie header or a session token is given.
+type SessionVerifier interface {
+	Whoami(ctx context.Context, cookie, token string) (*Identity, error)
+}
+
+// Value object describing an identity managed by an external identity provider
+type Identity struct {
+	ID        string
+	Email     string
+	FirstName string
+	LastName  string
+
+	// Whether the identity has proven to own the email, e.g. by a verification link
+	EmailVerified bool
+
+	// Whether the terms of service has been accepted
+	TOSAccepted bool
+}
+
+// Full name of the identity. Falls back to the email if no name is known
+func (i *Identity) Name() string {
+	name := strings.TrimSpace(i.FirstName + " " + i.LastName)
+	if name == "" {
+		return i.Email
+	}
+
+	return name
+}
@@ -2,20 +2,67 @@ package bingo
 
 import (
 	"context"
-	"regexp"
+	"errors"
 	"strings"
+	"time"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("bingo: invitation could not be found")
+	ErrInvitationInactive = errors.New("bingo: invitation is no longer active")
+	ErrInvitationNotOpen  = errors.New("bingo: invitation is not open yet")
+	ErrInvitationExpired  = errors.New("bingo: invitation has expired")
+	ErrInvitationFull     = errors.New("bingo: invitation is full")
 )
 
 type InvitationRepository interface {
 	Get(ctx context.Context, invId string) (*Invitation, error)
 	Save(ctx context.Context, inv *Invitation) error
+
+	// Get invitation by its normalized join code. Saving an invitation with a join code already taken by another
+	// invitation returns ErrJoinCodeTaken.
+	GetByJoinCode(ctx context.Context, code string) (*Invitation, error)
+
+	// Reserve room for a player joining with the amount of cards, by counting them on the invitation atomically.
+	// Returns ErrInvitationFull if the player or cards would exceed the limits of the invitation, or if players are
+	// already waiting in line for room.
+	Reserve(ctx context.Context, inv *Invitation, cardAmount int) error
+
+	// Reserve room for more cards for a player who has joined, by counting them on the invitation atomically. Returns
+	// ErrInvitationFull if the cards would exceed the limit of the invitation, or if players are waiting in line.
+	ReserveCards(ctx context.Context, inv *Invitation, cardAmount int) error
+
+	// Reserve room for the first player in line on the waitlist, by counting them and their cards on the invitation
+	// and taking them off the waitlist atomically. Returns ErrInvitationFull if there is no room for them.
+	ReserveWaitlisted(ctx context.Context, inv *Invitation, cardAmount int) error
+
+	// Put a player in line on the waitlist of the invitation, and return their position. Positions are handed out in
+	// increasing order, also to players waitlisted concurrently.
+	Waitlist(ctx context.Context, inv *Invitation) (int, error)
+
+	// Release the place the player takes on the invitation, among the joined players or in line on the waitlist. The
+	// cards of the player stay counted, as they have been handed out. Players yet to confirm their email take no place,
+	// so nothing is released for them.
+	Release(ctx context.Context, inv *Invitation, p *Player) error
+
+	// Release the room of cards revoked from a player, by no longer counting them on the invitation atomically.
+	ReleaseCards(ctx context.Context, inv *Invitation, cardAmount int) error
 }
 
 type InvitationService struct {
 	invRepo    InvitationRepository
 	playerRepo PlayerRepository
+	gameRepo   GameRepository
+	authz      gameAuthorizer
+	outboxRepo OutboxRepository
+	roster     roster
+	tx         Transactor
+
+	// Verifies the tokens players confirm their email with
+	confirmations ConfirmationTokenSigner
 }
 
+// Get invitation by its id. Invitations are public, so everyone invited can see what they are joining.
 func (is *InvitationService) Get(ctx context.Context, invId string) (*Invitation, error) {
 	inv, err := is.invRepo.Get(ctx, invId)
 	if err != nil {
@@ -25,27 +72,41 @@ func (is *InvitationService) Get(ctx context.Context, invId string) (*Invitation
 	return inv, nil
 }
 
-func (is *InvitationService) Create(ctx context.Context, gameId string, method InvitationDeliveryMethod, maxCards int, criteria []InvitationCriterion) (*Invitation, error) {
-	inv := CreateInvitation(gameId, method, maxCards, criteria)
+// Create invitation to the game. Players joining invitations requiring confirmation have to confirm their email before
+// they get their cards. Only members allowed to manage the game can invite.
+func (is *InvitationService) Create(ctx context.Context, gameId string, method InvitationDeliveryMethod, maxCards int, criteria []InvitationCriterion, limits InvitationLimits, requireConfirmation bool) (*Invitation, error) {
+	g, err := is.gameRepo.Get(ctx, gameId)
+	if err != nil {
+		return nil, err
+	}
+	if err := is.authz.authorize(ctx, g, PermissionManageGame); err != nil {
+		return nil, err
+	}
+
+	inv := CreateInvitation(gameId, method, maxCards, criteria, limits, requireConfirmation)
 
 	// Make sure invitation is valid
 	if err := inv.Validate(); err != nil {
 		return nil, err
 	}
 
-	// Persist invitation
-	if err := is.invRepo.Save(ctx, inv); err != nil {
+	// Persist invitation along with a join code players can join it by
+	if err := is.saveWithJoinCode(ctx, inv); err != nil {
 		return nil, err
 	}
 
 	return inv, nil
 }
 
+// Deactivate invitation, so no more players can join by it. Only members allowed to manage the game can deactivate.
 func (is *InvitationService) Deactivate(ctx context.Context, invId string) (*Invitation, error) {
 	inv, err := is.invRepo.Get(ctx, invId)
 	if err != nil {
 		return nil, err
 	}
+	if err := is.authorize(ctx, inv, PermissionManageGame); err != nil {
+		return nil, err
+	}
 
 	inv.Active = false
 	if err = is.invRepo.Save(ctx, inv); err != nil {
@@ -55,32 +116,196 @@ func (is *InvitationService) Deactivate(ctx context.Context, invId string) (*Inv
 	return inv, nil
 }
 
-func (is *InvitationService) Join(ctx context.Context, invId string, name, email string, cardAmount int) (*Player, error) {
+// Set the schedule and capacity of the invitation. Players on the waitlist are promoted if the capacity is raised. Only
+// members allowed to manage the game can set limits.
+func (is *InvitationService) SetLimits(ctx context.Context, invId string, limits InvitationLimits) (*Invitation, error) {
 	inv, err := is.invRepo.Get(ctx, invId)
 	if err != nil {
 		return nil, err
 	}
+	if err := is.authorize(ctx, inv, PermissionManageGame); err != nil {
+		return nil, err
+	}
 
-	// Create a new player and validate it
-	p := NewPlayer(name, email, cardAmount)
-	err = inv.ValidatePlayer(p)
+	inv.setLimits(limits)
+	if err := inv.Validate(); err != nil {
+		return nil, err
+	}
+	if err := is.invRepo.Save(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	if err := is.roster.promoteWaitlisted(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// Join the game by the invitation. A player is created along with the amount of cards asked for, which are generated
+// by the game as any other cards. Player and cards are persisted atomically. The language is the one the player wants
+// mails in, or empty to get them in the language of the game. Players can only join open invitations. Players joining
+// full invitations are put on the waitlist, and get their cards once promoted. Joining again with the same email tops
+// up the cards of the player already joined, up to the max amount of cards of the invitation. Players joining
+// invitations requiring confirmation are pending until they confirm their email by the link mailed to them, and expire
+// if they do not confirm in time.
+func (is *InvitationService) Join(ctx context.Context, invId string, name, email string, cardAmount int, lang Language) (*Player, error) {
+	inv, err := is.invRepo.Get(ctx, invId)
 	if err != nil {
 		return nil, err
 	}
+	if status := inv.StatusAt(time.Now()); status != InvitationStatusFull {
+		if err := status.err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create a new player and validate it
+	p := NewPlayer(inv.ID, name, email, lang)
+	if err := inv.ValidatePlayer(p); err != nil {
+		return nil, err
+	}
+	if err := inv.ValidateCardAmount(cardAmount); err != nil {
+		return nil, err
+	}
+
+	// Persist the player and generate cards owned by them if there is room for them on the invitation. Otherwise they
+	// wait in line. Players already joined are looked up within the transaction, so concurrent joins by the same email
+	// can not exceed the max amount of cards together. The game is read within the transaction as well, so it is read
+	// again if the transaction is retried
+	var g *Game
+	err = is.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		g, err = is.gameRepo.Get(ctx, inv.GameID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := is.playerRepo.GetByEmail(ctx, inv.ID, p.Email)
+		if err == nil {
+			p = existing
+			return is.topUp(ctx, inv, g, p, cardAmount)
+		}
+		if !errors.Is(err, ErrPlayerNotFound) {
+			return err
+		}
+
+		// Room is only reserved once the player confirms, so pending players do not take up room of others
+		if inv.RequireConfirmation {
+			return is.awaitConfirmation(ctx, p, cardAmount)
+		}
+
+		err = is.invRepo.Reserve(ctx, inv, cardAmount)
+		if errors.Is(err, ErrInvitationFull) {
+			position, err := is.invRepo.Waitlist(ctx, inv)
+			if err != nil {
+				return err
+			}
+			p.waitlist(position, cardAmount)
+			return is.playerRepo.Save(ctx, p)
+		}
+		if err != nil {
+			return err
+		}
 
-	// Persist the player
-	err = is.playerRepo.Save(ctx, p)
+		return is.roster.admit(ctx, inv, g, p, cardAmount, inv.mailsCards())
+	})
 	if err != nil {
 		return nil, err
 	}
+	inv.Game = g
+	p.Invitation = inv
 
 	return p, nil
 }
 
-func NewInvitationService(invRepo InvitationRepository, playerRepo PlayerRepository) *InvitationService {
+// Top up the cards of the player already joined by the invitation, or waiting in line, up to the max amount of cards.
+// Players who have joined get the cards added delivered along with the ones they have. Must be run within a transaction.
+func (is *InvitationService) topUp(ctx context.Context, inv *Invitation, g *Game, p *Player, cardAmount int) error {
+	if p.Status == PlayerStatusRemoved {
+		return ErrPlayerRemoved
+	}
+	left := inv.MaxCardAmount - p.cardAmount()
+	if left <= 0 {
+		return ErrPlayerCardLimit
+	}
+	if cardAmount > left {
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr("CardAmount", "max", "card amount must not exceed the %d cards left for the email", left)
+	}
+
+	switch p.Status {
+	case PlayerStatusWaitlisted:
+		p.RequestedCards += cardAmount
+		return is.playerRepo.Save(ctx, p)
+	case PlayerStatusUnconfirmed:
+		return is.awaitConfirmation(ctx, p, p.RequestedCards+cardAmount)
+	}
+	if err := is.invRepo.ReserveCards(ctx, inv, cardAmount); err != nil {
+		return err
+	}
+
+	return is.roster.admit(ctx, inv, g, p, cardAmount, inv.mailsCards())
+}
+
+// Persist the player as pending confirmation of their email, and queue mailing them the link to confirm by. Joining
+// again before confirming mails a new link, and gives the player more time. Must be run within a transaction.
+func (is *InvitationService) awaitConfirmation(ctx context.Context, p *Player, cardAmount int) error {
+	p.awaitConfirmation(cardAmount, time.Now().Add(PlayerConfirmationLifetime))
+	if err := is.playerRepo.Save(ctx, p); err != nil {
+		return err
+	}
+
+	return is.outboxRepo.Save(ctx, NewPlayerConfirmationMessage(p))
+}
+
+// Let the player join the game, with room reserved for them on the invitation, and persist them along with the cards
+// generated for them. Cards to deliver by mail are queued for delivery by the outbox along with the player, so they are
+// not lost if mailing fails. Must be run within a transaction.
+func (r roster) admit(ctx context.Context, inv *Invitation, g *Game, p *Player, cardAmount int, deliver bool) error {
+	p.join()
+	if deliver {
+		p.DeliveryStatus = DeliveryStatusPending
+	}
+	if err := r.playerRepo.Save(ctx, p); err != nil {
+		return err
+	}
+
+	cards, err := r.cards.generate(ctx, g, cardAmount, CreditReasonCardsGenerated, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Cards = append(p.Cards, cards...)
+
+	if p.DeliveryStatus == DeliveryStatusPending {
+		return r.outboxRepo.Save(ctx, NewPlayerCardsMessage(p))
+	}
+	return nil
+}
+
+// Authorize that the actor has a role in the game of the invitation granting the permission. The game is fetched and
+// set on the invitation if not already present.
+func (is *InvitationService) authorize(ctx context.Context, inv *Invitation, perm Permission) error {
+	if inv.Game == nil {
+		g, err := is.gameRepo.Get(ctx, inv.GameID)
+		if err != nil {
+			return err
+		}
+		inv.Game = g
+	}
+
+	return is.authz.authorize(ctx, inv.Game, perm)
+}
+
+func NewInvitationService(repos Repositories, confirmations ConfirmationTokenSigner, tx Transactor) *InvitationService {
 	return &InvitationService{
-		invRepo:    invRepo,
-		playerRepo: playerRepo,
+		invRepo:       repos.Invitations,
+		playerRepo:    repos.Players,
+		gameRepo:      repos.Games,
+		authz:         gameAuthorizer{orgRepo: repos.Organizations},
+		outboxRepo:    repos.Outbox,
+		roster:        newRoster(repos, tx),
+		tx:            tx,
+		confirmations: confirmations,
 	}
 }
 
@@ -91,10 +316,29 @@ type Invitation struct {
 	MaxCardAmount  int                      `json:"maxCardAmount"`
 	Active         bool                     `json:"active"`
 
+	// Short code players can join by instead of the id, e.g. when read aloud or printed on a poster
+	JoinCode string `json:"joinCode"`
+
 	GameID string `json:"gameId"`
 	Game   *Game  `json:"game"`
 
 	Criteria []InvitationCriterion `json:"criteria"`
+
+	// Time the invitation opens and expires, if scheduled. Zero when not
+	OpensAt   time.Time `json:"opensAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// Max amount of players joining and cards handed out in total. Zero when unlimited
+	MaxPlayers    int `json:"maxPlayers"`
+	MaxTotalCards int `json:"maxTotalCards"`
+
+	// Amount of players joined and cards handed out, counted when reserved, and players waiting in line for room
+	PlayerCount   int `json:"playerCount"`
+	CardCount     int `json:"cardCount"`
+	WaitlistCount int `json:"waitlistCount"`
+
+	// Whether players have to confirm their email before they get their cards
+	RequireConfirmation bool `json:"requireConfirmation"`
 }
 
 var (
@@ -117,13 +361,47 @@ func (inv *Invitation) Validate() error {
 		}
 	}
 
+	if inv.JoinCode != "" && (len(inv.JoinCode) != JoinCodeLength || NormalizeJoinCode(inv.JoinCode) != inv.JoinCode) {
+		return ErrInvitationValidation.withFieldErr("JoinCode", "len", "join code must be %d characters in normalized form", JoinCodeLength)
+	}
+
 	if inv.MaxCardAmount < 1 {
 		return ErrInvitationValidation.withFieldErr("MaxCardAmount", "min", "Max card amount must be greater than 0")
 	}
 
+	if inv.MaxPlayers < 0 {
+		return ErrInvitationValidation.withFieldErr("MaxPlayers", "min", "max players must not be negative")
+	}
+	if inv.MaxTotalCards < 0 {
+		return ErrInvitationValidation.withFieldErr("MaxTotalCards", "min", "max total cards must not be negative")
+	}
+	if !inv.OpensAt.IsZero() && !inv.ExpiresAt.IsZero() && !inv.ExpiresAt.After(inv.OpensAt) {
+		return ErrInvitationValidation.withFieldErr("ExpiresAt", "gtfield", "invitation must expire after it opens")
+	}
+
 	return nil
 }
 
+// Status of the invitation at the given time, telling whether players can join it
+func (inv *Invitation) StatusAt(now time.Time) InvitationStatus {
+	switch {
+	case !inv.Active:
+		return InvitationStatusInactive
+	case !inv.OpensAt.IsZero() && now.Before(inv.OpensAt):
+		return InvitationStatusNotOpen
+	case !inv.ExpiresAt.IsZero() && !now.Before(inv.ExpiresAt):
+		return InvitationStatusExpired
+	case inv.MaxPlayers > 0 && inv.PlayerCount >= inv.MaxPlayers:
+		return InvitationStatusFull
+	case inv.MaxTotalCards > 0 && inv.CardCount >= inv.MaxTotalCards:
+		return InvitationStatusFull
+	case inv.WaitlistCount > 0:
+		return InvitationStatusFull
+	}
+
+	return InvitationStatusOpen
+}
+
 func (inv *Invitation) ValidatePlayer(p *Player) error {
 	if p.Email == "" {
 		return ErrInvitationCriteriaPlayerValidation.withFieldErr("Email", "empty", "email has to have a value")
@@ -133,69 +411,94 @@ func (inv *Invitation) ValidatePlayer(p *Player) error {
 		return ErrInvitationCriteriaPlayerValidation.withFieldErr("Name", "empty", "name has to have a value")
 	}
 
+	if p.Language != "" && !p.Language.Valid() {
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr("Language", "noMatch", "language %s does not match any of the available: %s, %s", p.Language, LanguageDanish, LanguageEnglish)
+	}
+
 	// Validate player by criteria
 	for _, c := range inv.Criteria {
-		err := c.ValidatePlayer(p)
+		m, err := c.compile(1)
 		if err != nil {
 			return err
 		}
+		if !m(p) {
+			return c.failedErr()
+		}
 	}
 
 	return nil
 }
 
-func CreateInvitation(gameId string, method InvitationDeliveryMethod, maxCards int, criteria []InvitationCriterion) *Invitation {
-	return &Invitation{
-		GameID:         gameId,
-		DeliveryMethod: method,
-		MaxCardAmount:  maxCards,
-		Criteria:       criteria,
+// Check the amount of cards a player asks for when joining is within the max of the invitation. Players asking for more
+// cards than are left are waitlisted
+func (inv *Invitation) ValidateCardAmount(amount int) error {
+	if amount < 1 {
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr("CardAmount", "min", "card amount must be greater than 0")
+	}
+	if amount > inv.MaxCardAmount {
+		return ErrInvitationCriteriaPlayerValidation.withFieldErr("CardAmount", "max", "card amount must not exceed %d", inv.MaxCardAmount)
 	}
+
+	return nil
 }
 
-// Value object
-type InvitationCriterion struct {
-	Kind  InvitationCriterionKind  `json:"kind"`
-	Field InvitationCriterionField `json:"field"`
-	Value string                   `json:"value"`
+// Whether cards of players joining by the invitation are delivered by mail
+func (inv *Invitation) mailsCards() bool {
+	return inv.DeliveryMethod == InvitationDeliveryMethodMail
 }
 
-func (ic InvitationCriterion) Validate() error {
-	availCriteria := strings.Join([]string{
-		string(InvitationCriterionKindRegex),
-	}, ", ")
+func (inv *Invitation) setLimits(limits InvitationLimits) {
+	inv.OpensAt = limits.OpensAt
+	inv.ExpiresAt = limits.ExpiresAt
+	inv.MaxPlayers = limits.MaxPlayers
+	inv.MaxTotalCards = limits.MaxTotalCards
+}
 
-	if ic.Kind != InvitationCriterionKindRegex {
-		return ErrInvitationValidation.withFieldErr("kind", "noMatch", "invitation criterium kind %s does not match any of the available: %s", ic.Kind, availCriteria)
+// Create invitation to the game. New invitations are active, until they are deactivated
+func CreateInvitation(gameId string, method InvitationDeliveryMethod, maxCards int, criteria []InvitationCriterion, limits InvitationLimits, requireConfirmation bool) *Invitation {
+	inv := &Invitation{
+		Active:              true,
+		GameID:              gameId,
+		DeliveryMethod:      method,
+		MaxCardAmount:       maxCards,
+		Criteria:            criteria,
+		RequireConfirmation: requireConfirmation,
 	}
+	inv.setLimits(limits)
 
-	return nil
+	return inv
 }
 
-func (ic InvitationCriterion) ValidatePlayer(p *Player) error {
-
-	switch ic.Field {
-	case InvitationCriterionFieldEmail:
-		return ic.validateValue("Email", p.Email)
-	case InvitationCriterionFieldName:
-		return ic.validateValue("Name", p.Name)
-	}
-
-	return nil
+// Schedule and capacity of an invitation. Zero values mean no limit
+type InvitationLimits struct {
+	OpensAt       time.Time
+	ExpiresAt     time.Time
+	MaxPlayers    int
+	MaxTotalCards int
 }
 
-func (ic InvitationCriterion) validateValue(field, v string) error {
-	switch ic.Kind {
-	case InvitationCriterionKindRegex:
-		regex, err := regexp.Compile(ic.Value)
-		if err != nil {
-			return ErrInvitationCriteriaPlayerValidation.withFieldErr(field, "malformedRegex", "given regex %s is invalid", ic.Value)
-		}
+// Whether players can join an invitation, and why not
+type InvitationStatus string
 
-		// If regex did not match return validation err
-		if !regex.Match([]byte(v)) {
-			return ErrInvitationCriteriaPlayerValidation.withFieldErr(field, "failedCriteria", "did not match regex value %s", ic.Value)
-		}
+const (
+	InvitationStatusOpen     InvitationStatus = "OPEN"
+	InvitationStatusInactive InvitationStatus = "INACTIVE"
+	InvitationStatusNotOpen  InvitationStatus = "NOT_OPEN"
+	InvitationStatusExpired  InvitationStatus = "EXPIRED"
+	InvitationStatusFull     InvitationStatus = "FULL"
+)
+
+// Error joining an invitation with the status, or nil if it is open
+func (s InvitationStatus) err() error {
+	switch s {
+	case InvitationStatusInactive:
+		return ErrInvitationInactive
+	case InvitationStatusNotOpen:
+		return ErrInvitationNotOpen
+	case InvitationStatusExpired:
+		return ErrInvitationExpired
+	case InvitationStatusFull:
+		return ErrInvitationFull
 	}
 
 	return nil
@@ -207,16 +510,3 @@ const (
 	InvitationDeliveryMethodMail     InvitationDeliveryMethod = "MAIL"
 	InvitationDeliveryMethodDownload InvitationDeliveryMethod = "DOWNLOAD"
 )
-
-type InvitationCriterionKind string
-
-const (
-	InvitationCriterionKindRegex InvitationCriterionKind = "REGEX"
-)
-
-type InvitationCriterionField string
-
-const (
-	InvitationCriterionFieldEmail InvitationCriterionField = "EMAIL"
-	InvitationCriterionFieldName  InvitationCriterionField = "NAME"
-)
@@ -0,0 +1,728 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitationService_Create(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+
+	cases := []struct {
+		caseName     string
+		actorId      string
+		gameId       string
+		limits       bingo.InvitationLimits
+		expectSave   bool
+		expectValErr bool
+		expectedErr  error
+	}{
+		{
+			caseName:   "success",
+			actorId:    testGame.HostId,
+			gameId:     testGame.ID,
+			expectSave: true,
+		},
+		{
+			caseName: "success with limits",
+			actorId:  testGame.HostId,
+			gameId:   testGame.ID,
+			limits: bingo.InvitationLimits{
+				OpensAt:       time.Now().Add(time.Hour),
+				ExpiresAt:     time.Now().Add(48 * time.Hour),
+				MaxPlayers:    100,
+				MaxTotalCards: 250,
+			},
+			expectSave: true,
+		},
+		{
+			caseName: "expires before opening",
+			actorId:  testGame.HostId,
+			gameId:   testGame.ID,
+			limits: bingo.InvitationLimits{
+				OpensAt:   time.Now().Add(time.Hour),
+				ExpiresAt: time.Now(),
+			},
+			expectValErr: true,
+		},
+		{
+			caseName:     "negative max players",
+			actorId:      testGame.HostId,
+			gameId:       testGame.ID,
+			limits:       bingo.InvitationLimits{MaxPlayers: -1},
+			expectValErr: true,
+		},
+		{
+			caseName:    "game not found",
+			actorId:     testGame.HostId,
+			gameId:      "",
+			expectedErr: bingo.ErrGameNotFound,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			gameId:      testGame.ID,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			if tc.expectSave {
+				mocks.invRepo.ExpectSave(func(_ context.Context, inv *bingo.Invitation) error {
+					inv.ID = requiretest.UUIDv4(t)
+					return nil
+				})
+			}
+
+			inv, err := invSvc.Create(NewActorContext(t, tc.actorId), tc.gameId, bingo.InvitationDeliveryMethodDownload, 3, nil, tc.limits, false)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				require.Nil(t, inv, "invitation must be nil when error is expected")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, inv, "invitation must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.Equal(t, testGame.ID, inv.GameID, "invitation must be for the game")
+				require.True(t, inv.Active, "new invitation must be active")
+				require.Equal(t, tc.limits.MaxPlayers, inv.MaxPlayers, "invitation must have the max players given")
+				require.Equal(t, tc.limits.MaxTotalCards, inv.MaxTotalCards, "invitation must have the max total cards given")
+				require.Len(t, inv.JoinCode, bingo.JoinCodeLength, "new invitation must have a join code")
+			}
+		})
+	}
+}
+
+func TestInvitationService_Deactivate(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		invId       string
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName:   "success",
+			actorId:    testGame.HostId,
+			invId:      testInv.ID,
+			expectSave: true,
+		},
+		{
+			caseName:    "invitation not found",
+			actorId:     testGame.HostId,
+			invId:       "",
+			expectedErr: bingo.ErrInvitationNotFound,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			invId:       testInv.ID,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			if tc.invId == testInv.ID {
+				mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			}
+			if tc.expectSave {
+				mocks.invRepo.ExpectSave(func(_ context.Context, _ *bingo.Invitation) error { return nil })
+			}
+
+			inv, err := invSvc.Deactivate(NewActorContext(t, tc.actorId), tc.invId)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, inv, "invitation must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.False(t, inv.Active, "invitation must be deactivated")
+			}
+		})
+	}
+}
+
+func TestInvitation_StatusAt(t *testing.T) {
+
+	now := time.Now()
+	cases := []struct {
+		caseName string
+		mutate   func(inv *bingo.Invitation)
+		expected bingo.InvitationStatus
+	}{
+		{
+			caseName: "open without limits",
+			mutate:   func(inv *bingo.Invitation) {},
+			expected: bingo.InvitationStatusOpen,
+		},
+		{
+			caseName: "open within schedule",
+			mutate: func(inv *bingo.Invitation) {
+				inv.OpensAt = now.Add(-time.Hour)
+				inv.ExpiresAt = now.Add(time.Hour)
+			},
+			expected: bingo.InvitationStatusOpen,
+		},
+		{
+			caseName: "inactive",
+			mutate:   func(inv *bingo.Invitation) { inv.Active = false },
+			expected: bingo.InvitationStatusInactive,
+		},
+		{
+			caseName: "not open yet",
+			mutate:   func(inv *bingo.Invitation) { inv.OpensAt = now.Add(time.Second) },
+			expected: bingo.InvitationStatusNotOpen,
+		},
+		{
+			caseName: "expired at expiry",
+			mutate:   func(inv *bingo.Invitation) { inv.ExpiresAt = now },
+			expected: bingo.InvitationStatusExpired,
+		},
+		{
+			caseName: "full of players",
+			mutate: func(inv *bingo.Invitation) {
+				inv.MaxPlayers = 2
+				inv.PlayerCount = 2
+			},
+			expected: bingo.InvitationStatusFull,
+		},
+		{
+			caseName: "out of cards",
+			mutate: func(inv *bingo.Invitation) {
+				inv.MaxTotalCards = 10
+				inv.CardCount = 10
+			},
+			expected: bingo.InvitationStatusFull,
+		},
+		{
+			caseName: "players waiting in line",
+			mutate: func(inv *bingo.Invitation) {
+				inv.MaxPlayers = 10
+				inv.PlayerCount = 9
+				inv.WaitlistCount = 1
+			},
+			expected: bingo.InvitationStatusFull,
+		},
+		{
+			caseName: "inactive before expired",
+			mutate: func(inv *bingo.Invitation) {
+				inv.Active = false
+				inv.ExpiresAt = now.Add(-time.Hour)
+			},
+			expected: bingo.InvitationStatusInactive,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			inv := MustMakeTestInvitation(t, MustMakeTestGame(t))
+			tc.mutate(inv)
+			require.Equal(t, tc.expected, inv.StatusAt(now), "invitation must have the expected status")
+		})
+	}
+}
+
+func TestInvitationService_Get(t *testing.T) {
+
+	testInv := MustMakeTestInvitation(t, MustMakeTestGame(t))
+
+	cases := []struct {
+		caseName    string
+		ctx         context.Context
+		invId       string
+		expectedErr error
+	}{
+		{
+			caseName: "success without actor",
+			ctx:      context.Background(),
+			invId:    testInv.ID,
+		},
+		{
+			caseName:    "invitation not found",
+			ctx:         context.Background(),
+			invId:       "",
+			expectedErr: bingo.ErrInvitationNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+
+			inv, err := invSvc.Get(tc.ctx, tc.invId)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, inv, "invitation must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.Equal(t, testInv.ID, inv.ID, "invitation must be the one asked for")
+			}
+		})
+	}
+}
+
+func TestInvitationService_Join(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	mailInv := MustMakeTestInvitation(t, testGame)
+	mailInv.DeliveryMethod = bingo.InvitationDeliveryMethodMail
+	inactiveInv := MustMakeTestInvitation(t, testGame)
+	inactiveInv.Active = false
+	scheduledInv := MustMakeTestInvitation(t, testGame)
+	scheduledInv.OpensAt = time.Now().Add(time.Hour)
+	expiredInv := MustMakeTestInvitation(t, testGame)
+	expiredInv.ExpiresAt = time.Now().Add(-time.Minute)
+	limitedInv := MustMakeTestInvitation(t, testGame)
+	limitedInv.OpensAt = time.Now().Add(-time.Hour)
+	limitedInv.ExpiresAt = time.Now().Add(time.Hour)
+	limitedInv.MaxPlayers = 10
+	limitedInv.MaxTotalCards = 20
+	limitedInv.PlayerCount = 5
+	limitedInv.CardCount = 18
+	fullInv := MustMakeTestInvitation(t, testGame)
+	fullInv.MaxPlayers = 10
+	fullInv.PlayerCount = 10
+	fullMailInv := MustCopyInvitation(t, fullInv)
+	fullMailInv.ID = requiretest.UUIDv4(t)
+	fullMailInv.DeliveryMethod = bingo.InvitationDeliveryMethodMail
+
+	cases := []struct {
+		caseName       string
+		inv            *bingo.Invitation
+		cardAmount     int
+		lang           bingo.Language
+		expectJoin     bool
+		expectWaitlist bool
+		expectMail     bool
+		expectValErr   bool
+		expectedErr    error
+	}{
+		{
+			caseName:   "success",
+			inv:        testInv,
+			cardAmount: testInv.MaxCardAmount,
+			expectJoin: true,
+		},
+		{
+			caseName:   "mail invitation",
+			inv:        mailInv,
+			cardAmount: 1,
+			expectJoin: true,
+			expectMail: true,
+		},
+		{
+			caseName:     "card amount above max",
+			inv:          testInv,
+			cardAmount:   testInv.MaxCardAmount + 1,
+			expectValErr: true,
+		},
+		{
+			caseName:     "no cards",
+			inv:          testInv,
+			cardAmount:   0,
+			expectValErr: true,
+		},
+		{
+			caseName:     "unknown language",
+			inv:          testInv,
+			cardAmount:   1,
+			lang:         bingo.Language("xx"),
+			expectValErr: true,
+		},
+		{
+			caseName:    "inactive invitation",
+			inv:         inactiveInv,
+			cardAmount:  1,
+			expectedErr: bingo.ErrInvitationInactive,
+		},
+		{
+			caseName:    "not open yet",
+			inv:         scheduledInv,
+			cardAmount:  1,
+			expectedErr: bingo.ErrInvitationNotOpen,
+		},
+		{
+			caseName:    "expired",
+			inv:         expiredInv,
+			cardAmount:  1,
+			expectedErr: bingo.ErrInvitationExpired,
+		},
+		{
+			caseName:       "full",
+			inv:            fullInv,
+			cardAmount:     1,
+			expectWaitlist: true,
+		},
+		{
+			caseName:   "within limits",
+			inv:        limitedInv,
+			cardAmount: 2,
+			expectJoin: true,
+		},
+		{
+			caseName:       "more cards than left",
+			inv:            limitedInv,
+			cardAmount:     3,
+			expectWaitlist: true,
+		},
+		{
+			caseName:       "mail invitation full",
+			inv:            fullMailInv,
+			cardAmount:     2,
+			expectWaitlist: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *tc.inv))
+			var savedCards []bingo.Card
+			var queued *bingo.OutboxMessage
+			var reserved int
+			if tc.expectJoin || tc.expectWaitlist {
+				mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+				mocks.playerRepo.ExpectGetByEmail(func(_ context.Context, invId string, email string) (*bingo.Player, error) {
+					require.Equal(t, "player@test.com", email, "player must be looked up by the normalized email")
+					return nil, bingo.ErrPlayerNotFound
+				})
+				mocks.invRepo.ExpectReserve(func(_ context.Context, inv *bingo.Invitation, cardAmount int) error {
+					if tc.expectWaitlist {
+						return bingo.ErrInvitationFull
+					}
+					reserved = cardAmount
+					return nil
+				})
+			}
+			if tc.expectWaitlist {
+				mocks.invRepo.ExpectWaitlist(func(_ context.Context, _ *bingo.Invitation) (int, error) { return 7, nil })
+				mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+					p.ID = requiretest.UUIDv4(t)
+					return nil
+				})
+			}
+			if tc.expectJoin {
+				mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+					p.ID = requiretest.UUIDv4(t)
+					return nil
+				})
+				mocks.cardRepo.ExpectSaveAll(func(_ context.Context, cards []bingo.Card) error {
+					savedCards = cards
+					return nil
+				})
+				mocks.gameRepo.ExpectAllocateCardNumbers(MakeCardNumbersAllocateHandler(t, *testGame))
+			}
+			if tc.expectMail {
+				mocks.outboxRepo.ExpectSave(func(_ context.Context, msg *bingo.OutboxMessage) error {
+					queued = msg
+					return nil
+				})
+			}
+
+			p, err := invSvc.Join(context.Background(), tc.inv.ID, " Player Name ", "Player@Test.com", tc.cardAmount, tc.lang)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			if tc.expectWaitlist {
+				require.Equal(t, bingo.PlayerStatusWaitlisted, p.Status, "player must be on the waitlist")
+				require.Equal(t, 7, p.WaitlistPosition, "player must be in line at the position taken")
+				require.Equal(t, tc.cardAmount, p.RequestedCards, "player must wait for the cards asked for")
+				require.Empty(t, p.Cards, "player on the waitlist must not have cards")
+				require.Empty(t, p.DeliveryStatus, "cards of player on the waitlist must not await delivery")
+				return
+			}
+			require.Equal(t, bingo.PlayerStatusJoined, p.Status, "player must have joined")
+			require.Equal(t, "Player Name", p.Name, "player name must be trimmed")
+			require.Equal(t, "player@test.com", p.Email, "player email must be normalized")
+			require.Equal(t, tc.inv.ID, p.InvitationID, "player must have joined by the invitation")
+			require.Len(t, p.Cards, tc.cardAmount, "player must have the cards asked for")
+			require.Equal(t, tc.cardAmount, reserved, "room for the cards must be reserved on the invitation")
+			if tc.expectMail {
+				require.Equal(t, bingo.DeliveryStatusPending, p.DeliveryStatus, "cards of mail invitations must await delivery")
+				require.Equal(t, p.ID, queued.PlayerID, "cards of the player must be queued for delivery")
+				require.Equal(t, bingo.OutboxStatusPending, queued.Status, "queued message must be pending")
+			} else {
+				require.Empty(t, p.DeliveryStatus, "cards of download invitations are not delivered")
+			}
+			require.Len(t, savedCards, tc.cardAmount, "cards of player must be saved")
+			for _, c := range savedCards {
+				require.Equal(t, p.ID, c.PlayerID, "card must be owned by the player")
+				require.Equal(t, testGame.ID, c.GameID, "card must be generated for the game")
+			}
+		})
+	}
+}
+
+func TestInvitationService_JoinAgain(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	mailInv := MustMakeTestInvitation(t, testGame)
+	mailInv.DeliveryMethod = bingo.InvitationDeliveryMethodMail
+
+	withCards := func(inv *bingo.Invitation, amount int) *bingo.Player {
+		p := MustMakeTestPlayer(t, inv)
+		for i := 0; i < amount; i++ {
+			p.Cards = append(p.Cards, bingo.Card{ID: requiretest.UUIDv4(t), GameID: testGame.ID, PlayerID: p.ID})
+		}
+		return p
+	}
+	waitlisted := MustMakeWaitlistedPlayer(t, testInv, 1, 1)
+	removed := withCards(testInv, 1)
+	removed.Status = bingo.PlayerStatusRemoved
+	withVoid := withCards(testInv, 3)
+	withVoid.Cards[0].Revocation = &bingo.CardRevocation{Reason: "misprint", RevokedAt: time.Now()}
+
+	cases := []struct {
+		caseName      string
+		inv           *bingo.Invitation
+		existing      *bingo.Player
+		cardAmount    int
+		expectTopUp   bool
+		expectMail    bool
+		expectedCards int
+		expectValErr  bool
+		expectedErr   error
+	}{
+		{
+			caseName:      "top up joined player",
+			inv:           testInv,
+			existing:      withCards(testInv, 1),
+			cardAmount:    2,
+			expectTopUp:   true,
+			expectedCards: 3,
+		},
+		{
+			caseName:      "top up redelivers all cards by mail",
+			inv:           mailInv,
+			existing:      withCards(mailInv, 2),
+			cardAmount:    1,
+			expectTopUp:   true,
+			expectMail:    true,
+			expectedCards: 3,
+		},
+		{
+			caseName:     "top up beyond max",
+			inv:          testInv,
+			existing:     withCards(testInv, 2),
+			cardAmount:   2,
+			expectValErr: true,
+		},
+		{
+			caseName:    "max cards already",
+			inv:         testInv,
+			existing:    withCards(testInv, 3),
+			cardAmount:  1,
+			expectedErr: bingo.ErrPlayerCardLimit,
+		},
+		{
+			caseName:      "void cards not counted",
+			inv:           testInv,
+			existing:      withVoid,
+			cardAmount:    1,
+			expectTopUp:   true,
+			expectedCards: 4,
+		},
+		{
+			caseName:    "removed player",
+			inv:         testInv,
+			existing:    removed,
+			cardAmount:  1,
+			expectedErr: bingo.ErrPlayerRemoved,
+		},
+		{
+			caseName:   "top up waitlisted player",
+			inv:        testInv,
+			existing:   waitlisted,
+			cardAmount: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *tc.inv))
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+			mocks.playerRepo.ExpectGetByEmail(func(_ context.Context, invId string, _ string) (*bingo.Player, error) {
+				require.Equal(t, tc.inv.ID, invId, "player must be looked up on the invitation")
+				return MustCopyPlayer(t, tc.existing), nil
+			})
+			var reserved int
+			if tc.expectTopUp {
+				mocks.invRepo.ExpectReserveCards(func(_ context.Context, _ *bingo.Invitation, cardAmount int) error {
+					reserved = cardAmount
+					return nil
+				})
+				mocks.playerRepo.ExpectSave(func(_ context.Context, _ *bingo.Player) error { return nil })
+				mocks.cardRepo.ExpectSaveAll(func(_ context.Context, cards []bingo.Card) error {
+					require.Len(t, cards, tc.cardAmount, "only the cards topped up must be generated")
+					return nil
+				})
+				mocks.gameRepo.ExpectAllocateCardNumbers(MakeCardNumbersAllocateHandler(t, *testGame))
+			}
+			if tc.existing.Status == bingo.PlayerStatusWaitlisted {
+				mocks.playerRepo.ExpectSave(func(_ context.Context, _ *bingo.Player) error { return nil })
+			}
+			var queued *bingo.OutboxMessage
+			if tc.expectMail {
+				mocks.outboxRepo.ExpectSave(func(_ context.Context, msg *bingo.OutboxMessage) error {
+					queued = msg
+					return nil
+				})
+			}
+
+			p, err := invSvc.Join(context.Background(), tc.inv.ID, "Player Name", " PLAYER@test.com", tc.cardAmount, "")
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, tc.existing.ID, p.ID, "existing player must be topped up")
+			if tc.existing.Status == bingo.PlayerStatusWaitlisted {
+				require.Equal(t, tc.existing.RequestedCards+tc.cardAmount, p.RequestedCards, "player must wait for the cards topped up")
+				return
+			}
+			require.Equal(t, tc.cardAmount, reserved, "room for the cards topped up must be reserved")
+			require.Len(t, p.Cards, tc.expectedCards, "player must have the cards topped up")
+			if tc.expectMail {
+				require.Equal(t, p.ID, queued.PlayerID, "cards of the player must be queued for delivery again")
+			}
+		})
+	}
+}
+
+type invitationServiceMocks struct {
+	invRepo    *mock.InvitationRepository
+	playerRepo *mock.PlayerRepository
+	gameRepo   *mock.GameRepository
+	cardRepo   *mock.CardRespository
+	orgRepo    *mock.OrganizationRepository
+	userRepo   *mock.UserRepository
+	creditRepo *mock.CreditRepository
+	outboxRepo *mock.OutboxRepository
+}
+
+func MustCreateInvitationService(tb testing.TB) (*bingo.InvitationService, *invitationServiceMocks) {
+	tb.Helper()
+
+	invRepo := mock.NewInvitationRepository(tb)
+	playerRepo := mock.NewPlayerRepository(tb)
+	gameRepo := mock.NewGameRepository(tb)
+	cardRepo := mock.NewCardRepository(tb)
+	orgRepo := mock.NewOrganizationRepository(tb)
+	userRepo := mock.NewUserRepository(tb)
+	creditRepo := mock.NewCreditRepository(tb)
+	outboxRepo := mock.NewOutboxRepository(tb)
+
+	invSvc := bingo.NewInvitationService(bingo.Repositories{
+		Users:         userRepo,
+		Organizations: orgRepo,
+		Games:         gameRepo,
+		Cards:         cardRepo,
+		Invitations:   invRepo,
+		Players:       playerRepo,
+		Credits:       creditRepo,
+		Outbox:        outboxRepo,
+	}, mock.ConfirmationTokenSigner{}, mock.NewTransactor(tb))
+	mocks := &invitationServiceMocks{
+		invRepo:    invRepo,
+		playerRepo: playerRepo,
+		gameRepo:   gameRepo,
+		cardRepo:   cardRepo,
+		orgRepo:    orgRepo,
+		userRepo:   userRepo,
+		creditRepo: creditRepo,
+		outboxRepo: outboxRepo,
+	}
+
+	return invSvc, mocks
+}
+
+func MakeSingleInvitationGetHandler(tb testing.TB, inv bingo.Invitation) mock.InvitationGetHandler {
+	tb.Helper()
+
+	return func(_ context.Context, id string) (*bingo.Invitation, error) {
+		if id != inv.ID {
+			return nil, bingo.ErrInvitationNotFound
+		}
+
+		return &inv, nil
+	}
+}
+
+func MustCopyInvitation(tb testing.TB, inv *bingo.Invitation) *bingo.Invitation {
+	tb.Helper()
+
+	cp := *inv
+	return &cp
+}
+
+func MustMakeTestInvitation(tb testing.TB, g *bingo.Game) *bingo.Invitation {
+	tb.Helper()
+
+	return &bingo.Invitation{
+		ID:             requiretest.UUIDv4(tb),
+		DeliveryMethod: bingo.InvitationDeliveryMethodDownload,
+		MaxCardAmount:  3,
+		Active:         true,
+		GameID:         g.ID,
+	}
+}
@@ -0,0 +1,117 @@
+package bingo
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrJoinCodeTaken            = errors.New("bingo: join code is already taken by another invitation")
+	ErrJoinCodeGenerationFailed = errors.New("bingo: join code could not be generated")
+)
+
+const (
+	// Length of join codes. 32^8 codes leave plenty of room before a random one collides
+	JoinCodeLength = 8
+
+	// Attempts of generating a join code not already taken, before giving up
+	joinCodeMaxAttempts = 5
+)
+
+// Crockford's base32 alphabet, leaving out letters easily mistaken for digits, and U so codes do not spell words
+const joinCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// Get invitation by its join code, as typed in or scanned by players. Codes are matched leniently, see NormalizeJoinCode.
+func (is *InvitationService) GetByJoinCode(ctx context.Context, code string) (*Invitation, error) {
+	code = NormalizeJoinCode(code)
+	if len(code) != JoinCodeLength {
+		return nil, ErrInvitationNotFound
+	}
+
+	return is.invRepo.GetByJoinCode(ctx, code)
+}
+
+// Make sure the invitation has a join code, and return it. Invitations created before join codes existed are given one
+// the first time it is asked for. Only members allowed to manage the game can hand out join codes.
+func (is *InvitationService) EnsureJoinCode(ctx context.Context, invId string) (*Invitation, error) {
+	inv, err := is.invRepo.Get(ctx, invId)
+	if err != nil {
+		return nil, err
+	}
+	if err := is.authorize(ctx, inv, PermissionManageGame); err != nil {
+		return nil, err
+	}
+	if inv.JoinCode != "" {
+		return inv, nil
+	}
+
+	if err := is.saveWithJoinCode(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// Persist the invitation with a new random join code. Codes already taken are retried with another one
+func (is *InvitationService) saveWithJoinCode(ctx context.Context, inv *Invitation) error {
+	for i := 0; i < joinCodeMaxAttempts; i++ {
+		code, err := NewJoinCode()
+		if err != nil {
+			return err
+		}
+		inv.JoinCode = code
+
+		err = is.invRepo.Save(ctx, inv)
+		if !errors.Is(err, ErrJoinCodeTaken) {
+			return err
+		}
+	}
+
+	return ErrJoinCodeGenerationFailed
+}
+
+// Generate a random join code
+func NewJoinCode() (string, error) {
+	b := make([]byte, JoinCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", ErrJoinCodeGenerationFailed
+	}
+
+	// The alphabet has 32 symbols, so the low 5 bits of each byte pick one without bias
+	code := make([]byte, JoinCodeLength)
+	for i, v := range b {
+		code[i] = joinCodeAlphabet[v&31]
+	}
+
+	return string(code), nil
+}
+
+// Normalize a join code as typed by a player. Case, spaces and dashes are ignored, and letters mistaken for digits are
+// read as the digits, e.g. O as 0 and I or L as 1
+func NormalizeJoinCode(code string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		switch r {
+		case ' ', '-':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// Format the join code for reading aloud or printing, as two groups of four, e.g. 7K3M-9QXD
+func FormatJoinCode(code string) string {
+	if len(code) != JoinCodeLength {
+		return code
+	}
+
+	return code[:JoinCodeLength/2] + "-" + code[JoinCodeLength/2:]
+}
@@ -0,0 +1,190 @@
+package bingo_test
+
+import (
+	"context"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeJoinCode(t *testing.T) {
+	cases := []struct {
+		caseName string
+		code     string
+		expected string
+	}{
+		{caseName: "normalized", code: "7K3M9QXD", expected: "7K3M9QXD"},
+		{caseName: "lower case", code: "7k3m9qxd", expected: "7K3M9QXD"},
+		{caseName: "formatted", code: "7K3M-9QXD", expected: "7K3M9QXD"},
+		{caseName: "spaced", code: " 7K3M 9QXD ", expected: "7K3M9QXD"},
+		{caseName: "letters mistaken for digits", code: "O0Il-1L00", expected: "00111100"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			require.Equal(t, tc.expected, bingo.NormalizeJoinCode(tc.code), "code must be normalized")
+		})
+	}
+}
+
+func TestNewJoinCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code, err := bingo.NewJoinCode()
+		require.NoError(t, err, "no error is expected")
+		require.Len(t, code, bingo.JoinCodeLength, "code must be of the join code length")
+		require.Equal(t, code, bingo.NormalizeJoinCode(code), "generated codes must be normalized")
+		require.False(t, seen[code], "codes must be random")
+		seen[code] = true
+	}
+}
+
+func TestInvitationService_GetByJoinCode(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.JoinCode = "7K3M9QXD"
+
+	cases := []struct {
+		caseName     string
+		code         string
+		expectLookup bool
+		expectedErr  error
+	}{
+		{
+			caseName:     "success",
+			code:         "7K3M9QXD",
+			expectLookup: true,
+		},
+		{
+			caseName:     "as typed",
+			code:         "7k3m-9qxd",
+			expectLookup: true,
+		},
+		{
+			caseName:     "unknown",
+			code:         "7K3M9QXE",
+			expectLookup: true,
+			expectedErr:  bingo.ErrInvitationNotFound,
+		},
+		{
+			caseName:    "too short to be a code",
+			code:        "7K3M",
+			expectedErr: bingo.ErrInvitationNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+
+			if tc.expectLookup {
+				mocks.invRepo.ExpectGetByJoinCode(func(_ context.Context, code string) (*bingo.Invitation, error) {
+					if code != testInv.JoinCode {
+						return nil, bingo.ErrInvitationNotFound
+					}
+					return MustCopyInvitation(t, testInv), nil
+				})
+			}
+
+			inv, err := invSvc.GetByJoinCode(context.Background(), tc.code)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, inv, "invitation must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, testInv.ID, inv.ID, "invitation of the code must be returned")
+		})
+	}
+}
+
+func TestInvitationService_EnsureJoinCode(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	withCode := MustMakeTestInvitation(t, testGame)
+	withCode.JoinCode = "7K3M9QXD"
+	withoutCode := MustMakeTestInvitation(t, testGame)
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		inv         *bingo.Invitation
+		takenSaves  int
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName: "keeps existing code",
+			actorId:  testGame.HostId,
+			inv:      withCode,
+		},
+		{
+			caseName:   "assigns missing code",
+			actorId:    testGame.HostId,
+			inv:        withoutCode,
+			expectSave: true,
+		},
+		{
+			caseName:   "retries taken code",
+			actorId:    testGame.HostId,
+			inv:        withoutCode,
+			takenSaves: 2,
+			expectSave: true,
+		},
+		{
+			caseName:    "gives up when codes keep being taken",
+			actorId:     testGame.HostId,
+			inv:         withoutCode,
+			takenSaves:  5,
+			expectedErr: bingo.ErrJoinCodeGenerationFailed,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			inv:         withoutCode,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *tc.inv))
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			tried := make(map[string]bool)
+			for i := 0; i < tc.takenSaves; i++ {
+				mocks.invRepo.ExpectSave(func(_ context.Context, inv *bingo.Invitation) error {
+					require.False(t, tried[inv.JoinCode], "a new code must be tried")
+					tried[inv.JoinCode] = true
+					return bingo.ErrJoinCodeTaken
+				})
+			}
+			if tc.expectSave {
+				mocks.invRepo.ExpectSave(func(_ context.Context, _ *bingo.Invitation) error { return nil })
+			}
+
+			inv, err := invSvc.EnsureJoinCode(NewActorContext(t, tc.actorId), tc.inv.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, inv, "invitation must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Len(t, inv.JoinCode, bingo.JoinCodeLength, "invitation must have a join code")
+			if tc.inv.JoinCode != "" {
+				require.Equal(t, tc.inv.JoinCode, inv.JoinCode, "existing code must be kept")
+			}
+			require.False(t, tried[inv.JoinCode], "code must not be one already taken")
+			require.Equal(t, testGame.ID, inv.Game.ID, "game must be set for rendering the invitation")
+		})
+	}
+}
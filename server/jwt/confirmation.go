@@ -0,0 +1,78 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"github.com/golang-jwt/jwt/v4"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+const confirmationAudience = "player-confirmation"
+
+type confirmationClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+}
+
+// Signs the tokens players confirm their email with as HS256 JWTs. The key is derived from the shared secret, so
+// confirmation tokens can never pass as access tokens, or the other way around.
+type ConfirmationSigner struct {
+	key []byte
+}
+
+// Sign the claims into a compact JWT string.
+func (s *ConfirmationSigner) Sign(claims bingo.ConfirmationClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, confirmationClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{confirmationAudience},
+			Subject:   claims.PlayerID,
+			IssuedAt:  jwt.NewNumericDate(claims.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+		},
+		Email: claims.Email,
+	})
+
+	return token.SignedString(s.key)
+}
+
+// Verify the signature, audience and expiry of the token. Returns domain error if the token is not valid.
+func (s *ConfirmationSigner) Verify(token string) (*bingo.ConfirmationClaims, error) {
+	var claims confirmationClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrUnexpectedSigningMethod
+		}
+
+		return s.key, nil
+	})
+	if err != nil {
+		return nil, bingo.ErrInvalidConfirmationToken
+	}
+	if !claims.VerifyIssuer(issuer, true) || !claims.VerifyAudience(confirmationAudience, true) || claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return nil, bingo.ErrInvalidConfirmationToken
+	}
+
+	return &bingo.ConfirmationClaims{
+		PlayerID:  claims.Subject,
+		Email:     claims.Email,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+func NewConfirmationSigner(secret string) *ConfirmationSigner {
+	return &ConfirmationSigner{
+		key: deriveKey(secret, confirmationAudience),
+	}
+}
+
+// Derive the key of tokens for the audience from the shared secret, so tokens of one audience never verify as
+// tokens of another
+func deriveKey(secret, audience string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(audience))
+
+	return mac.Sum(nil)
+}
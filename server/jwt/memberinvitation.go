@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+const memberInvitationAudience = "member-invitation"
+
+type memberInvitationClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+}
+
+// Signs the tokens members accept their invitations to games with as HS256 JWTs. The key is derived from the shared
+// secret like the one of confirmation tokens, so invitations never pass as other tokens.
+type MemberInvitationSigner struct {
+	key []byte
+}
+
+// Sign the claims into a compact JWT string.
+func (s *MemberInvitationSigner) Sign(claims bingo.MemberInvitationClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, memberInvitationClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{memberInvitationAudience},
+			Subject:   claims.GameID,
+			IssuedAt:  jwt.NewNumericDate(claims.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+		},
+		Email: claims.Email,
+	})
+
+	return token.SignedString(s.key)
+}
+
+// Verify the signature, audience and expiry of the token. Returns domain error if the token is not valid.
+func (s *MemberInvitationSigner) Verify(token string) (*bingo.MemberInvitationClaims, error) {
+	var claims memberInvitationClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrUnexpectedSigningMethod
+		}
+
+		return s.key, nil
+	})
+	if err != nil {
+		return nil, bingo.ErrInvalidMemberInvitation
+	}
+	if !claims.VerifyIssuer(issuer, true) || !claims.VerifyAudience(memberInvitationAudience, true) || claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return nil, bingo.ErrInvalidMemberInvitation
+	}
+
+	return &bingo.MemberInvitationClaims{
+		GameID:    claims.Subject,
+		Email:     claims.Email,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+func NewMemberInvitationSigner(secret string) *MemberInvitationSigner {
+	return &MemberInvitationSigner{
+		key: deriveKey(secret, memberInvitationAudience),
+	}
+}
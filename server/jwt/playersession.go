@@ -0,0 +1,57 @@
+package jwt
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+const playerSessionAudience = "player-session"
+
+// Signs the tokens of player sessions as HS256 JWTs. The key is derived from the shared secret like the one of
+// confirmation tokens, so player sessions never pass as user access tokens.
+type PlayerSessionSigner struct {
+	key []byte
+}
+
+// Sign the claims into a compact JWT string.
+func (s *PlayerSessionSigner) Sign(claims bingo.PlayerSessionClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Audience:  jwt.ClaimStrings{playerSessionAudience},
+		Subject:   claims.PlayerID,
+		IssuedAt:  jwt.NewNumericDate(claims.IssuedAt),
+		ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+	})
+
+	return token.SignedString(s.key)
+}
+
+// Verify the signature, audience and expiry of the token. Returns domain error if the token is not valid.
+func (s *PlayerSessionSigner) Verify(token string) (*bingo.PlayerSessionClaims, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrUnexpectedSigningMethod
+		}
+
+		return s.key, nil
+	})
+	if err != nil {
+		return nil, bingo.ErrInvalidPlayerSession
+	}
+	if !claims.VerifyIssuer(issuer, true) || !claims.VerifyAudience(playerSessionAudience, true) || claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return nil, bingo.ErrInvalidPlayerSession
+	}
+
+	return &bingo.PlayerSessionClaims{
+		PlayerID:  claims.Subject,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+func NewPlayerSessionSigner(secret string) *PlayerSessionSigner {
+	return &PlayerSessionSigner{
+		key: deriveKey(secret, playerSessionAudience),
+	}
+}
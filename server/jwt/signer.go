@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v4"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+const issuer = "bingo-box"
+
+var (
+	ErrUnexpectedSigningMethod = errors.New("jwt: unexpected signing method")
+)
+
+// Signs access tokens as HS256 JWTs with a shared secret.
+type Signer struct {
+	secret []byte
+}
+
+// Sign the claims into a compact JWT string.
+func (s *Signer) Sign(claims bingo.AccessTokenClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   claims.UserID,
+		IssuedAt:  jwt.NewNumericDate(claims.IssuedAt),
+		ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+	})
+
+	return token.SignedString(s.secret)
+}
+
+// Verify the signature and expiry of the token. Returns domain error if the token is not valid.
+func (s *Signer) Verify(token string) (*bingo.AccessTokenClaims, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrUnexpectedSigningMethod
+		}
+
+		return s.secret, nil
+	})
+	if err != nil {
+		return nil, bingo.ErrInvalidAccessToken
+	}
+	if !claims.VerifyIssuer(issuer, true) || claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return nil, bingo.ErrInvalidAccessToken
+	}
+
+	return &bingo.AccessTokenClaims{
+		UserID:    claims.Subject,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{
+		secret: []byte(secret),
+	}
+}
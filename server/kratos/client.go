@@ -0,0 +1,110 @@
+package kratos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Name of the cookie Kratos stores browser sessions in
+const SessionCookieName = "ory_kratos_session"
+
+// Client for the public Kratos API
+type Client struct {
+	publicURL string
+	http      *http.Client
+}
+
+type whoamiResponse struct {
+	ID       string `json:"id"`
+	Active   bool   `json:"active"`
+	Identity struct {
+		ID     string `json:"id"`
+		Traits struct {
+			Email string `json:"email"`
+			Name  struct {
+				First string `json:"first"`
+				Last  string `json:"last"`
+			} `json:"name"`
+			TOS bool `json:"tos"`
+		} `json:"traits"`
+		VerifiableAddresses []struct {
+			Value    string `json:"value"`
+			Verified bool   `json:"verified"`
+			Via      string `json:"via"`
+		} `json:"verifiable_addresses"`
+	} `json:"identity"`
+}
+
+// Check whether the email trait of the identity is one of its verified addresses
+func (r whoamiResponse) emailVerified() bool {
+	for _, a := range r.Identity.VerifiableAddresses {
+		if a.Via == "email" && a.Verified && strings.EqualFold(a.Value, r.Identity.Traits.Email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Verify the session by calling the Kratos whoami endpoint with either the session cookie or the session token,
+// and return the identity of the session. Returns domain error if the session is not valid.
+func (c *Client) Whoami(ctx context.Context, cookie, token string) (*bingo.Identity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.publicURL+"/sessions/whoami", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	if token != "" {
+		req.Header.Set("X-Session-Token", token)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, bingo.ErrInvalidSession
+	default:
+		return nil, fmt.Errorf("kratos: unexpected whoami response status %d", res.StatusCode)
+	}
+
+	var body whoamiResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if !body.Active || body.Identity.ID == "" {
+		return nil, bingo.ErrInvalidSession
+	}
+
+	traits := body.Identity.Traits
+	return &bingo.Identity{
+		ID:            body.Identity.ID,
+		Email:         traits.Email,
+		FirstName:     traits.Name.First,
+		LastName:      traits.Name.Last,
+		EmailVerified: body.emailVerified(),
+		TOSAccepted:   traits.TOS,
+	}, nil
+}
+
+func NewClient(publicURL string) *Client {
+	return &Client{
+		publicURL: strings.TrimRight(publicURL, "/"),
+		http: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
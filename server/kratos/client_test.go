@@ -0,0 +1,111 @@
+package kratos_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/kratos"
+	"github.com/stretchr/testify/require"
+)
+
+var implementsSessionVerifier bingo.SessionVerifier = &kratos.Client{}
+
+const (
+	validCookie   = kratos.SessionCookieName + "=valid"
+	validToken    = "valid-token"
+	inactiveToken = "inactive-token"
+)
+
+// Stub standing in for the public Kratos API
+func NewKratosStub(tb testing.TB) *httptest.Server {
+	tb.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sessions/whoami" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		active := true
+		verified := false
+		switch {
+		case r.Header.Get("Cookie") == validCookie:
+			verified = true
+		case r.Header.Get("X-Session-Token") == validToken:
+		case r.Header.Get("X-Session-Token") == inactiveToken:
+			active = false
+		default:
+			rw.WriteHeader(http.StatusUnauthorized)
+			rw.Write([]byte(`{"error":{"code":401,"status":"Unauthorized"}}`))
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		if active {
+			rw.Write([]byte(fmt.Sprintf(`{"id":"session-id","active":true,"identity":{"id":"identity-id","traits":{"email":"test@test.com","name":{"first":"Test","last":"Person"},"tos":true},"verifiable_addresses":[{"value":"Test@test.com","verified":%t,"via":"email"}]}}`, verified)))
+		} else {
+			rw.Write([]byte(`{"id":"session-id","active":false,"identity":{"id":"identity-id","traits":{"email":"test@test.com"}}}`))
+		}
+	}))
+	tb.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClient_Whoami(t *testing.T) {
+	stub := NewKratosStub(t)
+	client := kratos.NewClient(stub.URL + "/")
+
+	cases := []struct {
+		cn               string
+		cookie           string
+		token            string
+		expectedVerified bool
+		expectedErrIs    error
+	}{
+		{
+			cn:               "success cookie",
+			cookie:           validCookie,
+			expectedVerified: true,
+		},
+		{
+			cn:    "success token",
+			token: validToken,
+		},
+		{
+			cn:            "fail unauthorized",
+			token:         "unknown-token",
+			expectedErrIs: bingo.ErrInvalidSession,
+		},
+		{
+			cn:            "fail inactive session",
+			token:         inactiveToken,
+			expectedErrIs: bingo.ErrInvalidSession,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			id, err := client.Whoami(context.Background(), c.cookie, c.token)
+			if c.expectedErrIs != nil {
+				require.ErrorIs(t, err, c.expectedErrIs, "expected different error")
+				require.Nil(t, id, "expected no identity")
+				return
+			}
+
+			require.NoError(t, err, "expected no error")
+			require.Equal(t, &bingo.Identity{
+				ID:            "identity-id",
+				Email:         "test@test.com",
+				FirstName:     "Test",
+				LastName:      "Person",
+				EmailVerified: c.expectedVerified,
+				TOSAccepted:   true,
+			}, id, "expected identity mapped from session")
+		})
+	}
+}
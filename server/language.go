@@ -0,0 +1,25 @@
+package bingo
+
+// Language mails and other texts are written in, as an ISO 639-1 code
+type Language string
+
+const (
+	LanguageDanish  Language = "da"
+	LanguageEnglish Language = "en"
+
+	// Language used when neither the player nor the game has one
+	DefaultLanguage = LanguageEnglish
+)
+
+// Languages texts are translated to
+var Languages = []Language{LanguageDanish, LanguageEnglish}
+
+func (l Language) Valid() bool {
+	for _, lang := range Languages {
+		if l == lang {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Mailer writing mails as .eml files to a directory instead of sending them. Meant for development, where the files
+// can be opened by any mail client
+type File struct {
+	Dir  string
+	From string
+
+	// Disambiguates mails written within the same clock tick
+	mu  sync.Mutex
+	seq int
+}
+
+func (f *File) Send(_ context.Context, m *bingo.Mail) error {
+	now := time.Now()
+	msg, err := compose(m, f.From, now)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.seq++
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405.000000000"), f.seq)
+	f.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(f.Dir, name), msg, 0o644)
+}
+
+func NewFile(dir string) *File {
+	return &File{
+		Dir:  dir,
+		From: DefaultFrom,
+	}
+}
+
+// Mailer keeping mails in memory instead of sending them. Meant for tests asserting on what was sent
+type Memory struct {
+	mu   sync.Mutex
+	sent []bingo.Mail
+}
+
+func (mm *Memory) Send(_ context.Context, m *bingo.Mail) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	mm.sent = append(mm.sent, *m)
+	return nil
+}
+
+// Mails sent so far, oldest first
+func (mm *Memory) Sent() []bingo.Mail {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	sent := make([]bingo.Mail, len(mm.sent))
+	copy(sent, mm.sent)
+	return sent
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
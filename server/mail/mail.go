@@ -1,62 +1,133 @@
+// Package mail implements the mailer of the domain with different providers. Mailgun and SMTP are used for real
+// delivery, while mails are written to files or kept in memory during development and tests.
 package mail
 
 import (
-	"context"
+	"bytes"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"net/textproto"
+	"path/filepath"
+	"time"
 
-	"github.com/mailgun/mailgun-go/v4"
 	bingo "github.com/nohns/bingo-box/server"
 )
 
-type Mailer struct {
-	client *mailgun.MailgunImpl
+const (
+	// Sender used when no other is configured
+	DefaultFrom = "Bingo Box <info@bingobox.io>"
 
-	BaseDownloadLink string
-}
+	replyTo = "Bingo box support <contact@bingobox.io>"
 
-func (m *Mailer) SendCards(ctx context.Context, to *bingo.Player, cardsFile io.ReadCloser) error {
-	return m.sendCardsWithMailgun(ctx, to, cardsFile)
-}
+	// Max length of base64 lines in the body, as per RFC 2045
+	base64LineLen = 76
+)
+
+// Compose the mail as a MIME message. The plain text and html bodies are alternatives of the first part, followed by
+// the attachments
+func compose(m *bingo.Mail, from string, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	// Headers of the message itself
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Reply-To: %s\r\n", replyTo)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", w.Boundary())
 
-func (m *Mailer) sendCardsWithMailgun(ctx context.Context, to *bingo.Player, cardsFile io.ReadCloser) error {
-	dlLink := fmt.Sprintf("%s/player/%s/downloadCards", m.BaseDownloadLink, to.ID)
-	sender := "Bingo Box <info@bingobox.io>"
-	subject := "Your bingo cards are ready"
-	body := fmt.Sprintf(`
-	<html>
-		<body>
-			<h1>Here. Your cards are ready!</h1>
-			<p>
-				Print them out by opening the pdf file attached to this email or get them by clicking 
-				<a href="%s">here</a>
-			</p>
-			<p>
-				Best regards,<br/>
-				Bingo box
-			</p>
-		</body>
-	</html>
-	`, dlLink)
-	recipient := to.Email
-
-	msg := m.client.NewMessage(sender, subject, "", recipient)
-	msg.SetHtml(body)
-	msg.SetReplyTo("Bingo box support <contact@bingobox.io>")
-	msg.AddReaderAttachment("cards.pdf", cardsFile)
-
-	_, _, err := m.client.Send(ctx, msg)
+	// Bodies, ordered from least to most preferred as per RFC 2046
+	var alt bytes.Buffer
+	aw := multipart.NewWriter(&alt)
+	bodies := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	}
+	for _, b := range bodies {
+		if b.content == "" {
+			continue
+		}
+		part, err := aw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {b.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, []byte(b.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+	body, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": aw.Boundary()})},
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if _, err := body.Write(alt.Bytes()); err != nil {
+		return nil, err
 	}
 
-	return nil
+	for _, a := range m.Attachments {
+		contentType := mime.TypeByExtension(filepath.Ext(a.Filename))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, a.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
 }
 
-func NewMailer(domain, apiKey string) *Mailer {
-	mg := mailgun.NewMailgun(domain, apiKey)
+// Write the data base64 encoded, wrapped at the max line length
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := base64LineLen
+		if len(encoded) < n {
+			n = len(encoded)
+		}
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:n]); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+
+	return nil
+}
 
-	return &Mailer{
-		client: mg,
+// Bare address of the mailbox, e.g. the sender without its display name
+func addressOf(mailbox string) (string, error) {
+	addr, err := netmail.ParseAddress(mailbox)
+	if err != nil {
+		return "", err
 	}
+
+	return addr.Address, nil
 }
@@ -0,0 +1,229 @@
+package mail_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mail"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	implementsMailerMailgun bingo.Mailer = &mail.Mailgun{}
+	implementsMailerSMTP    bingo.Mailer = &mail.SMTP{}
+	implementsMailerFile    bingo.Mailer = &mail.File{}
+	implementsMailerMemory  bingo.Mailer = &mail.Memory{}
+)
+
+func MustMakeTestMail(tb testing.TB) *bingo.Mail {
+	tb.Helper()
+
+	return &bingo.Mail{
+		To:      "player@test.com",
+		Subject: "Your bingo cards are ready",
+		HTML:    "<h1>Here. Your cards are ready!</h1>",
+		Text:    "Here. Your cards are ready!",
+		Attachments: []bingo.MailAttachment{
+			{Filename: "cards.pdf", Content: []byte("%PDF-1.4")},
+		},
+	}
+}
+
+// Parse the MIME message and require it to carry the test mail
+func RequireMessageOf(t *testing.T, expected *bingo.Mail, raw io.Reader) {
+	t.Helper()
+
+	msg, err := netmail.ReadMessage(raw)
+	require.NoError(t, err, "message must be parseable")
+	require.Equal(t, expected.To, msg.Header.Get("To"), "message must be addressed to the recipient")
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err, "subject must be decodable")
+	require.Equal(t, expected.Subject, subject, "message must have the subject")
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err, "content type must be parseable")
+	require.Equal(t, "multipart/mixed", mediaType, "message must be multipart")
+
+	parts, contents := RequireParts(t, msg.Body, params["boundary"])
+	require.Len(t, parts, 1+len(expected.Attachments), "message must have the bodies and attachments as parts")
+
+	// Bodies are alternatives of the first part
+	mediaType, params, err = mime.ParseMediaType(parts[0].Header.Get("Content-Type"))
+	require.NoError(t, err, "content type of bodies must be parseable")
+	require.Equal(t, "multipart/alternative", mediaType, "bodies must be alternatives")
+	_, bodies := RequireParts(t, bytes.NewReader(contents[0]), params["boundary"])
+	require.Len(t, bodies, 2, "message must have a plain text and html body")
+	require.Equal(t, expected.Text, string(RequireBase64(t, bodies[0])), "first body must be the plain text")
+	require.Equal(t, expected.HTML, string(RequireBase64(t, bodies[1])), "last body must be the html")
+
+	for i, a := range expected.Attachments {
+		require.Equal(t, a.Filename, parts[i+1].FileName(), "attachment must have its filename")
+		require.Equal(t, a.Content, RequireBase64(t, contents[i+1]), "attachment must have its content")
+	}
+}
+
+// Read the parts of the multipart body along with their raw content
+func RequireParts(t *testing.T, body io.Reader, boundary string) ([]*multipart.Part, [][]byte) {
+	t.Helper()
+
+	r := multipart.NewReader(body, boundary)
+	parts := make([]*multipart.Part, 0)
+	contents := make([][]byte, 0)
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts, contents
+		}
+		require.NoError(t, err, "parts must be readable")
+		b, err := io.ReadAll(p)
+		require.NoError(t, err, "part must be readable")
+		parts = append(parts, p)
+		contents = append(contents, b)
+	}
+}
+
+func RequireBase64(t *testing.T, encoded []byte) []byte {
+	t.Helper()
+
+	b, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(encoded)))
+	require.NoError(t, err, "part must be base64 encoded")
+	return b
+}
+
+func TestFile_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mails")
+	m := mail.NewFile(dir)
+	testMail := MustMakeTestMail(t)
+
+	require.NoError(t, m.Send(context.Background(), testMail), "no error is expected")
+	require.NoError(t, m.Send(context.Background(), testMail), "no error is expected")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err, "directory must be created")
+	require.Len(t, entries, 2, "each mail must be written to its own file")
+
+	for _, e := range entries {
+		require.Equal(t, ".eml", filepath.Ext(e.Name()), "mails must be written as .eml files")
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		require.NoError(t, err, "mail file must be readable")
+		RequireMessageOf(t, testMail, f)
+		f.Close()
+	}
+}
+
+func TestMemory_Send(t *testing.T) {
+	m := mail.NewMemory()
+	testMail := MustMakeTestMail(t)
+
+	require.NoError(t, m.Send(context.Background(), testMail), "no error is expected")
+	require.Equal(t, []bingo.Mail{*testMail}, m.Sent(), "sent mails must be kept")
+}
+
+func TestSMTP_Send(t *testing.T) {
+	srv := NewSMTPStub(t)
+	host, port, err := net.SplitHostPort(srv.ln.Addr().String())
+	require.NoError(t, err, "address of stub must be splittable")
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err, "port of stub must be a number")
+
+	m := mail.NewSMTP(host, p, mail.TLSModeNone)
+	m.From = "Bingo Box <info@bingobox.test>"
+	testMail := MustMakeTestMail(t)
+
+	require.NoError(t, m.Send(context.Background(), testMail), "no error is expected")
+
+	received := <-srv.received
+	require.Equal(t, "info@bingobox.test", received.from, "envelope sender must be the bare address")
+	require.Equal(t, []string{testMail.To}, received.rcpts, "envelope recipient must be the recipient")
+	RequireMessageOf(t, testMail, strings.NewReader(received.data))
+}
+
+func TestSMTP_SendUnknownTLSMode(t *testing.T) {
+	m := mail.NewSMTP("127.0.0.1", 25, mail.TLSMode("ssl"))
+
+	err := m.Send(context.Background(), MustMakeTestMail(t))
+	require.ErrorIs(t, err, mail.ErrUnknownTLSMode, "error must be of expected error kind")
+}
+
+type smtpEnvelope struct {
+	from  string
+	rcpts []string
+	data  string
+}
+
+// Plain text SMTP server accepting a single mail
+type smtpStub struct {
+	ln       net.Listener
+	received chan smtpEnvelope
+}
+
+func NewSMTPStub(tb testing.TB) *smtpStub {
+	tb.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tb, err, "stub must be able to listen")
+	tb.Cleanup(func() { ln.Close() })
+
+	srv := &smtpStub{ln: ln, received: make(chan smtpEnvelope, 1)}
+	go srv.serve()
+
+	return srv
+}
+
+func (s *smtpStub) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 stub ESMTP")
+
+	var env smtpEnvelope
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 stub")
+		case "MAIL":
+			env.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			env.rcpts = append(env.rcpts, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			b, err := io.ReadAll(bufio.NewReader(tp.DotReader()))
+			if err != nil {
+				return
+			}
+			env.data = string(b)
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			s.received <- env
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
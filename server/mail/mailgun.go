@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/mailgun/mailgun-go/v4"
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Mailer sending mails through the Mailgun API
+type Mailgun struct {
+	client *mailgun.MailgunImpl
+
+	From string
+}
+
+func (m *Mailgun) Send(ctx context.Context, mail *bingo.Mail) error {
+	msg := m.client.NewMessage(m.From, mail.Subject, mail.Text, mail.To)
+	msg.SetHtml(mail.HTML)
+	msg.SetReplyTo(replyTo)
+	for _, a := range mail.Attachments {
+		msg.AddBufferAttachment(a.Filename, a.Content)
+	}
+
+	_, _, err := m.client.Send(ctx, msg)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func NewMailgun(domain, apiKey string) *Mailgun {
+	mg := mailgun.NewMailgun(domain, apiKey)
+
+	return &Mailgun{
+		client: mg,
+		From:   DefaultFrom,
+	}
+}
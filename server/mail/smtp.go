@@ -0,0 +1,152 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+var (
+	ErrUnknownTLSMode = errors.New("mail: unknown smtp tls mode")
+)
+
+// How the connection to the SMTP server is secured
+type TLSMode string
+
+const (
+	// Upgrade the plain connection with the STARTTLS command. Fails if the server does not support it
+	TLSModeSTARTTLS TLSMode = "starttls"
+
+	// Connect with TLS right away, usually on port 465
+	TLSModeImplicit TLSMode = "implicit"
+
+	// Send in plain text. Only meant for local SMTP sinks
+	TLSModeNone TLSMode = "none"
+)
+
+// Timeout connecting to the SMTP server, when the context has no deadline
+const smtpDialTimeout = 30 * time.Second
+
+// Mailer sending mails to an SMTP server
+type SMTP struct {
+	Host string
+	Port int
+
+	// Credentials for PLAIN auth. No auth is done if the username is empty
+	Username string
+	Password string
+
+	TLS TLSMode
+
+	// Accept any certificate the server presents, e.g. the self-signed one of a local SMTP sink
+	InsecureSkipVerify bool
+
+	From string
+}
+
+func (s *SMTP) Send(ctx context.Context, m *bingo.Mail) error {
+	msg, err := compose(m, s.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, err := addressOf(s.From)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(m.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// Connect to the server, securing the connection as configured
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	tlsConf := &tls.Config{
+		ServerName:         s.Host,
+		InsecureSkipVerify: s.InsecureSkipVerify,
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpDialTimeout)
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+
+	var conn net.Conn
+	var err error
+	switch s.TLS {
+	case TLSModeImplicit:
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConf}).DialContext(ctx, "tcp", addr)
+	case TLSModeSTARTTLS, TLSModeNone:
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTLSMode, s.TLS)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Abort the whole conversation with the server when the deadline is reached
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if s.TLS == TLSModeSTARTTLS {
+		if err := c.StartTLS(tlsConf); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func NewSMTP(host string, port int, tlsMode TLSMode) *SMTP {
+	return &SMTP{
+		Host: host,
+		Port: port,
+		TLS:  tlsMode,
+		From: DefaultFrom,
+	}
+}
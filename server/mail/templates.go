@@ -0,0 +1,121 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("mail: template could not be found")
+)
+
+// Templates of every mail, translated to each language. Each mail has an html template and a plain text template,
+// where the latter also defines the subject
+//
+//go:embed templates
+var templateFS embed.FS
+
+// Name of the template defining the subject in the plain text template
+const subjectTemplate = "subject"
+
+// Names of months in each language, january first
+var monthNames = map[bingo.Language][12]string{
+	bingo.LanguageDanish:  {"januar", "februar", "marts", "april", "maj", "juni", "juli", "august", "september", "oktober", "november", "december"},
+	bingo.LanguageEnglish: {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+}
+
+// Format the date as written in the language, e.g. "2. januar 2006" in danish
+func formatDate(lang bingo.Language, t time.Time) string {
+	month := monthNames[lang][t.Month()-1]
+	if lang == bingo.LanguageDanish {
+		return fmt.Sprintf("%d. %s %d", t.Day(), month, t.Year())
+	}
+
+	return fmt.Sprintf("%s %d, %d", month, t.Day(), t.Year())
+}
+
+type translatedTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// Renders mails from the templates embedded in the binary
+type Templates struct {
+	// Template of each mail, by language
+	templates map[bingo.Language]map[bingo.MailTemplate]translatedTemplate
+}
+
+func (t *Templates) RenderMail(tmpl bingo.MailTemplate, lang bingo.Language, data interface{}) (*bingo.Mail, error) {
+	tt, ok := t.templates[lang][tmpl]
+	if !ok {
+		lang = bingo.DefaultLanguage
+		if tt, ok = t.templates[lang][tmpl]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, tmpl)
+		}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tt.text.ExecuteTemplate(&subject, subjectTemplate, data); err != nil {
+		return nil, err
+	}
+	if err := tt.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := tt.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &bingo.Mail{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// Parse the embedded templates of every language
+func NewTemplates() (*Templates, error) {
+	t := &Templates{
+		templates: make(map[bingo.Language]map[bingo.MailTemplate]translatedTemplate),
+	}
+
+	for _, lang := range bingo.Languages {
+		lang := lang
+		funcs := map[string]interface{}{
+			"date": func(d time.Time) string { return formatDate(lang, d) },
+		}
+
+		entries, err := templateFS.ReadDir(path.Join("templates", string(lang)))
+		if err != nil {
+			return nil, err
+		}
+		t.templates[lang] = make(map[bingo.MailTemplate]translatedTemplate)
+		for _, e := range entries {
+			if path.Ext(e.Name()) != ".html" {
+				continue
+			}
+			name := strings.TrimSuffix(e.Name(), ".html")
+			base := path.Join("templates", string(lang), name)
+
+			html, err := htmltemplate.New(e.Name()).Funcs(funcs).ParseFS(templateFS, base+".html")
+			if err != nil {
+				return nil, err
+			}
+			text, err := texttemplate.New(name+".txt").Funcs(funcs).ParseFS(templateFS, base+".txt")
+			if err != nil {
+				return nil, err
+			}
+			t.templates[lang][bingo.MailTemplate(name)] = translatedTemplate{html: html, text: text}
+		}
+	}
+
+	return t, nil
+}
@@ -0,0 +1,151 @@
+package mail_test
+
+import (
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mail"
+	"github.com/stretchr/testify/require"
+)
+
+var implementsMailRenderer bingo.MailRenderer = &mail.Templates{}
+
+func TestTemplates_RenderMail(t *testing.T) {
+	templates, err := mail.NewTemplates()
+	require.NoError(t, err, "embedded templates must parse")
+
+	data := bingo.PlayerCardsMailData{
+		PlayerName:   "Jane <Doe>",
+		GameName:     "Christmas bingo",
+		Date:         time.Date(2021, time.December, 24, 18, 0, 0, 0, time.UTC),
+		DownloadLink: "https://bingobox.test/player/1/downloadCards",
+	}
+
+	cases := []struct {
+		caseName        string
+		lang            bingo.Language
+		expectedSubject string
+		expectedDate    string
+	}{
+		{
+			caseName:        "danish",
+			lang:            bingo.LanguageDanish,
+			expectedSubject: "Dine bingoplader til Christmas bingo er klar",
+			expectedDate:    "24. december 2021",
+		},
+		{
+			caseName:        "english",
+			lang:            bingo.LanguageEnglish,
+			expectedSubject: "Your bingo cards for Christmas bingo are ready",
+			expectedDate:    "December 24, 2021",
+		},
+		{
+			caseName:        "untranslated falls back to default",
+			lang:            bingo.Language("de"),
+			expectedSubject: "Your bingo cards for Christmas bingo are ready",
+			expectedDate:    "December 24, 2021",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			m, err := templates.RenderMail(bingo.MailTemplatePlayerCards, tc.lang, data)
+			require.NoError(t, err, "no error is expected")
+
+			require.Equal(t, tc.expectedSubject, m.Subject, "subject must be rendered in the language")
+			require.Contains(t, m.Text, tc.expectedDate, "date must be written in the language")
+			require.Contains(t, m.Text, data.DownloadLink, "plain text must have the download link")
+			require.Contains(t, m.Text, "Jane <Doe>", "plain text must not be escaped")
+			require.Contains(t, m.HTML, tc.expectedDate, "date must be written in the language")
+			require.Contains(t, m.HTML, `href="`+data.DownloadLink+`"`, "html must link to the download")
+			require.Contains(t, m.HTML, "Jane &lt;Doe&gt;", "html must be escaped")
+		})
+	}
+}
+
+func TestTemplates_RenderMailConfirmation(t *testing.T) {
+	templates, err := mail.NewTemplates()
+	require.NoError(t, err, "embedded templates must parse")
+
+	data := bingo.PlayerConfirmationMailData{
+		PlayerName:  "Jane Doe",
+		GameName:    "Christmas bingo",
+		ConfirmLink: "https://bingobox.test/join/confirm?token=abc",
+		ConfirmBy:   time.Date(2021, time.December, 24, 18, 0, 0, 0, time.UTC),
+	}
+
+	for _, lang := range bingo.Languages {
+		t.Run(string(lang), func(t *testing.T) {
+			m, err := templates.RenderMail(bingo.MailTemplatePlayerConfirmation, lang, data)
+			require.NoError(t, err, "no error is expected")
+
+			require.Contains(t, m.Subject, data.GameName, "subject must name the game")
+			require.Contains(t, m.Text, data.ConfirmLink, "plain text must have the confirmation link")
+			require.Contains(t, m.HTML, `href="`+data.ConfirmLink+`"`, "html must link to the confirmation")
+		})
+	}
+}
+
+func TestTemplates_RenderMailMagicLink(t *testing.T) {
+	templates, err := mail.NewTemplates()
+	require.NoError(t, err, "embedded templates must parse")
+
+	data := bingo.PlayerMagicLinkMailData{
+		PlayerName: "Jane Doe",
+		GameName:   "Christmas bingo",
+		Link:       "https://bingobox.test/player/session?token=abc",
+		ExpiresAt:  time.Date(2021, time.December, 24, 18, 0, 0, 0, time.UTC),
+	}
+
+	for _, lang := range bingo.Languages {
+		t.Run(string(lang), func(t *testing.T) {
+			m, err := templates.RenderMail(bingo.MailTemplatePlayerMagicLink, lang, data)
+			require.NoError(t, err, "no error is expected")
+
+			require.Contains(t, m.Subject, data.GameName, "subject must name the game")
+			require.Contains(t, m.Text, data.Link, "plain text must have the magic link")
+			require.Contains(t, m.HTML, `href="`+data.Link+`"`, "html must link to the session")
+		})
+	}
+}
+
+func TestTemplates_RenderMailMemberInvitation(t *testing.T) {
+	templates, err := mail.NewTemplates()
+	require.NoError(t, err, "embedded templates must parse")
+
+	data := bingo.MemberInvitationMailData{
+		GameName:   "Christmas bingo",
+		Role:       bingo.GameRoleCaller,
+		AcceptLink: "https://bingobox.test/games/1/members/accept?token=abc",
+		AcceptBy:   time.Date(2021, time.December, 24, 18, 0, 0, 0, time.UTC),
+	}
+
+	cases := []struct {
+		lang         bingo.Language
+		expectedRole string
+	}{
+		{bingo.LanguageDanish, "opråber"},
+		{bingo.LanguageEnglish, "caller"},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.lang), func(t *testing.T) {
+			m, err := templates.RenderMail(bingo.MailTemplateMemberInvitation, tc.lang, data)
+			require.NoError(t, err, "no error is expected")
+
+			require.Contains(t, m.Subject, data.GameName, "subject must name the game")
+			require.Contains(t, m.Text, tc.expectedRole, "plain text must name the role in the language")
+			require.Contains(t, m.Text, data.AcceptLink, "plain text must have the accept link")
+			require.Contains(t, m.HTML, `href="`+data.AcceptLink+`"`, "html must link to the acceptance")
+		})
+	}
+}
+
+func TestTemplates_RenderMailUnknown(t *testing.T) {
+	templates, err := mail.NewTemplates()
+	require.NoError(t, err, "embedded templates must parse")
+
+	_, err = templates.RenderMail(bingo.MailTemplate("unknown"), bingo.LanguageDanish, nil)
+	require.ErrorIs(t, err, mail.ErrTemplateNotFound, "error must be of expected error kind")
+}
@@ -0,0 +1,314 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrMemberNotFound      = errors.New("bingo: game member could not be found")
+	ErrMemberAlreadyExists = errors.New("bingo: game member already exists")
+	ErrMemberValidation    = NewValErr("bingo: game member validation failed")
+
+	ErrInvalidMemberInvitation = errors.New("bingo: member invitation token is invalid or expired")
+)
+
+// Time members invited to a game have to accept, by the link mailed to them
+const MemberInvitationLifetime = 7 * 24 * time.Hour
+
+// Signs and verifies the tokens of the links members accept their invitation with. Holding a token proves access to
+// the email it was mailed to.
+type MemberInvitationSigner interface {
+	Sign(claims MemberInvitationClaims) (string, error)
+	Verify(token string) (*MemberInvitationClaims, error)
+}
+
+type MemberInvitationClaims struct {
+	GameID string
+	Email  string
+
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Role of a user in a game. The owner is the host of the game, while the other roles are given to helpers.
+type GameRole string
+
+const (
+	GameRoleOwner   GameRole = "OWNER"
+	GameRoleCohost  GameRole = "COHOST"
+	GameRoleCaller  GameRole = "CALLER"
+	GameRoleChecker GameRole = "CHECKER"
+)
+
+// Action on a game which requires a role allowing it
+type Permission string
+
+const (
+	PermissionViewGame      Permission = "game:view"
+	PermissionManageGame    Permission = "game:manage"
+	PermissionManageMembers Permission = "members:manage"
+	PermissionCallNumbers   Permission = "numbers:call"
+	PermissionCheckCards    Permission = "cards:check"
+	PermissionViewPlayers   Permission = "players:view"
+)
+
+// Permissions granted by each role
+var rolePermissions = map[GameRole][]Permission{
+	GameRoleOwner: {
+		PermissionViewGame,
+		PermissionManageGame,
+		PermissionManageMembers,
+		PermissionCallNumbers,
+		PermissionCheckCards,
+		PermissionViewPlayers,
+	},
+	GameRoleCohost: {
+		PermissionViewGame,
+		PermissionManageGame,
+		PermissionCallNumbers,
+		PermissionCheckCards,
+		PermissionViewPlayers,
+	},
+	GameRoleCaller: {
+		PermissionViewGame,
+		PermissionCallNumbers,
+	},
+	GameRoleChecker: {
+		PermissionViewGame,
+		PermissionCheckCards,
+		PermissionViewPlayers,
+	},
+}
+
+// Reports whether the role grants the permission
+func (r GameRole) Can(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reports whether the role can be given to a member. The owner role belongs to the host only.
+func (r GameRole) Assignable() bool {
+	return r == GameRoleCohost || r == GameRoleCaller || r == GameRoleChecker
+}
+
+// Value object describing a helper of a game. Members are invited by email, and are linked to a user once the user
+// with the email accepts.
+type GameMember struct {
+	UserID string   `json:"userId,omitempty"`
+	Email  string   `json:"email"`
+	Role   GameRole `json:"role"`
+
+	InvitedAt  time.Time `json:"invitedAt"`
+	AcceptedAt time.Time `json:"acceptedAt"`
+}
+
+// Reports whether the invitation to become member has been accepted
+func (m GameMember) Accepted() bool {
+	return m.UserID != ""
+}
+
+func (m GameMember) Validate() error {
+	if m.Email == "" {
+		return ErrMemberValidation.withFieldErr("Email", "empty", "email has to have a value")
+	}
+	if !m.Role.Assignable() {
+		return ErrMemberValidation.withFieldErr("Role", "noMatch", "role %s can not be given to members. Available: %s, %s, %s", m.Role, GameRoleCohost, GameRoleCaller, GameRoleChecker)
+	}
+
+	return nil
+}
+
+// Invite a member to the game by email. The invitation is mailed to the member with a link to accept it by. The owner
+// is responsible for managing members.
+func (gs *GameService) InviteMember(ctx context.Context, gameId, email string, role GameRole) (*Game, error) {
+	g, err := gs.getAuthorized(ctx, gameId, PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.InviteMember(email, role); err != nil {
+		return nil, err
+	}
+	err = gs.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := gs.gameRepo.Save(ctx, g); err != nil {
+			return err
+		}
+
+		return gs.outboxRepo.Save(ctx, NewMemberInvitationMessage(g, NormalizeEmail(email)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Accept the invitation to become member of the game, by the token of the link mailed to the member. The actor becomes
+// the member invited, as holding the token proves access to the email invited.
+func (gs *GameService) AcceptMembership(ctx context.Context, gameId, token string) (*Game, error) {
+	actor := UserFromContext(ctx)
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+
+	claims, err := gs.invitations.Verify(token)
+	if err != nil {
+		return nil, ErrInvalidMemberInvitation
+	}
+	if claims.GameID != gameId || claims.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidMemberInvitation
+	}
+
+	g, err := gs.gameRepo.Get(ctx, gameId)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.AcceptMember(claims.Email, actor); err != nil {
+		return nil, err
+	}
+	if err := gs.gameRepo.Save(ctx, g); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Change the role of the member with the email.
+func (gs *GameService) ChangeMemberRole(ctx context.Context, gameId, email string, role GameRole) (*Game, error) {
+	g, err := gs.getAuthorized(ctx, gameId, PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.ChangeMemberRole(email, role); err != nil {
+		return nil, err
+	}
+	if err := gs.gameRepo.Save(ctx, g); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Remove the member with the email from the game, whether the invitation was accepted or not.
+func (gs *GameService) RemoveMember(ctx context.Context, gameId, email string) (*Game, error) {
+	g, err := gs.getAuthorized(ctx, gameId, PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.RemoveMember(email); err != nil {
+		return nil, err
+	}
+	if err := gs.gameRepo.Save(ctx, g); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Role of the user in the game. Ok is false if the user has no role in the game
+func (g *Game) RoleOf(userId string) (role GameRole, ok bool) {
+	if userId == "" {
+		return "", false
+	}
+	if g.HostId == userId {
+		return GameRoleOwner, true
+	}
+	for _, m := range g.Members {
+		if m.UserID == userId {
+			return m.Role, true
+		}
+	}
+
+	return "", false
+}
+
+// Add pending member to the game
+func (g *Game) InviteMember(email string, role GameRole) error {
+	m := GameMember{
+		Email:     NormalizeEmail(email),
+		Role:      role,
+		InvitedAt: time.Now(),
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if _, ok := g.memberIndex(m.Email); ok {
+		return ErrMemberAlreadyExists
+	}
+
+	g.Members = append(g.Members, m)
+	return nil
+}
+
+// Link the user to the pending member with the email
+func (g *Game) AcceptMember(email string, u *User) error {
+	i, ok := g.memberIndex(email)
+	if !ok {
+		return ErrMemberNotFound
+	}
+
+	// Accepting twice does nothing
+	if g.Members[i].Accepted() {
+		return nil
+	}
+
+	g.Members[i].UserID = u.ID
+	g.Members[i].AcceptedAt = time.Now()
+	return nil
+}
+
+func (g *Game) ChangeMemberRole(email string, role GameRole) error {
+	i, ok := g.memberIndex(email)
+	if !ok {
+		return ErrMemberNotFound
+	}
+
+	m := g.Members[i]
+	m.Role = role
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	g.Members[i] = m
+	return nil
+}
+
+func (g *Game) RemoveMember(email string) error {
+	i, ok := g.memberIndex(email)
+	if !ok {
+		return ErrMemberNotFound
+	}
+
+	g.Members = append(g.Members[:i], g.Members[i+1:]...)
+	return nil
+}
+
+// Member with the email. Ok is false if no member has the email
+func (g *Game) Member(email string) (m GameMember, ok bool) {
+	i, ok := g.memberIndex(email)
+	if !ok {
+		return GameMember{}, false
+	}
+
+	return g.Members[i], true
+}
+
+func (g *Game) memberIndex(email string) (int, bool) {
+	email = NormalizeEmail(email)
+	for i, m := range g.Members {
+		if m.Email == email {
+			return i, true
+		}
+	}
+
+	return -1, false
+}
@@ -0,0 +1,472 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameRole_Can(t *testing.T) {
+
+	cases := []struct {
+		role     bingo.GameRole
+		perm     bingo.Permission
+		expected bool
+	}{
+		{bingo.GameRoleOwner, bingo.PermissionManageMembers, true},
+		{bingo.GameRoleCohost, bingo.PermissionManageMembers, false},
+		{bingo.GameRoleCohost, bingo.PermissionManageGame, true},
+		{bingo.GameRoleCaller, bingo.PermissionCallNumbers, true},
+		{bingo.GameRoleCaller, bingo.PermissionCheckCards, false},
+		{bingo.GameRoleChecker, bingo.PermissionCheckCards, true},
+		{bingo.GameRoleChecker, bingo.PermissionCallNumbers, false},
+		{bingo.GameRole("UNKNOWN"), bingo.PermissionViewGame, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.role)+" "+string(tc.perm), func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.role.Can(tc.perm), "unexpected permission of role")
+		})
+	}
+}
+
+func TestGameService_InviteMember(t *testing.T) {
+
+	testGame := MustMakeTestGameWithMembers(t)
+	cohost := testGame.Members[0]
+
+	cases := []struct {
+		caseName     string
+		actorId      string
+		email        string
+		role         bingo.GameRole
+		expectSave   bool
+		expectValErr bool
+		expectedErr  error
+	}{
+		{
+			caseName:   "success",
+			actorId:    testGame.HostId,
+			email:      " New@Test.com",
+			role:       bingo.GameRoleChecker,
+			expectSave: true,
+		},
+		{
+			caseName:    "already member",
+			actorId:     testGame.HostId,
+			email:       cohost.Email,
+			role:        bingo.GameRoleCaller,
+			expectedErr: bingo.ErrMemberAlreadyExists,
+		},
+		{
+			caseName:     "owner role not assignable",
+			actorId:      testGame.HostId,
+			email:        "new@test.com",
+			role:         bingo.GameRoleOwner,
+			expectValErr: true,
+		},
+		{
+			caseName:    "forbidden cohost",
+			actorId:     cohost.UserID,
+			email:       "new@test.com",
+			role:        bingo.GameRoleCaller,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+			var queued *bingo.OutboxMessage
+			if tc.expectSave {
+				mocks.gameRepo.ExpectSave(MakeGameSaveHandler(t))
+				mocks.outboxRepo.ExpectSave(func(_ context.Context, msg *bingo.OutboxMessage) error {
+					queued = msg
+					return nil
+				})
+			}
+
+			g, err := gameSvc.InviteMember(NewActorContext(t, tc.actorId), testGame.ID, tc.email, tc.role)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, g, "game must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			m := g.Members[len(g.Members)-1]
+			require.Equal(t, "new@test.com", m.Email, "member email must be normalized")
+			require.False(t, m.Accepted(), "invited member must be pending")
+			require.Equal(t, bingo.MessageKindMemberInvitation, queued.Kind, "invitation must be queued for mailing")
+			require.Equal(t, testGame.ID, queued.GameID, "invitation must be for the game")
+			require.Equal(t, "new@test.com", queued.Email, "invitation must be mailed to the member")
+		})
+	}
+}
+
+func TestGameService_AcceptMembership(t *testing.T) {
+
+	testGame := MustMakeTestGameWithMembers(t)
+	pending := testGame.Members[1]
+	actor := &bingo.User{ID: requiretest.UUIDv4(t), Email: "other@test.com"}
+	signer := mock.MemberInvitationSigner{}
+	validUntil := time.Now().Add(time.Hour)
+
+	cases := []struct {
+		caseName    string
+		ctx         context.Context
+		claims      bingo.MemberInvitationClaims
+		expectGet   bool
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName:   "success",
+			ctx:        bingo.NewContextWithUser(context.Background(), actor),
+			claims:     bingo.MemberInvitationClaims{GameID: testGame.ID, Email: pending.Email, ExpiresAt: validUntil},
+			expectGet:  true,
+			expectSave: true,
+		},
+		{
+			caseName:    "not invited",
+			ctx:         bingo.NewContextWithUser(context.Background(), actor),
+			claims:      bingo.MemberInvitationClaims{GameID: testGame.ID, Email: "stranger@test.com", ExpiresAt: validUntil},
+			expectGet:   true,
+			expectedErr: bingo.ErrMemberNotFound,
+		},
+		{
+			caseName:    "expired",
+			ctx:         bingo.NewContextWithUser(context.Background(), actor),
+			claims:      bingo.MemberInvitationClaims{GameID: testGame.ID, Email: pending.Email, ExpiresAt: time.Now().Add(-time.Hour)},
+			expectedErr: bingo.ErrInvalidMemberInvitation,
+		},
+		{
+			caseName:    "other game",
+			ctx:         bingo.NewContextWithUser(context.Background(), actor),
+			claims:      bingo.MemberInvitationClaims{GameID: requiretest.UUIDv4(t), Email: pending.Email, ExpiresAt: validUntil},
+			expectedErr: bingo.ErrInvalidMemberInvitation,
+		},
+		{
+			caseName:    "no actor",
+			ctx:         context.Background(),
+			claims:      bingo.MemberInvitationClaims{GameID: testGame.ID, Email: pending.Email, ExpiresAt: validUntil},
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			if tc.expectGet {
+				mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+			}
+			if tc.expectSave {
+				mocks.gameRepo.ExpectSave(MakeGameSaveHandler(t))
+			}
+
+			token, err := signer.Sign(tc.claims)
+			require.NoError(t, err, "no error is expected when signing token")
+
+			g, err := gameSvc.AcceptMembership(tc.ctx, testGame.ID, token)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			role, ok := g.RoleOf(actor.ID)
+			require.True(t, ok, "actor must be member after accepting")
+			require.Equal(t, pending.Role, role, "actor must have the role invited to")
+		})
+	}
+
+	t.Run("invalid token", func(t *testing.T) {
+		gameSvc, mocks := MustCreateGameService(t)
+		defer mocks.gameRepo.RequireExpectationsMet()
+
+		_, err := gameSvc.AcceptMembership(bingo.NewContextWithUser(context.Background(), actor), testGame.ID, "garbage")
+		require.ErrorIs(t, err, bingo.ErrInvalidMemberInvitation, "error must be of expected error kind")
+	})
+}
+
+func TestGameService_ChangeMemberRole(t *testing.T) {
+
+	testGame := MustMakeTestGameWithMembers(t)
+	cohost := testGame.Members[0]
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		email       string
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName:   "success",
+			actorId:    testGame.HostId,
+			email:      cohost.Email,
+			expectSave: true,
+		},
+		{
+			caseName:    "member not found",
+			actorId:     testGame.HostId,
+			email:       "stranger@test.com",
+			expectedErr: bingo.ErrMemberNotFound,
+		},
+		{
+			caseName:    "forbidden cohost",
+			actorId:     cohost.UserID,
+			email:       cohost.Email,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+			if tc.expectSave {
+				mocks.gameRepo.ExpectSave(MakeGameSaveHandler(t))
+			}
+
+			g, err := gameSvc.ChangeMemberRole(NewActorContext(t, tc.actorId), testGame.ID, tc.email, bingo.GameRoleCaller)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			role, _ := g.RoleOf(cohost.UserID)
+			require.Equal(t, bingo.GameRoleCaller, role, "member must have the new role")
+		})
+	}
+}
+
+func TestGameService_RemoveMember(t *testing.T) {
+
+	testGame := MustMakeTestGameWithMembers(t)
+	cohost := testGame.Members[0]
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		email       string
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName:   "success",
+			actorId:    testGame.HostId,
+			email:      cohost.Email,
+			expectSave: true,
+		},
+		{
+			caseName:    "member not found",
+			actorId:     testGame.HostId,
+			email:       "stranger@test.com",
+			expectedErr: bingo.ErrMemberNotFound,
+		},
+		{
+			caseName:    "forbidden stranger",
+			actorId:     requiretest.UUIDv4(t),
+			email:       cohost.Email,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+			if tc.expectSave {
+				mocks.gameRepo.ExpectSave(MakeGameSaveHandler(t))
+			}
+
+			g, err := gameSvc.RemoveMember(NewActorContext(t, tc.actorId), testGame.ID, tc.email)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			_, ok := g.RoleOf(cohost.UserID)
+			require.False(t, ok, "removed member must have no role")
+		})
+	}
+}
+
+// Members are only allowed the actions their role permits
+func TestGameService_MemberRoles(t *testing.T) {
+
+	testGame := MustMakeTestGameWithMembers(t)
+	caller := bingo.GameMember{
+		UserID:     requiretest.UUIDv4(t),
+		Email:      "caller@test.com",
+		Role:       bingo.GameRoleCaller,
+		InvitedAt:  time.Now(),
+		AcceptedAt: time.Now(),
+	}
+	testGame.Members = append(testGame.Members, caller)
+
+	t.Run("caller can call numbers", func(t *testing.T) {
+		gameSvc, mocks := MustCreateGameService(t)
+		defer mocks.gameRepo.RequireExpectationsMet()
+
+		mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+		mocks.gameRepo.ExpectSave(MakeGameSaveHandler(t))
+
+		_, err := gameSvc.CallNumber(NewActorContext(t, caller.UserID), testGame.ID, 1)
+		require.NoError(t, err, "caller must be allowed to call numbers")
+	})
+
+	t.Run("caller can not generate cards", func(t *testing.T) {
+		gameSvc, mocks := MustCreateGameService(t)
+		defer mocks.gameRepo.RequireExpectationsMet()
+
+		mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+
+		_, err := gameSvc.GenerateCards(NewActorContext(t, caller.UserID), testGame.ID, 1)
+		require.ErrorIs(t, err, bingo.ErrForbidden, "caller must not be allowed to generate cards")
+	})
+
+	t.Run("pending member has no role", func(t *testing.T) {
+		gameSvc, mocks := MustCreateGameService(t)
+		defer mocks.gameRepo.RequireExpectationsMet()
+
+		mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+
+		_, err := gameSvc.Get(NewActorContext(t, ""), testGame.ID)
+		require.ErrorIs(t, err, bingo.ErrForbidden, "pending members must not be allowed to view game")
+	})
+}
+
+func TestOutboxService_DeliverMemberInvitation(t *testing.T) {
+
+	testGame := MustMakeTestGameWithMembers(t)
+	cohost := testGame.Members[0]
+	pending := testGame.Members[1]
+
+	cases := []struct {
+		caseName   string
+		email      string
+		expectMail bool
+	}{
+		{
+			caseName:   "sent",
+			email:      pending.Email,
+			expectMail: true,
+		},
+		{
+			caseName: "accepted since queued",
+			email:    cohost.Email,
+		},
+		{
+			caseName: "removed since queued",
+			email:    "removed@test.com",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			outboxSvc, mocks := MustCreateOutboxService(t)
+			defer mocks.outboxRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.templates.RequireExpectationsMet()
+			defer mocks.mailer.RequireExpectationsMet()
+
+			msg := bingo.NewMemberInvitationMessage(testGame, tc.email)
+			msg.ID = requiretest.UUIDv4(t)
+
+			claimed := false
+			claimHandler := func(_ context.Context, _ time.Time, _ time.Duration) (*bingo.OutboxMessage, error) {
+				if claimed {
+					return nil, bingo.ErrOutboxEmpty
+				}
+				claimed = true
+				return msg, nil
+			}
+			mocks.outboxRepo.ExpectClaimDue(claimHandler)
+			mocks.outboxRepo.ExpectClaimDue(claimHandler)
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+			if tc.expectMail {
+				var data bingo.MemberInvitationMailData
+				mocks.templates.ExpectRenderMail(func(tmpl bingo.MailTemplate, _ bingo.Language, d interface{}) (*bingo.Mail, error) {
+					require.Equal(t, bingo.MailTemplateMemberInvitation, tmpl, "member invitation template must be rendered")
+					data = d.(bingo.MemberInvitationMailData)
+					return &bingo.Mail{}, nil
+				})
+				mocks.mailer.ExpectSend(func(_ context.Context, m *bingo.Mail) error {
+					require.Equal(t, pending.Email, m.To, "invitation must be sent to the member")
+					require.Equal(t, pending.Role, data.Role, "invitation must name the role")
+					require.True(t, strings.HasPrefix(data.AcceptLink, testDownloadLinkBase+"/games/"+testGame.ID+"/members/accept?token="), "link must point to the accept page")
+					return nil
+				})
+			}
+			var savedMsg *bingo.OutboxMessage
+			mocks.outboxRepo.ExpectSave(func(_ context.Context, m *bingo.OutboxMessage) error {
+				savedMsg = m
+				return nil
+			})
+
+			_, err := outboxSvc.DeliverDue(context.Background())
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.OutboxStatusSent, savedMsg.Status, "message must be done, whether or not the link was needed")
+		})
+	}
+}
+
+// Make test game with an accepted co-host and a pending checker
+func MustMakeTestGameWithMembers(tb testing.TB) *bingo.Game {
+	tb.Helper()
+
+	g := MustMakeTestGame(tb)
+	g.Members = []bingo.GameMember{
+		{
+			UserID:     requiretest.UUIDv4(tb),
+			Email:      "cohost@test.com",
+			Role:       bingo.GameRoleCohost,
+			InvitedAt:  time.Now().Add(-time.Hour),
+			AcceptedAt: time.Now(),
+		},
+		{
+			Email:     "checker@test.com",
+			Role:      bingo.GameRoleChecker,
+			InvitedAt: time.Now().Add(-time.Hour),
+		},
+	}
+
+	return g
+}
+
+// Copy game, so members can be changed without affecting other test cases
+func MustCopyGame(tb testing.TB, g *bingo.Game) *bingo.Game {
+	tb.Helper()
+
+	cp := *g
+	cp.Members = append([]bingo.GameMember(nil), g.Members...)
+	cp.CalledNumbers = append([]bingo.Ball(nil), g.CalledNumbers...)
+
+	return &cp
+}
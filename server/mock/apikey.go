@@ -0,0 +1,103 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type APIKeySaveHandler func(ctx context.Context, k *bingo.APIKey) error
+type APIKeyGetHandler func(ctx context.Context, id string) (*bingo.APIKey, error)
+type APIKeyGetByHashHandler func(ctx context.Context, hash string) (*bingo.APIKey, error)
+type APIKeyListByUserHandler func(ctx context.Context, userId string) ([]bingo.APIKey, error)
+
+type APIKeyRepository struct {
+	tb testing.TB
+
+	saveVisited  int
+	saveExpected int
+	saveHandlers []APIKeySaveHandler
+
+	getVisited  int
+	getExpected int
+	getHandlers []APIKeyGetHandler
+
+	getByHashVisited  int
+	getByHashExpected int
+	getByHashHandlers []APIKeyGetByHashHandler
+
+	listByUserVisited  int
+	listByUserExpected int
+	listByUserHandlers []APIKeyListByUserHandler
+}
+
+func (ar *APIKeyRepository) ExpectSave(h APIKeySaveHandler) {
+	ar.saveHandlers = append(ar.saveHandlers, h)
+	ar.saveExpected++
+}
+
+func (ar *APIKeyRepository) ExpectGet(h APIKeyGetHandler) {
+	ar.getHandlers = append(ar.getHandlers, h)
+	ar.getExpected++
+}
+
+func (ar *APIKeyRepository) ExpectGetByHash(h APIKeyGetByHashHandler) {
+	ar.getByHashHandlers = append(ar.getByHashHandlers, h)
+	ar.getByHashExpected++
+}
+
+func (ar *APIKeyRepository) ExpectListByUser(h APIKeyListByUserHandler) {
+	ar.listByUserHandlers = append(ar.listByUserHandlers, h)
+	ar.listByUserExpected++
+}
+
+func (ar *APIKeyRepository) Save(ctx context.Context, k *bingo.APIKey) error {
+	require.Less(ar.tb, ar.saveVisited, ar.saveExpected, "mock(api_key_repository): Save() called more times than expected")
+	h := ar.saveHandlers[ar.saveVisited]
+	ar.saveVisited++
+
+	return h(ctx, k)
+}
+
+func (ar *APIKeyRepository) Get(ctx context.Context, id string) (*bingo.APIKey, error) {
+	require.Less(ar.tb, ar.getVisited, ar.getExpected, "mock(api_key_repository): Get() called more times than expected")
+	h := ar.getHandlers[ar.getVisited]
+	ar.getVisited++
+
+	return h(ctx, id)
+}
+
+func (ar *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*bingo.APIKey, error) {
+	require.Less(ar.tb, ar.getByHashVisited, ar.getByHashExpected, "mock(api_key_repository): GetByHash() called more times than expected")
+	h := ar.getByHashHandlers[ar.getByHashVisited]
+	ar.getByHashVisited++
+
+	return h(ctx, hash)
+}
+
+func (ar *APIKeyRepository) ListByUser(ctx context.Context, userId string) ([]bingo.APIKey, error) {
+	require.Less(ar.tb, ar.listByUserVisited, ar.listByUserExpected, "mock(api_key_repository): ListByUser() called more times than expected")
+	h := ar.listByUserHandlers[ar.listByUserVisited]
+	ar.listByUserVisited++
+
+	return h(ctx, userId)
+}
+
+func (ar *APIKeyRepository) RequireExpectationsMet() {
+	require.Equal(ar.tb, ar.saveExpected, ar.saveVisited, "mock(api_key_repository): Save() call expectations was not met.")
+	require.Equal(ar.tb, ar.getExpected, ar.getVisited, "mock(api_key_repository): Get() call expectations was not met.")
+	require.Equal(ar.tb, ar.getByHashExpected, ar.getByHashVisited, "mock(api_key_repository): GetByHash() call expectations was not met.")
+	require.Equal(ar.tb, ar.listByUserExpected, ar.listByUserVisited, "mock(api_key_repository): ListByUser() call expectations was not met.")
+}
+
+func NewAPIKeyRepository(tb testing.TB) *APIKeyRepository {
+	return &APIKeyRepository{
+		tb:                 tb,
+		saveHandlers:       make([]APIKeySaveHandler, 0, 1),
+		getHandlers:        make([]APIKeyGetHandler, 0, 1),
+		getByHashHandlers:  make([]APIKeyGetByHashHandler, 0, 1),
+		listByUserHandlers: make([]APIKeyListByUserHandler, 0, 1),
+	}
+}
@@ -11,6 +11,9 @@ import (
 type CardSaveHandler func(ctx context.Context, card *bingo.Card) error
 type CardSaveAllHandler func(ctx context.Context, cards []bingo.Card) error
 type CardGetByNumberHandler func(ctx context.Context, cardNum int, gameId string) (*bingo.Card, error)
+type CardFindByNumbersHandler func(ctx context.Context, gameId string, numbers []int) ([]bingo.Card, error)
+type CardAssignHandler func(ctx context.Context, gameId string, numbers []int, playerId string) error
+type CardCountUnassignedHandler func(ctx context.Context, gameId string) (int, error)
 
 type CardRespository struct {
 	tb            testing.TB
@@ -25,6 +28,18 @@ type CardRespository struct {
 	getByNumbersExpected int
 	getByNumbersExecuted int
 	getByNumberHandlers  []CardGetByNumberHandler
+
+	findByNumbersExpected int
+	findByNumbersExecuted int
+	findByNumbersHandlers []CardFindByNumbersHandler
+
+	assignsExpected int
+	assignsExecuted int
+	assignHandlers  []CardAssignHandler
+
+	countUnassignedsExpected int
+	countUnassignedsExecuted int
+	countUnassignedHandlers  []CardCountUnassignedHandler
 }
 
 func (gr *CardRespository) ExpectSave(h CardSaveHandler) {
@@ -42,6 +57,21 @@ func (gr *CardRespository) ExpectGetByNumber(h CardGetByNumberHandler) {
 	gr.getByNumbersExpected++
 }
 
+func (gr *CardRespository) ExpectFindByNumbers(h CardFindByNumbersHandler) {
+	gr.findByNumbersHandlers = append(gr.findByNumbersHandlers, h)
+	gr.findByNumbersExpected++
+}
+
+func (gr *CardRespository) ExpectAssign(h CardAssignHandler) {
+	gr.assignHandlers = append(gr.assignHandlers, h)
+	gr.assignsExpected++
+}
+
+func (gr *CardRespository) ExpectCountUnassigned(h CardCountUnassignedHandler) {
+	gr.countUnassignedHandlers = append(gr.countUnassignedHandlers, h)
+	gr.countUnassignedsExpected++
+}
+
 func (gr *CardRespository) Save(ctx context.Context, card *bingo.Card) error {
 	require.Less(gr.tb, gr.savesExecuted, gr.savesExpected, "mock(card_repository): Save() called more times than expected")
 
@@ -68,10 +98,40 @@ func (gr *CardRespository) GetByNumber(ctx context.Context, cardNum int, gameId
 	return h(ctx, cardNum, gameId)
 }
 
+func (gr *CardRespository) FindByNumbers(ctx context.Context, gameId string, numbers []int) ([]bingo.Card, error) {
+	require.Less(gr.tb, gr.findByNumbersExecuted, gr.findByNumbersExpected, "mock(card_repository): FindByNumbers() called more times than expected")
+
+	h := gr.findByNumbersHandlers[gr.findByNumbersExecuted]
+	gr.findByNumbersExecuted++
+
+	return h(ctx, gameId, numbers)
+}
+
+func (gr *CardRespository) Assign(ctx context.Context, gameId string, numbers []int, playerId string) error {
+	require.Less(gr.tb, gr.assignsExecuted, gr.assignsExpected, "mock(card_repository): Assign() called more times than expected")
+
+	h := gr.assignHandlers[gr.assignsExecuted]
+	gr.assignsExecuted++
+
+	return h(ctx, gameId, numbers, playerId)
+}
+
+func (gr *CardRespository) CountUnassigned(ctx context.Context, gameId string) (int, error) {
+	require.Less(gr.tb, gr.countUnassignedsExecuted, gr.countUnassignedsExpected, "mock(card_repository): CountUnassigned() called more times than expected")
+
+	h := gr.countUnassignedHandlers[gr.countUnassignedsExecuted]
+	gr.countUnassignedsExecuted++
+
+	return h(ctx, gameId)
+}
+
 func (cr *CardRespository) RequireExpectationsMet() {
 	require.Equal(cr.tb, cr.savesExecuted, cr.savesExpected, "mock(game_repository): Save() was not called enough times")
 	require.Equal(cr.tb, cr.saveAllsExecuted, cr.saveAllsExpected, "mock(game_repository): SaveAll() was not called enough times")
 	require.Equal(cr.tb, cr.getByNumbersExecuted, cr.getByNumbersExpected, "mock(game_repository): GetByNumber() was not called enough times")
+	require.Equal(cr.tb, cr.findByNumbersExecuted, cr.findByNumbersExpected, "mock(card_repository): FindByNumbers() was not called enough times")
+	require.Equal(cr.tb, cr.assignsExecuted, cr.assignsExpected, "mock(card_repository): Assign() was not called enough times")
+	require.Equal(cr.tb, cr.countUnassignedsExecuted, cr.countUnassignedsExpected, "mock(card_repository): CountUnassigned() was not called enough times")
 }
 
 func NewCardRepository(tb testing.TB) *CardRespository {
@@ -80,5 +140,9 @@ func NewCardRepository(tb testing.TB) *CardRespository {
 		saveHandlers:        make([]CardSaveHandler, 0, 1),
 		saveAllHandlers:     make([]CardSaveAllHandler, 0, 1),
 		getByNumberHandlers: make([]CardGetByNumberHandler, 0, 1),
+
+		findByNumbersHandlers:   make([]CardFindByNumbersHandler, 0, 1),
+		assignHandlers:          make([]CardAssignHandler, 0, 1),
+		countUnassignedHandlers: make([]CardCountUnassignedHandler, 0, 1),
 	}
 }
@@ -0,0 +1,82 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type CreditRepository struct {
+	tb             testing.TB
+	appendVisited  int
+	appendExpected int
+	appendHandlers []CreditAppendHandler
+
+	balanceVisited  int
+	balanceExpected int
+	balanceHandlers []CreditBalanceHandler
+
+	listVisited  int
+	listExpected int
+	listHandlers []CreditListHandler
+}
+
+type CreditAppendHandler func(ctx context.Context, e *bingo.CreditEntry) error
+type CreditBalanceHandler func(ctx context.Context, acc bingo.CreditAccount) (int, error)
+type CreditListHandler func(ctx context.Context, acc bingo.CreditAccount) ([]bingo.CreditEntry, error)
+
+func (cr *CreditRepository) ExpectAppend(h CreditAppendHandler) {
+	cr.appendHandlers = append(cr.appendHandlers, h)
+	cr.appendExpected++
+}
+
+func (cr *CreditRepository) ExpectBalance(h CreditBalanceHandler) {
+	cr.balanceHandlers = append(cr.balanceHandlers, h)
+	cr.balanceExpected++
+}
+
+func (cr *CreditRepository) ExpectList(h CreditListHandler) {
+	cr.listHandlers = append(cr.listHandlers, h)
+	cr.listExpected++
+}
+
+func (cr *CreditRepository) Append(ctx context.Context, e *bingo.CreditEntry) error {
+	require.Less(cr.tb, cr.appendVisited, cr.appendExpected, "mock(credit_repository): Append() called more times than expected")
+	h := cr.appendHandlers[cr.appendVisited]
+	cr.appendVisited++
+
+	return h(ctx, e)
+}
+
+func (cr *CreditRepository) Balance(ctx context.Context, acc bingo.CreditAccount) (int, error) {
+	require.Less(cr.tb, cr.balanceVisited, cr.balanceExpected, "mock(credit_repository): Balance() called more times than expected")
+	h := cr.balanceHandlers[cr.balanceVisited]
+	cr.balanceVisited++
+
+	return h(ctx, acc)
+}
+
+func (cr *CreditRepository) List(ctx context.Context, acc bingo.CreditAccount) ([]bingo.CreditEntry, error) {
+	require.Less(cr.tb, cr.listVisited, cr.listExpected, "mock(credit_repository): List() called more times than expected")
+	h := cr.listHandlers[cr.listVisited]
+	cr.listVisited++
+
+	return h(ctx, acc)
+}
+
+func (cr *CreditRepository) RequireExpectationsMet() {
+	require.Equal(cr.tb, cr.appendExpected, cr.appendVisited, "mock(credit_repository): Append() call expectations was not met.")
+	require.Equal(cr.tb, cr.balanceExpected, cr.balanceVisited, "mock(credit_repository): Balance() call expectations was not met.")
+	require.Equal(cr.tb, cr.listExpected, cr.listVisited, "mock(credit_repository): List() call expectations was not met.")
+}
+
+func NewCreditRepository(tb testing.TB) *CreditRepository {
+	return &CreditRepository{
+		tb:              tb,
+		appendHandlers:  make([]CreditAppendHandler, 0, 1),
+		balanceHandlers: make([]CreditBalanceHandler, 0, 1),
+		listHandlers:    make([]CreditListHandler, 0, 1),
+	}
+}
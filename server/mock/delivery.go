@@ -0,0 +1,109 @@
+package mock
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type CardRenderHandler func(w io.Writer, g *bingo.Game, cards []bingo.Card) error
+
+type CardRenderer struct {
+	tb             testing.TB
+	renderVisited  int
+	renderExpected int
+	renderHandlers []CardRenderHandler
+}
+
+func (cr *CardRenderer) ExpectRenderCards(h CardRenderHandler) {
+	cr.renderHandlers = append(cr.renderHandlers, h)
+	cr.renderExpected++
+}
+
+func (cr *CardRenderer) RenderCards(w io.Writer, g *bingo.Game, cards []bingo.Card) error {
+	require.Less(cr.tb, cr.renderVisited, cr.renderExpected, "mock(card_renderer): RenderCards() called more times than expected")
+	h := cr.renderHandlers[cr.renderVisited]
+	cr.renderVisited++
+
+	return h(w, g, cards)
+}
+
+func (cr *CardRenderer) RequireExpectationsMet() {
+	require.Equal(cr.tb, cr.renderExpected, cr.renderVisited, "mock(card_renderer): RenderCards() call expectations was not met.")
+}
+
+func NewCardRenderer(tb testing.TB) *CardRenderer {
+	return &CardRenderer{
+		tb:             tb,
+		renderHandlers: make([]CardRenderHandler, 0, 1),
+	}
+}
+
+type MailSendHandler func(ctx context.Context, m *bingo.Mail) error
+
+type Mailer struct {
+	tb           testing.TB
+	sendVisited  int
+	sendExpected int
+	sendHandlers []MailSendHandler
+}
+
+func (m *Mailer) ExpectSend(h MailSendHandler) {
+	m.sendHandlers = append(m.sendHandlers, h)
+	m.sendExpected++
+}
+
+func (m *Mailer) Send(ctx context.Context, mail *bingo.Mail) error {
+	require.Less(m.tb, m.sendVisited, m.sendExpected, "mock(mailer): Send() called more times than expected")
+	h := m.sendHandlers[m.sendVisited]
+	m.sendVisited++
+
+	return h(ctx, mail)
+}
+
+func (m *Mailer) RequireExpectationsMet() {
+	require.Equal(m.tb, m.sendExpected, m.sendVisited, "mock(mailer): Send() call expectations was not met.")
+}
+
+func NewMailer(tb testing.TB) *Mailer {
+	return &Mailer{
+		tb:           tb,
+		sendHandlers: make([]MailSendHandler, 0, 1),
+	}
+}
+
+type MailRenderHandler func(tmpl bingo.MailTemplate, lang bingo.Language, data interface{}) (*bingo.Mail, error)
+
+type MailRenderer struct {
+	tb             testing.TB
+	renderVisited  int
+	renderExpected int
+	renderHandlers []MailRenderHandler
+}
+
+func (mr *MailRenderer) ExpectRenderMail(h MailRenderHandler) {
+	mr.renderHandlers = append(mr.renderHandlers, h)
+	mr.renderExpected++
+}
+
+func (mr *MailRenderer) RenderMail(tmpl bingo.MailTemplate, lang bingo.Language, data interface{}) (*bingo.Mail, error) {
+	require.Less(mr.tb, mr.renderVisited, mr.renderExpected, "mock(mail_renderer): RenderMail() called more times than expected")
+	h := mr.renderHandlers[mr.renderVisited]
+	mr.renderVisited++
+
+	return h(tmpl, lang, data)
+}
+
+func (mr *MailRenderer) RequireExpectationsMet() {
+	require.Equal(mr.tb, mr.renderExpected, mr.renderVisited, "mock(mail_renderer): RenderMail() call expectations was not met.")
+}
+
+func NewMailRenderer(tb testing.TB) *MailRenderer {
+	return &MailRenderer{
+		tb:             tb,
+		renderHandlers: make([]MailRenderHandler, 0, 1),
+	}
+}
@@ -17,10 +17,20 @@ type GameRepository struct {
 	getVisited  int
 	getExpected int
 	getHandlers []GameGetHandler
+
+	findVisited  int
+	findExpected int
+	findHandlers []GameFindHandler
+
+	allocateCardNumbersVisited  int
+	allocateCardNumbersExpected int
+	allocateCardNumbersHandlers []GameAllocateCardNumbersHandler
 }
 
 type GameSaveHandler func(ctx context.Context, game *bingo.Game) error
 type GameGetHandler func(ctx context.Context, id string) (*bingo.Game, error)
+type GameFindHandler func(ctx context.Context, filter bingo.GameFilter) ([]bingo.Game, error)
+type GameAllocateCardNumbersHandler func(ctx context.Context, id string, amount int) (int, error)
 
 func (gr *GameRepository) ExpectSave(h GameSaveHandler) {
 	gr.saveHandlers = append(gr.saveHandlers, h)
@@ -32,6 +42,16 @@ func (gr *GameRepository) ExpectGet(h GameGetHandler) {
 	gr.getExpected++
 }
 
+func (gr *GameRepository) ExpectFind(h GameFindHandler) {
+	gr.findHandlers = append(gr.findHandlers, h)
+	gr.findExpected++
+}
+
+func (gr *GameRepository) ExpectAllocateCardNumbers(h GameAllocateCardNumbersHandler) {
+	gr.allocateCardNumbersHandlers = append(gr.allocateCardNumbersHandlers, h)
+	gr.allocateCardNumbersExpected++
+}
+
 func (gr *GameRepository) Save(ctx context.Context, game *bingo.Game) error {
 	require.Less(gr.tb, gr.saveVisited, gr.saveExpected, "mock(game_repository): Save() called more times than expected")
 	h := gr.saveHandlers[gr.saveVisited]
@@ -48,9 +68,27 @@ func (gr *GameRepository) Get(ctx context.Context, id string) (*bingo.Game, erro
 	return h(ctx, id)
 }
 
+func (gr *GameRepository) Find(ctx context.Context, filter bingo.GameFilter) ([]bingo.Game, error) {
+	require.Less(gr.tb, gr.findVisited, gr.findExpected, "mock(game_repository): Find() called more times than expected")
+	h := gr.findHandlers[gr.findVisited]
+	gr.findVisited++
+
+	return h(ctx, filter)
+}
+
+func (gr *GameRepository) AllocateCardNumbers(ctx context.Context, id string, amount int) (int, error) {
+	require.Less(gr.tb, gr.allocateCardNumbersVisited, gr.allocateCardNumbersExpected, "mock(game_repository): AllocateCardNumbers() called more times than expected")
+	h := gr.allocateCardNumbersHandlers[gr.allocateCardNumbersVisited]
+	gr.allocateCardNumbersVisited++
+
+	return h(ctx, id, amount)
+}
+
 func (gr *GameRepository) RequireExpectationsMet() {
 	require.Equal(gr.tb, gr.saveExpected, gr.saveVisited, "mock(game_repository): Save() call expectations was not met.")
 	require.Equal(gr.tb, gr.getExpected, gr.getVisited, "mock(game_repository): Get() call expectations was not met.")
+	require.Equal(gr.tb, gr.findExpected, gr.findVisited, "mock(game_repository): Find() call expectations was not met.")
+	require.Equal(gr.tb, gr.allocateCardNumbersExpected, gr.allocateCardNumbersVisited, "mock(game_repository): AllocateCardNumbers() call expectations was not met.")
 }
 
 func NewGameRepository(tb testing.TB) *GameRepository {
@@ -58,5 +96,8 @@ func NewGameRepository(tb testing.TB) *GameRepository {
 		tb:           tb,
 		saveHandlers: make([]GameSaveHandler, 0, 1),
 		getHandlers:  make([]GameGetHandler, 0, 1),
+		findHandlers: make([]GameFindHandler, 0, 1),
+
+		allocateCardNumbersHandlers: make([]GameAllocateCardNumbersHandler, 0, 1),
 	}
 }
@@ -0,0 +1,42 @@
+package mock
+
+import (
+	"bytes"
+
+	bingo "github.com/nohns/bingo-box/server"
+)
+
+// Fake password hasher. The "hash" is the password with a prefix, so expected hashes are easy to construct in tests.
+// Hashes made with the legacy prefix are accepted, but reported as needing a rehash.
+type Hasher struct{}
+
+const (
+	fakeHashPrefix       = "fakehash:"
+	fakeLegacyHashPrefix = "legacyhash:"
+)
+
+func (Hasher) Hash(passwd string) ([]byte, error) {
+	return FakeHash(passwd), nil
+}
+
+func (Hasher) Compare(hash []byte, passwd string) error {
+	if !bytes.Equal(hash, FakeHash(passwd)) && !bytes.Equal(hash, FakeLegacyHash(passwd)) {
+		return bingo.ErrPasswordMismatch
+	}
+
+	return nil
+}
+
+func (Hasher) NeedsRehash(hash []byte) bool {
+	return !bytes.HasPrefix(hash, []byte(fakeHashPrefix))
+}
+
+// Get the hash the fake hasher produces for the password
+func FakeHash(passwd string) []byte {
+	return []byte(fakeHashPrefix + passwd)
+}
+
+// Get an outdated hash for the password, which the fake hasher still accepts
+func FakeLegacyHash(passwd string) []byte {
+	return []byte(fakeLegacyHashPrefix + passwd)
+}
@@ -0,0 +1,200 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type InvitationGetHandler func(ctx context.Context, invId string) (*bingo.Invitation, error)
+type InvitationSaveHandler func(ctx context.Context, inv *bingo.Invitation) error
+type InvitationReserveHandler func(ctx context.Context, inv *bingo.Invitation, cardAmount int) error
+type InvitationWaitlistHandler func(ctx context.Context, inv *bingo.Invitation) (int, error)
+type InvitationReleaseHandler func(ctx context.Context, inv *bingo.Invitation, p *bingo.Player) error
+
+type InvitationRepository struct {
+	tb testing.TB
+
+	getVisited  int
+	getExpected int
+	getHandlers []InvitationGetHandler
+
+	saveVisited  int
+	saveExpected int
+	saveHandlers []InvitationSaveHandler
+
+	getByJoinCodeVisited  int
+	getByJoinCodeExpected int
+	getByJoinCodeHandlers []InvitationGetHandler
+
+	reserveVisited  int
+	reserveExpected int
+	reserveHandlers []InvitationReserveHandler
+
+	reserveCardsVisited  int
+	reserveCardsExpected int
+	reserveCardsHandlers []InvitationReserveHandler
+
+	reserveWaitlistedVisited  int
+	reserveWaitlistedExpected int
+	reserveWaitlistedHandlers []InvitationReserveHandler
+
+	waitlistVisited  int
+	waitlistExpected int
+	waitlistHandlers []InvitationWaitlistHandler
+
+	releaseVisited  int
+	releaseExpected int
+	releaseHandlers []InvitationReleaseHandler
+
+	releaseCardsVisited  int
+	releaseCardsExpected int
+	releaseCardsHandlers []InvitationReserveHandler
+}
+
+func (ir *InvitationRepository) ExpectGet(h InvitationGetHandler) {
+	ir.getHandlers = append(ir.getHandlers, h)
+	ir.getExpected++
+}
+
+func (ir *InvitationRepository) ExpectSave(h InvitationSaveHandler) {
+	ir.saveHandlers = append(ir.saveHandlers, h)
+	ir.saveExpected++
+}
+
+func (ir *InvitationRepository) ExpectGetByJoinCode(h InvitationGetHandler) {
+	ir.getByJoinCodeHandlers = append(ir.getByJoinCodeHandlers, h)
+	ir.getByJoinCodeExpected++
+}
+
+func (ir *InvitationRepository) ExpectReserve(h InvitationReserveHandler) {
+	ir.reserveHandlers = append(ir.reserveHandlers, h)
+	ir.reserveExpected++
+}
+
+func (ir *InvitationRepository) ExpectReserveCards(h InvitationReserveHandler) {
+	ir.reserveCardsHandlers = append(ir.reserveCardsHandlers, h)
+	ir.reserveCardsExpected++
+}
+
+func (ir *InvitationRepository) ExpectReserveWaitlisted(h InvitationReserveHandler) {
+	ir.reserveWaitlistedHandlers = append(ir.reserveWaitlistedHandlers, h)
+	ir.reserveWaitlistedExpected++
+}
+
+func (ir *InvitationRepository) ExpectWaitlist(h InvitationWaitlistHandler) {
+	ir.waitlistHandlers = append(ir.waitlistHandlers, h)
+	ir.waitlistExpected++
+}
+
+func (ir *InvitationRepository) ExpectRelease(h InvitationReleaseHandler) {
+	ir.releaseHandlers = append(ir.releaseHandlers, h)
+	ir.releaseExpected++
+}
+
+func (ir *InvitationRepository) ExpectReleaseCards(h InvitationReserveHandler) {
+	ir.releaseCardsHandlers = append(ir.releaseCardsHandlers, h)
+	ir.releaseCardsExpected++
+}
+
+func (ir *InvitationRepository) Get(ctx context.Context, invId string) (*bingo.Invitation, error) {
+	require.Less(ir.tb, ir.getVisited, ir.getExpected, "mock(invitation_repository): Get() called more times than expected")
+	h := ir.getHandlers[ir.getVisited]
+	ir.getVisited++
+
+	return h(ctx, invId)
+}
+
+func (ir *InvitationRepository) Save(ctx context.Context, inv *bingo.Invitation) error {
+	require.Less(ir.tb, ir.saveVisited, ir.saveExpected, "mock(invitation_repository): Save() called more times than expected")
+	h := ir.saveHandlers[ir.saveVisited]
+	ir.saveVisited++
+
+	return h(ctx, inv)
+}
+
+func (ir *InvitationRepository) GetByJoinCode(ctx context.Context, code string) (*bingo.Invitation, error) {
+	require.Less(ir.tb, ir.getByJoinCodeVisited, ir.getByJoinCodeExpected, "mock(invitation_repository): GetByJoinCode() called more times than expected")
+	h := ir.getByJoinCodeHandlers[ir.getByJoinCodeVisited]
+	ir.getByJoinCodeVisited++
+
+	return h(ctx, code)
+}
+
+func (ir *InvitationRepository) Reserve(ctx context.Context, inv *bingo.Invitation, cardAmount int) error {
+	require.Less(ir.tb, ir.reserveVisited, ir.reserveExpected, "mock(invitation_repository): Reserve() called more times than expected")
+	h := ir.reserveHandlers[ir.reserveVisited]
+	ir.reserveVisited++
+
+	return h(ctx, inv, cardAmount)
+}
+
+func (ir *InvitationRepository) ReserveCards(ctx context.Context, inv *bingo.Invitation, cardAmount int) error {
+	require.Less(ir.tb, ir.reserveCardsVisited, ir.reserveCardsExpected, "mock(invitation_repository): ReserveCards() called more times than expected")
+	h := ir.reserveCardsHandlers[ir.reserveCardsVisited]
+	ir.reserveCardsVisited++
+
+	return h(ctx, inv, cardAmount)
+}
+
+func (ir *InvitationRepository) ReserveWaitlisted(ctx context.Context, inv *bingo.Invitation, cardAmount int) error {
+	require.Less(ir.tb, ir.reserveWaitlistedVisited, ir.reserveWaitlistedExpected, "mock(invitation_repository): ReserveWaitlisted() called more times than expected")
+	h := ir.reserveWaitlistedHandlers[ir.reserveWaitlistedVisited]
+	ir.reserveWaitlistedVisited++
+
+	return h(ctx, inv, cardAmount)
+}
+
+func (ir *InvitationRepository) Waitlist(ctx context.Context, inv *bingo.Invitation) (int, error) {
+	require.Less(ir.tb, ir.waitlistVisited, ir.waitlistExpected, "mock(invitation_repository): Waitlist() called more times than expected")
+	h := ir.waitlistHandlers[ir.waitlistVisited]
+	ir.waitlistVisited++
+
+	return h(ctx, inv)
+}
+
+func (ir *InvitationRepository) Release(ctx context.Context, inv *bingo.Invitation, p *bingo.Player) error {
+	require.Less(ir.tb, ir.releaseVisited, ir.releaseExpected, "mock(invitation_repository): Release() called more times than expected")
+	h := ir.releaseHandlers[ir.releaseVisited]
+	ir.releaseVisited++
+
+	return h(ctx, inv, p)
+}
+
+func (ir *InvitationRepository) ReleaseCards(ctx context.Context, inv *bingo.Invitation, cardAmount int) error {
+	require.Less(ir.tb, ir.releaseCardsVisited, ir.releaseCardsExpected, "mock(invitation_repository): ReleaseCards() called more times than expected")
+	h := ir.releaseCardsHandlers[ir.releaseCardsVisited]
+	ir.releaseCardsVisited++
+
+	return h(ctx, inv, cardAmount)
+}
+
+func (ir *InvitationRepository) RequireExpectationsMet() {
+	require.Equal(ir.tb, ir.getExpected, ir.getVisited, "mock(invitation_repository): Get() call expectations was not met.")
+	require.Equal(ir.tb, ir.saveExpected, ir.saveVisited, "mock(invitation_repository): Save() call expectations was not met.")
+	require.Equal(ir.tb, ir.getByJoinCodeExpected, ir.getByJoinCodeVisited, "mock(invitation_repository): GetByJoinCode() call expectations was not met.")
+	require.Equal(ir.tb, ir.reserveExpected, ir.reserveVisited, "mock(invitation_repository): Reserve() call expectations was not met.")
+	require.Equal(ir.tb, ir.reserveCardsExpected, ir.reserveCardsVisited, "mock(invitation_repository): ReserveCards() call expectations was not met.")
+	require.Equal(ir.tb, ir.reserveWaitlistedExpected, ir.reserveWaitlistedVisited, "mock(invitation_repository): ReserveWaitlisted() call expectations was not met.")
+	require.Equal(ir.tb, ir.waitlistExpected, ir.waitlistVisited, "mock(invitation_repository): Waitlist() call expectations was not met.")
+	require.Equal(ir.tb, ir.releaseExpected, ir.releaseVisited, "mock(invitation_repository): Release() call expectations was not met.")
+	require.Equal(ir.tb, ir.releaseCardsExpected, ir.releaseCardsVisited, "mock(invitation_repository): ReleaseCards() call expectations was not met.")
+}
+
+func NewInvitationRepository(tb testing.TB) *InvitationRepository {
+	return &InvitationRepository{
+		tb:              tb,
+		getHandlers:     make([]InvitationGetHandler, 0, 1),
+		saveHandlers:    make([]InvitationSaveHandler, 0, 1),
+		reserveHandlers: make([]InvitationReserveHandler, 0, 1),
+
+		getByJoinCodeHandlers:     make([]InvitationGetHandler, 0, 1),
+		reserveCardsHandlers:      make([]InvitationReserveHandler, 0, 1),
+		reserveWaitlistedHandlers: make([]InvitationReserveHandler, 0, 1),
+		waitlistHandlers:          make([]InvitationWaitlistHandler, 0, 1),
+		releaseHandlers:           make([]InvitationReleaseHandler, 0, 1),
+		releaseCardsHandlers:      make([]InvitationReserveHandler, 0, 1),
+	}
+}
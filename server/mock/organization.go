@@ -0,0 +1,102 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type OrganizationRepository struct {
+	tb           testing.TB
+	saveVisited  int
+	saveExpected int
+	saveHandlers []OrganizationSaveHandler
+
+	getVisited  int
+	getExpected int
+	getHandlers []OrganizationGetHandler
+
+	listByMemberVisited  int
+	listByMemberExpected int
+	listByMemberHandlers []OrganizationListByMemberHandler
+
+	addCreditsVisited  int
+	addCreditsExpected int
+	addCreditsHandlers []OrganizationAddCreditsHandler
+}
+
+type OrganizationSaveHandler func(ctx context.Context, org *bingo.Organization) error
+type OrganizationGetHandler func(ctx context.Context, id string) (*bingo.Organization, error)
+type OrganizationListByMemberHandler func(ctx context.Context, userId string) ([]bingo.Organization, error)
+type OrganizationAddCreditsHandler func(ctx context.Context, id string, amount int) error
+
+func (or *OrganizationRepository) ExpectSave(h OrganizationSaveHandler) {
+	or.saveHandlers = append(or.saveHandlers, h)
+	or.saveExpected++
+}
+
+func (or *OrganizationRepository) ExpectGet(h OrganizationGetHandler) {
+	or.getHandlers = append(or.getHandlers, h)
+	or.getExpected++
+}
+
+func (or *OrganizationRepository) ExpectListByMember(h OrganizationListByMemberHandler) {
+	or.listByMemberHandlers = append(or.listByMemberHandlers, h)
+	or.listByMemberExpected++
+}
+
+func (or *OrganizationRepository) ExpectAddCredits(h OrganizationAddCreditsHandler) {
+	or.addCreditsHandlers = append(or.addCreditsHandlers, h)
+	or.addCreditsExpected++
+}
+
+func (or *OrganizationRepository) Save(ctx context.Context, org *bingo.Organization) error {
+	require.Less(or.tb, or.saveVisited, or.saveExpected, "mock(organization_repository): Save() called more times than expected")
+	h := or.saveHandlers[or.saveVisited]
+	or.saveVisited++
+
+	return h(ctx, org)
+}
+
+func (or *OrganizationRepository) Get(ctx context.Context, id string) (*bingo.Organization, error) {
+	require.Less(or.tb, or.getVisited, or.getExpected, "mock(organization_repository): Get() called more times than expected")
+	h := or.getHandlers[or.getVisited]
+	or.getVisited++
+
+	return h(ctx, id)
+}
+
+func (or *OrganizationRepository) ListByMember(ctx context.Context, userId string) ([]bingo.Organization, error) {
+	require.Less(or.tb, or.listByMemberVisited, or.listByMemberExpected, "mock(organization_repository): ListByMember() called more times than expected")
+	h := or.listByMemberHandlers[or.listByMemberVisited]
+	or.listByMemberVisited++
+
+	return h(ctx, userId)
+}
+
+func (or *OrganizationRepository) AddCredits(ctx context.Context, id string, amount int) error {
+	require.Less(or.tb, or.addCreditsVisited, or.addCreditsExpected, "mock(organization_repository): AddCredits() called more times than expected")
+	h := or.addCreditsHandlers[or.addCreditsVisited]
+	or.addCreditsVisited++
+
+	return h(ctx, id, amount)
+}
+
+func (or *OrganizationRepository) RequireExpectationsMet() {
+	require.Equal(or.tb, or.saveExpected, or.saveVisited, "mock(organization_repository): Save() call expectations was not met.")
+	require.Equal(or.tb, or.getExpected, or.getVisited, "mock(organization_repository): Get() call expectations was not met.")
+	require.Equal(or.tb, or.listByMemberExpected, or.listByMemberVisited, "mock(organization_repository): ListByMember() call expectations was not met.")
+	require.Equal(or.tb, or.addCreditsExpected, or.addCreditsVisited, "mock(organization_repository): AddCredits() call expectations was not met.")
+}
+
+func NewOrganizationRepository(tb testing.TB) *OrganizationRepository {
+	return &OrganizationRepository{
+		tb:                   tb,
+		saveHandlers:         make([]OrganizationSaveHandler, 0, 1),
+		getHandlers:          make([]OrganizationGetHandler, 0, 1),
+		listByMemberHandlers: make([]OrganizationListByMemberHandler, 0, 1),
+		addCreditsHandlers:   make([]OrganizationAddCreditsHandler, 0, 1),
+	}
+}
@@ -0,0 +1,103 @@
+package mock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type OutboxRepository struct {
+	tb           testing.TB
+	saveVisited  int
+	saveExpected int
+	saveHandlers []OutboxSaveHandler
+
+	getVisited  int
+	getExpected int
+	getHandlers []OutboxGetHandler
+
+	claimDueVisited  int
+	claimDueExpected int
+	claimDueHandlers []OutboxClaimDueHandler
+
+	findVisited  int
+	findExpected int
+	findHandlers []OutboxFindHandler
+}
+
+type OutboxSaveHandler func(ctx context.Context, msg *bingo.OutboxMessage) error
+type OutboxGetHandler func(ctx context.Context, msgId string) (*bingo.OutboxMessage, error)
+type OutboxClaimDueHandler func(ctx context.Context, now time.Time, lease time.Duration) (*bingo.OutboxMessage, error)
+type OutboxFindHandler func(ctx context.Context, status bingo.OutboxStatus) ([]bingo.OutboxMessage, error)
+
+func (or *OutboxRepository) ExpectSave(h OutboxSaveHandler) {
+	or.saveHandlers = append(or.saveHandlers, h)
+	or.saveExpected++
+}
+
+func (or *OutboxRepository) ExpectGet(h OutboxGetHandler) {
+	or.getHandlers = append(or.getHandlers, h)
+	or.getExpected++
+}
+
+func (or *OutboxRepository) ExpectClaimDue(h OutboxClaimDueHandler) {
+	or.claimDueHandlers = append(or.claimDueHandlers, h)
+	or.claimDueExpected++
+}
+
+func (or *OutboxRepository) ExpectFind(h OutboxFindHandler) {
+	or.findHandlers = append(or.findHandlers, h)
+	or.findExpected++
+}
+
+func (or *OutboxRepository) Save(ctx context.Context, msg *bingo.OutboxMessage) error {
+	require.Less(or.tb, or.saveVisited, or.saveExpected, "mock(outbox_repository): Save() called more times than expected")
+	h := or.saveHandlers[or.saveVisited]
+	or.saveVisited++
+
+	return h(ctx, msg)
+}
+
+func (or *OutboxRepository) Get(ctx context.Context, msgId string) (*bingo.OutboxMessage, error) {
+	require.Less(or.tb, or.getVisited, or.getExpected, "mock(outbox_repository): Get() called more times than expected")
+	h := or.getHandlers[or.getVisited]
+	or.getVisited++
+
+	return h(ctx, msgId)
+}
+
+func (or *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*bingo.OutboxMessage, error) {
+	require.Less(or.tb, or.claimDueVisited, or.claimDueExpected, "mock(outbox_repository): ClaimDue() called more times than expected")
+	h := or.claimDueHandlers[or.claimDueVisited]
+	or.claimDueVisited++
+
+	return h(ctx, now, lease)
+}
+
+func (or *OutboxRepository) Find(ctx context.Context, status bingo.OutboxStatus) ([]bingo.OutboxMessage, error) {
+	require.Less(or.tb, or.findVisited, or.findExpected, "mock(outbox_repository): Find() called more times than expected")
+	h := or.findHandlers[or.findVisited]
+	or.findVisited++
+
+	return h(ctx, status)
+}
+
+func (or *OutboxRepository) RequireExpectationsMet() {
+	require.Equal(or.tb, or.saveExpected, or.saveVisited, "mock(outbox_repository): Save() call expectations was not met.")
+	require.Equal(or.tb, or.getExpected, or.getVisited, "mock(outbox_repository): Get() call expectations was not met.")
+	require.Equal(or.tb, or.claimDueExpected, or.claimDueVisited, "mock(outbox_repository): ClaimDue() call expectations was not met.")
+	require.Equal(or.tb, or.findExpected, or.findVisited, "mock(outbox_repository): Find() call expectations was not met.")
+}
+
+func NewOutboxRepository(tb testing.TB) *OutboxRepository {
+	return &OutboxRepository{
+		tb:               tb,
+		saveHandlers:     make([]OutboxSaveHandler, 0, 1),
+		getHandlers:      make([]OutboxGetHandler, 0, 1),
+		claimDueHandlers: make([]OutboxClaimDueHandler, 0, 1),
+		findHandlers:     make([]OutboxFindHandler, 0, 1),
+	}
+}
@@ -0,0 +1,125 @@
+package mock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type PlayerSaveHandler func(ctx context.Context, player *bingo.Player) error
+type PlayerGetHandler func(ctx context.Context, playerId string) (*bingo.Player, error)
+type PlayerFindHandler func(ctx context.Context, filter bingo.PlayerFilter) ([]bingo.Player, error)
+type PlayerGetByEmailHandler func(ctx context.Context, invId string, email string) (*bingo.Player, error)
+type PlayerRecordDeliveryHandler func(ctx context.Context, playerId string, status bingo.DeliveryStatus, deliveryErr string, at time.Time) error
+
+type PlayerRepository struct {
+	tb testing.TB
+
+	saveVisited  int
+	saveExpected int
+	saveHandlers []PlayerSaveHandler
+
+	getVisited  int
+	getExpected int
+	getHandlers []PlayerGetHandler
+
+	findVisited  int
+	findExpected int
+	findHandlers []PlayerFindHandler
+
+	getByEmailVisited  int
+	getByEmailExpected int
+	getByEmailHandlers []PlayerGetByEmailHandler
+
+	recordDeliveryVisited  int
+	recordDeliveryExpected int
+	recordDeliveryHandlers []PlayerRecordDeliveryHandler
+}
+
+func (pr *PlayerRepository) ExpectSave(h PlayerSaveHandler) {
+	pr.saveHandlers = append(pr.saveHandlers, h)
+	pr.saveExpected++
+}
+
+func (pr *PlayerRepository) ExpectGet(h PlayerGetHandler) {
+	pr.getHandlers = append(pr.getHandlers, h)
+	pr.getExpected++
+}
+
+func (pr *PlayerRepository) ExpectFind(h PlayerFindHandler) {
+	pr.findHandlers = append(pr.findHandlers, h)
+	pr.findExpected++
+}
+
+func (pr *PlayerRepository) ExpectGetByEmail(h PlayerGetByEmailHandler) {
+	pr.getByEmailHandlers = append(pr.getByEmailHandlers, h)
+	pr.getByEmailExpected++
+}
+
+func (pr *PlayerRepository) ExpectRecordDelivery(h PlayerRecordDeliveryHandler) {
+	pr.recordDeliveryHandlers = append(pr.recordDeliveryHandlers, h)
+	pr.recordDeliveryExpected++
+}
+
+func (pr *PlayerRepository) Save(ctx context.Context, player *bingo.Player) error {
+	require.Less(pr.tb, pr.saveVisited, pr.saveExpected, "mock(player_repository): Save() called more times than expected")
+	h := pr.saveHandlers[pr.saveVisited]
+	pr.saveVisited++
+
+	return h(ctx, player)
+}
+
+func (pr *PlayerRepository) Get(ctx context.Context, playerId string) (*bingo.Player, error) {
+	require.Less(pr.tb, pr.getVisited, pr.getExpected, "mock(player_repository): Get() called more times than expected")
+	h := pr.getHandlers[pr.getVisited]
+	pr.getVisited++
+
+	return h(ctx, playerId)
+}
+
+func (pr *PlayerRepository) Find(ctx context.Context, filter bingo.PlayerFilter) ([]bingo.Player, error) {
+	require.Less(pr.tb, pr.findVisited, pr.findExpected, "mock(player_repository): Find() called more times than expected")
+	h := pr.findHandlers[pr.findVisited]
+	pr.findVisited++
+
+	return h(ctx, filter)
+}
+
+func (pr *PlayerRepository) GetByEmail(ctx context.Context, invId string, email string) (*bingo.Player, error) {
+	require.Less(pr.tb, pr.getByEmailVisited, pr.getByEmailExpected, "mock(player_repository): GetByEmail() called more times than expected")
+	h := pr.getByEmailHandlers[pr.getByEmailVisited]
+	pr.getByEmailVisited++
+
+	return h(ctx, invId, email)
+}
+
+func (pr *PlayerRepository) RecordDelivery(ctx context.Context, playerId string, status bingo.DeliveryStatus, deliveryErr string, at time.Time) error {
+	require.Less(pr.tb, pr.recordDeliveryVisited, pr.recordDeliveryExpected, "mock(player_repository): RecordDelivery() called more times than expected")
+	h := pr.recordDeliveryHandlers[pr.recordDeliveryVisited]
+	pr.recordDeliveryVisited++
+
+	return h(ctx, playerId, status, deliveryErr, at)
+}
+
+func (pr *PlayerRepository) RequireExpectationsMet() {
+	require.Equal(pr.tb, pr.saveExpected, pr.saveVisited, "mock(player_repository): Save() call expectations was not met.")
+	require.Equal(pr.tb, pr.getExpected, pr.getVisited, "mock(player_repository): Get() call expectations was not met.")
+	require.Equal(pr.tb, pr.findExpected, pr.findVisited, "mock(player_repository): Find() call expectations was not met.")
+	require.Equal(pr.tb, pr.getByEmailExpected, pr.getByEmailVisited, "mock(player_repository): GetByEmail() call expectations was not met.")
+	require.Equal(pr.tb, pr.recordDeliveryExpected, pr.recordDeliveryVisited, "mock(player_repository): RecordDelivery() call expectations was not met.")
+}
+
+func NewPlayerRepository(tb testing.TB) *PlayerRepository {
+	return &PlayerRepository{
+		tb:           tb,
+		saveHandlers: make([]PlayerSaveHandler, 0, 1),
+		getHandlers:  make([]PlayerGetHandler, 0, 1),
+		findHandlers: make([]PlayerFindHandler, 0, 1),
+
+		getByEmailHandlers:     make([]PlayerGetByEmailHandler, 0, 1),
+		recordDeliveryHandlers: make([]PlayerRecordDeliveryHandler, 0, 1),
+	}
+}
@@ -0,0 +1,202 @@
+package mock
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type RefreshTokenSaveHandler func(ctx context.Context, t *bingo.RefreshToken) error
+type RefreshTokenGetByHashHandler func(ctx context.Context, hash string) (*bingo.RefreshToken, error)
+type RefreshTokenRevokeHandler func(ctx context.Context, hash string, at time.Time) error
+type RefreshTokenRevokeFamilyHandler func(ctx context.Context, familyId string, at time.Time) error
+
+type RefreshTokenRepository struct {
+	tb           testing.TB
+	saveVisited  int
+	saveExpected int
+	saveHandlers []RefreshTokenSaveHandler
+
+	getByHashVisited  int
+	getByHashExpected int
+	getByHashHandlers []RefreshTokenGetByHashHandler
+
+	revokeVisited  int
+	revokeExpected int
+	revokeHandlers []RefreshTokenRevokeHandler
+
+	revokeFamilyVisited  int
+	revokeFamilyExpected int
+	revokeFamilyHandlers []RefreshTokenRevokeFamilyHandler
+}
+
+func (rr *RefreshTokenRepository) ExpectSave(h RefreshTokenSaveHandler) {
+	rr.saveHandlers = append(rr.saveHandlers, h)
+	rr.saveExpected++
+}
+
+func (rr *RefreshTokenRepository) ExpectGetByHash(h RefreshTokenGetByHashHandler) {
+	rr.getByHashHandlers = append(rr.getByHashHandlers, h)
+	rr.getByHashExpected++
+}
+
+func (rr *RefreshTokenRepository) ExpectRevoke(h RefreshTokenRevokeHandler) {
+	rr.revokeHandlers = append(rr.revokeHandlers, h)
+	rr.revokeExpected++
+}
+
+func (rr *RefreshTokenRepository) ExpectRevokeFamily(h RefreshTokenRevokeFamilyHandler) {
+	rr.revokeFamilyHandlers = append(rr.revokeFamilyHandlers, h)
+	rr.revokeFamilyExpected++
+}
+
+func (rr *RefreshTokenRepository) Save(ctx context.Context, t *bingo.RefreshToken) error {
+	require.Less(rr.tb, rr.saveVisited, rr.saveExpected, "mock(refresh_token_repository): Save() called more times than expected")
+	h := rr.saveHandlers[rr.saveVisited]
+	rr.saveVisited++
+
+	return h(ctx, t)
+}
+
+func (rr *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*bingo.RefreshToken, error) {
+	require.Less(rr.tb, rr.getByHashVisited, rr.getByHashExpected, "mock(refresh_token_repository): GetByHash() called more times than expected")
+	h := rr.getByHashHandlers[rr.getByHashVisited]
+	rr.getByHashVisited++
+
+	return h(ctx, hash)
+}
+
+func (rr *RefreshTokenRepository) Revoke(ctx context.Context, hash string, at time.Time) error {
+	require.Less(rr.tb, rr.revokeVisited, rr.revokeExpected, "mock(refresh_token_repository): Revoke() called more times than expected")
+	h := rr.revokeHandlers[rr.revokeVisited]
+	rr.revokeVisited++
+
+	return h(ctx, hash, at)
+}
+
+func (rr *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyId string, at time.Time) error {
+	require.Less(rr.tb, rr.revokeFamilyVisited, rr.revokeFamilyExpected, "mock(refresh_token_repository): RevokeFamily() called more times than expected")
+	h := rr.revokeFamilyHandlers[rr.revokeFamilyVisited]
+	rr.revokeFamilyVisited++
+
+	return h(ctx, familyId, at)
+}
+
+func (rr *RefreshTokenRepository) RequireExpectationsMet() {
+	require.Equal(rr.tb, rr.saveExpected, rr.saveVisited, "mock(refresh_token_repository): Save() call expectations was not met.")
+	require.Equal(rr.tb, rr.getByHashExpected, rr.getByHashVisited, "mock(refresh_token_repository): GetByHash() call expectations was not met.")
+	require.Equal(rr.tb, rr.revokeExpected, rr.revokeVisited, "mock(refresh_token_repository): Revoke() call expectations was not met.")
+	require.Equal(rr.tb, rr.revokeFamilyExpected, rr.revokeFamilyVisited, "mock(refresh_token_repository): RevokeFamily() call expectations was not met.")
+}
+
+func NewRefreshTokenRepository(tb testing.TB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		tb:                   tb,
+		saveHandlers:         make([]RefreshTokenSaveHandler, 0, 1),
+		getByHashHandlers:    make([]RefreshTokenGetByHashHandler, 0, 1),
+		revokeHandlers:       make([]RefreshTokenRevokeHandler, 0, 1),
+		revokeFamilyHandlers: make([]RefreshTokenRevokeFamilyHandler, 0, 1),
+	}
+}
+
+// Fake access token signer. Tokens are the plain user id and expiry separated by a colon, so they are easy to craft in tests.
+type AccessTokenSigner struct{}
+
+func (AccessTokenSigner) Sign(claims bingo.AccessTokenClaims) (string, error) {
+	return claims.UserID + ":" + strconv.FormatInt(claims.ExpiresAt.Unix(), 10), nil
+}
+
+func (AccessTokenSigner) Verify(token string) (*bingo.AccessTokenClaims, error) {
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 {
+		return nil, bingo.ErrInvalidAccessToken
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, bingo.ErrInvalidAccessToken
+	}
+
+	return &bingo.AccessTokenClaims{
+		UserID:    parts[0],
+		ExpiresAt: time.Unix(exp, 0),
+	}, nil
+}
+
+// Fake confirmation token signer. Tokens are the plain player id, email and expiry separated by colons, so they are easy
+// to craft in tests.
+type ConfirmationTokenSigner struct{}
+
+func (ConfirmationTokenSigner) Sign(claims bingo.ConfirmationClaims) (string, error) {
+	return claims.PlayerID + ":" + claims.Email + ":" + strconv.FormatInt(claims.ExpiresAt.Unix(), 10), nil
+}
+
+func (ConfirmationTokenSigner) Verify(token string) (*bingo.ConfirmationClaims, error) {
+	parts := strings.SplitN(token, ":", 3)
+	if len(parts) != 3 {
+		return nil, bingo.ErrInvalidConfirmationToken
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, bingo.ErrInvalidConfirmationToken
+	}
+
+	return &bingo.ConfirmationClaims{
+		PlayerID:  parts[0],
+		Email:     parts[1],
+		ExpiresAt: time.Unix(exp, 0),
+	}, nil
+}
+
+// Fake player session signer. Tokens are the plain player id and expiry separated by a colon, so they are easy to craft
+// in tests.
+type PlayerSessionSigner struct{}
+
+func (PlayerSessionSigner) Sign(claims bingo.PlayerSessionClaims) (string, error) {
+	return claims.PlayerID + ":" + strconv.FormatInt(claims.ExpiresAt.Unix(), 10), nil
+}
+
+func (PlayerSessionSigner) Verify(token string) (*bingo.PlayerSessionClaims, error) {
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 {
+		return nil, bingo.ErrInvalidPlayerSession
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, bingo.ErrInvalidPlayerSession
+	}
+
+	return &bingo.PlayerSessionClaims{
+		PlayerID:  parts[0],
+		ExpiresAt: time.Unix(exp, 0),
+	}, nil
+}
+
+// Fake member invitation signer. Tokens are the plain game id, email and expiry separated by colons, so they are easy
+// to craft in tests.
+type MemberInvitationSigner struct{}
+
+func (MemberInvitationSigner) Sign(claims bingo.MemberInvitationClaims) (string, error) {
+	return claims.GameID + ":" + claims.Email + ":" + strconv.FormatInt(claims.ExpiresAt.Unix(), 10), nil
+}
+
+func (MemberInvitationSigner) Verify(token string) (*bingo.MemberInvitationClaims, error) {
+	parts := strings.SplitN(token, ":", 3)
+	if len(parts) != 3 {
+		return nil, bingo.ErrInvalidMemberInvitation
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, bingo.ErrInvalidMemberInvitation
+	}
+
+	return &bingo.MemberInvitationClaims{
+		GameID:    parts[0],
+		Email:     parts[1],
+		ExpiresAt: time.Unix(exp, 0),
+	}, nil
+}
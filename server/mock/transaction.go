@@ -0,0 +1,28 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Transactor running the functions directly. Fails the test if a function is run within another
+type Transactor struct {
+	tb      testing.TB
+	running bool
+}
+
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	require.False(t.tb, t.running, "mock(transactor): WithinTransaction() called within another transaction")
+	t.running = true
+	defer func() { t.running = false }()
+
+	return fn(ctx)
+}
+
+func NewTransactor(tb testing.TB) *Transactor {
+	return &Transactor{
+		tb: tb,
+	}
+}
@@ -0,0 +1,122 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/stretchr/testify/require"
+)
+
+type UserGetHandler func(ctx context.Context, id string) (*bingo.User, error)
+type UserGetByEmailHandler func(ctx context.Context, email string) (*bingo.User, error)
+type UserGetByIdentityIDHandler func(ctx context.Context, identityId string) (*bingo.User, error)
+type UserSaveHandler func(ctx context.Context, user *bingo.User) error
+type UserAddCreditsHandler func(ctx context.Context, id string, amount int) error
+
+type UserRepository struct {
+	tb          testing.TB
+	getVisited  int
+	getExpected int
+	getHandlers []UserGetHandler
+
+	getByEmailVisited  int
+	getByEmailExpected int
+	getByEmailHandlers []UserGetByEmailHandler
+
+	getByIdentityIDVisited  int
+	getByIdentityIDExpected int
+	getByIdentityIDHandlers []UserGetByIdentityIDHandler
+
+	saveVisited  int
+	saveExpected int
+	saveHandlers []UserSaveHandler
+
+	addCreditsVisited  int
+	addCreditsExpected int
+	addCreditsHandlers []UserAddCreditsHandler
+}
+
+func (ur *UserRepository) ExpectGet(h UserGetHandler) {
+	ur.getHandlers = append(ur.getHandlers, h)
+	ur.getExpected++
+}
+
+func (ur *UserRepository) ExpectGetByEmail(h UserGetByEmailHandler) {
+	ur.getByEmailHandlers = append(ur.getByEmailHandlers, h)
+	ur.getByEmailExpected++
+}
+
+func (ur *UserRepository) ExpectGetByIdentityID(h UserGetByIdentityIDHandler) {
+	ur.getByIdentityIDHandlers = append(ur.getByIdentityIDHandlers, h)
+	ur.getByIdentityIDExpected++
+}
+
+func (ur *UserRepository) ExpectSave(h UserSaveHandler) {
+	ur.saveHandlers = append(ur.saveHandlers, h)
+	ur.saveExpected++
+}
+
+func (ur *UserRepository) ExpectAddCredits(h UserAddCreditsHandler) {
+	ur.addCreditsHandlers = append(ur.addCreditsHandlers, h)
+	ur.addCreditsExpected++
+}
+
+func (ur *UserRepository) Get(ctx context.Context, id string) (*bingo.User, error) {
+	require.Less(ur.tb, ur.getVisited, ur.getExpected, "mock(user_repository): Get() called more times than expected")
+	h := ur.getHandlers[ur.getVisited]
+	ur.getVisited++
+
+	return h(ctx, id)
+}
+
+func (ur *UserRepository) GetByEmail(ctx context.Context, email string) (*bingo.User, error) {
+	require.Less(ur.tb, ur.getByEmailVisited, ur.getByEmailExpected, "mock(user_repository): GetByEmail() called more times than expected")
+	h := ur.getByEmailHandlers[ur.getByEmailVisited]
+	ur.getByEmailVisited++
+
+	return h(ctx, email)
+}
+
+func (ur *UserRepository) GetByIdentityID(ctx context.Context, identityId string) (*bingo.User, error) {
+	require.Less(ur.tb, ur.getByIdentityIDVisited, ur.getByIdentityIDExpected, "mock(user_repository): GetByIdentityID() called more times than expected")
+	h := ur.getByIdentityIDHandlers[ur.getByIdentityIDVisited]
+	ur.getByIdentityIDVisited++
+
+	return h(ctx, identityId)
+}
+
+func (ur *UserRepository) Save(ctx context.Context, user *bingo.User) error {
+	require.Less(ur.tb, ur.saveVisited, ur.saveExpected, "mock(user_repository): Save() called more times than expected")
+	h := ur.saveHandlers[ur.saveVisited]
+	ur.saveVisited++
+
+	return h(ctx, user)
+}
+
+func (ur *UserRepository) AddCredits(ctx context.Context, id string, amount int) error {
+	require.Less(ur.tb, ur.addCreditsVisited, ur.addCreditsExpected, "mock(user_repository): AddCredits() called more times than expected")
+	h := ur.addCreditsHandlers[ur.addCreditsVisited]
+	ur.addCreditsVisited++
+
+	return h(ctx, id, amount)
+}
+
+func (ur *UserRepository) RequireExpectationsMet() {
+	require.Equal(ur.tb, ur.getExpected, ur.getVisited, "mock(user_repository): Get() call expectations was not met.")
+	require.Equal(ur.tb, ur.getByEmailExpected, ur.getByEmailVisited, "mock(user_repository): GetByEmail() call expectations was not met.")
+	require.Equal(ur.tb, ur.getByIdentityIDExpected, ur.getByIdentityIDVisited, "mock(user_repository): GetByIdentityID() call expectations was not met.")
+	require.Equal(ur.tb, ur.saveExpected, ur.saveVisited, "mock(user_repository): Save() call expectations was not met.")
+	require.Equal(ur.tb, ur.addCreditsExpected, ur.addCreditsVisited, "mock(user_repository): AddCredits() call expectations was not met.")
+}
+
+func NewUserRepository(tb testing.TB) *UserRepository {
+	return &UserRepository{
+		tb:                      tb,
+		getHandlers:             make([]UserGetHandler, 0, 1),
+		getByEmailHandlers:      make([]UserGetByEmailHandler, 0, 1),
+		getByIdentityIDHandlers: make([]UserGetByIdentityIDHandler, 0, 1),
+		saveHandlers:            make([]UserSaveHandler, 0, 1),
+		addCreditsHandlers:      make([]UserAddCreditsHandler, 0, 1),
+	}
+}
@@ -0,0 +1,155 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DocAPIKey struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Name      string             `bson:"name"`
+	Hint      string             `bson:"hint"`
+	Hash      string             `bson:"hash"`
+	Scopes    []string           `bson:"scopes"`
+	RevokedAt time.Time          `bson:"revoked_at"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (dk DocAPIKey) ToAggregate() *bingo.APIKey {
+	scopes := make([]bingo.Scope, 0, len(dk.Scopes))
+	for _, s := range dk.Scopes {
+		scopes = append(scopes, bingo.Scope(s))
+	}
+
+	return &bingo.APIKey{
+		ID:        dk.ID.Hex(),
+		UserID:    dk.UserID.Hex(),
+		Name:      dk.Name,
+		Hint:      dk.Hint,
+		Hash:      dk.Hash,
+		Scopes:    scopes,
+		RevokedAt: dk.RevokedAt,
+		CreatedAt: dk.CreatedAt,
+	}
+}
+
+func DocFromAPIKey(k *bingo.APIKey) (DocAPIKey, error) {
+	oid := primitive.NewObjectID()
+	if k.ID != "" {
+		var err error
+		oid, err = primitive.ObjectIDFromHex(k.ID)
+		if err != nil {
+			return DocAPIKey{}, ErrMalformedHexObjectID
+		}
+	}
+	uOid, err := primitive.ObjectIDFromHex(k.UserID)
+	if err != nil {
+		return DocAPIKey{}, ErrMalformedHexObjectID
+	}
+	scopes := make([]string, 0, len(k.Scopes))
+	for _, s := range k.Scopes {
+		scopes = append(scopes, string(s))
+	}
+
+	return DocAPIKey{
+		ID:        oid,
+		UserID:    uOid,
+		Name:      k.Name,
+		Hint:      k.Hint,
+		Hash:      k.Hash,
+		Scopes:    scopes,
+		RevokedAt: k.RevokedAt,
+		CreatedAt: k.CreatedAt,
+	}, nil
+}
+
+type APIKeyRepository struct {
+	db *DB
+}
+
+func (ar *APIKeyRepository) Get(ctx context.Context, id string) (*bingo.APIKey, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrMalformedHexObjectID
+	}
+
+	var doc DocAPIKey
+	err = ar.db.APIKeys.FindOne(ctx, bson.M{"_id": oid}).Decode(&doc)
+	if err != nil {
+		return nil, notFoundErr(err, bingo.ErrAPIKeyNotFound)
+	}
+
+	return doc.ToAggregate(), nil
+}
+
+func (ar *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*bingo.APIKey, error) {
+	var doc DocAPIKey
+	err := ar.db.APIKeys.FindOne(ctx, bson.M{"hash": hash}).Decode(&doc)
+	if err != nil {
+		return nil, notFoundErr(err, bingo.ErrAPIKeyNotFound)
+	}
+
+	return doc.ToAggregate(), nil
+}
+
+// List api keys of user, newest first
+func (ar *APIKeyRepository) ListByUser(ctx context.Context, userId string) ([]bingo.APIKey, error) {
+	uOid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrMalformedHexObjectID
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := ar.db.APIKeys.Find(ctx, bson.M{"user_id": uOid}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	keys := make([]bingo.APIKey, 0)
+	for cur.Next(ctx) {
+		var doc DocAPIKey
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		keys = append(keys, *doc.ToAggregate())
+	}
+
+	return keys, cur.Err()
+}
+
+func (ar *APIKeyRepository) Save(ctx context.Context, k *bingo.APIKey) error {
+	doc, err := DocFromAPIKey(k)
+	if err != nil {
+		return err
+	}
+	opts := options.Replace().SetUpsert(true)
+	res, err := ar.db.APIKeys.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts)
+	if err != nil {
+		return err
+	}
+	if k.ID == "" {
+		if res.UpsertedID == nil {
+			return ErrNoUpsertedObjectID
+		}
+		oid, ok := res.UpsertedID.(primitive.ObjectID)
+		if !ok {
+			return ErrNoUpsertedObjectID
+		}
+		k.ID = oid.Hex()
+	}
+
+	return nil
+}
+
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,123 @@
+package mongo_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mongo"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var implementsAPIKeyRepo bingo.APIKeyRepository = &mongo.APIKeyRepository{}
+
+// Test that mongodb api key doc <-> api key entity conversion works
+func TestDocAPIKey(t *testing.T) {
+	k := &bingo.APIKey{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    primitive.NewObjectID().Hex(),
+		Name:      "scoreboard",
+		Hint:      "bb_abcd",
+		Hash:      "hash",
+		Scopes:    []bingo.Scope{bingo.ScopeGamesRead, bingo.ScopeGamesCall},
+		RevokedAt: time.Now(),
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("test data out of date", func(t *testing.T) {
+		fieldsCount := reflect.Indirect(reflect.ValueOf(k)).NumField()
+		expectedfc := 8
+		require.Equal(t, expectedfc, fieldsCount, "api key test data missing one or more fields")
+	})
+
+	t.Run("bidirectional conversion", func(t *testing.T) {
+		doc, err := mongo.DocFromAPIKey(k)
+		require.NoError(t, err, "no error expected from mongo.DocFromAPIKey")
+
+		ck := doc.ToAggregate()
+		require.EqualValues(t, k, ck, "expected values of round-trip conversion to equal initial data")
+	})
+}
+
+func TestAPIKeyRepository_GetByHash(t *testing.T) {
+	apiKeyRepo := mongo.NewAPIKeyRepository(sharedDB)
+	insertDoc := mongo.DocAPIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    primitive.NewObjectID(),
+		Name:      "get key",
+		Hash:      "get key hash",
+		Scopes:    []string{string(bingo.ScopeGamesRead)},
+		CreatedAt: time.Now(),
+	}
+	MustInsertOneAPIKeyDoc(t, context.Background(), insertDoc)
+
+	cases := []struct {
+		cn            string
+		hash          string
+		expectedErrIs error
+	}{
+		{
+			cn:   "success",
+			hash: insertDoc.Hash,
+		},
+		{
+			cn:            "fail not found",
+			hash:          "unknown hash",
+			expectedErrIs: bingo.ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			k, err := apiKeyRepo.GetByHash(context.Background(), c.hash)
+			if c.expectedErrIs != nil {
+				require.ErrorIs(t, err, c.expectedErrIs, "expected different error")
+			} else {
+				require.NoError(t, err, "expected no error")
+				require.Equal(t, insertDoc.ID.Hex(), k.ID, "expected inserted key to be found")
+				require.True(t, k.HasScope(bingo.ScopeGamesRead), "expected scopes to be read")
+			}
+		})
+	}
+}
+
+func TestAPIKeyRepository_ListByUser(t *testing.T) {
+	ctx := context.Background()
+	apiKeyRepo := mongo.NewAPIKeyRepository(sharedDB)
+	olderDoc := mongo.DocAPIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    primitive.NewObjectID(),
+		Name:      "older key",
+		Hash:      "list key hash 1",
+		Scopes:    []string{string(bingo.ScopeGamesRead)},
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+	newerDoc := olderDoc
+	newerDoc.ID = primitive.NewObjectID()
+	newerDoc.Name = "newer key"
+	newerDoc.Hash = "list key hash 2"
+	newerDoc.CreatedAt = time.Now()
+	otherDoc := olderDoc
+	otherDoc.ID = primitive.NewObjectID()
+	otherDoc.UserID = primitive.NewObjectID()
+	otherDoc.Hash = "list key hash 3"
+	MustInsertOneAPIKeyDoc(t, ctx, olderDoc)
+	MustInsertOneAPIKeyDoc(t, ctx, newerDoc)
+	MustInsertOneAPIKeyDoc(t, ctx, otherDoc)
+
+	keys, err := apiKeyRepo.ListByUser(ctx, olderDoc.UserID.Hex())
+	require.NoError(t, err, "expected no error")
+	require.Len(t, keys, 2, "expected only keys of the user")
+	require.Equal(t, newerDoc.ID.Hex(), keys[0].ID, "expected newest key first")
+	require.Equal(t, olderDoc.ID.Hex(), keys[1].ID, "expected oldest key last")
+}
+
+func MustInsertOneAPIKeyDoc(tb testing.TB, ctx context.Context, doc mongo.DocAPIKey) {
+	tb.Helper()
+
+	_, err := sharedDB.APIKeys.InsertOne(ctx, doc)
+	require.NoError(tb, err, "expected no error from inserting api key")
+}
@@ -3,14 +3,13 @@ package mongo
 import (
 	"context"
 	"errors"
+	"time"
 
 	bingo "github.com/nohns/bingo-box/server"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"go.mongodb.org/mongo-driver/mongo/readconcern"
-	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 type docCard struct {
@@ -21,9 +20,18 @@ type docCard struct {
 	GameID primitive.ObjectID `bson:"game_id"`
 
 	// Player who owns the card, if it was generated via invitation
-	PlayerID primitive.ObjectID `bson:"player_id"`
+	PlayerID primitive.ObjectID `bson:"player_id,omitempty"`
 
 	GridNumbers []docCardGridNumber `bson:"grid_numbers"`
+
+	// Revocation voiding the card, if it has been revoked
+	Revocation *docCardRevocation `bson:"revocation,omitempty"`
+}
+
+type docCardRevocation struct {
+	RevokedBy string    `bson:"revoked_by,omitempty"`
+	Reason    string    `bson:"reason"`
+	RevokedAt time.Time `bson:"revoked_at"`
 }
 
 type docCardGridNumber struct {
@@ -47,10 +55,19 @@ func (dc docCard) ToAggregate(p *bingo.Player) (*bingo.Card, error) {
 		ID:          dc.ID.Hex(),
 		Number:      dc.Number,
 		GameID:      dc.GameID.Hex(),
-		PlayerID:    dc.PlayerID.Hex(),
 		Player:      p,
 		GridNumbers: gridNums,
 	}
+	if dc.Revocation != nil {
+		c.Revocation = &bingo.CardRevocation{
+			RevokedBy: dc.Revocation.RevokedBy,
+			Reason:    dc.Revocation.Reason,
+			RevokedAt: dc.Revocation.RevokedAt,
+		}
+	}
+	if !dc.PlayerID.IsZero() {
+		c.PlayerID = dc.PlayerID.Hex()
+	}
 	if err := c.Validate(); err != nil {
 		return nil, err
 	}
@@ -58,17 +75,26 @@ func (dc docCard) ToAggregate(p *bingo.Player) (*bingo.Card, error) {
 }
 
 func DocFromCard(c *bingo.Card) (docCard, error) {
-	oid, err := primitive.ObjectIDFromHex(c.ID)
-	if err != nil {
-		return docCard{}, err
+	oid := primitive.NewObjectID()
+	if c.ID != "" {
+		var err error
+		oid, err = primitive.ObjectIDFromHex(c.ID)
+		if err != nil {
+			return docCard{}, ErrMalformedHexObjectID
+		}
 	}
 	gOid, err := primitive.ObjectIDFromHex(c.GameID)
 	if err != nil {
-		return docCard{}, err
+		return docCard{}, ErrMalformedHexObjectID
 	}
-	pOid, err := primitive.ObjectIDFromHex(c.PlayerID)
-	if err != nil {
-		return docCard{}, err
+
+	// Cards not generated via invitation has no player
+	var pOid primitive.ObjectID
+	if c.PlayerID != "" {
+		pOid, err = primitive.ObjectIDFromHex(c.PlayerID)
+		if err != nil {
+			return docCard{}, ErrMalformedHexObjectID
+		}
 	}
 	gridNums := make([]docCardGridNumber, 0, len(c.GridNumbers))
 	for _, gn := range c.GridNumbers {
@@ -78,12 +104,21 @@ func DocFromCard(c *bingo.Card) (docCard, error) {
 			Number: gn.Number,
 		})
 	}
+	var rev *docCardRevocation
+	if c.Revocation != nil {
+		rev = &docCardRevocation{
+			RevokedBy: c.Revocation.RevokedBy,
+			Reason:    c.Revocation.Reason,
+			RevokedAt: c.Revocation.RevokedAt,
+		}
+	}
 	return docCard{
 		ID:          oid,
 		Number:      c.Number,
 		GameID:      gOid,
 		PlayerID:    pOid,
 		GridNumbers: gridNums,
+		Revocation:  rev,
 	}, nil
 }
 
@@ -99,23 +134,21 @@ func (cr *CardRepository) GetByNumber(ctx context.Context, cardNum int, gameId s
 	var doc docCard
 	res := cr.db.Cards.FindOne(ctx, bson.M{"number": cardNum, "game_id": gOid})
 	if err := res.Decode(&doc); err != nil {
-		return nil, err
+		return nil, notFoundErr(err, bingo.ErrCardNotFound)
 	}
 
-	// Find associated player to card
-	var pDoc *DocPlayer
-	err = cr.db.Players.FindOne(ctx, bson.M{"_id": doc.PlayerID}).Decode(pDoc)
-	if errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, err
-	} else if err != nil {
+	// Find associated player to card. Cards not generated via invitation has no player
+	var p *bingo.Player
+	var pDoc DocPlayer
+	err = cr.db.Players.FindOne(ctx, bson.M{"_id": doc.PlayerID}).Decode(&pDoc)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, err
 	}
-	var p *bingo.Player
-	if pDoc != nil {
+	if err == nil {
 		p, err = pDoc.ToAggregate(nil, make([]bingo.Card, 0))
-	}
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	aggr, err := doc.ToAggregate(p)
@@ -126,33 +159,87 @@ func (cr *CardRepository) GetByNumber(ctx context.Context, cardNum int, gameId s
 	return aggr, nil
 }
 
-// Save all given cards with Save() method but wrapped in a mongodb acid transaction
-func (cr *CardRepository) SaveAll(ctx context.Context, cards []bingo.Card) error {
-	wc := writeconcern.New(writeconcern.WMajority())
-	rc := readconcern.Snapshot()
-	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
-	sess, err := cr.db.client.StartSession()
+func (cr *CardRepository) FindByNumbers(ctx context.Context, gameId string, numbers []int) ([]bingo.Card, error) {
+	gOid, err := primitive.ObjectIDFromHex(gameId)
 	if err != nil {
-		return err
+		return nil, ErrMalformedHexObjectID
 	}
-	err = mongo.WithSession(ctx, sess, func(sc mongo.SessionContext) error {
-		if err = sess.StartTransaction(txnOpts); err != nil {
-			return err
+	filter := bson.M{"game_id": gOid, "number": bson.M{"$in": numbers}}
+	cur, err := cr.db.Cards.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "number", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	cards := make([]bingo.Card, 0, len(numbers))
+	for cur.Next(ctx) {
+		var doc docCard
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
 		}
-		for _, c := range cards {
-			if err := cr.Save(ctx, &c); err != nil {
-				return err
-			}
+		c, err := doc.ToAggregate(nil)
+		if err != nil {
+			return nil, err
 		}
-		return nil
-	})
+		cards = append(cards, *c)
+	}
+
+	return cards, cur.Err()
+}
+
+func (cr *CardRepository) Assign(ctx context.Context, gameId string, numbers []int, playerId string) error {
+	gOid, err := primitive.ObjectIDFromHex(gameId)
 	if err != nil {
-		if tErr := sess.AbortTransaction(ctx); tErr != nil {
-			return tErr
-		}
+		return ErrMalformedHexObjectID
+	}
+	pOid, err := primitive.ObjectIDFromHex(playerId)
+	if err != nil {
+		return ErrMalformedHexObjectID
+	}
 
+	// Cards are only assigned if no player has them, so cards sold concurrently are not assigned twice
+	filter := unassignedFilter(gOid)
+	filter["number"] = bson.M{"$in": numbers}
+	res, err := cr.db.Cards.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"player_id": pOid}})
+	if err != nil {
 		return err
 	}
+	if res.ModifiedCount != int64(len(numbers)) {
+		return bingo.ErrCardAssigned
+	}
+
+	return nil
+}
+
+func (cr *CardRepository) CountUnassigned(ctx context.Context, gameId string) (int, error) {
+	gOid, err := primitive.ObjectIDFromHex(gameId)
+	if err != nil {
+		return 0, ErrMalformedHexObjectID
+	}
+	n, err := cr.db.Cards.CountDocuments(ctx, unassignedFilter(gOid))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// Filter of the cards of the game assigned to no player, which are not void. Null matches fields missing as well
+func unassignedFilter(gOid primitive.ObjectID) bson.M {
+	return bson.M{
+		"game_id":    gOid,
+		"player_id":  nil,
+		"revocation": nil,
+	}
+}
+
+// Save all given cards with Save() method. Use a transactor to save them atomically
+func (cr *CardRepository) SaveAll(ctx context.Context, cards []bingo.Card) error {
+	for i := range cards {
+		if err := cr.Save(ctx, &cards[i]); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -163,18 +250,16 @@ func (cr *CardRepository) Save(ctx context.Context, c *bingo.Card) error {
 	if err != nil {
 		return err
 	}
-	oid, err := primitive.ObjectIDFromHex(c.ID)
+	opts := options.Replace().SetUpsert(true)
+	_, err = cr.db.Cards.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return domainErr{domain: bingo.ErrCardNumberExists, cause: err}
+	}
 	if err != nil {
 		return err
 	}
-	opts := options.Update().SetUpsert(true)
-	res, err := cr.db.Cards.UpdateByID(ctx, oid, doc, opts)
-	oid, ok := res.UpsertedID.(primitive.ObjectID)
-	if !ok {
-		return errors.New("mongo: returned inserted id was not of type primitive.ObjectID")
-	}
 	if c.ID == "" {
-		c.ID = oid.Hex()
+		c.ID = doc.ID.Hex()
 	}
 
 	return nil
@@ -3,6 +3,7 @@ package mongo_test
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	bingo "github.com/nohns/bingo-box/server"
 	"github.com/nohns/bingo-box/server/mongo"
@@ -36,7 +37,7 @@ func TestDocCard(t *testing.T) {
 
 	t.Run("test data out of date", func(t *testing.T) {
 		cFieldsCount := reflect.Indirect(reflect.ValueOf(c)).NumField()
-		expectedfc := 6
+		expectedfc := 7
 		require.Equal(t, expectedfc, cFieldsCount, "game test data missing one or more fields")
 	})
 
@@ -48,4 +49,31 @@ func TestDocCard(t *testing.T) {
 		require.NoError(t, err, "no error exptected from doc.ToAggregate()")
 		require.EqualValues(t, c, cc, "Expected values of round-trip conversion to equal initial data")
 	})
+	t.Run("conversion of revoked card", func(t *testing.T) {
+		rc := *c
+		rc.Revocation = &bingo.CardRevocation{
+			RevokedBy: primitive.NewObjectID().Hex(),
+			Reason:    "paid with a fake note",
+			RevokedAt: time.Now().UTC().Truncate(time.Millisecond),
+		}
+
+		doc, err := mongo.DocFromCard(&rc)
+		require.NoError(t, err, "no error expected from mongo.DocFromCard")
+
+		cc, err := doc.ToAggregate(rc.Player)
+		require.NoError(t, err, "no error exptected from doc.ToAggregate()")
+		require.EqualValues(t, &rc, cc, "Expected revocation to survive round-trip conversion")
+	})
+	t.Run("conversion without player", func(t *testing.T) {
+		nc := *c
+		nc.PlayerID = ""
+
+		doc, err := mongo.DocFromCard(&nc)
+		require.NoError(t, err, "no error expected from mongo.DocFromCard")
+		require.True(t, doc.PlayerID.IsZero(), "player id of doc must be zero when card has no player")
+
+		cc, err := doc.ToAggregate(nil)
+		require.NoError(t, err, "no error exptected from doc.ToAggregate()")
+		require.Empty(t, cc.PlayerID, "player id must be empty when card has no player")
+	})
 }
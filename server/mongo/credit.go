@@ -0,0 +1,172 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DocCreditEntry struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	UserID         string             `bson:"user_id,omitempty"`
+	OrganizationID string             `bson:"organization_id,omitempty"`
+	GameID         string             `bson:"game_id,omitempty"`
+	Reason         string             `bson:"reason"`
+	Amount         int                `bson:"amount"`
+	Note           string             `bson:"note,omitempty"`
+	CreatedBy      string             `bson:"created_by"`
+	CreatedAt      time.Time          `bson:"created_at"`
+}
+
+func (de DocCreditEntry) ToAggregate() *bingo.CreditEntry {
+	return &bingo.CreditEntry{
+		ID:             de.ID.Hex(),
+		UserID:         de.UserID,
+		OrganizationID: de.OrganizationID,
+		GameID:         de.GameID,
+		Reason:         bingo.CreditReason(de.Reason),
+		Amount:         de.Amount,
+		Note:           de.Note,
+		CreatedBy:      de.CreatedBy,
+		CreatedAt:      de.CreatedAt,
+	}
+}
+
+func DocFromCreditEntry(e *bingo.CreditEntry) (DocCreditEntry, error) {
+	oid := primitive.NewObjectID()
+	if e.ID != "" {
+		var err error
+		oid, err = primitive.ObjectIDFromHex(e.ID)
+		if err != nil {
+			return DocCreditEntry{}, ErrMalformedHexObjectID
+		}
+	}
+
+	return DocCreditEntry{
+		ID:             oid,
+		UserID:         e.UserID,
+		OrganizationID: e.OrganizationID,
+		GameID:         e.GameID,
+		Reason:         string(e.Reason),
+		Amount:         e.Amount,
+		Note:           e.Note,
+		CreatedBy:      e.CreatedBy,
+		CreatedAt:      e.CreatedAt,
+	}, nil
+}
+
+// Query matching the entries of the account
+func creditAccountFilter(acc bingo.CreditAccount) bson.M {
+	if acc.OrganizationID != "" {
+		return bson.M{"organization_id": acc.OrganizationID}
+	}
+
+	return bson.M{"user_id": acc.UserID, "organization_id": bson.M{"$exists": false}}
+}
+
+type CreditRepository struct {
+	db *DB
+}
+
+// Append entry to the ledger. Entries are only ever inserted, never replaced
+func (cr *CreditRepository) Append(ctx context.Context, e *bingo.CreditEntry) error {
+	doc, err := DocFromCreditEntry(e)
+	if err != nil {
+		return err
+	}
+	if _, err := cr.db.CreditEntries.InsertOne(ctx, doc); err != nil {
+		return err
+	}
+	e.ID = doc.ID.Hex()
+
+	return nil
+}
+
+// Sum the amounts of the entries of the account
+func (cr *CreditRepository) Balance(ctx context.Context, acc bingo.CreditAccount) (int, error) {
+	pipeline := []bson.M{
+		{"$match": creditAccountFilter(acc)},
+		{"$group": bson.M{"_id": nil, "balance": bson.M{"$sum": "$amount"}}},
+	}
+	cur, err := cr.db.CreditEntries.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	// No entries means no credits
+	if !cur.Next(ctx) {
+		return 0, cur.Err()
+	}
+	var res struct {
+		Balance int `bson:"balance"`
+	}
+	if err := cur.Decode(&res); err != nil {
+		return 0, err
+	}
+
+	return res.Balance, nil
+}
+
+// List entries of the account, newest first
+func (cr *CreditRepository) List(ctx context.Context, acc bingo.CreditAccount) ([]bingo.CreditEntry, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := cr.db.CreditEntries.Find(ctx, creditAccountFilter(acc), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	entries := make([]bingo.CreditEntry, 0)
+	for cur.Next(ctx) {
+		var doc DocCreditEntry
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		entries = append(entries, *doc.ToAggregate())
+	}
+
+	return entries, cur.Err()
+}
+
+// Add amount to the game credits of the user or organization in the collection. Debits are only applied if the credits
+// cover them, so concurrent debits can not overdraw the account
+func addCredits(ctx context.Context, coll *mongo.Collection, id string, amount int, notFound error) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrMalformedHexObjectID
+	}
+
+	filter := bson.M{"_id": oid}
+	if amount < 0 {
+		filter["game_credits"] = bson.M{"$gte": -amount}
+	}
+	res, err := coll.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"game_credits": amount}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	// Tell owners who do not exist apart from owners lacking credits
+	n, err := coll.CountDocuments(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return bingo.ErrInsufficientCredits
+}
+
+func NewCreditRepository(db *DB) *CreditRepository {
+	return &CreditRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,90 @@
+package mongo_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mongo"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var implementsCreditRepo bingo.CreditRepository = &mongo.CreditRepository{}
+
+// Test that mongodb credit entry doc <-> credit entry conversion works
+func TestDocCreditEntry(t *testing.T) {
+	e := &bingo.CreditEntry{
+		ID:             primitive.NewObjectID().Hex(),
+		UserID:         "",
+		OrganizationID: primitive.NewObjectID().Hex(),
+		GameID:         primitive.NewObjectID().Hex(),
+		Reason:         bingo.CreditReasonCardsGenerated,
+		Amount:         -3,
+		Note:           "note",
+		CreatedBy:      primitive.NewObjectID().Hex(),
+		CreatedAt:      time.Now(),
+	}
+
+	t.Run("test data out of date", func(t *testing.T) {
+		fieldsCount := reflect.Indirect(reflect.ValueOf(e)).NumField()
+		expectedfc := 9
+		require.Equal(t, expectedfc, fieldsCount, "credit entry test data missing one or more fields")
+	})
+
+	t.Run("bidirectional conversion", func(t *testing.T) {
+		doc, err := mongo.DocFromCreditEntry(e)
+		require.NoError(t, err, "no error expected from mongo.DocFromCreditEntry")
+
+		ce := doc.ToAggregate()
+		require.EqualValues(t, e, ce, "expected values of round-trip conversion to equal initial data")
+	})
+}
+
+func TestCreditRepository_Balance(t *testing.T) {
+	ctx := context.Background()
+	creditRepo := mongo.NewCreditRepository(sharedDB)
+	userId := primitive.NewObjectID().Hex()
+	orgId := primitive.NewObjectID().Hex()
+
+	entries := []bingo.CreditEntry{
+		{UserID: userId, Reason: bingo.CreditReasonGrant, Amount: 10, CreatedAt: time.Now()},
+		{UserID: userId, Reason: bingo.CreditReasonCardsGenerated, Amount: -4, CreatedAt: time.Now()},
+		{OrganizationID: orgId, Reason: bingo.CreditReasonGrant, Amount: 7, CreatedAt: time.Now()},
+	}
+	for i := range entries {
+		require.NoError(t, creditRepo.Append(ctx, &entries[i]), "expected no error from appending entry")
+	}
+
+	cases := []struct {
+		cn       string
+		acc      bingo.CreditAccount
+		expected int
+	}{
+		{
+			cn:       "user",
+			acc:      bingo.CreditAccount{UserID: userId},
+			expected: 6,
+		},
+		{
+			cn:       "organization",
+			acc:      bingo.CreditAccount{OrganizationID: orgId},
+			expected: 7,
+		},
+		{
+			cn:       "no entries",
+			acc:      bingo.CreditAccount{UserID: primitive.NewObjectID().Hex()},
+			expected: 0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			balance, err := creditRepo.Balance(ctx, c.acc)
+			require.NoError(t, err, "expected no error")
+			require.Equal(t, c.expected, balance, "expected balance to be the sum of the account entries")
+		})
+	}
+}
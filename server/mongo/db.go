@@ -4,18 +4,24 @@ import (
 	"context"
 	"errors"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 )
 
 type DB struct {
-	client      *mongo.Client
-	Games       *mongo.Collection
-	Cards       *mongo.Collection
-	Users       *mongo.Collection
-	Players     *mongo.Collection
-	Invitations *mongo.Collection
+	client        *mongo.Client
+	Games         *mongo.Collection
+	Cards         *mongo.Collection
+	Users         *mongo.Collection
+	Players       *mongo.Collection
+	Invitations   *mongo.Collection
+	RefreshTokens *mongo.Collection
+	APIKeys       *mongo.Collection
+	Organizations *mongo.Collection
+	CreditEntries *mongo.Collection
+	Outbox        *mongo.Collection
 }
 
 func (db *DB) Close(ctx context.Context) error {
@@ -55,12 +61,148 @@ func New(ctx context.Context, connURI string) (*DB, error) {
 	}
 	db := client.Database(u.Database)
 
+	if err := createIndexes(ctx, db); err != nil {
+		return nil, err
+	}
+
 	return &DB{
-		client:      client,
-		Games:       db.Collection("games"),
-		Cards:       db.Collection("cards"),
-		Users:       db.Collection("users"),
-		Players:     db.Collection("players"),
-		Invitations: db.Collection("invitations"),
+		client:        client,
+		Games:         db.Collection("games"),
+		Cards:         db.Collection("cards"),
+		Users:         db.Collection("users"),
+		Players:       db.Collection("players"),
+		Invitations:   db.Collection("invitations"),
+		RefreshTokens: db.Collection("refresh_tokens"),
+		APIKeys:       db.Collection("api_keys"),
+		Organizations: db.Collection("organizations"),
+		CreditEntries: db.Collection("credit_entries"),
+		Outbox:        db.Collection("outbox"),
+	}, nil
+}
+
+// Codes of the errors of mongo commands
+const (
+	codeNamespaceNotFound = 26
+	codeIndexNotFound     = 27
+)
+
+// Create indexes the repositories rely on. Existing indexes are left as is, besides the ones replaced
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	// Users are registered once by email, also when registering concurrently
+	_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Tokens and api keys are looked up by their hash, which identify one of them
+	_, err = db.Collection("refresh_tokens").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("api_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Players can only join an invitation once with the same email. Players sold cards at the door may have no email,
+	// so only players with one are unique. The index replaces the one unique for all players
+	_, err = db.Collection("players").Indexes().DropOne(ctx, "invitation_id_1_email_1")
+	var cmdErr mongo.CommandError
+	if err != nil && !(errors.As(err, &cmdErr) && (cmdErr.Code == codeIndexNotFound || cmdErr.Code == codeNamespaceNotFound)) {
+		return err
+	}
+	_, err = db.Collection("players").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "invitation_id", Value: 1}, {Key: "email", Value: 1}},
+		Options: options.Index().
+			SetName("invitation_id_1_email_1_present").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"email": bson.M{"$gt": ""}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Players who do not confirm their email in time expire
+	_, err = db.Collection("players").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "confirm_by", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Card numbers are unique within a game, so cards generated concurrently can not share a number
+	_, err = db.Collection("cards").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "game_id", Value: 1}, {Key: "number", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Join codes resolve to one invitation. Invitations created before join codes existed have none
+	_, err = db.Collection("invitations").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "join_code", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+
+	return err
+}
+
+// Update pipeline replacing the stored document by the doc, but keeping the fields as stored. Fields changed
+// atomically elsewhere, e.g. balances, are then not overwritten when saving an entity read before they changed. The
+// fields of the doc are used when inserting
+func replaceKeeping(doc interface{}, fields ...string) (bson.A, error) {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	if err := bson.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+
+	kept := bson.M{}
+	for _, f := range fields {
+		kept[f] = bson.M{"$ifNull": bson.A{"$" + f, bson.M{"$literal": m[f]}}}
+	}
+	return bson.A{
+		bson.M{"$replaceWith": bson.M{"$mergeObjects": bson.A{bson.M{"$literal": m}, kept}}},
 	}, nil
 }
+
+// Error matching both a domain error and the underlying mongo error, so callers can check for either with errors.Is()
+type domainErr struct {
+	domain error
+	cause  error
+}
+
+func (e domainErr) Error() string {
+	return e.domain.Error() + ": " + e.cause.Error()
+}
+
+func (e domainErr) Is(target error) bool {
+	return target == e.domain
+}
+
+func (e domainErr) Unwrap() error {
+	return e.cause
+}
+
+// Translate a no documents error into the given domain error. Other errors are returned as is.
+func notFoundErr(err error, domain error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domainErr{domain: domain, cause: err}
+	}
+
+	return err
+}
@@ -14,23 +14,47 @@ type DocGame struct {
 	ID             primitive.ObjectID `bson:"_id"`
 	Name           string             `bson:"name"`
 	HostID         primitive.ObjectID `bson:"host_id"`
+	OrganizationID string             `bson:"organization_id,omitempty"`
 	NextCardNumber int                `bson:"next_card_number"`
 	CalledNumbers  []int              `bson:"called_numbers"`
+	Language       string             `bson:"language,omitempty"`
+	Members        []DocGameMember    `bson:"members"`
 	UpdatedAt      time.Time          `bson:"updated_at"`
 	CreatedAt      time.Time          `bson:"created_at"`
 }
 
+type DocGameMember struct {
+	UserID     string    `bson:"user_id,omitempty"`
+	Email      string    `bson:"email"`
+	Role       string    `bson:"role"`
+	InvitedAt  time.Time `bson:"invited_at"`
+	AcceptedAt time.Time `bson:"accepted_at"`
+}
+
 func (dg DocGame) ToAggregate() (*bingo.Game, error) {
 	calledNums := make([]bingo.Ball, 0, len(dg.CalledNumbers))
 	for _, n := range dg.CalledNumbers {
 		calledNums = append(calledNums, bingo.Ball{Number: n})
 	}
+	members := make([]bingo.GameMember, 0, len(dg.Members))
+	for _, m := range dg.Members {
+		members = append(members, bingo.GameMember{
+			UserID:     m.UserID,
+			Email:      m.Email,
+			Role:       bingo.GameRole(m.Role),
+			InvitedAt:  m.InvitedAt,
+			AcceptedAt: m.AcceptedAt,
+		})
+	}
 	g := &bingo.Game{
 		ID:             dg.ID.Hex(),
 		Name:           dg.Name,
 		HostId:         dg.HostID.Hex(),
+		OrganizationID: dg.OrganizationID,
+		Members:        members,
 		NextCardNumber: dg.NextCardNumber,
 		CalledNumbers:  calledNums,
+		Language:       bingo.Language(dg.Language),
 		UpdatedAt:      dg.UpdatedAt,
 		CreatedAt:      dg.CreatedAt,
 	}
@@ -57,12 +81,25 @@ func DocFromGame(g *bingo.Game) (DocGame, error) {
 	for _, cn := range g.CalledNumbers {
 		nums = append(nums, cn.Number)
 	}
+	members := make([]DocGameMember, 0, len(g.Members))
+	for _, m := range g.Members {
+		members = append(members, DocGameMember{
+			UserID:     m.UserID,
+			Email:      m.Email,
+			Role:       string(m.Role),
+			InvitedAt:  m.InvitedAt,
+			AcceptedAt: m.AcceptedAt,
+		})
+	}
 	return DocGame{
 		ID:             oid,
 		Name:           g.Name,
 		HostID:         hOid,
+		OrganizationID: g.OrganizationID,
 		NextCardNumber: g.NextCardNumber,
 		CalledNumbers:  nums,
+		Language:       string(g.Language),
+		Members:        members,
 		UpdatedAt:      g.UpdatedAt,
 		CreatedAt:      g.CreatedAt,
 	}, nil
@@ -80,7 +117,7 @@ func (gr *GameRepository) Get(ctx context.Context, id string) (*bingo.Game, erro
 	var doc DocGame
 	res := gr.db.Games.FindOne(ctx, bson.M{"_id": oid})
 	if err := res.Decode(&doc); err != nil {
-		return nil, err
+		return nil, notFoundErr(err, bingo.ErrGameNotFound)
 	}
 
 	aggr, err := doc.ToAggregate()
@@ -91,14 +128,66 @@ func (gr *GameRepository) Get(ctx context.Context, id string) (*bingo.Game, erro
 	return aggr, nil
 }
 
+// Find games matching the filter, newest first
+func (gr *GameRepository) Find(ctx context.Context, filter bingo.GameFilter) ([]bingo.Game, error) {
+	query := bson.M{}
+	if filter.HostID != "" {
+		hOid, err := primitive.ObjectIDFromHex(filter.HostID)
+		if err != nil {
+			return nil, ErrMalformedHexObjectID
+		}
+		query["host_id"] = hOid
+	}
+	if filter.OrganizationID != "" {
+		query["organization_id"] = filter.OrganizationID
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := gr.db.Games.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	games := make([]bingo.Game, 0)
+	for cur.Next(ctx) {
+		var doc DocGame
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		g, err := doc.ToAggregate()
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, *g)
+	}
+
+	return games, cur.Err()
+}
+
+// Save game to mongodb. The next card number is only set when the game is created, as it is allocated atomically
+// afterwards, so saving a game read before cards were generated does not hand out their numbers again
 func (gr *GameRepository) Save(ctx context.Context, g *bingo.Game) error {
 	g.UpdatedAt = time.Now()
 	doc, err := DocFromGame(g)
 	if err != nil {
 		return err
 	}
-	opts := options.Replace().SetUpsert(true)
-	res, err := gr.db.Games.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts)
+	update := bson.M{
+		"$set": bson.M{
+			"name":            doc.Name,
+			"host_id":         doc.HostID,
+			"organization_id": doc.OrganizationID,
+			"called_numbers":  doc.CalledNumbers,
+			"language":        doc.Language,
+			"members":         doc.Members,
+			"updated_at":      doc.UpdatedAt,
+			"created_at":      doc.CreatedAt,
+		},
+		"$setOnInsert": bson.M{"next_card_number": doc.NextCardNumber},
+	}
+	opts := options.Update().SetUpsert(true)
+	res, err := gr.db.Games.UpdateOne(ctx, bson.M{"_id": doc.ID}, update, opts)
 	if err != nil {
 		return err
 	}
@@ -116,6 +205,23 @@ func (gr *GameRepository) Save(ctx context.Context, g *bingo.Game) error {
 	return nil
 }
 
+// Allocate the amount of card numbers by incrementing the next card number of the game, and return the first of them
+func (gr *GameRepository) AllocateCardNumbers(ctx context.Context, id string, amount int) (int, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, ErrMalformedHexObjectID
+	}
+
+	var doc DocGame
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	res := gr.db.Games.FindOneAndUpdate(ctx, bson.M{"_id": oid}, bson.M{"$inc": bson.M{"next_card_number": amount}}, opts)
+	if err := res.Decode(&doc); err != nil {
+		return 0, notFoundErr(err, bingo.ErrGameNotFound)
+	}
+
+	return doc.NextCardNumber, nil
+}
+
 func NewGameRepository(db *DB) *GameRepository {
 	return &GameRepository{
 		db: db,
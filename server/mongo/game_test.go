@@ -23,6 +23,21 @@ func TestDocGame(t *testing.T) {
 		Name:           "game name",
 		HostId:         primitive.NewObjectID().Hex(),
 		Host:           nil,
+		OrganizationID: primitive.NewObjectID().Hex(),
+		Members: []bingo.GameMember{
+			{
+				UserID:     primitive.NewObjectID().Hex(),
+				Email:      "cohost@test.com",
+				Role:       bingo.GameRoleCohost,
+				InvitedAt:  time.Now(),
+				AcceptedAt: time.Now(),
+			},
+			{
+				Email:     "caller@test.com",
+				Role:      bingo.GameRoleCaller,
+				InvitedAt: time.Now(),
+			},
+		},
 		NextCardNumber: 1,
 		CalledNumbers: []bingo.Ball{
 			{
@@ -32,13 +47,14 @@ func TestDocGame(t *testing.T) {
 				Number: 5,
 			},
 		},
+		Language:  bingo.LanguageDanish,
 		UpdatedAt: time.Now(),
 		CreatedAt: time.Now(),
 	}
 
 	t.Run("test data out of date", func(t *testing.T) {
 		gFieldsCount := reflect.Indirect(reflect.ValueOf(g)).NumField()
-		expectedfc := 8
+		expectedfc := 11
 		require.Equal(t, expectedfc, gFieldsCount, "game test data missing one or more fields")
 	})
 
@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"errors"
+	"time"
 
 	bingo "github.com/nohns/bingo-box/server"
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,35 +17,92 @@ type DocInvitation struct {
 	DeliveryMethod string             `bson:"delivery_method"`
 	MaxCardAmount  int                `bson:"max_card_amount"`
 	Active         bool               `bson:"active"`
+	JoinCode       string             `bson:"join_code,omitempty"`
 
 	GameID primitive.ObjectID `bson:"game_id"`
 
 	Criteria []DocInvitationCriterion `json:"criteria,inline"`
+
+	OpensAt       time.Time `bson:"opens_at,omitempty"`
+	ExpiresAt     time.Time `bson:"expires_at,omitempty"`
+	MaxPlayers    int       `bson:"max_players"`
+	MaxTotalCards int       `bson:"max_total_cards"`
+	PlayerCount   int       `bson:"player_count"`
+	CardCount     int       `bson:"card_count"`
+	WaitlistCount int       `bson:"waitlist_count"`
+
+	RequireConfirmation bool `bson:"require_confirmation"`
 }
 
 type DocInvitationCriterion struct {
-	Kind  string `bson:"kind"`
-	Field string `bson:"field"`
-	Value string `bson:"value"`
+	Kind     string                   `bson:"kind"`
+	Field    string                   `bson:"field"`
+	Value    string                   `bson:"value"`
+	Values   []string                 `bson:"values,omitempty"`
+	Criteria []DocInvitationCriterion `bson:"criteria,omitempty"`
 }
 
-func (di DocInvitation) ToAggregate(g *bingo.Game) (*bingo.Invitation, error) {
-	criteria := make([]bingo.InvitationCriterion, 0, len(di.Criteria))
-	for _, c := range di.Criteria {
+// Convert criteria docs, including the criteria they group
+func criteriaFromDocs(docs []DocInvitationCriterion) []bingo.InvitationCriterion {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	criteria := make([]bingo.InvitationCriterion, 0, len(docs))
+	for _, c := range docs {
 		criteria = append(criteria, bingo.InvitationCriterion{
-			Kind:  bingo.InvitationCriterionKind(c.Kind),
-			Field: bingo.InvitationCriterionField(c.Field),
-			Value: c.Value,
+			Kind:     bingo.InvitationCriterionKind(c.Kind),
+			Field:    bingo.InvitationCriterionField(c.Field),
+			Value:    c.Value,
+			Values:   c.Values,
+			Criteria: criteriaFromDocs(c.Criteria),
+		})
+	}
+
+	return criteria
+}
+
+// Convert criteria to docs, including the criteria they group
+func docsFromCriteria(criteria []bingo.InvitationCriterion) []DocInvitationCriterion {
+	if len(criteria) == 0 {
+		return nil
+	}
+
+	docs := make([]DocInvitationCriterion, 0, len(criteria))
+	for _, c := range criteria {
+		docs = append(docs, DocInvitationCriterion{
+			Kind:     string(c.Kind),
+			Field:    string(c.Field),
+			Value:    c.Value,
+			Values:   c.Values,
+			Criteria: docsFromCriteria(c.Criteria),
 		})
 	}
+
+	return docs
+}
+
+func (di DocInvitation) ToAggregate(g *bingo.Game) (*bingo.Invitation, error) {
+	criteria := make([]bingo.InvitationCriterion, 0, len(di.Criteria))
+	criteria = append(criteria, criteriaFromDocs(di.Criteria)...)
 	inv := &bingo.Invitation{
 		ID:             di.ID.Hex(),
 		DeliveryMethod: bingo.InvitationDeliveryMethod(di.DeliveryMethod),
 		MaxCardAmount:  di.MaxCardAmount,
 		Active:         di.Active,
+		JoinCode:       di.JoinCode,
 		GameID:         di.GameID.Hex(),
 		Game:           g,
 		Criteria:       criteria,
+		OpensAt:        di.OpensAt,
+		ExpiresAt:      di.ExpiresAt,
+		MaxPlayers:     di.MaxPlayers,
+		MaxTotalCards:  di.MaxTotalCards,
+		PlayerCount:    di.PlayerCount,
+		CardCount:      di.CardCount,
+		WaitlistCount:  di.WaitlistCount,
+
+		RequireConfirmation: di.RequireConfirmation,
 	}
 	if err := inv.Validate(); err != nil {
 		return nil, err
@@ -66,20 +124,24 @@ func DocFromInvitation(inv *bingo.Invitation) (DocInvitation, error) {
 		return DocInvitation{}, ErrMalformedHexObjectID
 	}
 	criteria := make([]DocInvitationCriterion, 0, len(inv.Criteria))
-	for _, c := range inv.Criteria {
-		criteria = append(criteria, DocInvitationCriterion{
-			Kind:  string(c.Kind),
-			Field: string(c.Field),
-			Value: c.Value,
-		})
-	}
+	criteria = append(criteria, docsFromCriteria(inv.Criteria)...)
 	doc := DocInvitation{
 		ID:             oid,
 		DeliveryMethod: string(inv.DeliveryMethod),
 		MaxCardAmount:  inv.MaxCardAmount,
 		Active:         inv.Active,
+		JoinCode:       inv.JoinCode,
 		GameID:         gOid,
 		Criteria:       criteria,
+		OpensAt:        inv.OpensAt,
+		ExpiresAt:      inv.ExpiresAt,
+		MaxPlayers:     inv.MaxPlayers,
+		MaxTotalCards:  inv.MaxTotalCards,
+		PlayerCount:    inv.PlayerCount,
+		CardCount:      inv.CardCount,
+		WaitlistCount:  inv.WaitlistCount,
+
+		RequireConfirmation: inv.RequireConfirmation,
 	}
 	return doc, nil
 }
@@ -93,15 +155,25 @@ func (ir *InvitationRepository) Get(ctx context.Context, id string) (*bingo.Invi
 	if err != nil {
 		return nil, ErrMalformedHexObjectID
 	}
+
+	return ir.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (ir *InvitationRepository) GetByJoinCode(ctx context.Context, code string) (*bingo.Invitation, error) {
+	return ir.findOne(ctx, bson.M{"join_code": code})
+}
+
+// Find the invitation matching the filter along with its game
+func (ir *InvitationRepository) findOne(ctx context.Context, filter bson.M) (*bingo.Invitation, error) {
 	var doc DocInvitation
-	res := ir.db.Invitations.FindOne(ctx, bson.M{"_id": oid})
+	res := ir.db.Invitations.FindOne(ctx, filter)
 	if err := res.Decode(&doc); err != nil {
-		return nil, err
+		return nil, notFoundErr(err, bingo.ErrInvitationNotFound)
 	}
 
 	// Find associated game to invitation
 	gDoc := &DocGame{}
-	err = ir.db.Games.FindOne(ctx, bson.M{"_id": doc.GameID}).Decode(gDoc)
+	err := ir.db.Games.FindOne(ctx, bson.M{"_id": doc.GameID}).Decode(gDoc)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		gDoc, err = nil, nil
 	} else if err != nil {
@@ -128,8 +200,35 @@ func (ir *InvitationRepository) Save(ctx context.Context, inv *bingo.Invitation)
 	if err != nil {
 		return err
 	}
-	opts := options.Replace().SetUpsert(true)
-	res, err := ir.db.Invitations.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts)
+
+	// Counters are maintained by reserving and releasing places, so they are only set when inserting. Otherwise a
+	// stale copy of the invitation would overwrite players reserved since it was fetched
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var set bson.M
+	if err := bson.Unmarshal(raw, &set); err != nil {
+		return err
+	}
+	delete(set, "_id")
+	delete(set, "player_count")
+	delete(set, "card_count")
+	delete(set, "waitlist_count")
+	update := bson.M{
+		"$set": set,
+		"$setOnInsert": bson.M{
+			"player_count":   doc.PlayerCount,
+			"card_count":     doc.CardCount,
+			"waitlist_count": doc.WaitlistCount,
+		},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	res, err := ir.db.Invitations.UpdateOne(ctx, bson.M{"_id": doc.ID}, update, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return domainErr{domain: bingo.ErrJoinCodeTaken, cause: err}
+	}
 	if err != nil {
 		return err
 	}
@@ -147,6 +246,163 @@ func (ir *InvitationRepository) Save(ctx context.Context, inv *bingo.Invitation)
 	return nil
 }
 
+// Filter matching the invitation if it is unlimited or has room for the amount of cards, and a player unless only
+// cards are reserved, so concurrent reservations can not exceed the limits. Invitations stored before limits existed
+// have no max fields, and are unlimited
+func roomFilter(oid primitive.ObjectID, withPlayer bool, cardAmount int) bson.M {
+	limits := bson.A{
+		bson.M{"$or": bson.A{
+			bson.M{"max_total_cards": bson.M{"$in": bson.A{0, nil}}},
+			bson.M{"$expr": bson.M{"$lte": bson.A{bson.M{"$add": bson.A{"$card_count", cardAmount}}, "$max_total_cards"}}},
+		}},
+	}
+	if withPlayer {
+		limits = append(limits, bson.M{"$or": bson.A{
+			bson.M{"max_players": bson.M{"$in": bson.A{0, nil}}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$player_count", "$max_players"}}},
+		}})
+	}
+
+	return bson.M{"_id": oid, "$and": limits}
+}
+
+func (ir *InvitationRepository) Reserve(ctx context.Context, inv *bingo.Invitation, cardAmount int) error {
+	oid, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return ErrMalformedHexObjectID
+	}
+
+	// Players joining can not take the room of the players waiting in line
+	filter := roomFilter(oid, true, cardAmount)
+	filter["waitlist_count"] = bson.M{"$in": bson.A{0, nil}}
+	update := bson.M{"$inc": bson.M{"player_count": 1, "card_count": cardAmount}}
+	res, err := ir.db.Invitations.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return bingo.ErrInvitationFull
+	}
+	inv.PlayerCount++
+	inv.CardCount += cardAmount
+
+	return nil
+}
+
+func (ir *InvitationRepository) ReserveCards(ctx context.Context, inv *bingo.Invitation, cardAmount int) error {
+	oid, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return ErrMalformedHexObjectID
+	}
+
+	filter := roomFilter(oid, false, cardAmount)
+	filter["waitlist_count"] = bson.M{"$in": bson.A{0, nil}}
+	res, err := ir.db.Invitations.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"card_count": cardAmount}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return bingo.ErrInvitationFull
+	}
+	inv.CardCount += cardAmount
+
+	return nil
+}
+
+func (ir *InvitationRepository) ReserveWaitlisted(ctx context.Context, inv *bingo.Invitation, cardAmount int) error {
+	oid, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return ErrMalformedHexObjectID
+	}
+
+	filter := roomFilter(oid, true, cardAmount)
+	filter["waitlist_count"] = bson.M{"$gt": 0}
+	update := bson.M{"$inc": bson.M{"player_count": 1, "card_count": cardAmount, "waitlist_count": -1}}
+	res, err := ir.db.Invitations.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return bingo.ErrInvitationFull
+	}
+	inv.PlayerCount++
+	inv.CardCount += cardAmount
+	inv.WaitlistCount--
+
+	return nil
+}
+
+func (ir *InvitationRepository) Waitlist(ctx context.Context, inv *bingo.Invitation) (int, error) {
+	oid, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return 0, ErrMalformedHexObjectID
+	}
+
+	// Positions are taken from a sequence, which unlike the count of waiting players never decreases
+	var doc struct {
+		WaitlistSeq   int `bson:"waitlist_seq"`
+		WaitlistCount int `bson:"waitlist_count"`
+	}
+	update := bson.M{"$inc": bson.M{"waitlist_seq": 1, "waitlist_count": 1}}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"waitlist_seq": 1, "waitlist_count": 1})
+	err = ir.db.Invitations.FindOneAndUpdate(ctx, bson.M{"_id": oid}, update, opts).Decode(&doc)
+	if err != nil {
+		return 0, notFoundErr(err, bingo.ErrInvitationNotFound)
+	}
+	inv.WaitlistCount = doc.WaitlistCount
+
+	return doc.WaitlistSeq, nil
+}
+
+func (ir *InvitationRepository) Release(ctx context.Context, inv *bingo.Invitation, p *bingo.Player) error {
+	oid, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return ErrMalformedHexObjectID
+	}
+
+	// Players yet to confirm their email have not been counted
+	if p.Status == bingo.PlayerStatusUnconfirmed {
+		return nil
+	}
+
+	counter := "player_count"
+	if p.Status == bingo.PlayerStatusWaitlisted {
+		counter = "waitlist_count"
+	}
+	filter := bson.M{"_id": oid, counter: bson.M{"$gt": 0}}
+	if _, err := ir.db.Invitations.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{counter: -1}}); err != nil {
+		return err
+	}
+	if p.Status == bingo.PlayerStatusWaitlisted {
+		inv.WaitlistCount--
+	} else {
+		inv.PlayerCount--
+	}
+
+	return nil
+}
+
+func (ir *InvitationRepository) ReleaseCards(ctx context.Context, inv *bingo.Invitation, cardAmount int) error {
+	oid, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return ErrMalformedHexObjectID
+	}
+
+	// Never count fewer cards than none, should the counts be out of sync
+	filter := bson.M{"_id": oid, "card_count": bson.M{"$gte": cardAmount}}
+	if _, err := ir.db.Invitations.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"card_count": -cardAmount}}); err != nil {
+		return err
+	}
+	inv.CardCount -= cardAmount
+	if inv.CardCount < 0 {
+		inv.CardCount = 0
+	}
+
+	return nil
+}
+
 func NewInvitationRepository(db *DB) *InvitationRepository {
 	return &InvitationRepository{
 		db: db,
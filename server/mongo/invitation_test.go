@@ -23,6 +23,7 @@ func TestDocInvitation(t *testing.T) {
 		DeliveryMethod: bingo.InvitationDeliveryMethodDownload,
 		MaxCardAmount:  3,
 		Active:         true,
+		JoinCode:       "7K3M9QXD",
 		GameID:         primitive.NewObjectID().Hex(),
 		Game:           nil,
 		Criteria: []bingo.InvitationCriterion{
@@ -31,12 +32,32 @@ func TestDocInvitation(t *testing.T) {
 				Field: bingo.InvitationCriterionFieldEmail,
 				Value: "email regex",
 			},
+			{
+				Kind: bingo.InvitationCriterionKindNot,
+				Criteria: []bingo.InvitationCriterion{
+					{
+						Kind:   bingo.InvitationCriterionKindOneOf,
+						Field:  bingo.InvitationCriterionFieldEmail,
+						Values: []string{"banned@test.com"},
+					},
+				},
+			},
 		},
+		OpensAt:       time.Now(),
+		ExpiresAt:     time.Now().Add(time.Hour),
+		MaxPlayers:    100,
+		MaxTotalCards: 300,
+		PlayerCount:   12,
+		CardCount:     30,
+		WaitlistCount: 2,
+
+		RequireConfirmation: true,
 	}
 
 	t.Run("test data out of date", func(t *testing.T) {
+		// Fields include the unexported cache of compiled criteria
 		invFieldsCount := reflect.Indirect(reflect.ValueOf(inv)).NumField()
-		expectedfc := 7
+		expectedfc := 17
 		require.Equal(t, expectedfc, invFieldsCount, "invitation test data missing one or more fields")
 	})
 
@@ -240,6 +261,188 @@ func TestInvitationRepository_Save(t *testing.T) {
 	}
 }
 
+func TestInvitationRepository_Reserve(t *testing.T) {
+	invRepo := mongo.NewInvitationRepository(sharedDB)
+
+	newDoc := func(maxPlayers, maxTotalCards, playerCount, cardCount, waitlistCount int) mongo.DocInvitation {
+		doc := mongo.DocInvitation{
+			ID:             primitive.NewObjectID(),
+			DeliveryMethod: string(bingo.InvitationDeliveryMethodDownload),
+			MaxCardAmount:  3,
+			Active:         true,
+			GameID:         primitive.NewObjectID(),
+			MaxPlayers:     maxPlayers,
+			MaxTotalCards:  maxTotalCards,
+			PlayerCount:    playerCount,
+			CardCount:      cardCount,
+			WaitlistCount:  waitlistCount,
+		}
+		MustInsertOneInvDoc(t, context.Background(), doc)
+		return doc
+	}
+
+	cases := []struct {
+		cn            string
+		doc           mongo.DocInvitation
+		cardAmount    int
+		waitlisted    bool
+		expectedErrIs error
+	}{
+		{
+			cn:         "success unlimited",
+			doc:        newDoc(0, 0, 40, 100, 0),
+			cardAmount: 3,
+		},
+		{
+			cn:         "success last player",
+			doc:        newDoc(10, 0, 9, 20, 0),
+			cardAmount: 3,
+		},
+		{
+			cn:         "success last cards",
+			doc:        newDoc(0, 30, 9, 27, 0),
+			cardAmount: 3,
+		},
+		{
+			cn:            "fail players full",
+			doc:           newDoc(10, 0, 10, 20, 0),
+			cardAmount:    1,
+			expectedErrIs: bingo.ErrInvitationFull,
+		},
+		{
+			cn:            "fail too few cards left",
+			doc:           newDoc(0, 30, 9, 28, 0),
+			cardAmount:    3,
+			expectedErrIs: bingo.ErrInvitationFull,
+		},
+		{
+			cn:            "fail players waiting in line",
+			doc:           newDoc(10, 0, 5, 20, 1),
+			cardAmount:    1,
+			expectedErrIs: bingo.ErrInvitationFull,
+		},
+		{
+			cn:         "success first in line",
+			doc:        newDoc(10, 0, 5, 20, 2),
+			cardAmount: 1,
+			waitlisted: true,
+		},
+		{
+			cn:            "fail first in line without room",
+			doc:           newDoc(10, 0, 10, 20, 2),
+			cardAmount:    1,
+			waitlisted:    true,
+			expectedErrIs: bingo.ErrInvitationFull,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			ctx := context.Background()
+			inv, err := c.doc.ToAggregate(nil)
+			require.NoError(t, err, "expected no error from invitation aggregate conversion")
+
+			if c.waitlisted {
+				err = invRepo.ReserveWaitlisted(ctx, inv, c.cardAmount)
+			} else {
+				err = invRepo.Reserve(ctx, inv, c.cardAmount)
+			}
+			doc := MustFindOneInvDoc(t, ctx, inv.ID)
+			if c.expectedErrIs != nil {
+				require.ErrorIs(t, err, c.expectedErrIs, "expected different error")
+				require.Equal(t, c.doc.PlayerCount, doc.PlayerCount, "expected player count to be unchanged")
+				require.Equal(t, c.doc.CardCount, doc.CardCount, "expected card count to be unchanged")
+				require.Equal(t, c.doc.WaitlistCount, doc.WaitlistCount, "expected waitlist count to be unchanged")
+				return
+			}
+			if c.waitlisted {
+				require.Equal(t, c.doc.WaitlistCount-1, doc.WaitlistCount, "expected player to be taken off the waitlist")
+			}
+
+			require.NoError(t, err, "expected no error")
+			require.Equal(t, c.doc.PlayerCount+1, doc.PlayerCount, "expected player to be counted")
+			require.Equal(t, c.doc.CardCount+c.cardAmount, doc.CardCount, "expected cards to be counted")
+			require.Equal(t, doc.PlayerCount, inv.PlayerCount, "expected player count of invitation to be updated")
+			require.Equal(t, doc.CardCount, inv.CardCount, "expected card count of invitation to be updated")
+		})
+	}
+}
+
+func TestInvitationRepository_Waitlist(t *testing.T) {
+	invRepo := mongo.NewInvitationRepository(sharedDB)
+	ctx := context.Background()
+
+	doc := mongo.DocInvitation{
+		ID:             primitive.NewObjectID(),
+		DeliveryMethod: string(bingo.InvitationDeliveryMethodDownload),
+		MaxCardAmount:  3,
+		Active:         true,
+		GameID:         primitive.NewObjectID(),
+		MaxPlayers:     1,
+		PlayerCount:    1,
+	}
+	MustInsertOneInvDoc(t, ctx, doc)
+	inv, err := doc.ToAggregate(nil)
+	require.NoError(t, err, "expected no error from invitation aggregate conversion")
+
+	first, err := invRepo.Waitlist(ctx, inv)
+	require.NoError(t, err, "expected no error")
+	second, err := invRepo.Waitlist(ctx, inv)
+	require.NoError(t, err, "expected no error")
+	require.Less(t, first, second, "expected positions in line to increase")
+	require.Equal(t, 2, inv.WaitlistCount, "expected waitlist count of invitation to be updated")
+
+	// Positions are not handed out again once players leave the line
+	err = invRepo.Release(ctx, inv, &bingo.Player{Status: bingo.PlayerStatusWaitlisted})
+	require.NoError(t, err, "expected no error")
+	third, err := invRepo.Waitlist(ctx, inv)
+	require.NoError(t, err, "expected no error")
+	require.Less(t, second, third, "expected positions in line to increase after release")
+
+	saved := MustFindOneInvDoc(t, ctx, inv.ID)
+	require.Equal(t, 2, saved.WaitlistCount, "expected waitlisted players to be counted")
+}
+
+func TestInvitationRepository_GetByJoinCode(t *testing.T) {
+	invRepo := mongo.NewInvitationRepository(sharedDB)
+	ctx := context.Background()
+
+	code, err := bingo.NewJoinCode()
+	require.NoError(t, err, "expected no error generating join code")
+	doc := mongo.DocInvitation{
+		ID:             primitive.NewObjectID(),
+		DeliveryMethod: string(bingo.InvitationDeliveryMethodDownload),
+		MaxCardAmount:  3,
+		Active:         true,
+		JoinCode:       code,
+		GameID:         primitive.NewObjectID(),
+	}
+	MustInsertOneInvDoc(t, ctx, doc)
+
+	inv, err := invRepo.GetByJoinCode(ctx, code)
+	require.NoError(t, err, "expected no error")
+	require.Equal(t, doc.ID.Hex(), inv.ID, "expected invitation of the code")
+
+	_, err = invRepo.GetByJoinCode(ctx, "00000000")
+	require.ErrorIs(t, err, bingo.ErrInvitationNotFound, "expected unknown code not to be found")
+
+	// Codes are unique, while invitations without one are not affected
+	other := &bingo.Invitation{
+		DeliveryMethod: bingo.InvitationDeliveryMethodDownload,
+		MaxCardAmount:  3,
+		Active:         true,
+		JoinCode:       code,
+		GameID:         primitive.NewObjectID().Hex(),
+	}
+	err = invRepo.Save(ctx, other)
+	require.ErrorIs(t, err, bingo.ErrJoinCodeTaken, "expected taken code to be rejected")
+	other.JoinCode = ""
+	require.NoError(t, invRepo.Save(ctx, other), "expected invitation without code to be saved")
+	another := *other
+	another.ID = ""
+	require.NoError(t, invRepo.Save(ctx, &another), "expected more invitations without code to be saved")
+}
+
 func MustInsertOneInvDoc(tb testing.TB, ctx context.Context, doc mongo.DocInvitation) {
 	tb.Helper()
 
@@ -0,0 +1,171 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DocOrganization struct {
+	ID          primitive.ObjectID      `bson:"_id"`
+	Name        string                  `bson:"name"`
+	Members     []DocOrganizationMember `bson:"members"`
+	GameCredits int                     `bson:"game_credits"`
+	UpdatedAt   time.Time               `bson:"updated_at"`
+	CreatedAt   time.Time               `bson:"created_at"`
+}
+
+type DocOrganizationMember struct {
+	UserID  primitive.ObjectID `bson:"user_id"`
+	Role    string             `bson:"role"`
+	AddedAt time.Time          `bson:"added_at"`
+}
+
+func (do DocOrganization) ToAggregate() (*bingo.Organization, error) {
+	members := make([]bingo.OrganizationMember, 0, len(do.Members))
+	for _, m := range do.Members {
+		members = append(members, bingo.OrganizationMember{
+			UserID:  m.UserID.Hex(),
+			Role:    bingo.OrganizationRole(m.Role),
+			AddedAt: m.AddedAt,
+		})
+	}
+	org := &bingo.Organization{
+		ID:          do.ID.Hex(),
+		Name:        do.Name,
+		Members:     members,
+		GameCredits: do.GameCredits,
+		UpdatedAt:   do.UpdatedAt,
+		CreatedAt:   do.CreatedAt,
+	}
+	if err := org.Validate(); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func DocFromOrganization(org *bingo.Organization) (DocOrganization, error) {
+	oid := primitive.NewObjectID()
+	if org.ID != "" {
+		var err error
+		oid, err = primitive.ObjectIDFromHex(org.ID)
+		if err != nil {
+			return DocOrganization{}, ErrMalformedHexObjectID
+		}
+	}
+	members := make([]DocOrganizationMember, 0, len(org.Members))
+	for _, m := range org.Members {
+		uOid, err := primitive.ObjectIDFromHex(m.UserID)
+		if err != nil {
+			return DocOrganization{}, ErrMalformedHexObjectID
+		}
+		members = append(members, DocOrganizationMember{
+			UserID:  uOid,
+			Role:    string(m.Role),
+			AddedAt: m.AddedAt,
+		})
+	}
+
+	return DocOrganization{
+		ID:          oid,
+		Name:        org.Name,
+		Members:     members,
+		GameCredits: org.GameCredits,
+		UpdatedAt:   org.UpdatedAt,
+		CreatedAt:   org.CreatedAt,
+	}, nil
+}
+
+type OrganizationRepository struct {
+	db *DB
+}
+
+func (or *OrganizationRepository) Get(ctx context.Context, id string) (*bingo.Organization, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrMalformedHexObjectID
+	}
+
+	var doc DocOrganization
+	err = or.db.Organizations.FindOne(ctx, bson.M{"_id": oid}).Decode(&doc)
+	if err != nil {
+		return nil, notFoundErr(err, bingo.ErrOrganizationNotFound)
+	}
+
+	return doc.ToAggregate()
+}
+
+// List organizations the user is member of, ordered by name
+func (or *OrganizationRepository) ListByMember(ctx context.Context, userId string) ([]bingo.Organization, error) {
+	uOid, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, ErrMalformedHexObjectID
+	}
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cur, err := or.db.Organizations.Find(ctx, bson.M{"members.user_id": uOid}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	orgs := make([]bingo.Organization, 0)
+	for cur.Next(ctx) {
+		var doc DocOrganization
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		org, err := doc.ToAggregate()
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *org)
+	}
+
+	return orgs, cur.Err()
+}
+
+// Save organization to mongodb. The game credits are only set when the organization is created, as they are added
+// atomically afterwards
+func (or *OrganizationRepository) Save(ctx context.Context, org *bingo.Organization) error {
+	doc, err := DocFromOrganization(org)
+	if err != nil {
+		return err
+	}
+	update, err := replaceKeeping(doc, "game_credits")
+	if err != nil {
+		return err
+	}
+	opts := options.Update().SetUpsert(true)
+	res, err := or.db.Organizations.UpdateOne(ctx, bson.M{"_id": doc.ID}, update, opts)
+	if err != nil {
+		return err
+	}
+	if org.ID == "" {
+		if res.UpsertedID == nil {
+			return ErrNoUpsertedObjectID
+		}
+		oid, ok := res.UpsertedID.(primitive.ObjectID)
+		if !ok {
+			return ErrNoUpsertedObjectID
+		}
+		org.ID = oid.Hex()
+	}
+
+	return nil
+}
+
+// Add amount to the game credits of the organization. Debits are only applied if the credits cover them
+func (or *OrganizationRepository) AddCredits(ctx context.Context, id string, amount int) error {
+	return addCredits(ctx, or.db.Organizations, id, amount, bingo.ErrOrganizationNotFound)
+}
+
+func NewOrganizationRepository(db *DB) *OrganizationRepository {
+	return &OrganizationRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,100 @@
+package mongo_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mongo"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var implementsOrganizationRepo bingo.OrganizationRepository = &mongo.OrganizationRepository{}
+
+// Test that mongodb organization doc <-> organization aggregate root conversion works
+func TestDocOrganization(t *testing.T) {
+	org := &bingo.Organization{
+		ID:   primitive.NewObjectID().Hex(),
+		Name: "bingo association",
+		Members: []bingo.OrganizationMember{
+			{
+				UserID:  primitive.NewObjectID().Hex(),
+				Role:    bingo.OrganizationRoleAdmin,
+				AddedAt: time.Now(),
+			},
+			{
+				UserID:  primitive.NewObjectID().Hex(),
+				Role:    bingo.OrganizationRoleMember,
+				AddedAt: time.Now(),
+			},
+		},
+		GameCredits: 10,
+		UpdatedAt:   time.Now(),
+		CreatedAt:   time.Now(),
+	}
+
+	t.Run("test data out of date", func(t *testing.T) {
+		fieldsCount := reflect.Indirect(reflect.ValueOf(org)).NumField()
+		expectedfc := 6
+		require.Equal(t, expectedfc, fieldsCount, "organization test data missing one or more fields")
+	})
+
+	t.Run("bidirectional conversion", func(t *testing.T) {
+		doc, err := mongo.DocFromOrganization(org)
+		require.NoError(t, err, "no error expected from mongo.DocFromOrganization")
+
+		corg, err := doc.ToAggregate()
+		require.NoError(t, err, "no error expected from DocOrganization.ToAggregate")
+		require.EqualValues(t, org, corg, "expected values of round-trip conversion to equal initial data")
+	})
+}
+
+func TestOrganizationRepository_ListByMember(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := mongo.NewOrganizationRepository(sharedDB)
+	userOid := primitive.NewObjectID()
+	memberDoc := mongo.DocOrganization{
+		ID:   primitive.NewObjectID(),
+		Name: "b association",
+		Members: []mongo.DocOrganizationMember{
+			{UserID: primitive.NewObjectID(), Role: string(bingo.OrganizationRoleAdmin)},
+			{UserID: userOid, Role: string(bingo.OrganizationRoleMember)},
+		},
+		CreatedAt: time.Now(),
+	}
+	adminDoc := mongo.DocOrganization{
+		ID:   primitive.NewObjectID(),
+		Name: "a association",
+		Members: []mongo.DocOrganizationMember{
+			{UserID: userOid, Role: string(bingo.OrganizationRoleAdmin)},
+		},
+		CreatedAt: time.Now(),
+	}
+	otherDoc := mongo.DocOrganization{
+		ID:   primitive.NewObjectID(),
+		Name: "other association",
+		Members: []mongo.DocOrganizationMember{
+			{UserID: primitive.NewObjectID(), Role: string(bingo.OrganizationRoleAdmin)},
+		},
+		CreatedAt: time.Now(),
+	}
+	MustInsertOneOrganizationDoc(t, ctx, memberDoc)
+	MustInsertOneOrganizationDoc(t, ctx, adminDoc)
+	MustInsertOneOrganizationDoc(t, ctx, otherDoc)
+
+	orgs, err := orgRepo.ListByMember(ctx, userOid.Hex())
+	require.NoError(t, err, "expected no error")
+	require.Len(t, orgs, 2, "expected only organizations of the user")
+	require.Equal(t, adminDoc.ID.Hex(), orgs[0].ID, "expected organizations ordered by name")
+	require.Equal(t, memberDoc.ID.Hex(), orgs[1].ID, "expected organizations ordered by name")
+}
+
+func MustInsertOneOrganizationDoc(tb testing.TB, ctx context.Context, doc mongo.DocOrganization) {
+	tb.Helper()
+
+	_, err := sharedDB.Organizations.InsertOne(ctx, doc)
+	require.NoError(tb, err, "expected no error from inserting organization")
+}
@@ -0,0 +1,148 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DocOutboxMessage struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Kind          string             `bson:"kind"`
+	PlayerID      string             `bson:"player_id,omitempty"`
+	GameID        string             `bson:"game_id,omitempty"`
+	Email         string             `bson:"email,omitempty"`
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at"`
+	LastError     string             `bson:"last_error,omitempty"`
+	SentAt        time.Time          `bson:"sent_at"`
+	UpdatedAt     time.Time          `bson:"updated_at"`
+	CreatedAt     time.Time          `bson:"created_at"`
+}
+
+func (dm DocOutboxMessage) ToAggregate() *bingo.OutboxMessage {
+	return &bingo.OutboxMessage{
+		ID:            dm.ID.Hex(),
+		Kind:          bingo.MessageKind(dm.Kind),
+		PlayerID:      dm.PlayerID,
+		GameID:        dm.GameID,
+		Email:         dm.Email,
+		Status:        bingo.OutboxStatus(dm.Status),
+		Attempts:      dm.Attempts,
+		NextAttemptAt: dm.NextAttemptAt,
+		LastError:     dm.LastError,
+		SentAt:        dm.SentAt,
+		UpdatedAt:     dm.UpdatedAt,
+		CreatedAt:     dm.CreatedAt,
+	}
+}
+
+func DocFromOutboxMessage(msg *bingo.OutboxMessage) (DocOutboxMessage, error) {
+	oid := primitive.NewObjectID()
+	if msg.ID != "" {
+		var err error
+		oid, err = primitive.ObjectIDFromHex(msg.ID)
+		if err != nil {
+			return DocOutboxMessage{}, ErrMalformedHexObjectID
+		}
+	}
+
+	return DocOutboxMessage{
+		ID:            oid,
+		Kind:          string(msg.Kind),
+		PlayerID:      msg.PlayerID,
+		GameID:        msg.GameID,
+		Email:         msg.Email,
+		Status:        string(msg.Status),
+		Attempts:      msg.Attempts,
+		NextAttemptAt: msg.NextAttemptAt,
+		LastError:     msg.LastError,
+		SentAt:        msg.SentAt,
+		UpdatedAt:     msg.UpdatedAt,
+		CreatedAt:     msg.CreatedAt,
+	}, nil
+}
+
+type OutboxRepository struct {
+	db *DB
+}
+
+func (or *OutboxRepository) Get(ctx context.Context, id string) (*bingo.OutboxMessage, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrMalformedHexObjectID
+	}
+
+	var doc DocOutboxMessage
+	err = or.db.Outbox.FindOne(ctx, bson.M{"_id": oid}).Decode(&doc)
+	if err != nil {
+		return nil, notFoundErr(err, bingo.ErrOutboxMessageNotFound)
+	}
+
+	return doc.ToAggregate(), nil
+}
+
+// Claim the oldest due pending message. Postponing its next attempt happens in the same atomic update as finding it,
+// so concurrent workers never claim the same message
+func (or *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*bingo.OutboxMessage, error) {
+	filter := bson.M{
+		"status":          string(bingo.OutboxStatusPending),
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"next_attempt_at": 1})
+
+	var doc DocOutboxMessage
+	err := or.db.Outbox.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		return nil, notFoundErr(err, bingo.ErrOutboxEmpty)
+	}
+
+	return doc.ToAggregate(), nil
+}
+
+// Find messages with the status, newest first
+func (or *OutboxRepository) Find(ctx context.Context, status bingo.OutboxStatus) ([]bingo.OutboxMessage, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cur, err := or.db.Outbox.Find(ctx, bson.M{"status": string(status)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	msgs := make([]bingo.OutboxMessage, 0)
+	for cur.Next(ctx) {
+		var doc DocOutboxMessage
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, *doc.ToAggregate())
+	}
+
+	return msgs, cur.Err()
+}
+
+func (or *OutboxRepository) Save(ctx context.Context, msg *bingo.OutboxMessage) error {
+	doc, err := DocFromOutboxMessage(msg)
+	if err != nil {
+		return err
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := or.db.Outbox.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts); err != nil {
+		return err
+	}
+	msg.ID = doc.ID.Hex()
+
+	return nil
+}
+
+func NewOutboxRepository(db *DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
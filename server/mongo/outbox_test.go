@@ -0,0 +1,69 @@
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mongo"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var implementsOutboxRepo bingo.OutboxRepository = &mongo.OutboxRepository{}
+
+// Test that mongodb outbox message doc <-> outbox message conversion works
+func TestDocOutboxMessage(t *testing.T) {
+	msg := &bingo.OutboxMessage{
+		ID:            primitive.NewObjectID().Hex(),
+		Kind:          bingo.MessageKindPlayerCards,
+		PlayerID:      primitive.NewObjectID().Hex(),
+		GameID:        primitive.NewObjectID().Hex(),
+		Email:         "member@test.com",
+		Status:        bingo.OutboxStatusPending,
+		Attempts:      2,
+		NextAttemptAt: time.Now(),
+		LastError:     "mailbox full",
+		SentAt:        time.Time{},
+		UpdatedAt:     time.Now(),
+		CreatedAt:     time.Now(),
+	}
+
+	t.Run("test data out of date", func(t *testing.T) {
+		fieldsCount := reflect.Indirect(reflect.ValueOf(msg)).NumField()
+		expectedfc := 12
+		require.Equal(t, expectedfc, fieldsCount, "outbox message test data missing one or more fields")
+	})
+
+	t.Run("bidirectional conversion", func(t *testing.T) {
+		doc, err := mongo.DocFromOutboxMessage(msg)
+		require.NoError(t, err, "no error expected from mongo.DocFromOutboxMessage")
+
+		m := doc.ToAggregate()
+		require.EqualValues(t, msg, m, "expected values of round-trip conversion to equal initial data")
+	})
+}
+
+func TestOutboxRepository_ClaimDue(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := mongo.NewOutboxRepository(sharedDB)
+	now := time.Now()
+
+	due := bingo.NewPlayerCardsMessage(&bingo.Player{ID: primitive.NewObjectID().Hex()})
+	due.NextAttemptAt = now.Add(-time.Minute)
+	later := bingo.NewPlayerCardsMessage(&bingo.Player{ID: primitive.NewObjectID().Hex()})
+	later.NextAttemptAt = now.Add(time.Hour)
+	for _, msg := range []*bingo.OutboxMessage{due, later} {
+		require.NoError(t, outboxRepo.Save(ctx, msg), "expected no error from saving message")
+	}
+
+	claimed, err := outboxRepo.ClaimDue(ctx, now, bingo.OutboxLease)
+	require.NoError(t, err, "expected due message to be claimed")
+	require.Equal(t, due.ID, claimed.ID, "expected the due message to be claimed")
+
+	_, err = outboxRepo.ClaimDue(ctx, now, bingo.OutboxLease)
+	require.True(t, errors.Is(err, bingo.ErrOutboxEmpty), "expected claimed message not to be claimed again during its lease")
+}
@@ -13,25 +13,46 @@ import (
 )
 
 type DocPlayer struct {
-	ID           primitive.ObjectID `bson:"_id"`
-	Name         string             `bson:"name"`
-	Email        string             `bson:"email"`
-	InvitationID primitive.ObjectID `bson:"invitation_id"`
-	UpdatedAt    time.Time          `bson:"updated_at"`
-	CreatedAt    time.Time          `bson:"created_at"`
+	ID               primitive.ObjectID `bson:"_id"`
+	Name             string             `bson:"name"`
+	Email            string             `bson:"email"`
+	Language         string             `bson:"language,omitempty"`
+	InvitationID     primitive.ObjectID `bson:"invitation_id"`
+	Status           string             `bson:"status"`
+	WaitlistPosition int                `bson:"waitlist_position,omitempty"`
+	RequestedCards   int                `bson:"requested_cards,omitempty"`
+	ConfirmBy        time.Time          `bson:"confirm_by,omitempty"`
+	DeliveryStatus   string             `bson:"delivery_status,omitempty"`
+	DeliveryError    string             `bson:"delivery_error,omitempty"`
+	DeliveredAt      time.Time          `bson:"delivered_at"`
+	UpdatedAt        time.Time          `bson:"updated_at"`
+	CreatedAt        time.Time          `bson:"created_at"`
 }
 
 // Convert mongo document player struct to aggregate player.
 func (dp DocPlayer) ToAggregate(inv *bingo.Invitation, cards []bingo.Card) (*bingo.Player, error) {
+	// Players stored before the waitlist existed have all joined
+	status := bingo.PlayerStatus(dp.Status)
+	if status == "" {
+		status = bingo.PlayerStatusJoined
+	}
 	p := &bingo.Player{
-		ID:           dp.ID.Hex(),
-		Name:         dp.Name,
-		Email:        dp.Email,
-		InvitationID: dp.InvitationID.Hex(),
-		Invitation:   inv,
-		Cards:        cards,
-		UpdatedAt:    dp.UpdatedAt,
-		CreatedAt:    dp.CreatedAt,
+		ID:               dp.ID.Hex(),
+		Name:             dp.Name,
+		Email:            dp.Email,
+		Language:         bingo.Language(dp.Language),
+		InvitationID:     dp.InvitationID.Hex(),
+		Invitation:       inv,
+		Cards:            cards,
+		Status:           status,
+		WaitlistPosition: dp.WaitlistPosition,
+		RequestedCards:   dp.RequestedCards,
+		ConfirmBy:        dp.ConfirmBy,
+		DeliveryStatus:   bingo.DeliveryStatus(dp.DeliveryStatus),
+		DeliveryError:    dp.DeliveryError,
+		DeliveredAt:      dp.DeliveredAt,
+		UpdatedAt:        dp.UpdatedAt,
+		CreatedAt:        dp.CreatedAt,
 	}
 	if err := p.Validate(); err != nil {
 		return nil, err
@@ -54,12 +75,20 @@ func DocFromPlayer(p *bingo.Player) (DocPlayer, error) {
 		return DocPlayer{}, ErrMalformedHexObjectID
 	}
 	return DocPlayer{
-		ID:           oid,
-		Name:         p.Name,
-		Email:        p.Email,
-		InvitationID: iOid,
-		UpdatedAt:    p.UpdatedAt,
-		CreatedAt:    p.CreatedAt,
+		ID:               oid,
+		Name:             p.Name,
+		Email:            p.Email,
+		Language:         string(p.Language),
+		InvitationID:     iOid,
+		Status:           string(p.Status),
+		WaitlistPosition: p.WaitlistPosition,
+		RequestedCards:   p.RequestedCards,
+		ConfirmBy:        p.ConfirmBy,
+		DeliveryStatus:   string(p.DeliveryStatus),
+		DeliveryError:    p.DeliveryError,
+		DeliveredAt:      p.DeliveredAt,
+		UpdatedAt:        p.UpdatedAt,
+		CreatedAt:        p.CreatedAt,
 	}, nil
 }
 
@@ -75,12 +104,31 @@ func (pr *PlayerRepository) Get(ctx context.Context, id string) (*bingo.Player,
 	var doc DocPlayer
 	res := pr.db.Players.FindOne(ctx, bson.M{"_id": oid})
 	if err := res.Decode(&doc); err != nil {
-		return nil, err
+		return nil, notFoundErr(err, bingo.ErrPlayerNotFound)
+	}
+
+	return pr.aggregate(ctx, doc)
+}
+
+func (pr *PlayerRepository) GetByEmail(ctx context.Context, invId string, email string) (*bingo.Player, error) {
+	iOid, err := primitive.ObjectIDFromHex(invId)
+	if err != nil {
+		return nil, ErrMalformedHexObjectID
 	}
+	var doc DocPlayer
+	res := pr.db.Players.FindOne(ctx, bson.M{"invitation_id": iOid, "email": bingo.NormalizeEmail(email)})
+	if err := res.Decode(&doc); err != nil {
+		return nil, notFoundErr(err, bingo.ErrPlayerNotFound)
+	}
+
+	return pr.aggregate(ctx, doc)
+}
 
+// Convert the player doc to the aggregate, along with the invitation and cards associated
+func (pr *PlayerRepository) aggregate(ctx context.Context, doc DocPlayer) (*bingo.Player, error) {
 	// Find associated invitation for player
 	invDoc := &DocInvitation{}
-	err = pr.db.Invitations.FindOne(ctx, bson.M{"_id": doc.InvitationID}).Decode(invDoc)
+	err := pr.db.Invitations.FindOne(ctx, bson.M{"_id": doc.InvitationID}).Decode(invDoc)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, ErrNoAssociatedDocuments
 	} else if err != nil {
@@ -120,6 +168,80 @@ func (pr *PlayerRepository) Get(ctx context.Context, id string) (*bingo.Player,
 	return aggr, nil
 }
 
+// Find players of the game matching the filter, oldest first. Cards and invitations are not loaded
+func (pr *PlayerRepository) Find(ctx context.Context, filter bingo.PlayerFilter) ([]bingo.Player, error) {
+	gOid, err := primitive.ObjectIDFromHex(filter.GameID)
+	if err != nil {
+		return nil, ErrMalformedHexObjectID
+	}
+
+	// Players are associated with the game through the invitation they joined by
+	invIds := make([]primitive.ObjectID, 0)
+	invCur, err := pr.db.Invitations.Find(ctx, bson.M{"game_id": gOid}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer invCur.Close(ctx)
+	for invCur.Next(ctx) {
+		var invDoc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := invCur.Decode(&invDoc); err != nil {
+			return nil, err
+		}
+		invIds = append(invIds, invDoc.ID)
+	}
+	if err := invCur.Err(); err != nil {
+		return nil, err
+	}
+
+	query := bson.M{"invitation_id": bson.M{"$in": invIds}}
+	if filter.InvitationID != "" {
+		iOid, err := primitive.ObjectIDFromHex(filter.InvitationID)
+		if err != nil {
+			return nil, ErrMalformedHexObjectID
+		}
+		query["invitation_id"] = bson.M{"$in": invIds, "$eq": iOid}
+	}
+	switch filter.Status {
+	case "":
+	case bingo.PlayerStatusJoined:
+		// Players stored before the waitlist existed have no status, but have all joined
+		query["status"] = bson.M{"$in": bson.A{string(filter.Status), nil}}
+	default:
+		query["status"] = string(filter.Status)
+	}
+	if filter.DeliveryStatus != "" {
+		query["delivery_status"] = string(filter.DeliveryStatus)
+	}
+
+	// Players on the waitlist are found in line
+	sort := bson.D{{Key: "created_at", Value: 1}}
+	if filter.Status == bingo.PlayerStatusWaitlisted {
+		sort = bson.D{{Key: "waitlist_position", Value: 1}}
+	}
+	cur, err := pr.db.Players.Find(ctx, query, options.Find().SetSort(sort))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	players := make([]bingo.Player, 0)
+	for cur.Next(ctx) {
+		var doc DocPlayer
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		p, err := doc.ToAggregate(nil, make([]bingo.Card, 0))
+		if err != nil {
+			return nil, err
+		}
+		players = append(players, *p)
+	}
+
+	return players, cur.Err()
+}
+
 func (pr *PlayerRepository) Save(ctx context.Context, p *bingo.Player) error {
 	p.UpdatedAt = time.Now()
 	doc, err := DocFromPlayer(p)
@@ -128,6 +250,9 @@ func (pr *PlayerRepository) Save(ctx context.Context, p *bingo.Player) error {
 	}
 	opts := options.Replace().SetUpsert(true)
 	res, err := pr.db.Players.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return domainErr{domain: bingo.ErrPlayerExists, cause: err}
+	}
 	if err != nil {
 		return err
 	}
@@ -145,6 +270,33 @@ func (pr *PlayerRepository) Save(ctx context.Context, p *bingo.Player) error {
 	return nil
 }
 
+// Record the outcome of delivering the cards of the player by setting the delivery fields only, so changes made to the
+// player while the cards were mailed are kept
+func (pr *PlayerRepository) RecordDelivery(ctx context.Context, id string, status bingo.DeliveryStatus, deliveryErr string, at time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrMalformedHexObjectID
+	}
+
+	set := bson.M{
+		"delivery_status": string(status),
+		"delivery_error":  deliveryErr,
+		"updated_at":      at,
+	}
+	if status == bingo.DeliveryStatusSent {
+		set["delivered_at"] = at
+	}
+	res, err := pr.db.Players.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return bingo.ErrPlayerNotFound
+	}
+
+	return nil
+}
+
 func NewPlayerRepository(db *DB) *PlayerRepository {
 	return &PlayerRepository{
 		db: db,
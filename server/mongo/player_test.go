@@ -19,19 +19,27 @@ var implementsPlayerRepo bingo.PlayerRepository = &mongo.PlayerRepository{}
 // Test that mongodb card player <-> player aggregate root conversion works
 func TestDocPlayer(t *testing.T) {
 	p := &bingo.Player{
-		ID:           primitive.NewObjectID().Hex(),
-		Name:         "test name",
-		Email:        "test@test.com",
-		InvitationID: primitive.NewObjectID().Hex(),
-		Invitation:   nil,
-		Cards:        nil,
-		UpdatedAt:    time.Now(),
-		CreatedAt:    time.Now(),
+		ID:               primitive.NewObjectID().Hex(),
+		Name:             "test name",
+		Email:            "test@test.com",
+		Language:         bingo.LanguageDanish,
+		InvitationID:     primitive.NewObjectID().Hex(),
+		Invitation:       nil,
+		Cards:            nil,
+		Status:           bingo.PlayerStatusWaitlisted,
+		WaitlistPosition: 4,
+		RequestedCards:   2,
+		ConfirmBy:        time.Now(),
+		DeliveryStatus:   bingo.DeliveryStatusFailed,
+		DeliveryError:    "mailbox full",
+		DeliveredAt:      time.Now(),
+		UpdatedAt:        time.Now(),
+		CreatedAt:        time.Now(),
 	}
 
 	t.Run("test data out of date", func(t *testing.T) {
 		pFieldsCount := reflect.Indirect(reflect.ValueOf(p)).NumField()
-		expectedfc := 8
+		expectedfc := 16
 		require.Equal(t, expectedfc, pFieldsCount, "player test data missing one or more fields")
 	})
 
@@ -143,6 +151,53 @@ func TestPlayerRepository_Get(t *testing.T) {
 	}
 }
 
+func TestPlayerRepository_GetByEmail(t *testing.T) {
+	playerRepo := mongo.NewPlayerRepository(sharedDB)
+	ctx := context.Background()
+	insertInvDepDoc := mongo.DocInvitation{
+		ID:             primitive.NewObjectID(),
+		DeliveryMethod: string(bingo.InvitationDeliveryMethodDownload),
+		MaxCardAmount:  3,
+		Active:         true,
+		GameID:         primitive.NewObjectID(),
+	}
+	insertDoc := mongo.DocPlayer{
+		ID:           primitive.NewObjectID(),
+		Name:         "test name",
+		Email:        "by-email@test.com",
+		InvitationID: insertInvDepDoc.ID,
+		Status:       string(bingo.PlayerStatusJoined),
+		UpdatedAt:    time.Now(),
+		CreatedAt:    time.Now(),
+	}
+	MustInsertOneInvDoc(t, ctx, insertInvDepDoc)
+	MustInsertOnePlayerDoc(t, ctx, insertDoc)
+
+	t.Run("success normalized email", func(t *testing.T) {
+		cp, err := playerRepo.GetByEmail(ctx, insertInvDepDoc.ID.Hex(), " By-Email@Test.com ")
+		require.NoError(t, err, "expected no error")
+		p, err := insertDoc.ToAggregate(nil, []bingo.Card{})
+		require.NoError(t, err, "expected no error from player aggregate conversion")
+		MustComparePlayers(t, p, cp)
+	})
+
+	t.Run("fail other invitation", func(t *testing.T) {
+		_, err := playerRepo.GetByEmail(ctx, primitive.NewObjectID().Hex(), insertDoc.Email)
+		require.ErrorIs(t, err, bingo.ErrPlayerNotFound, "expected player not to be found")
+	})
+
+	t.Run("fail same email twice", func(t *testing.T) {
+		p := &bingo.Player{
+			Name:         "other name",
+			Email:        insertDoc.Email,
+			InvitationID: insertInvDepDoc.ID.Hex(),
+			Status:       bingo.PlayerStatusJoined,
+		}
+		err := playerRepo.Save(ctx, p)
+		require.ErrorIs(t, err, bingo.ErrPlayerExists, "expected player with same email on invitation to be rejected")
+	})
+}
+
 func TestPlayerRepository_Save(t *testing.T) {
 	playerRepo := mongo.NewPlayerRepository(sharedDB)
 	commonSub := &bingo.Player{
@@ -235,6 +290,37 @@ func TestPlayerRepository_Save(t *testing.T) {
 	}
 }
 
+func TestPlayerRepository_RecordDelivery(t *testing.T) {
+	ctx := context.Background()
+	playerRepo := mongo.NewPlayerRepository(sharedDB)
+	insertDoc := mongo.DocPlayer{
+		ID:             primitive.NewObjectID(),
+		Name:           "test name",
+		Email:          "delivery@test.com",
+		InvitationID:   primitive.NewObjectID(),
+		Status:         string(bingo.PlayerStatusRemoved),
+		DeliveryStatus: string(bingo.DeliveryStatusPending),
+		DeliveryError:  "mailbox full",
+		UpdatedAt:      time.Now(),
+		CreatedAt:      time.Now(),
+	}
+	MustInsertOnePlayerDoc(t, ctx, insertDoc)
+
+	at := time.Now().Truncate(time.Millisecond)
+	err := playerRepo.RecordDelivery(ctx, insertDoc.ID.Hex(), bingo.DeliveryStatusSent, "", at)
+	require.NoError(t, err, "expected no error when recording delivery")
+
+	// Only the delivery must be recorded, so the player removed meanwhile stays removed
+	doc := MustFindOnePlayerDoc(t, ctx, insertDoc.ID.Hex())
+	require.Equal(t, string(bingo.DeliveryStatusSent), doc.DeliveryStatus, "expected delivery status to be recorded")
+	require.Empty(t, doc.DeliveryError, "expected delivery error to be cleared")
+	require.True(t, at.Equal(doc.DeliveredAt), "expected time of delivery to be recorded")
+	require.Equal(t, string(bingo.PlayerStatusRemoved), doc.Status, "expected status of player to be kept")
+
+	err = playerRepo.RecordDelivery(ctx, primitive.NewObjectID().Hex(), bingo.DeliveryStatusSent, "", at)
+	require.ErrorIs(t, err, bingo.ErrPlayerNotFound, "expected delivery to unknown player to be refused")
+}
+
 func MustInsertOnePlayerDoc(tb testing.TB, ctx context.Context, doc mongo.DocPlayer) {
 	tb.Helper()
 
@@ -0,0 +1,126 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DocRefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	FamilyID  string             `bson:"family_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Hash      string             `bson:"hash"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	RevokedAt time.Time          `bson:"revoked_at"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (dt DocRefreshToken) ToAggregate() *bingo.RefreshToken {
+	return &bingo.RefreshToken{
+		ID:        dt.ID.Hex(),
+		FamilyID:  dt.FamilyID,
+		UserID:    dt.UserID.Hex(),
+		Hash:      dt.Hash,
+		ExpiresAt: dt.ExpiresAt,
+		RevokedAt: dt.RevokedAt,
+		CreatedAt: dt.CreatedAt,
+	}
+}
+
+func DocFromRefreshToken(t *bingo.RefreshToken) (DocRefreshToken, error) {
+	oid := primitive.NewObjectID()
+	if t.ID != "" {
+		var err error
+		oid, err = primitive.ObjectIDFromHex(t.ID)
+		if err != nil {
+			return DocRefreshToken{}, ErrMalformedHexObjectID
+		}
+	}
+	uOid, err := primitive.ObjectIDFromHex(t.UserID)
+	if err != nil {
+		return DocRefreshToken{}, ErrMalformedHexObjectID
+	}
+	return DocRefreshToken{
+		ID:        oid,
+		FamilyID:  t.FamilyID,
+		UserID:    uOid,
+		Hash:      t.Hash,
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+		CreatedAt: t.CreatedAt,
+	}, nil
+}
+
+type RefreshTokenRepository struct {
+	db *DB
+}
+
+func (rr *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*bingo.RefreshToken, error) {
+	var doc DocRefreshToken
+	err := rr.db.RefreshTokens.FindOne(ctx, bson.M{"hash": hash}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, bingo.ErrRefreshTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return doc.ToAggregate(), nil
+}
+
+func (rr *RefreshTokenRepository) Save(ctx context.Context, t *bingo.RefreshToken) error {
+	doc, err := DocFromRefreshToken(t)
+	if err != nil {
+		return err
+	}
+	opts := options.Replace().SetUpsert(true)
+	res, err := rr.db.RefreshTokens.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts)
+	if err != nil {
+		return err
+	}
+	if t.ID == "" {
+		if res.UpsertedID == nil {
+			return ErrNoUpsertedObjectID
+		}
+		oid, ok := res.UpsertedID.(primitive.ObjectID)
+		if !ok {
+			return ErrNoUpsertedObjectID
+		}
+		t.ID = oid.Hex()
+	}
+
+	return nil
+}
+
+// Revoke the token by its hash, only if it has not already been revoked, so it is rotated once
+func (rr *RefreshTokenRepository) Revoke(ctx context.Context, hash string, at time.Time) error {
+	filter := bson.M{"hash": hash, "revoked_at": time.Time{}}
+	res, err := rr.db.RefreshTokens.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount == 0 {
+		return bingo.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+// Revoke all tokens in the family which have not already been revoked
+func (rr *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyId string, at time.Time) error {
+	filter := bson.M{"family_id": familyId, "revoked_at": time.Time{}}
+	_, err := rr.db.RefreshTokens.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	return err
+}
+
+func NewRefreshTokenRepository(db *DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		db: db,
+	}
+}
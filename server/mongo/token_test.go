@@ -0,0 +1,155 @@
+package mongo_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mongo"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var implementsRefreshTokenRepo bingo.RefreshTokenRepository = &mongo.RefreshTokenRepository{}
+
+// Test that mongodb refresh token doc <-> refresh token entity conversion works
+func TestDocRefreshToken(t *testing.T) {
+	rt := &bingo.RefreshToken{
+		ID:        primitive.NewObjectID().Hex(),
+		FamilyID:  "family",
+		UserID:    primitive.NewObjectID().Hex(),
+		Hash:      "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+		RevokedAt: time.Now(),
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("test data out of date", func(t *testing.T) {
+		fieldsCount := reflect.Indirect(reflect.ValueOf(rt)).NumField()
+		expectedfc := 7
+		require.Equal(t, expectedfc, fieldsCount, "refresh token test data missing one or more fields")
+	})
+
+	t.Run("bidirectional conversion", func(t *testing.T) {
+		doc, err := mongo.DocFromRefreshToken(rt)
+		require.NoError(t, err, "no error expected from mongo.DocFromRefreshToken")
+
+		crt := doc.ToAggregate()
+		require.EqualValues(t, rt, crt, "expected values of round-trip conversion to equal initial data")
+	})
+}
+
+func TestRefreshTokenRepository_GetByHash(t *testing.T) {
+	tokenRepo := mongo.NewRefreshTokenRepository(sharedDB)
+	insertDoc := mongo.DocRefreshToken{
+		ID:        primitive.NewObjectID(),
+		FamilyID:  "get family",
+		UserID:    primitive.NewObjectID(),
+		Hash:      "get hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+	MustInsertOneRefreshTokenDoc(t, context.Background(), insertDoc)
+
+	cases := []struct {
+		cn            string
+		hash          string
+		expectErr     bool
+		expectedErrIs error
+	}{
+		{
+			cn:        "success",
+			hash:      insertDoc.Hash,
+			expectErr: false,
+		},
+		{
+			cn:            "fail not found",
+			hash:          "unknown hash",
+			expectErr:     true,
+			expectedErrIs: bingo.ErrRefreshTokenNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			rt, err := tokenRepo.GetByHash(context.Background(), c.hash)
+			if c.expectErr {
+				require.Error(t, err, "expected error")
+				require.ErrorIs(t, err, c.expectedErrIs, "expected different error")
+			} else {
+				require.NoError(t, err, "expected no error")
+				require.Equal(t, insertDoc.ID.Hex(), rt.ID, "expected inserted token to be found")
+				require.False(t, rt.Revoked(), "expected inserted token not to be revoked")
+			}
+		})
+	}
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	ctx := context.Background()
+	tokenRepo := mongo.NewRefreshTokenRepository(sharedDB)
+	familyDoc := mongo.DocRefreshToken{
+		ID:        primitive.NewObjectID(),
+		FamilyID:  "revoke family",
+		UserID:    primitive.NewObjectID(),
+		Hash:      "revoke hash 1",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+	siblingDoc := familyDoc
+	siblingDoc.ID = primitive.NewObjectID()
+	siblingDoc.Hash = "revoke hash 2"
+	otherDoc := familyDoc
+	otherDoc.ID = primitive.NewObjectID()
+	otherDoc.FamilyID = "other family"
+	otherDoc.Hash = "revoke hash 3"
+	MustInsertOneRefreshTokenDoc(t, ctx, familyDoc)
+	MustInsertOneRefreshTokenDoc(t, ctx, siblingDoc)
+	MustInsertOneRefreshTokenDoc(t, ctx, otherDoc)
+
+	err := tokenRepo.RevokeFamily(ctx, familyDoc.FamilyID, time.Now())
+	require.NoError(t, err, "expected no error when revoking family")
+
+	for _, doc := range []mongo.DocRefreshToken{familyDoc, siblingDoc} {
+		rt, err := tokenRepo.GetByHash(ctx, doc.Hash)
+		require.NoError(t, err, "expected no error")
+		require.True(t, rt.Revoked(), "expected token in family to be revoked")
+	}
+
+	rt, err := tokenRepo.GetByHash(ctx, otherDoc.Hash)
+	require.NoError(t, err, "expected no error")
+	require.False(t, rt.Revoked(), "expected token in other family not to be revoked")
+}
+
+func TestRefreshTokenRepository_Revoke(t *testing.T) {
+	ctx := context.Background()
+	tokenRepo := mongo.NewRefreshTokenRepository(sharedDB)
+	insertDoc := mongo.DocRefreshToken{
+		ID:        primitive.NewObjectID(),
+		FamilyID:  "rotate family",
+		UserID:    primitive.NewObjectID(),
+		Hash:      "rotate hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+	MustInsertOneRefreshTokenDoc(t, ctx, insertDoc)
+
+	err := tokenRepo.Revoke(ctx, insertDoc.Hash, time.Now())
+	require.NoError(t, err, "expected no error when revoking token first time")
+
+	rt, err := tokenRepo.GetByHash(ctx, insertDoc.Hash)
+	require.NoError(t, err, "expected no error")
+	require.True(t, rt.Revoked(), "expected token to be revoked")
+
+	err = tokenRepo.Revoke(ctx, insertDoc.Hash, time.Now())
+	require.ErrorIs(t, err, bingo.ErrRefreshTokenReused, "expected token revoked already to be reported as reused")
+}
+
+func MustInsertOneRefreshTokenDoc(tb testing.TB, ctx context.Context, doc mongo.DocRefreshToken) {
+	tb.Helper()
+
+	_, err := sharedDB.RefreshTokens.InsertOne(ctx, doc)
+	require.NoError(tb, err, "expected no error from inserting refresh token")
+}
@@ -7,6 +7,7 @@ import (
 	bingo "github.com/nohns/bingo-box/server"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -15,18 +16,28 @@ type DocUser struct {
 	Name           string             `bson:"name"`
 	Email          string             `bson:"email"`
 	HashedPassword []byte             `bson:"password"`
+	IdentityID     string             `bson:"identity_id,omitempty"`
+	TOSAcceptedAt  time.Time          `bson:"tos_accepted_at"`
+	ActiveOrgID    string             `bson:"active_organization_id,omitempty"`
+	GameCredits    int                `bson:"game_credits"`
+	Admin          bool               `bson:"admin"`
 	UpdatedAt      time.Time          `bson:"updated_at"`
 	CreatedAt      time.Time          `bson:"created_at"`
 }
 
 func (du DocUser) ToAggregate() (*bingo.User, error) {
 	u := &bingo.User{
-		ID:             du.ID.Hex(),
-		Name:           du.Name,
-		Email:          du.Email,
-		HashedPassword: du.HashedPassword,
-		UpdatedAt:      du.UpdatedAt,
-		CreatedAt:      du.CreatedAt,
+		ID:                   du.ID.Hex(),
+		Name:                 du.Name,
+		Email:                du.Email,
+		HashedPassword:       du.HashedPassword,
+		IdentityID:           du.IdentityID,
+		TOSAcceptedAt:        du.TOSAcceptedAt,
+		ActiveOrganizationID: du.ActiveOrgID,
+		GameCredits:          du.GameCredits,
+		Admin:                du.Admin,
+		UpdatedAt:            du.UpdatedAt,
+		CreatedAt:            du.CreatedAt,
 	}
 	if err := u.Validate(); err != nil {
 		return nil, err
@@ -48,6 +59,11 @@ func DocFromUser(u *bingo.User) (DocUser, error) {
 		Name:           u.Name,
 		Email:          u.Email,
 		HashedPassword: u.HashedPassword,
+		IdentityID:     u.IdentityID,
+		TOSAcceptedAt:  u.TOSAcceptedAt,
+		ActiveOrgID:    u.ActiveOrganizationID,
+		GameCredits:    u.GameCredits,
+		Admin:          u.Admin,
 		UpdatedAt:      u.UpdatedAt,
 		CreatedAt:      u.CreatedAt,
 	}
@@ -72,7 +88,7 @@ func (cr *UserRepository) Get(ctx context.Context, id string) (*bingo.User, erro
 	var doc DocUser
 	res := cr.db.Users.FindOne(ctx, bson.M{"_id": oid})
 	if err := res.Decode(&doc); err != nil {
-		return nil, err
+		return nil, notFoundErr(err, bingo.ErrUserNotFound)
 	}
 
 	aggr, err := doc.ToAggregate()
@@ -87,9 +103,24 @@ func (cr *UserRepository) GetByEmail(ctx context.Context, email string) (*bingo.
 	var doc DocUser
 	res := cr.db.Users.FindOne(ctx, bson.M{"email": email})
 	if err := res.Decode(&doc); err != nil {
+		return nil, notFoundErr(err, bingo.ErrUserNotFound)
+	}
+
+	aggr, err := doc.ToAggregate()
+	if err != nil {
 		return nil, err
 	}
 
+	return aggr, nil
+}
+
+func (cr *UserRepository) GetByIdentityID(ctx context.Context, identityId string) (*bingo.User, error) {
+	var doc DocUser
+	res := cr.db.Users.FindOne(ctx, bson.M{"identity_id": identityId})
+	if err := res.Decode(&doc); err != nil {
+		return nil, notFoundErr(err, bingo.ErrUserNotFound)
+	}
+
 	aggr, err := doc.ToAggregate()
 	if err != nil {
 		return nil, err
@@ -98,15 +129,23 @@ func (cr *UserRepository) GetByEmail(ctx context.Context, email string) (*bingo.
 	return aggr, nil
 }
 
-// Save card to mongodb
+// Save user to mongodb. Emails are unique among users, so registering an email twice fails with a domain error. The
+// game credits are only set when the user is created, as they are added atomically afterwards
 func (cr *UserRepository) Save(ctx context.Context, c *bingo.User) error {
 	c.UpdatedAt = time.Now()
 	doc, err := DocFromUser(c)
 	if err != nil {
 		return err
 	}
-	opts := options.Replace().SetUpsert(true)
-	res, err := cr.db.Users.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, opts)
+	update, err := replaceKeeping(doc, "game_credits")
+	if err != nil {
+		return err
+	}
+	opts := options.Update().SetUpsert(true)
+	res, err := cr.db.Users.UpdateOne(ctx, bson.M{"_id": doc.ID}, update, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return domainErr{domain: bingo.ErrUserAlreadyExists, cause: err}
+	}
 	if err != nil {
 		return err
 	}
@@ -123,3 +162,8 @@ func (cr *UserRepository) Save(ctx context.Context, c *bingo.User) error {
 
 	return nil
 }
+
+// Add amount to the game credits of the user. Debits are only applied if the credits cover them
+func (cr *UserRepository) AddCredits(ctx context.Context, id string, amount int) error {
+	return addCredits(ctx, cr.db.Users, id, amount, bingo.ErrUserNotFound)
+}
@@ -19,17 +19,22 @@ var implementsUserRepo bingo.UserRepository = &mongo.UserRepository{}
 // Test that mongodb card player <-> player aggregate root conversion works
 func TestDocUser(t *testing.T) {
 	u := &bingo.User{
-		ID:             primitive.NewObjectID().Hex(),
-		Name:           "test name",
-		Email:          "test@test.com",
-		HashedPassword: []byte("test pass"),
-		UpdatedAt:      time.Now(),
-		CreatedAt:      time.Now(),
+		ID:                   primitive.NewObjectID().Hex(),
+		Name:                 "test name",
+		Email:                "test@test.com",
+		HashedPassword:       []byte("test pass"),
+		IdentityID:           "test identity",
+		TOSAcceptedAt:        time.Now(),
+		ActiveOrganizationID: primitive.NewObjectID().Hex(),
+		GameCredits:          12,
+		Admin:                true,
+		UpdatedAt:            time.Now(),
+		CreatedAt:            time.Now(),
 	}
 
 	t.Run("test data out of date", func(t *testing.T) {
 		pFieldsCount := reflect.Indirect(reflect.ValueOf(u)).NumField()
-		expectedfc := 6
+		expectedfc := 11
 		require.Equal(t, expectedfc, pFieldsCount, "player test data missing one or more fields")
 	})
 
@@ -86,6 +91,14 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 			expectedErrIs: mongodb.ErrNoDocuments,
 			valFunc:       nil,
 		},
+		{
+			cn:            "fail user not found",
+			ctx:           context.Background(),
+			email:         "email@notfound.com",
+			expectErr:     true,
+			expectedErrIs: bingo.ErrUserNotFound,
+			valFunc:       nil,
+		},
 	}
 
 	for _, c := range cases {
@@ -106,12 +119,73 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 	}
 }
 
+func TestUserRepository_GetByIdentityID(t *testing.T) {
+	userRepo := mongo.NewUserRepository(sharedDB)
+	insertDoc := mongo.DocUser{
+		ID:             primitive.NewObjectID(),
+		Name:           "test name",
+		Email:          "identity@test.com",
+		HashedPassword: nil,
+		IdentityID:     primitive.NewObjectID().Hex(),
+		UpdatedAt:      time.Now(),
+		CreatedAt:      time.Now(),
+	}
+	MustInsertOneUserDoc(t, context.Background(), insertDoc)
+
+	cases := []struct {
+		cn            string
+		ctx           context.Context
+		identityId    string
+		expectErr     bool
+		valFunc       valUserFunc
+		expectedErrIs error
+	}{
+		{
+			cn:            "success",
+			ctx:           context.Background(),
+			identityId:    insertDoc.IdentityID,
+			expectErr:     false,
+			expectedErrIs: nil,
+			valFunc: func(ctx context.Context, t *testing.T, cu *bingo.User) {
+				u, err := insertDoc.ToAggregate()
+				require.NoError(t, err, "expected no error from user aggregate conversion")
+				MustCompareUsers(t, u, cu)
+			},
+		},
+		{
+			cn:            "fail user not found",
+			ctx:           context.Background(),
+			identityId:    "unknown identity",
+			expectErr:     true,
+			expectedErrIs: bingo.ErrUserNotFound,
+			valFunc:       nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			ctx := c.ctx
+
+			u, err := userRepo.GetByIdentityID(ctx, c.identityId)
+			if c.expectErr {
+				require.Error(t, err, "expected error")
+				if c.expectedErrIs != nil {
+					require.ErrorIs(t, err, c.expectedErrIs, "expected different error")
+				}
+			} else {
+				require.NoError(t, err, "expected no error")
+				c.valFunc(ctx, t, u)
+			}
+		})
+	}
+}
+
 func TestUserRepository_Get(t *testing.T) {
 	userRepo := mongo.NewUserRepository(sharedDB)
 	insertDoc := mongo.DocUser{
 		ID:             primitive.NewObjectID(),
 		Name:           "test name",
-		Email:          "test@test.com",
+		Email:          "get@test.com",
 		HashedPassword: []byte("test hash"),
 		UpdatedAt:      time.Now(),
 		CreatedAt:      time.Now(),
@@ -178,7 +252,7 @@ func TestUserRepository_Save(t *testing.T) {
 	userRepo := mongo.NewUserRepository(sharedDB)
 	commonSub := &bingo.User{
 		Name:           "test name",
-		Email:          "test@test.com",
+		Email:          "save@test.com",
 		HashedPassword: []byte("pass"),
 		UpdatedAt:      time.Now(),
 		CreatedAt:      time.Now(),
@@ -217,7 +291,7 @@ func TestUserRepository_Save(t *testing.T) {
 			u:   commonSub,
 			beforeFunc: func(ctx context.Context, t *testing.T, u *bingo.User) {
 				// Mutate user
-				u.Email = "test2@test.com"
+				u.Email = "save2@test.com"
 				u.HashedPassword = []byte("new hash")
 				u.Name = "new name"
 			},
@@ -231,6 +305,20 @@ func TestUserRepository_Save(t *testing.T) {
 			expectErr:     false,
 			expectedErrIs: nil,
 		},
+		{
+			cn:  "insert fail email taken",
+			ctx: context.Background(),
+			u: &bingo.User{
+				Name:      "other name",
+				Email:     "save2@test.com",
+				UpdatedAt: time.Now(),
+				CreatedAt: time.Now(),
+			},
+			beforeFunc:    nil,
+			valFunc:       nil,
+			expectErr:     true,
+			expectedErrIs: bingo.ErrUserAlreadyExists,
+		},
 		{
 			cn:  "insert fail id not hex",
 			ctx: context.Background(),
@@ -265,6 +353,39 @@ func TestUserRepository_Save(t *testing.T) {
 	}
 }
 
+func TestUserRepository_AddCredits(t *testing.T) {
+	ctx := context.Background()
+	userRepo := mongo.NewUserRepository(sharedDB)
+	insertDoc := mongo.DocUser{
+		ID:          primitive.NewObjectID(),
+		Name:        "test name",
+		Email:       "credits@test.com",
+		GameCredits: 2,
+		UpdatedAt:   time.Now(),
+		CreatedAt:   time.Now(),
+	}
+	MustInsertOneUserDoc(t, ctx, insertDoc)
+
+	err := userRepo.AddCredits(ctx, insertDoc.ID.Hex(), -2)
+	require.NoError(t, err, "expected no error when debiting the balance")
+
+	err = userRepo.AddCredits(ctx, insertDoc.ID.Hex(), -1)
+	require.ErrorIs(t, err, bingo.ErrInsufficientCredits, "expected debit exceeding the balance to be refused")
+
+	err = userRepo.AddCredits(ctx, primitive.NewObjectID().Hex(), 1)
+	require.ErrorIs(t, err, bingo.ErrUserNotFound, "expected credits to unknown user to be refused")
+
+	// Saving the user read before the credits changed must not overwrite them
+	u, err := insertDoc.ToAggregate()
+	require.NoError(t, err, "expected no error from user aggregate conversion")
+	u.Name = "new name"
+	require.NoError(t, userRepo.Save(ctx, u), "expected no error when saving user")
+
+	doc := MustFindOneUserDoc(t, ctx, insertDoc.ID.Hex())
+	require.Equal(t, 0, doc.GameCredits, "expected credits to be kept when saving user")
+	require.Equal(t, "new name", doc.Name, "expected user to be saved")
+}
+
 func MustInsertOneUserDoc(tb testing.TB, ctx context.Context, doc mongo.DocUser) {
 	tb.Helper()
 
@@ -0,0 +1,281 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOrganizationNotFound      = errors.New("bingo: organization could not be found")
+	ErrOrganizationValidation    = NewValErr("bingo: organization validation failed")
+	ErrOrganizationMemberExists  = errors.New("bingo: user is already member of the organization")
+	ErrOrganizationMemberMissing = errors.New("bingo: user is not member of the organization")
+	ErrLastOrganizationAdmin     = errors.New("bingo: organization must have at least one admin")
+)
+
+// Role of a user in an organization. Admins manage the organization, and own its games, while members co-host them.
+type OrganizationRole string
+
+const (
+	OrganizationRoleAdmin  OrganizationRole = "ADMIN"
+	OrganizationRoleMember OrganizationRole = "MEMBER"
+)
+
+// Role the organization role gives in the games owned by the organization
+func (r OrganizationRole) GameRole() GameRole {
+	if r == OrganizationRoleAdmin {
+		return GameRoleOwner
+	}
+
+	return GameRoleCohost
+}
+
+func (r OrganizationRole) Valid() bool {
+	return r == OrganizationRoleAdmin || r == OrganizationRoleMember
+}
+
+type OrganizationRepository interface {
+	Get(ctx context.Context, id string) (*Organization, error)
+	Save(ctx context.Context, org *Organization) error
+	ListByMember(ctx context.Context, userId string) ([]Organization, error)
+
+	// Add amount to the game credits of the organization atomically. Debits exceeding the credits are refused with
+	// ErrInsufficientCredits. Saving the organization does not change the credits
+	AddCredits(ctx context.Context, id string, amount int) error
+}
+
+type OrganizationService struct {
+	orgRepo  OrganizationRepository
+	userRepo UserRepository
+}
+
+// Create organization with the actor as its admin.
+func (os *OrganizationService) Create(ctx context.Context, name string) (*Organization, error) {
+	actor := UserFromContext(ctx)
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+
+	org := CreateOrganization(name, actor.ID)
+	if err := org.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.orgRepo.Save(ctx, org); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// Get organization by its id. Only members are allowed to get it.
+func (os *OrganizationService) Get(ctx context.Context, id string) (*Organization, error) {
+	return os.getAuthorized(ctx, id, false)
+}
+
+// List the organizations the actor is member of.
+func (os *OrganizationService) List(ctx context.Context) ([]Organization, error) {
+	actor := UserFromContext(ctx)
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+
+	return os.orgRepo.ListByMember(ctx, actor.ID)
+}
+
+// Add the user with the email to the organization. Only admins are allowed to add members.
+func (os *OrganizationService) AddMember(ctx context.Context, orgId, email string, role OrganizationRole) (*Organization, error) {
+	org, err := os.getAuthorized(ctx, orgId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := os.userRepo.GetByEmail(ctx, NormalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+	if err := org.AddMember(u.ID, role); err != nil {
+		return nil, err
+	}
+	if err := os.orgRepo.Save(ctx, org); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// Remove the user from the organization. Admins can remove anyone, while members can only leave by removing themselves.
+func (os *OrganizationService) RemoveMember(ctx context.Context, orgId, userId string) (*Organization, error) {
+	actor := UserFromContext(ctx)
+	org, err := os.getAuthorized(ctx, orgId, actor == nil || actor.ID != userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := org.RemoveMember(userId); err != nil {
+		return nil, err
+	}
+	if err := os.orgRepo.Save(ctx, org); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// Switch the organization the actor is acting on behalf of, e.g. when creating and listing games. An empty id switches
+// back to the personal account of the actor.
+func (os *OrganizationService) SwitchActive(ctx context.Context, orgId string) (*User, error) {
+	actor := UserFromContext(ctx)
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+	if orgId != "" {
+		if _, err := os.getAuthorized(ctx, orgId, false); err != nil {
+			return nil, err
+		}
+	}
+
+	u, err := os.userRepo.Get(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.ActiveOrganizationID = orgId
+	u.UpdatedAt = time.Now()
+	if err := os.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Get organization and authorize that the actor is member, or admin if required
+func (os *OrganizationService) getAuthorized(ctx context.Context, id string, requireAdmin bool) (*Organization, error) {
+	actor := UserFromContext(ctx)
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+
+	org, err := os.orgRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	role, ok := org.RoleOf(actor.ID)
+	if !ok || (requireAdmin && role != OrganizationRoleAdmin) {
+		return nil, ErrForbidden
+	}
+
+	return org, nil
+}
+
+func NewOrganizationService(orgRepo OrganizationRepository, userRepo UserRepository) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+	}
+}
+
+// Aggregate root for a group of users, e.g. an association, sharing games and a pool of game credits.
+type Organization struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	Members []OrganizationMember `json:"members"`
+
+	// Balance of the credit ledger pooled by the organization, spent on the games it owns. Cached, so debits can be
+	// guarded atomically, while the ledger is the source of truth of the balance shown
+	GameCredits int `json:"gameCredits"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Value object
+type OrganizationMember struct {
+	UserID  string           `json:"userId"`
+	Role    OrganizationRole `json:"role"`
+	AddedAt time.Time        `json:"addedAt"`
+}
+
+func (org *Organization) Validate() error {
+	if strings.TrimSpace(org.Name) == "" {
+		return ErrOrganizationValidation.withFieldErr("Name", "empty", "name has to have a value")
+	}
+	for _, m := range org.Members {
+		if !m.Role.Valid() {
+			return ErrOrganizationValidation.withFieldErr("Role", "noMatch", "role %s does not match any of the available: %s, %s", m.Role, OrganizationRoleAdmin, OrganizationRoleMember)
+		}
+	}
+
+	return nil
+}
+
+// Role of the user in the organization. Ok is false if the user is not member
+func (org *Organization) RoleOf(userId string) (role OrganizationRole, ok bool) {
+	for _, m := range org.Members {
+		if m.UserID == userId {
+			return m.Role, true
+		}
+	}
+
+	return "", false
+}
+
+func (org *Organization) AddMember(userId string, role OrganizationRole) error {
+	if !role.Valid() {
+		return ErrOrganizationValidation.withFieldErr("Role", "noMatch", "role %s does not match any of the available: %s, %s", role, OrganizationRoleAdmin, OrganizationRoleMember)
+	}
+	if _, ok := org.RoleOf(userId); ok {
+		return ErrOrganizationMemberExists
+	}
+
+	org.Members = append(org.Members, OrganizationMember{
+		UserID:  userId,
+		Role:    role,
+		AddedAt: time.Now(),
+	})
+	org.UpdatedAt = time.Now()
+	return nil
+}
+
+// Remove the user from the organization. The last admin can not be removed, so the organization is never left without.
+func (org *Organization) RemoveMember(userId string) error {
+	admins := 0
+	idx := -1
+	for i, m := range org.Members {
+		if m.Role == OrganizationRoleAdmin {
+			admins++
+		}
+		if m.UserID == userId {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		return ErrOrganizationMemberMissing
+	}
+	if org.Members[idx].Role == OrganizationRoleAdmin && admins == 1 {
+		return ErrLastOrganizationAdmin
+	}
+
+	org.Members = append(org.Members[:idx], org.Members[idx+1:]...)
+	org.UpdatedAt = time.Now()
+	return nil
+}
+
+// Organization constructor. The creator becomes the first admin
+func CreateOrganization(name, creatorId string) *Organization {
+	now := time.Now()
+	return &Organization{
+		Name: strings.TrimSpace(name),
+		Members: []OrganizationMember{
+			{
+				UserID:  creatorId,
+				Role:    OrganizationRoleAdmin,
+				AddedAt: now,
+			},
+		},
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+}
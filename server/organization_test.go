@@ -0,0 +1,548 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationService_Create(t *testing.T) {
+
+	actorId := requiretest.UUIDv4(t)
+
+	cases := []struct {
+		caseName     string
+		ctx          context.Context
+		name         string
+		expectSave   bool
+		expectValErr bool
+		expectedErr  error
+	}{
+		{
+			caseName:   "success",
+			ctx:        NewActorContext(t, actorId),
+			name:       " Bingo Association ",
+			expectSave: true,
+		},
+		{
+			caseName:     "empty name",
+			ctx:          NewActorContext(t, actorId),
+			name:         " ",
+			expectValErr: true,
+		},
+		{
+			caseName:    "forbidden no actor",
+			ctx:         context.Background(),
+			name:        "Bingo Association",
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			orgSvc, mocks := MustCreateOrganizationService(t)
+			defer mocks.orgRepo.RequireExpectationsMet()
+
+			if tc.expectSave {
+				mocks.orgRepo.ExpectSave(MakeOrganizationSaveHandler(t))
+			}
+
+			org, err := orgSvc.Create(tc.ctx, tc.name)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, org, "organization must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.NotEmpty(t, org.ID, "organization must be saved")
+			require.Equal(t, "Bingo Association", org.Name, "name must be trimmed")
+			role, ok := org.RoleOf(actorId)
+			require.True(t, ok, "creator must be member")
+			require.Equal(t, bingo.OrganizationRoleAdmin, role, "creator must be admin")
+		})
+	}
+}
+
+func TestOrganizationService_AddMember(t *testing.T) {
+
+	testOrg := MustMakeTestOrganization(t)
+	admin := testOrg.Members[0]
+	member := testOrg.Members[1]
+	newUser := bingo.User{ID: requiretest.UUIDv4(t), Email: "new@test.com"}
+
+	cases := []struct {
+		caseName       string
+		actorId        string
+		email          string
+		role           bingo.OrganizationRole
+		expectGetEmail bool
+		expectSave     bool
+		expectValErr   bool
+		expectedErr    error
+	}{
+		{
+			caseName:       "success",
+			actorId:        admin.UserID,
+			email:          " New@Test.com",
+			role:           bingo.OrganizationRoleMember,
+			expectGetEmail: true,
+			expectSave:     true,
+		},
+		{
+			caseName:       "user not found",
+			actorId:        admin.UserID,
+			email:          "unknown@test.com",
+			role:           bingo.OrganizationRoleMember,
+			expectGetEmail: true,
+			expectedErr:    bingo.ErrUserNotFound,
+		},
+		{
+			caseName:       "invalid role",
+			actorId:        admin.UserID,
+			email:          newUser.Email,
+			role:           bingo.OrganizationRole("OWNER"),
+			expectGetEmail: true,
+			expectValErr:   true,
+		},
+		{
+			caseName:    "forbidden member",
+			actorId:     member.UserID,
+			email:       newUser.Email,
+			role:        bingo.OrganizationRoleMember,
+			expectedErr: bingo.ErrForbidden,
+		},
+		{
+			caseName:    "forbidden outsider",
+			actorId:     requiretest.UUIDv4(t),
+			email:       newUser.Email,
+			role:        bingo.OrganizationRoleMember,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			orgSvc, mocks := MustCreateOrganizationService(t)
+			defer mocks.orgRepo.RequireExpectationsMet()
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			mocks.orgRepo.ExpectGet(MakeSingleOrganizationGetHandler(t, *MustCopyOrganization(t, testOrg)))
+			if tc.expectGetEmail {
+				mocks.userRepo.ExpectGetByEmail(MakeSingleUserGetByEmailHandler(t, newUser))
+			}
+			if tc.expectSave {
+				mocks.orgRepo.ExpectSave(MakeOrganizationSaveHandler(t))
+			}
+
+			org, err := orgSvc.AddMember(NewActorContext(t, tc.actorId), testOrg.ID, tc.email, tc.role)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, org, "organization must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			role, ok := org.RoleOf(newUser.ID)
+			require.True(t, ok, "user must be member after being added")
+			require.Equal(t, tc.role, role, "member must have the role added with")
+		})
+	}
+}
+
+func TestOrganizationService_RemoveMember(t *testing.T) {
+
+	testOrg := MustMakeTestOrganization(t)
+	admin := testOrg.Members[0]
+	member := testOrg.Members[1]
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		userId      string
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName:   "success admin removes member",
+			actorId:    admin.UserID,
+			userId:     member.UserID,
+			expectSave: true,
+		},
+		{
+			caseName:   "success member leaves",
+			actorId:    member.UserID,
+			userId:     member.UserID,
+			expectSave: true,
+		},
+		{
+			caseName:    "last admin",
+			actorId:     admin.UserID,
+			userId:      admin.UserID,
+			expectedErr: bingo.ErrLastOrganizationAdmin,
+		},
+		{
+			caseName:    "not member",
+			actorId:     admin.UserID,
+			userId:      requiretest.UUIDv4(t),
+			expectedErr: bingo.ErrOrganizationMemberMissing,
+		},
+		{
+			caseName:    "forbidden member removes admin",
+			actorId:     member.UserID,
+			userId:      admin.UserID,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			orgSvc, mocks := MustCreateOrganizationService(t)
+			defer mocks.orgRepo.RequireExpectationsMet()
+
+			mocks.orgRepo.ExpectGet(MakeSingleOrganizationGetHandler(t, *MustCopyOrganization(t, testOrg)))
+			if tc.expectSave {
+				mocks.orgRepo.ExpectSave(MakeOrganizationSaveHandler(t))
+			}
+
+			org, err := orgSvc.RemoveMember(NewActorContext(t, tc.actorId), testOrg.ID, tc.userId)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, org, "organization must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			_, ok := org.RoleOf(tc.userId)
+			require.False(t, ok, "user must not be member after being removed")
+		})
+	}
+}
+
+func TestOrganizationService_SwitchActive(t *testing.T) {
+
+	testOrg := MustMakeTestOrganization(t)
+	member := testOrg.Members[1]
+	memberUser := bingo.User{ID: member.UserID, Email: "member@test.com"}
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		orgId       string
+		expectGet   bool
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName:   "success organization",
+			actorId:    member.UserID,
+			orgId:      testOrg.ID,
+			expectGet:  true,
+			expectSave: true,
+		},
+		{
+			caseName:   "success personal",
+			actorId:    member.UserID,
+			orgId:      "",
+			expectSave: true,
+		},
+		{
+			caseName:    "organization not found",
+			actorId:     member.UserID,
+			orgId:       requiretest.UUIDv4(t),
+			expectGet:   true,
+			expectedErr: bingo.ErrOrganizationNotFound,
+		},
+		{
+			caseName:    "forbidden outsider",
+			actorId:     requiretest.UUIDv4(t),
+			orgId:       testOrg.ID,
+			expectGet:   true,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			orgSvc, mocks := MustCreateOrganizationService(t)
+			defer mocks.orgRepo.RequireExpectationsMet()
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			if tc.expectGet {
+				mocks.orgRepo.ExpectGet(MakeSingleOrganizationGetHandler(t, *testOrg))
+			}
+			if tc.expectSave {
+				mocks.userRepo.ExpectGet(func(_ context.Context, id string) (*bingo.User, error) {
+					u := memberUser
+					return &u, nil
+				})
+				mocks.userRepo.ExpectSave(func(_ context.Context, u *bingo.User) error {
+					return nil
+				})
+			}
+
+			u, err := orgSvc.SwitchActive(NewActorContext(t, tc.actorId), tc.orgId)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, u, "user must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, tc.orgId, u.ActiveOrganizationID, "active organization must be switched")
+		})
+	}
+}
+
+// Members of the organization owning a game have a role in it, without being members of the game itself
+func TestGameService_OrganizationRoles(t *testing.T) {
+
+	testOrg := MustMakeTestOrganization(t)
+	testGame := MustMakeTestGame(t)
+	testGame.OrganizationID = testOrg.ID
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		expectedErr error
+	}{
+		{
+			caseName: "admin",
+			actorId:  testOrg.Members[0].UserID,
+		},
+		{
+			caseName: "member",
+			actorId:  testOrg.Members[1].UserID,
+		},
+		{
+			caseName:    "forbidden outsider",
+			actorId:     requiretest.UUIDv4(t),
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.orgRepo.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			mocks.orgRepo.ExpectGet(MakeSingleOrganizationGetHandler(t, *testOrg))
+
+			_, err := gameSvc.Get(NewActorContext(t, tc.actorId), testGame.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+			} else {
+				require.NoError(t, err, "no error is expected")
+			}
+		})
+	}
+}
+
+func TestGameService_CreateInOrganization(t *testing.T) {
+
+	testOrg := MustMakeTestOrganization(t)
+	member := testOrg.Members[1]
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			caseName:   "success",
+			actorId:    member.UserID,
+			expectSave: true,
+		},
+		{
+			caseName:    "forbidden outsider",
+			actorId:     requiretest.UUIDv4(t),
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.orgRepo.RequireExpectationsMet()
+			defer mocks.creditRepo.RequireExpectationsMet()
+
+			mocks.orgRepo.ExpectGet(MakeSingleOrganizationGetHandler(t, *testOrg))
+			if tc.expectSave {
+				mocks.gameRepo.ExpectSave(MakeGameSaveHandler(t))
+				mocks.orgRepo.ExpectAddCredits(func(_ context.Context, id string, amount int) error {
+					require.Equal(t, testOrg.ID, id, "game creation must be debited from the organization")
+					require.Equal(t, -bingo.GameCreationCost, amount, "cost of creating the game must be debited")
+					return nil
+				})
+				mocks.creditRepo.ExpectAppend(MakeCreditAppendHandler(t))
+			}
+
+			ctx := bingo.NewContextWithUser(context.Background(), &bingo.User{ID: tc.actorId, ActiveOrganizationID: testOrg.ID})
+			g, err := gameSvc.Create(ctx, tc.actorId, "association game")
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, g, "game must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, testOrg.ID, g.OrganizationID, "game must be owned by the active organization")
+		})
+	}
+}
+
+func TestGameService_List(t *testing.T) {
+
+	testOrg := MustMakeTestOrganization(t)
+	member := testOrg.Members[1]
+
+	cases := []struct {
+		caseName       string
+		ctx            context.Context
+		expectGetOrg   bool
+		expectedFilter bingo.GameFilter
+		expectedErr    error
+	}{
+		{
+			caseName:       "personal",
+			ctx:            NewActorContext(t, member.UserID),
+			expectedFilter: bingo.GameFilter{HostID: member.UserID},
+		},
+		{
+			caseName:       "active organization",
+			ctx:            bingo.NewContextWithUser(context.Background(), &bingo.User{ID: member.UserID, ActiveOrganizationID: testOrg.ID}),
+			expectGetOrg:   true,
+			expectedFilter: bingo.GameFilter{OrganizationID: testOrg.ID},
+		},
+		{
+			caseName:     "forbidden no longer member",
+			ctx:          bingo.NewContextWithUser(context.Background(), &bingo.User{ID: requiretest.UUIDv4(t), ActiveOrganizationID: testOrg.ID}),
+			expectGetOrg: true,
+			expectedErr:  bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			gameSvc, mocks := MustCreateGameService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.orgRepo.RequireExpectationsMet()
+
+			if tc.expectGetOrg {
+				mocks.orgRepo.ExpectGet(MakeSingleOrganizationGetHandler(t, *testOrg))
+			}
+			if tc.expectedErr == nil {
+				mocks.gameRepo.ExpectFind(func(_ context.Context, filter bingo.GameFilter) ([]bingo.Game, error) {
+					require.Equal(t, tc.expectedFilter, filter, "games must be filtered by the active owner")
+					return []bingo.Game{*MustMakeTestGame(t)}, nil
+				})
+			}
+
+			games, err := gameSvc.List(tc.ctx)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.Len(t, games, 1, "games found must be returned")
+			}
+		})
+	}
+}
+
+type organizationServiceMocks struct {
+	orgRepo  *mock.OrganizationRepository
+	userRepo *mock.UserRepository
+}
+
+func MustCreateOrganizationService(tb testing.TB) (*bingo.OrganizationService, *organizationServiceMocks) {
+	tb.Helper()
+
+	orgRepo := mock.NewOrganizationRepository(tb)
+	userRepo := mock.NewUserRepository(tb)
+
+	orgSvc := bingo.NewOrganizationService(orgRepo, userRepo)
+	mocks := &organizationServiceMocks{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+	}
+
+	return orgSvc, mocks
+}
+
+func MakeOrganizationSaveHandler(tb testing.TB) mock.OrganizationSaveHandler {
+	tb.Helper()
+
+	return func(ctx context.Context, org *bingo.Organization) error {
+		if org.ID == "" {
+			org.ID = requiretest.UUIDv4(tb)
+		}
+
+		return nil
+	}
+}
+
+func MakeSingleOrganizationGetHandler(tb testing.TB, org bingo.Organization) mock.OrganizationGetHandler {
+	tb.Helper()
+
+	return func(_ context.Context, id string) (*bingo.Organization, error) {
+		if id != org.ID {
+			return nil, bingo.ErrOrganizationNotFound
+		}
+
+		return &org, nil
+	}
+}
+
+// Make organization with an admin and a member
+func MustMakeTestOrganization(tb testing.TB) *bingo.Organization {
+	tb.Helper()
+
+	return &bingo.Organization{
+		ID:   requiretest.UUIDv4(tb),
+		Name: "Bingo Association",
+		Members: []bingo.OrganizationMember{
+			{
+				UserID:  requiretest.UUIDv4(tb),
+				Role:    bingo.OrganizationRoleAdmin,
+				AddedAt: time.Now().Add(-24 * time.Hour),
+			},
+			{
+				UserID:  requiretest.UUIDv4(tb),
+				Role:    bingo.OrganizationRoleMember,
+				AddedAt: time.Now(),
+			},
+		},
+		GameCredits: 5,
+		UpdatedAt:   time.Now(),
+		CreatedAt:   time.Now().Add(-24 * time.Hour),
+	}
+}
+
+// Copy organization so test cases mutating it do not affect each other
+func MustCopyOrganization(tb testing.TB, org *bingo.Organization) *bingo.Organization {
+	tb.Helper()
+
+	c := *org
+	c.Members = append([]bingo.OrganizationMember(nil), org.Members...)
+	return &c
+}
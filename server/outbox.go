@@ -0,0 +1,594 @@
+package bingo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrOutboxMessageNotFound = errors.New("bingo: outbox message could not be found")
+	ErrOutboxEmpty           = errors.New("bingo: no outbox messages are due")
+	ErrOutboxMessageNotDead  = errors.New("bingo: only dead outbox messages can be resent")
+	ErrUnknownMessageKind    = errors.New("bingo: outbox message is of an unknown kind")
+)
+
+const (
+	// Attempts of delivering a message before it is dead-lettered
+	OutboxMaxAttempts = 8
+
+	// Delay before retrying a failed delivery, which is doubled for each attempt up to the max
+	OutboxBaseBackoff = 30 * time.Second
+	OutboxMaxBackoff  = 6 * time.Hour
+
+	// Time a claimed message is reserved for the worker delivering it, before others may claim it again
+	OutboxLease = 5 * time.Minute
+)
+
+type OutboxRepository interface {
+	Save(ctx context.Context, msg *OutboxMessage) error
+	Get(ctx context.Context, msgId string) (*OutboxMessage, error)
+
+	// Claim the next pending message due at the given time, by postponing its next attempt with the lease. Returns
+	// ErrOutboxEmpty when no messages are due.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*OutboxMessage, error)
+
+	// Find messages with the status, newest first
+	Find(ctx context.Context, status OutboxStatus) ([]OutboxMessage, error)
+}
+
+// Renders cards to a printable file, e.g. a pdf
+type CardRenderer interface {
+	RenderCards(w io.Writer, g *Game, cards []Card) error
+}
+
+// Transport sending mails composed by the outbox
+type Mailer interface {
+	Send(ctx context.Context, m *Mail) error
+}
+
+// Renders the subject and bodies of mails from templates translated to the language. Falls back to the default
+// language if the template is not translated to the one given
+type MailRenderer interface {
+	RenderMail(tmpl MailTemplate, lang Language, data interface{}) (*Mail, error)
+}
+
+type Mail struct {
+	To      string
+	Subject string
+	HTML    string
+
+	// Plain text alternative of the html
+	Text string
+
+	Attachments []MailAttachment
+}
+
+type MailAttachment struct {
+	Filename string
+	Content  []byte
+}
+
+// Template mails are rendered from
+type MailTemplate string
+
+const (
+	MailTemplatePlayerCards        MailTemplate = "player_cards"
+	MailTemplatePlayerConfirmation MailTemplate = "player_confirmation"
+	MailTemplatePlayerMagicLink    MailTemplate = "player_magic_link"
+	MailTemplateMemberInvitation   MailTemplate = "member_invitation"
+)
+
+// Data the player cards template is rendered with
+type PlayerCardsMailData struct {
+	PlayerName string
+	GameName   string
+
+	// Date the cards were generated
+	Date time.Time
+
+	// Link for downloading the cards, in case the attachment is lost. It carries a player session valid for a while
+	DownloadLink string
+}
+
+// Data the player confirmation template is rendered with
+type PlayerConfirmationMailData struct {
+	PlayerName string
+	GameName   string
+
+	// Link for confirming the email, which is valid until the player expires
+	ConfirmLink string
+	ConfirmBy   time.Time
+}
+
+// Data the player magic link template is rendered with
+type PlayerMagicLinkMailData struct {
+	PlayerName string
+	GameName   string
+
+	// Link starting a session of the player, which is valid until it expires
+	Link      string
+	ExpiresAt time.Time
+}
+
+// Data the member invitation template is rendered with
+type MemberInvitationMailData struct {
+	GameName string
+	Role     GameRole
+
+	// Link for accepting the invitation, which is valid until it expires
+	AcceptLink string
+	AcceptBy   time.Time
+}
+
+// Kind of message, deciding how the mail is composed when delivered
+type MessageKind string
+
+const (
+	MessageKindPlayerCards        MessageKind = "PLAYER_CARDS"
+	MessageKindPlayerConfirmation MessageKind = "PLAYER_CONFIRMATION"
+	MessageKindPlayerMagicLink    MessageKind = "PLAYER_MAGIC_LINK"
+	MessageKindMemberInvitation   MessageKind = "MEMBER_INVITATION"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "PENDING"
+	OutboxStatusSent    OutboxStatus = "SENT"
+	OutboxStatusDead    OutboxStatus = "DEAD"
+)
+
+type OutboxService struct {
+	outboxRepo OutboxRepository
+	playerRepo PlayerRepository
+	invRepo    InvitationRepository
+	gameRepo   GameRepository
+	authz      gameAuthorizer
+	renderer   CardRenderer
+	templates  MailRenderer
+	mailer     Mailer
+
+	// Signs the tokens of the links players confirm their email with
+	confirmations ConfirmationTokenSigner
+
+	// Signs the tokens of the sessions magic links and download links start for players
+	sessions PlayerSessionSigner
+
+	// Signs the tokens of the links members accept their invitations with
+	invitations MemberInvitationSigner
+
+	// Base url of links to the web app mailed to players, e.g. for downloading their cards
+	linkBase string
+}
+
+// Deliver all messages that are due. Failed deliveries are retried with backoff by later runs, so only errors
+// persisting the outcome are returned. Returns the amount of messages attempted.
+func (obs *OutboxService) DeliverDue(ctx context.Context) (int, error) {
+	attempted := 0
+	for {
+		msg, err := obs.outboxRepo.ClaimDue(ctx, time.Now(), OutboxLease)
+		if errors.Is(err, ErrOutboxEmpty) {
+			return attempted, nil
+		}
+		if err != nil {
+			return attempted, err
+		}
+
+		if err := obs.deliver(ctx, msg); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+}
+
+// List messages with the status, e.g. the dead ones. Only admins can inspect the outbox.
+func (obs *OutboxService) List(ctx context.Context, status OutboxStatus) ([]OutboxMessage, error) {
+	if err := obs.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return obs.outboxRepo.Find(ctx, status)
+}
+
+// Resend a dead message, by queuing it for delivery with its attempts reset. Only admins can resend messages.
+func (obs *OutboxService) Resend(ctx context.Context, msgId string) (*OutboxMessage, error) {
+	if err := obs.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	msg, err := obs.outboxRepo.Get(ctx, msgId)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Status != OutboxStatusDead {
+		return nil, ErrOutboxMessageNotDead
+	}
+
+	msg.requeue(time.Now())
+	if err := obs.outboxRepo.Save(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// Attempt delivering the message and record the outcome on it, and on the player it was sent to
+func (obs *OutboxService) deliver(ctx context.Context, msg *OutboxMessage) error {
+	switch msg.Kind {
+	case MessageKindPlayerCards:
+		player, err := obs.deliverPlayerCards(ctx, msg)
+		msg.recordAttempt(err, time.Now())
+		if player != nil {
+			if err := obs.playerRepo.RecordDelivery(ctx, player.ID, deliveryStatusOf(msg), msg.LastError, msg.UpdatedAt); err != nil {
+				return err
+			}
+		}
+	case MessageKindPlayerConfirmation:
+		msg.recordAttempt(obs.deliverPlayerConfirmation(ctx, msg), time.Now())
+	case MessageKindPlayerMagicLink:
+		msg.recordAttempt(obs.deliverPlayerMagicLink(ctx, msg), time.Now())
+	case MessageKindMemberInvitation:
+		msg.recordAttempt(obs.deliverMemberInvitation(ctx, msg), time.Now())
+	default:
+		msg.recordAttempt(ErrUnknownMessageKind, time.Now())
+	}
+
+	return obs.outboxRepo.Save(ctx, msg)
+}
+
+// Render the cards of the player and mail them. The player is returned if found, even if delivery failed. Players
+// removed since the message was queued no longer have cards to get, so the message is done without sending anything
+func (obs *OutboxService) deliverPlayerCards(ctx context.Context, msg *OutboxMessage) (*Player, error) {
+	player, err := obs.playerRepo.Get(ctx, msg.PlayerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := player.requireCards(); err != nil {
+		return nil, nil
+	}
+	inv, err := obs.invRepo.Get(ctx, player.InvitationID)
+	if err != nil {
+		return player, err
+	}
+	g, err := obs.gameRepo.Get(ctx, inv.GameID)
+	if err != nil {
+		return player, err
+	}
+
+	// Void cards can no longer win, so they are not delivered
+	var buf bytes.Buffer
+	if err := obs.renderer.RenderCards(&buf, g, player.validCards()); err != nil {
+		return player, err
+	}
+
+	m, err := obs.composePlayerCardsMail(player, g)
+	if err != nil {
+		return player, err
+	}
+	m.Attachments = []MailAttachment{
+		{Filename: "cards.pdf", Content: buf.Bytes()},
+	}
+
+	return player, obs.mailer.Send(ctx, m)
+}
+
+// Mail the player a link to confirm their email with. Players who have confirmed or expired since the message was
+// queued need no link, so the message is done without sending anything
+func (obs *OutboxService) deliverPlayerConfirmation(ctx context.Context, msg *OutboxMessage) error {
+	player, err := obs.playerRepo.Get(ctx, msg.PlayerID)
+	if errors.Is(err, ErrPlayerNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if player.Status != PlayerStatusUnconfirmed || player.confirmationExpired(now) {
+		return nil
+	}
+	inv, err := obs.invRepo.Get(ctx, player.InvitationID)
+	if err != nil {
+		return err
+	}
+	g, err := obs.gameRepo.Get(ctx, inv.GameID)
+	if err != nil {
+		return err
+	}
+
+	token, err := obs.confirmations.Sign(ConfirmationClaims{
+		PlayerID:  player.ID,
+		Email:     player.Email,
+		IssuedAt:  now,
+		ExpiresAt: player.ConfirmBy,
+	})
+	if err != nil {
+		return err
+	}
+	data := PlayerConfirmationMailData{
+		PlayerName:  player.Name,
+		GameName:    g.Name,
+		ConfirmLink: fmt.Sprintf("%s/join/confirm?token=%s", obs.linkBase, url.QueryEscape(token)),
+		ConfirmBy:   player.ConfirmBy,
+	}
+	m, err := obs.templates.RenderMail(MailTemplatePlayerConfirmation, player.MailLanguage(g), data)
+	if err != nil {
+		return err
+	}
+	m.To = player.Email
+
+	return obs.mailer.Send(ctx, m)
+}
+
+// Mail the player a magic link starting a session of theirs. Players removed since the message was queued get no
+// link, so the message is done without sending anything
+func (obs *OutboxService) deliverPlayerMagicLink(ctx context.Context, msg *OutboxMessage) error {
+	player, err := obs.playerRepo.Get(ctx, msg.PlayerID)
+	if errors.Is(err, ErrPlayerNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if player.Status == PlayerStatusRemoved {
+		return nil
+	}
+	inv, err := obs.invRepo.Get(ctx, player.InvitationID)
+	if err != nil {
+		return err
+	}
+	g, err := obs.gameRepo.Get(ctx, inv.GameID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	token, err := obs.signPlayerSession(player, now, PlayerSessionLifetime)
+	if err != nil {
+		return err
+	}
+	data := PlayerMagicLinkMailData{
+		PlayerName: player.Name,
+		GameName:   g.Name,
+		Link:       fmt.Sprintf("%s/player/session?token=%s", obs.linkBase, url.QueryEscape(token)),
+		ExpiresAt:  now.Add(PlayerSessionLifetime),
+	}
+	m, err := obs.templates.RenderMail(MailTemplatePlayerMagicLink, player.MailLanguage(g), data)
+	if err != nil {
+		return err
+	}
+	m.To = player.Email
+
+	return obs.mailer.Send(ctx, m)
+}
+
+// Mail the member invited a link to accept the invitation by. Members who have accepted or been removed since the
+// message was queued need no link, so the message is done without sending anything
+func (obs *OutboxService) deliverMemberInvitation(ctx context.Context, msg *OutboxMessage) error {
+	g, err := obs.gameRepo.Get(ctx, msg.GameID)
+	if errors.Is(err, ErrGameNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m, ok := g.Member(msg.Email)
+	if !ok || m.Accepted() {
+		return nil
+	}
+
+	now := time.Now()
+	token, err := obs.invitations.Sign(MemberInvitationClaims{
+		GameID:    g.ID,
+		Email:     m.Email,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(MemberInvitationLifetime),
+	})
+	if err != nil {
+		return err
+	}
+	data := MemberInvitationMailData{
+		GameName:   g.Name,
+		Role:       m.Role,
+		AcceptLink: fmt.Sprintf("%s/games/%s/members/accept?token=%s", obs.linkBase, g.ID, url.QueryEscape(token)),
+		AcceptBy:   now.Add(MemberInvitationLifetime),
+	}
+	lang := DefaultLanguage
+	if g.Language.Valid() {
+		lang = g.Language
+	}
+	mail, err := obs.templates.RenderMail(MailTemplateMemberInvitation, lang, data)
+	if err != nil {
+		return err
+	}
+	mail.To = m.Email
+
+	return obs.mailer.Send(ctx, mail)
+}
+
+// Sign a token of a session of the player, lasting for the lifetime from now
+func (obs *OutboxService) signPlayerSession(p *Player, now time.Time, lifetime time.Duration) (string, error) {
+	return obs.sessions.Sign(PlayerSessionClaims{
+		PlayerID:  p.ID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(lifetime),
+	})
+}
+
+// Preview the mail players of the game get their cards by, as if sent to a made up player. Only members allowed to
+// manage the game can preview its mails.
+func (obs *OutboxService) PreviewPlayerCardsMail(ctx context.Context, gameId string, lang Language) (*Mail, error) {
+	g, err := obs.gameRepo.Get(ctx, gameId)
+	if err != nil {
+		return nil, err
+	}
+	if err := obs.authz.authorize(ctx, g, PermissionManageGame); err != nil {
+		return nil, err
+	}
+
+	player := &Player{
+		ID:        "preview",
+		Name:      "Jane Doe",
+		Email:     "jane.doe@example.com",
+		Language:  lang,
+		CreatedAt: time.Now(),
+	}
+
+	return obs.composePlayerCardsMail(player, g)
+}
+
+// Render the mail delivering cards to the player in their language, without the cards attached
+func (obs *OutboxService) composePlayerCardsMail(to *Player, g *Game) (*Mail, error) {
+	token, err := obs.signPlayerSession(to, time.Now(), PlayerCardsLinkLifetime)
+	if err != nil {
+		return nil, err
+	}
+	data := PlayerCardsMailData{
+		PlayerName:   to.Name,
+		GameName:     g.Name,
+		Date:         to.CreatedAt,
+		DownloadLink: fmt.Sprintf("%s/player/%s/downloadCards?token=%s", obs.linkBase, to.ID, url.QueryEscape(token)),
+	}
+	m, err := obs.templates.RenderMail(MailTemplatePlayerCards, to.MailLanguage(g), data)
+	if err != nil {
+		return nil, err
+	}
+	m.To = to.Email
+
+	return m, nil
+}
+
+func (obs *OutboxService) requireAdmin(ctx context.Context) error {
+	actor := UserFromContext(ctx)
+	if actor == nil || !actor.Admin {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func NewOutboxService(repos Repositories, renderer CardRenderer, templates MailRenderer, mailer Mailer, confirmations ConfirmationTokenSigner, sessions PlayerSessionSigner, invitations MemberInvitationSigner, linkBase string) *OutboxService {
+	return &OutboxService{
+		outboxRepo:    repos.Outbox,
+		playerRepo:    repos.Players,
+		invRepo:       repos.Invitations,
+		gameRepo:      repos.Games,
+		authz:         gameAuthorizer{orgRepo: repos.Organizations},
+		renderer:      renderer,
+		templates:     templates,
+		mailer:        mailer,
+		confirmations: confirmations,
+		sessions:      sessions,
+		invitations:   invitations,
+		linkBase:      linkBase,
+	}
+}
+
+// Entity
+type OutboxMessage struct {
+	ID   string      `json:"id"`
+	Kind MessageKind `json:"kind"`
+
+	// Player the message is sent to
+	PlayerID string `json:"playerId,omitempty"`
+
+	// Game and email of the member the message is sent to, if sent to a member instead of a player
+	GameID string `json:"gameId,omitempty"`
+	Email  string `json:"email,omitempty"`
+
+	Status        OutboxStatus `json:"status"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"nextAttemptAt"`
+	LastError     string       `json:"lastError,omitempty"`
+	SentAt        time.Time    `json:"sentAt"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Record the outcome of an attempt delivering the message. Failed messages are retried with exponential backoff, until
+// they have been attempted the max times, after which they are dead
+func (m *OutboxMessage) recordAttempt(err error, now time.Time) {
+	m.Attempts++
+	m.UpdatedAt = now
+	if err == nil {
+		m.Status = OutboxStatusSent
+		m.LastError = ""
+		m.SentAt = now
+		return
+	}
+
+	m.LastError = err.Error()
+	if m.Attempts >= OutboxMaxAttempts {
+		m.Status = OutboxStatusDead
+		return
+	}
+	m.NextAttemptAt = now.Add(OutboxBackoff(m.Attempts))
+}
+
+// Queue the message for delivery again as if it was new
+func (m *OutboxMessage) requeue(now time.Time) {
+	m.Status = OutboxStatusPending
+	m.Attempts = 0
+	m.NextAttemptAt = now
+	m.UpdatedAt = now
+}
+
+// Delay before the next attempt, after the given amount of failed attempts
+func OutboxBackoff(attempts int) time.Duration {
+	backoff := OutboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= OutboxMaxBackoff {
+			return OutboxMaxBackoff
+		}
+	}
+
+	return backoff
+}
+
+// Create message delivering the cards of the player by mail. It is due right away
+func NewPlayerCardsMessage(p *Player) *OutboxMessage {
+	return newPlayerMessage(MessageKindPlayerCards, p)
+}
+
+// Create message mailing the player a link to confirm their email with. It is due right away
+func NewPlayerConfirmationMessage(p *Player) *OutboxMessage {
+	return newPlayerMessage(MessageKindPlayerConfirmation, p)
+}
+
+// Create message mailing the player a magic link starting a session of theirs. It is due right away
+func NewPlayerMagicLinkMessage(p *Player) *OutboxMessage {
+	return newPlayerMessage(MessageKindPlayerMagicLink, p)
+}
+
+func newPlayerMessage(kind MessageKind, p *Player) *OutboxMessage {
+	now := time.Now()
+	return &OutboxMessage{
+		Kind:          kind,
+		PlayerID:      p.ID,
+		Status:        OutboxStatusPending,
+		NextAttemptAt: now,
+		UpdatedAt:     now,
+		CreatedAt:     now,
+	}
+}
+
+// Create message mailing the member of the game with the email a link to accept their invitation by. It is due right
+// away
+func NewMemberInvitationMessage(g *Game, email string) *OutboxMessage {
+	now := time.Now()
+	return &OutboxMessage{
+		Kind:          MessageKindMemberInvitation,
+		GameID:        g.ID,
+		Email:         email,
+		Status:        OutboxStatusPending,
+		NextAttemptAt: now,
+		UpdatedAt:     now,
+		CreatedAt:     now,
+	}
+}
@@ -0,0 +1,420 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+
+	cases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: bingo.OutboxBaseBackoff},
+		{attempts: 2, expected: 2 * bingo.OutboxBaseBackoff},
+		{attempts: 4, expected: 8 * bingo.OutboxBaseBackoff},
+		{attempts: 100, expected: bingo.OutboxMaxBackoff},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.expected, bingo.OutboxBackoff(tc.attempts), "backoff after %d attempts", tc.attempts)
+	}
+}
+
+func TestOutboxService_DeliverDue(t *testing.T) {
+
+	var ErrMailer = errors.New("mailer: mailbox full")
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.DeliveryMethod = bingo.InvitationDeliveryMethodMail
+
+	cases := []struct {
+		caseName             string
+		priorAttempts        int
+		sendErr              error
+		expectedStatus       bingo.OutboxStatus
+		expectedPlayerStatus bingo.DeliveryStatus
+	}{
+		{
+			caseName:             "sent",
+			expectedStatus:       bingo.OutboxStatusSent,
+			expectedPlayerStatus: bingo.DeliveryStatusSent,
+		},
+		{
+			caseName:             "failed is retried",
+			sendErr:              ErrMailer,
+			expectedStatus:       bingo.OutboxStatusPending,
+			expectedPlayerStatus: bingo.DeliveryStatusPending,
+		},
+		{
+			caseName:             "failed last attempt is dead",
+			priorAttempts:        bingo.OutboxMaxAttempts - 1,
+			sendErr:              ErrMailer,
+			expectedStatus:       bingo.OutboxStatusDead,
+			expectedPlayerStatus: bingo.DeliveryStatusFailed,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			outboxSvc, mocks := MustCreateOutboxService(t)
+			defer mocks.outboxRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.renderer.RequireExpectationsMet()
+			defer mocks.templates.RequireExpectationsMet()
+			defer mocks.mailer.RequireExpectationsMet()
+
+			testPlayer := MustMakeTestPlayer(t, testInv)
+			testPlayer.DeliveryStatus = bingo.DeliveryStatusPending
+			msg := MustMakeTestOutboxMessage(t, testPlayer)
+			msg.Attempts = tc.priorAttempts
+
+			claimed := false
+			claimHandler := func(_ context.Context, _ time.Time, lease time.Duration) (*bingo.OutboxMessage, error) {
+				if claimed {
+					return nil, bingo.ErrOutboxEmpty
+				}
+				claimed = true
+				require.Equal(t, bingo.OutboxLease, lease, "messages must be claimed for the lease")
+				return msg, nil
+			}
+			mocks.outboxRepo.ExpectClaimDue(claimHandler)
+			mocks.outboxRepo.ExpectClaimDue(claimHandler)
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *testPlayer))
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			mocks.renderer.ExpectRenderCards(func(w io.Writer, g *bingo.Game, _ []bingo.Card) error {
+				require.Equal(t, testGame.ID, g.ID, "cards must be rendered for the game of the player")
+				_, err := w.Write([]byte("%PDF"))
+				return err
+			})
+			mocks.templates.ExpectRenderMail(MakeMailRenderHandler(t))
+			mocks.mailer.ExpectSend(func(_ context.Context, m *bingo.Mail) error {
+				require.Equal(t, testPlayer.Email, m.To, "cards must be sent to the player")
+				require.Len(t, m.Attachments, 1, "cards file must be attached")
+				require.Equal(t, "%PDF", string(m.Attachments[0].Content), "rendered cards must be attached")
+				return tc.sendErr
+			})
+			var recordedStatus bingo.DeliveryStatus
+			var recordedErr string
+			mocks.playerRepo.ExpectRecordDelivery(func(_ context.Context, playerId string, status bingo.DeliveryStatus, deliveryErr string, _ time.Time) error {
+				require.Equal(t, testPlayer.ID, playerId, "outcome must be recorded on the player of the message")
+				recordedStatus = status
+				recordedErr = deliveryErr
+				return nil
+			})
+			var savedMsg *bingo.OutboxMessage
+			mocks.outboxRepo.ExpectSave(func(_ context.Context, m *bingo.OutboxMessage) error {
+				savedMsg = m
+				return nil
+			})
+
+			attempted, err := outboxSvc.DeliverDue(context.Background())
+			require.NoError(t, err, "failed deliveries must not be returned as errors")
+			require.Equal(t, 1, attempted, "the due message must be attempted")
+
+			require.Equal(t, tc.expectedStatus, savedMsg.Status, "outcome must be recorded on the message")
+			require.Equal(t, tc.priorAttempts+1, savedMsg.Attempts, "attempt must be counted")
+			require.Equal(t, tc.expectedPlayerStatus, recordedStatus, "outcome must be recorded on the player")
+			if tc.sendErr != nil {
+				require.Equal(t, tc.sendErr.Error(), savedMsg.LastError, "error must be recorded on the message")
+				require.Equal(t, tc.sendErr.Error(), recordedErr, "error must be recorded on the player")
+			}
+			if tc.expectedStatus == bingo.OutboxStatusPending {
+				require.True(t, savedMsg.NextAttemptAt.After(time.Now()), "retry must be backed off")
+			}
+		})
+	}
+}
+
+// Cards revoked since the message was queued can no longer win, so they must not be delivered
+func TestOutboxService_DeliverDue_VoidCards(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.DeliveryMethod = bingo.InvitationDeliveryMethodMail
+
+	outboxSvc, mocks := MustCreateOutboxService(t)
+	defer mocks.outboxRepo.RequireExpectationsMet()
+	defer mocks.playerRepo.RequireExpectationsMet()
+	defer mocks.renderer.RequireExpectationsMet()
+	defer mocks.mailer.RequireExpectationsMet()
+
+	testPlayer := MustMakeTestPlayer(t, testInv)
+	testPlayer.DeliveryStatus = bingo.DeliveryStatusPending
+	for i := 0; i < 3; i++ {
+		testPlayer.Cards = append(testPlayer.Cards, bingo.Card{ID: requiretest.UUIDv4(t), GameID: testGame.ID, PlayerID: testPlayer.ID, Number: i + 1})
+	}
+	testPlayer.Cards[1].Revocation = &bingo.CardRevocation{Reason: "misprint", RevokedAt: time.Now()}
+	msg := MustMakeTestOutboxMessage(t, testPlayer)
+
+	claimed := false
+	claimHandler := func(_ context.Context, _ time.Time, _ time.Duration) (*bingo.OutboxMessage, error) {
+		if claimed {
+			return nil, bingo.ErrOutboxEmpty
+		}
+		claimed = true
+		return msg, nil
+	}
+	mocks.outboxRepo.ExpectClaimDue(claimHandler)
+	mocks.outboxRepo.ExpectClaimDue(claimHandler)
+	mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *testPlayer))
+	mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+	mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+	mocks.renderer.ExpectRenderCards(func(w io.Writer, _ *bingo.Game, cards []bingo.Card) error {
+		require.Len(t, cards, 2, "void cards must not be rendered")
+		for _, c := range cards {
+			require.False(t, c.Void(), "void cards must not be rendered")
+		}
+		_, err := w.Write([]byte(fmt.Sprintf("%d cards", len(cards))))
+		return err
+	})
+	mocks.templates.ExpectRenderMail(MakeMailRenderHandler(t))
+	mocks.mailer.ExpectSend(func(_ context.Context, m *bingo.Mail) error {
+		require.Len(t, m.Attachments, 1, "cards file must be attached")
+		require.Equal(t, "2 cards", string(m.Attachments[0].Content), "only the valid cards must be attached")
+		return nil
+	})
+	mocks.playerRepo.ExpectRecordDelivery(func(_ context.Context, _ string, _ bingo.DeliveryStatus, _ string, _ time.Time) error { return nil })
+	mocks.outboxRepo.ExpectSave(func(_ context.Context, _ *bingo.OutboxMessage) error { return nil })
+
+	_, err := outboxSvc.DeliverDue(context.Background())
+	require.NoError(t, err, "no error is expected")
+}
+
+// Players removed since the message was queued have no cards to get, so nothing must be mailed nor recorded on them
+func TestOutboxService_DeliverDue_RemovedPlayer(t *testing.T) {
+
+	testInv := MustMakeTestInvitation(t, MustMakeTestGame(t))
+
+	outboxSvc, mocks := MustCreateOutboxService(t)
+	defer mocks.outboxRepo.RequireExpectationsMet()
+	defer mocks.playerRepo.RequireExpectationsMet()
+	defer mocks.renderer.RequireExpectationsMet()
+	defer mocks.mailer.RequireExpectationsMet()
+
+	testPlayer := MustMakeTestPlayer(t, testInv)
+	testPlayer.Status = bingo.PlayerStatusRemoved
+	msg := MustMakeTestOutboxMessage(t, testPlayer)
+
+	claimed := false
+	claimHandler := func(_ context.Context, _ time.Time, _ time.Duration) (*bingo.OutboxMessage, error) {
+		if claimed {
+			return nil, bingo.ErrOutboxEmpty
+		}
+		claimed = true
+		return msg, nil
+	}
+	mocks.outboxRepo.ExpectClaimDue(claimHandler)
+	mocks.outboxRepo.ExpectClaimDue(claimHandler)
+	mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *testPlayer))
+	var savedMsg *bingo.OutboxMessage
+	mocks.outboxRepo.ExpectSave(func(_ context.Context, m *bingo.OutboxMessage) error {
+		savedMsg = m
+		return nil
+	})
+
+	attempted, err := outboxSvc.DeliverDue(context.Background())
+	require.NoError(t, err, "no error is expected")
+	require.Equal(t, 1, attempted, "the due message must be attempted")
+	require.Equal(t, bingo.OutboxStatusSent, savedMsg.Status, "message must be done without sending anything")
+}
+
+func TestOutboxService_Resend(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testPlayer := MustMakeTestPlayer(t, MustMakeTestInvitation(t, testGame))
+
+	cases := []struct {
+		caseName    string
+		admin       bool
+		status      bingo.OutboxStatus
+		expectedErr error
+	}{
+		{
+			caseName: "success",
+			admin:    true,
+			status:   bingo.OutboxStatusDead,
+		},
+		{
+			caseName:    "not dead",
+			admin:       true,
+			status:      bingo.OutboxStatusSent,
+			expectedErr: bingo.ErrOutboxMessageNotDead,
+		},
+		{
+			caseName:    "forbidden non admin",
+			status:      bingo.OutboxStatusDead,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			outboxSvc, mocks := MustCreateOutboxService(t)
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			msg := MustMakeTestOutboxMessage(t, testPlayer)
+			msg.Status = tc.status
+			msg.Attempts = bingo.OutboxMaxAttempts
+			if tc.admin {
+				mocks.outboxRepo.ExpectGet(func(_ context.Context, id string) (*bingo.OutboxMessage, error) {
+					require.Equal(t, msg.ID, id, "message must be gotten by its id")
+					return msg, nil
+				})
+			}
+			if tc.expectedErr == nil {
+				mocks.outboxRepo.ExpectSave(func(_ context.Context, _ *bingo.OutboxMessage) error { return nil })
+			}
+
+			actor := &bingo.User{ID: requiretest.UUIDv4(t), Admin: tc.admin}
+			resent, err := outboxSvc.Resend(bingo.NewContextWithUser(context.Background(), actor), msg.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, resent, "message must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.OutboxStatusPending, resent.Status, "resent message must be pending")
+			require.Zero(t, resent.Attempts, "attempts of resent message must be reset")
+		})
+	}
+}
+
+func TestOutboxService_PreviewPlayerCardsMail(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testGame.Language = bingo.LanguageDanish
+
+	cases := []struct {
+		caseName     string
+		actorId      string
+		lang         bingo.Language
+		expectedLang bingo.Language
+		expectedErr  error
+	}{
+		{
+			caseName:     "language of game",
+			actorId:      testGame.HostId,
+			expectedLang: bingo.LanguageDanish,
+		},
+		{
+			caseName:     "language given",
+			actorId:      testGame.HostId,
+			lang:         bingo.LanguageEnglish,
+			expectedLang: bingo.LanguageEnglish,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			outboxSvc, mocks := MustCreateOutboxService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.templates.RequireExpectationsMet()
+
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			if tc.expectedErr == nil {
+				mocks.templates.ExpectRenderMail(func(tmpl bingo.MailTemplate, lang bingo.Language, data interface{}) (*bingo.Mail, error) {
+					require.Equal(t, bingo.MailTemplatePlayerCards, tmpl, "player cards template must be rendered")
+					require.Equal(t, tc.expectedLang, lang, "mail must be rendered in the expected language")
+					d, ok := data.(bingo.PlayerCardsMailData)
+					require.True(t, ok, "template must be rendered with player cards data")
+					require.Equal(t, testGame.Name, d.GameName, "template data must have the name of the game")
+					require.Contains(t, d.DownloadLink, testDownloadLinkBase, "download link must be based on the configured url")
+					require.Contains(t, d.DownloadLink, "?token=", "download link must carry a session of the player")
+					return &bingo.Mail{Subject: "subject"}, nil
+				})
+			}
+
+			m, err := outboxSvc.PreviewPlayerCardsMail(NewActorContext(t, tc.actorId), testGame.ID, tc.lang)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, m, "mail must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, "subject", m.Subject, "rendered mail must be returned")
+		})
+	}
+}
+
+const testDownloadLinkBase = "https://bingobox.test"
+
+type outboxServiceMocks struct {
+	outboxRepo *mock.OutboxRepository
+	playerRepo *mock.PlayerRepository
+	invRepo    *mock.InvitationRepository
+	gameRepo   *mock.GameRepository
+	orgRepo    *mock.OrganizationRepository
+	renderer   *mock.CardRenderer
+	templates  *mock.MailRenderer
+	mailer     *mock.Mailer
+}
+
+func MustCreateOutboxService(tb testing.TB) (*bingo.OutboxService, *outboxServiceMocks) {
+	tb.Helper()
+
+	outboxRepo := mock.NewOutboxRepository(tb)
+	playerRepo := mock.NewPlayerRepository(tb)
+	invRepo := mock.NewInvitationRepository(tb)
+	gameRepo := mock.NewGameRepository(tb)
+	orgRepo := mock.NewOrganizationRepository(tb)
+	renderer := mock.NewCardRenderer(tb)
+	templates := mock.NewMailRenderer(tb)
+	mailer := mock.NewMailer(tb)
+
+	outboxSvc := bingo.NewOutboxService(bingo.Repositories{
+		Organizations: orgRepo,
+		Games:         gameRepo,
+		Invitations:   invRepo,
+		Players:       playerRepo,
+		Outbox:        outboxRepo,
+	}, renderer, templates, mailer, mock.ConfirmationTokenSigner{}, mock.PlayerSessionSigner{}, mock.MemberInvitationSigner{}, testDownloadLinkBase)
+	mocks := &outboxServiceMocks{
+		outboxRepo: outboxRepo,
+		playerRepo: playerRepo,
+		invRepo:    invRepo,
+		gameRepo:   gameRepo,
+		orgRepo:    orgRepo,
+		renderer:   renderer,
+		templates:  templates,
+		mailer:     mailer,
+	}
+
+	return outboxSvc, mocks
+}
+
+func MakeMailRenderHandler(tb testing.TB) mock.MailRenderHandler {
+	tb.Helper()
+
+	return func(tmpl bingo.MailTemplate, lang bingo.Language, data interface{}) (*bingo.Mail, error) {
+		return &bingo.Mail{Subject: string(tmpl), HTML: string(lang), Text: string(lang)}, nil
+	}
+}
+
+func MustMakeTestOutboxMessage(tb testing.TB, p *bingo.Player) *bingo.OutboxMessage {
+	tb.Helper()
+
+	msg := bingo.NewPlayerCardsMessage(p)
+	msg.ID = requiretest.UUIDv4(tb)
+
+	return msg
+}
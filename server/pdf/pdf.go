@@ -11,7 +11,7 @@ import (
 
 type CardFileGen struct {
 	game           *bingo.Game
-	pdf            gopdf.GoPdf
+	pdf            *gopdf.GoPdf
 	currentPage    int
 	margin         float64
 	contentWidth   float64
@@ -184,10 +184,22 @@ func (fg *CardFileGen) Write(w io.Writer) error {
 	return fg.pdf.Write(w)
 }
 
+// Renders cards as pdf files
+type Renderer struct{}
+
+func (Renderer) RenderCards(w io.Writer, g *bingo.Game, cards []bingo.Card) error {
+	fg, err := GenFromCards(g, cards)
+	if err != nil {
+		return err
+	}
+
+	return fg.Write(w)
+}
+
 func GenFromCards(game *bingo.Game, cards []bingo.Card) (*CardFileGen, error) {
 
 	// Create A4 pdf and by using mm as the unit
-	pdf := gopdf.GoPdf{}
+	pdf := &gopdf.GoPdf{}
 	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4, Unit: gopdf.UnitMM})
 
 	// Set default A4 margins
@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"io"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/qr"
+	"github.com/signintech/gopdf"
+)
+
+// Width of the QR code on posters in mm, and in pixels of the image drawn
+const (
+	posterQRWidth = 140
+	posterQRPx    = 1200
+)
+
+// Render an A4 poster inviting players to the game, with a QR code of the join url and the join code to type in
+func (Renderer) RenderPoster(w io.Writer, g *bingo.Game, joinURL, joinCode string) error {
+	code, err := qr.Encode(joinURL)
+	if err != nil {
+		return err
+	}
+	img, err := code.Image(posterQRPx)
+	if err != nil {
+		return err
+	}
+
+	// Create A4 pdf and by using mm as the unit
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4, Unit: gopdf.UnitMM})
+	err = pdf.AddTTFFont("Times New Roman", "pdf/font/times_new_roman.ttf")
+	if err != nil {
+		return err
+	}
+	pdf.AddPage()
+
+	pageWidth := float64(210)
+	centered := func(text string, size, y float64) error {
+		if err := pdf.SetFont("Times New Roman", "", size); err != nil {
+			return err
+		}
+		tw, err := pdf.MeasureTextWidth(text)
+		if err != nil {
+			return err
+		}
+		pdf.SetX((pageWidth - tw) / 2)
+		pdf.SetY(y)
+		return pdf.Cell(nil, text)
+	}
+
+	if err := centered(g.Name, 36, 25); err != nil {
+		return err
+	}
+	if err := centered("Scan the code to join", 20, 45); err != nil {
+		return err
+	}
+	qrX := (pageWidth - posterQRWidth) / 2
+	if err := pdf.ImageFrom(img, qrX, 60, &gopdf.Rect{W: posterQRWidth, H: posterQRWidth}); err != nil {
+		return err
+	}
+	if err := centered("or go to "+joinURL, 14, 210); err != nil {
+		return err
+	}
+	if err := centered("Join code", 14, 230); err != nil {
+		return err
+	}
+	if err := centered(bingo.FormatJoinCode(joinCode), 40, 240); err != nil {
+		return err
+	}
+
+	return pdf.Write(w)
+}
@@ -2,30 +2,148 @@ package bingo
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 )
 
+var (
+	ErrPlayerNotFound    = errors.New("bingo: player could not be found")
+	ErrPlayerWaitlisted  = errors.New("bingo: player is on the waitlist")
+	ErrPlayerUnconfirmed = errors.New("bingo: player has not confirmed their email")
+	ErrPlayerRemoved     = errors.New("bingo: player has been removed")
+	ErrPlayerCardLimit   = errors.New("bingo: player already has the max amount of cards of the invitation")
+	ErrPlayerExists      = errors.New("bingo: player with the email has already joined by the invitation")
+)
+
 type PlayerRepository interface {
 	Save(ctx context.Context, player *Player) error
 	Get(ctx context.Context, playerId string) (*Player, error)
+	Find(ctx context.Context, filter PlayerFilter) ([]Player, error)
+
+	// Get the player who joined the invitation with the email, compared normalized. Players are unique by invitation
+	// and email, so saving another player with the same invitation and email returns ErrPlayerExists.
+	GetByEmail(ctx context.Context, invId string, email string) (*Player, error)
+
+	// Record the outcome of delivering the cards of the player at the time given, leaving the rest of the player as is.
+	// Cards are mailed outside of any transaction, so changes made to the player meanwhile must not be overwritten.
+	RecordDelivery(ctx context.Context, playerId string, status DeliveryStatus, deliveryErr string, at time.Time) error
+}
+
+// Filter players of a game. Zero value fields besides the game are not filtered by
+type PlayerFilter struct {
+	GameID         string
+	InvitationID   string
+	Status         PlayerStatus
+	DeliveryStatus DeliveryStatus
 }
 
+// Whether the player has joined the game, waits in line for room on the invitation, or waits for them to confirm
+// their email
+type PlayerStatus string
+
+const (
+	PlayerStatusJoined      PlayerStatus = "JOINED"
+	PlayerStatusWaitlisted  PlayerStatus = "WAITLISTED"
+	PlayerStatusUnconfirmed PlayerStatus = "UNCONFIRMED"
+	PlayerStatusRemoved     PlayerStatus = "REMOVED"
+)
+
+// Status of delivering cards to players by mail
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending DeliveryStatus = "PENDING"
+	DeliveryStatusSent    DeliveryStatus = "SENT"
+	DeliveryStatusFailed  DeliveryStatus = "FAILED"
+)
+
 type PlayerService struct {
 	playerRepo PlayerRepository
+	invRepo    InvitationRepository
+	gameRepo   GameRepository
+	cardRepo   CardRepository
+	outboxRepo OutboxRepository
+	authz      gameAuthorizer
+	roster     roster
+	tx         Transactor
+
+	// Signs the tokens of the sessions players start by magic links
+	sessions PlayerSessionSigner
+}
+
+// List players of the game matching the filter, e.g. the players whose cards could not be delivered. Only members of the
+// game allowed to view players can list them.
+func (ps *PlayerService) List(ctx context.Context, filter PlayerFilter) ([]Player, error) {
+	g, err := ps.gameRepo.Get(ctx, filter.GameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.authz.authorize(ctx, g, PermissionViewPlayers); err != nil {
+		return nil, err
+	}
+
+	return ps.playerRepo.Find(ctx, filter)
 }
 
+// Get player by its id. Only members of the game the player joined, who are allowed to view players, can get it.
 func (ps *PlayerService) Get(ctx context.Context, playerId string) (*Player, error) {
+	player, err := ps.getWithInvitation(ctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.authz.authorize(ctx, player.Invitation.Game, PermissionViewPlayers); err != nil {
+		return nil, err
+	}
+
+	return player, nil
+}
+
+// Get player with their cards for downloading them. Players get their own cards by their session, and members of
+// the game allowed to view players get the cards of any player. Players on the waitlist or yet to confirm their email
+// have no cards, and removed players can not get theirs.
+func (ps *PlayerService) GetCards(ctx context.Context, playerId string) (*Player, error) {
+	player, err := ps.getWithInvitation(ctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.authorizePlayer(ctx, player); err != nil {
+		return nil, err
+	}
+	if err := player.requireCards(); err != nil {
+		return nil, err
+	}
+
+	return player, nil
+}
+
+// Get player with the invitation and game they joined
+func (ps *PlayerService) getWithInvitation(ctx context.Context, playerId string) (*Player, error) {
 	player, err := ps.playerRepo.Get(ctx, playerId)
 	if err != nil {
 		return nil, err
 	}
 
+	inv, err := ps.invRepo.Get(ctx, player.InvitationID)
+	if err != nil {
+		return nil, err
+	}
+	player.Invitation = inv
+
 	return player, nil
 }
 
-func NewPlayerService(playerRepo PlayerRepository) *PlayerService {
+func NewPlayerService(repos Repositories, sessions PlayerSessionSigner, tx Transactor) *PlayerService {
 	return &PlayerService{
-		playerRepo: playerRepo,
+		playerRepo: repos.Players,
+		invRepo:    repos.Invitations,
+		gameRepo:   repos.Games,
+		cardRepo:   repos.Cards,
+		outboxRepo: repos.Outbox,
+		authz:      gameAuthorizer{orgRepo: repos.Organizations},
+		roster:     newRoster(repos, tx),
+		tx:         tx,
+		sessions:   sessions,
 	}
 }
 
@@ -35,6 +153,9 @@ type Player struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 
+	// Language the player wants mails in. Mails are written in the language of the game when empty
+	Language Language `json:"language,omitempty"`
+
 	// Invitation player has joined by
 	InvitationID string      `json:"invitationId,omitempty"`
 	Invitation   *Invitation `json:"invitation"`
@@ -42,6 +163,19 @@ type Player struct {
 	// Cards generated by user
 	Cards []Card `json:"cards"`
 
+	// Players joining full invitations wait in line at the position, and players joining invitations requiring
+	// confirmation wait for them to confirm their email by the time given. Either get the cards they asked for once
+	// they join
+	Status           PlayerStatus `json:"status"`
+	WaitlistPosition int          `json:"waitlistPosition,omitempty"`
+	RequestedCards   int          `json:"requestedCards,omitempty"`
+	ConfirmBy        time.Time    `json:"confirmBy"`
+
+	// Delivery of the cards by mail. Empty status if the cards are not delivered by mail
+	DeliveryStatus DeliveryStatus `json:"deliveryStatus,omitempty"`
+	DeliveryError  string         `json:"deliveryError,omitempty"`
+	DeliveredAt    time.Time      `json:"deliveredAt"`
+
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
 }
@@ -50,6 +184,127 @@ func (p *Player) Validate() error {
 	return nil
 }
 
-func NewPlayer(name, email string, cardAmount int) *Player {
-	return &Player{}
+// Language mails to the player are written in. The choice of the player takes precedence over the one of the game
+func (p *Player) MailLanguage(g *Game) Language {
+	switch {
+	case p.Language.Valid():
+		return p.Language
+	case g != nil && g.Language.Valid():
+		return g.Language
+	default:
+		return DefaultLanguage
+	}
+}
+
+// Amount of cards the player has, or has asked for if they have not joined yet. Void cards are not counted
+func (p *Player) cardAmount() int {
+	if p.Status == PlayerStatusWaitlisted || p.Status == PlayerStatusUnconfirmed {
+		return p.RequestedCards
+	}
+
+	return len(p.validCards())
+}
+
+// Cards of the player which are not void
+func (p *Player) validCards() []Card {
+	valid := make([]Card, 0, len(p.Cards))
+	for _, c := range p.Cards {
+		if !c.Void() {
+			valid = append(valid, c)
+		}
+	}
+
+	return valid
+}
+
+// Check the player has cards. Players on the waitlist or yet to confirm their email have none yet, and removed players
+// none anymore
+func (p *Player) requireCards() error {
+	switch p.Status {
+	case PlayerStatusWaitlisted:
+		return ErrPlayerWaitlisted
+	case PlayerStatusUnconfirmed:
+		return ErrPlayerUnconfirmed
+	case PlayerStatusRemoved:
+		return ErrPlayerRemoved
+	}
+
+	return nil
+}
+
+// Let the player wait for them to confirm their email, before they join with the amount of cards they asked for
+func (p *Player) awaitConfirmation(cardAmount int, by time.Time) {
+	p.Status = PlayerStatusUnconfirmed
+	p.RequestedCards = cardAmount
+	p.ConfirmBy = by
+}
+
+// Whether the player has confirmed their email too late to join
+func (p *Player) confirmationExpired(now time.Time) bool {
+	return p.Status == PlayerStatusUnconfirmed && !now.Before(p.ConfirmBy)
+}
+
+// Put the player in line on the waitlist, for the amount of cards they asked for
+func (p *Player) waitlist(position, cardAmount int) {
+	p.Status = PlayerStatusWaitlisted
+	p.WaitlistPosition = position
+	p.RequestedCards = cardAmount
+	p.ConfirmBy = time.Time{}
+}
+
+// Let the player join the game, once there is room for them
+func (p *Player) join() {
+	p.Status = PlayerStatusJoined
+	p.WaitlistPosition = 0
+	p.RequestedCards = 0
+	p.ConfirmBy = time.Time{}
+}
+
+func (p *Player) remove() {
+	p.Status = PlayerStatusRemoved
+	p.WaitlistPosition = 0
+	p.RequestedCards = 0
+	p.ConfirmBy = time.Time{}
+}
+
+// Revoke the cards of the player which are not void already. Returns the amount of cards revoked
+func (p *Player) revokeCards(rev CardRevocation) int {
+	revoked := 0
+	for i := range p.Cards {
+		if p.Cards[i].Void() {
+			continue
+		}
+		r := rev
+		p.Cards[i].Revocation = &r
+		revoked++
+	}
+
+	return revoked
+}
+
+// Delivery status of the cards of a player by the outcome of the outbox message delivering them. The player keeps
+// awaiting delivery while the message is retried, and failed once it is dead
+func deliveryStatusOf(msg *OutboxMessage) DeliveryStatus {
+	switch msg.Status {
+	case OutboxStatusSent:
+		return DeliveryStatusSent
+	case OutboxStatusDead:
+		return DeliveryStatusFailed
+	}
+
+	return DeliveryStatusPending
+}
+
+// Create player joining by the invitation. Cards are generated for the player when they join
+func NewPlayer(invitationId, name, email string, lang Language) *Player {
+	return &Player{
+		Name:         strings.TrimSpace(name),
+		Email:        NormalizeEmail(email),
+		Language:     lang,
+		InvitationID: invitationId,
+		Cards:        make([]Card, 0),
+		Status:       PlayerStatusJoined,
+		UpdatedAt:    time.Now(),
+		CreatedAt:    time.Now(),
+	}
 }
@@ -0,0 +1,312 @@
+package bingo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayerService_Get(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.Game = testGame
+	testPlayer := MustMakeTestPlayer(t, testInv)
+
+	cases := []struct {
+		caseName     string
+		ctx          context.Context
+		playerId     string
+		expectInvGet bool
+		expectedErr  error
+	}{
+		{
+			caseName:     "success",
+			ctx:          NewActorContext(t, testGame.HostId),
+			playerId:     testPlayer.ID,
+			expectInvGet: true,
+		},
+		{
+			caseName:    "player not found",
+			ctx:         NewActorContext(t, testGame.HostId),
+			playerId:    "",
+			expectedErr: bingo.ErrPlayerNotFound,
+		},
+		{
+			caseName:     "forbidden other host",
+			ctx:          NewActorContext(t, requiretest.UUIDv4(t)),
+			playerId:     testPlayer.ID,
+			expectInvGet: true,
+			expectedErr:  bingo.ErrForbidden,
+		},
+		{
+			caseName:     "forbidden no actor",
+			ctx:          context.Background(),
+			playerId:     testPlayer.ID,
+			expectInvGet: true,
+			expectedErr:  bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.invRepo.RequireExpectationsMet()
+
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *testPlayer))
+			if tc.expectInvGet {
+				mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			}
+
+			p, err := playerSvc.Get(tc.ctx, tc.playerId)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.Equal(t, testPlayer.ID, p.ID, "player must be the one asked for")
+				require.Equal(t, testGame.ID, p.Invitation.Game.ID, "invitation and game of player must be present")
+			}
+		})
+	}
+}
+
+func TestPlayerService_GetCards(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.Game = testGame
+	testPlayer := MustMakeTestPlayer(t, testInv)
+	waitlisted := MustMakeTestPlayer(t, testInv)
+	waitlisted.Status = bingo.PlayerStatusWaitlisted
+	removed := MustMakeTestPlayer(t, testInv)
+	removed.Status = bingo.PlayerStatusRemoved
+
+	cases := []struct {
+		caseName    string
+		ctx         context.Context
+		player      *bingo.Player
+		expectedErr error
+	}{
+		{
+			caseName: "success",
+			ctx:      bingo.NewContextWithPlayer(context.Background(), testPlayer),
+			player:   testPlayer,
+		},
+		{
+			caseName: "success host",
+			ctx:      NewActorContext(t, testGame.HostId),
+			player:   testPlayer,
+		},
+		{
+			caseName:    "player on the waitlist",
+			ctx:         bingo.NewContextWithPlayer(context.Background(), waitlisted),
+			player:      waitlisted,
+			expectedErr: bingo.ErrPlayerWaitlisted,
+		},
+		{
+			caseName:    "player removed",
+			ctx:         NewActorContext(t, testGame.HostId),
+			player:      removed,
+			expectedErr: bingo.ErrPlayerRemoved,
+		},
+		{
+			caseName:    "forbidden other player",
+			ctx:         bingo.NewContextWithPlayer(context.Background(), waitlisted),
+			player:      testPlayer,
+			expectedErr: bingo.ErrForbidden,
+		},
+		{
+			caseName:    "forbidden other host",
+			ctx:         NewActorContext(t, requiretest.UUIDv4(t)),
+			player:      testPlayer,
+			expectedErr: bingo.ErrForbidden,
+		},
+		{
+			caseName:    "forbidden no session",
+			ctx:         context.Background(),
+			player:      testPlayer,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.invRepo.RequireExpectationsMet()
+
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.player))
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+
+			p, err := playerSvc.GetCards(tc.ctx, tc.player.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, testGame.ID, p.Invitation.Game.ID, "game of player must be present for printing cards")
+		})
+	}
+}
+
+func TestPlayer_MailLanguage(t *testing.T) {
+
+	cases := []struct {
+		caseName   string
+		playerLang bingo.Language
+		gameLang   bingo.Language
+		expected   bingo.Language
+	}{
+		{
+			caseName:   "language of player",
+			playerLang: bingo.LanguageEnglish,
+			gameLang:   bingo.LanguageDanish,
+			expected:   bingo.LanguageEnglish,
+		},
+		{
+			caseName: "language of game",
+			gameLang: bingo.LanguageDanish,
+			expected: bingo.LanguageDanish,
+		},
+		{
+			caseName: "default language",
+			expected: bingo.DefaultLanguage,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			p := &bingo.Player{Language: tc.playerLang}
+			g := &bingo.Game{Language: tc.gameLang}
+
+			require.Equal(t, tc.expected, p.MailLanguage(g), "mail language must be of expected language")
+		})
+	}
+}
+
+func TestPlayerService_List(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		expectedErr error
+	}{
+		{
+			caseName: "success",
+			actorId:  testGame.HostId,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+
+			filter := bingo.PlayerFilter{GameID: testGame.ID, DeliveryStatus: bingo.DeliveryStatusFailed}
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			if tc.expectedErr == nil {
+				mocks.playerRepo.ExpectFind(func(_ context.Context, f bingo.PlayerFilter) ([]bingo.Player, error) {
+					require.Equal(t, filter, f, "players must be found by the filter given")
+					return []bingo.Player{}, nil
+				})
+			}
+
+			players, err := playerSvc.List(NewActorContext(t, tc.actorId), filter)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, players, "players must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+			}
+		})
+	}
+}
+
+type playerServiceMocks struct {
+	playerRepo *mock.PlayerRepository
+	invRepo    *mock.InvitationRepository
+	gameRepo   *mock.GameRepository
+	cardRepo   *mock.CardRespository
+	orgRepo    *mock.OrganizationRepository
+	userRepo   *mock.UserRepository
+	creditRepo *mock.CreditRepository
+	outboxRepo *mock.OutboxRepository
+}
+
+func MustCreatePlayerService(tb testing.TB) (*bingo.PlayerService, *playerServiceMocks) {
+	tb.Helper()
+
+	playerRepo := mock.NewPlayerRepository(tb)
+	invRepo := mock.NewInvitationRepository(tb)
+	gameRepo := mock.NewGameRepository(tb)
+	cardRepo := mock.NewCardRepository(tb)
+	orgRepo := mock.NewOrganizationRepository(tb)
+	userRepo := mock.NewUserRepository(tb)
+	creditRepo := mock.NewCreditRepository(tb)
+	outboxRepo := mock.NewOutboxRepository(tb)
+
+	playerSvc := bingo.NewPlayerService(bingo.Repositories{
+		Users:         userRepo,
+		Organizations: orgRepo,
+		Games:         gameRepo,
+		Cards:         cardRepo,
+		Invitations:   invRepo,
+		Players:       playerRepo,
+		Credits:       creditRepo,
+		Outbox:        outboxRepo,
+	}, mock.PlayerSessionSigner{}, mock.NewTransactor(tb))
+	mocks := &playerServiceMocks{
+		playerRepo: playerRepo,
+		invRepo:    invRepo,
+		gameRepo:   gameRepo,
+		cardRepo:   cardRepo,
+		orgRepo:    orgRepo,
+		userRepo:   userRepo,
+		creditRepo: creditRepo,
+		outboxRepo: outboxRepo,
+	}
+
+	return playerSvc, mocks
+}
+
+func MakeSinglePlayerGetHandler(tb testing.TB, p bingo.Player) mock.PlayerGetHandler {
+	tb.Helper()
+
+	return func(_ context.Context, id string) (*bingo.Player, error) {
+		if id != p.ID {
+			return nil, bingo.ErrPlayerNotFound
+		}
+
+		return &p, nil
+	}
+}
+
+func MustMakeTestPlayer(tb testing.TB, inv *bingo.Invitation) *bingo.Player {
+	tb.Helper()
+
+	return &bingo.Player{
+		ID:           requiretest.UUIDv4(tb),
+		Name:         "test player",
+		Email:        "player@test.com",
+		InvitationID: inv.ID,
+		Status:       bingo.PlayerStatusJoined,
+		UpdatedAt:    time.Now(),
+		CreatedAt:    time.Now(),
+	}
+}
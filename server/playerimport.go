@@ -0,0 +1,135 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrPlayerImportEmpty    = errors.New("bingo: player import has no rows")
+	ErrPlayerImportTooLarge = errors.New("bingo: player import exceeds the max amount of rows")
+)
+
+// Max amount of rows imported at once. Each row is imported in a transaction of its own, so large imports are split
+const PlayerImportMaxRows = 1000
+
+// Row of a player import, e.g. an attendee of a spreadsheet. Line is the line of the row in its source, which the
+// result of the row refers to
+type PlayerImportRow struct {
+	Line       int
+	Name       string
+	Email      string
+	CardAmount int
+	Language   Language
+
+	// Error reading the row from its source, e.g. a card amount that is not a number. The row fails with it
+	Err error
+}
+
+// Result of importing a row. Either the player imported, or the error the row failed with
+type PlayerImportResult struct {
+	Line   int     `json:"line"`
+	Email  string  `json:"email"`
+	Player *Player `json:"player,omitempty"`
+	Err    error   `json:"-"`
+}
+
+// Report of a player import, with the result of every row in the order they were imported
+type PlayerImportReport struct {
+	Imported   int                  `json:"imported"`
+	Waitlisted int                  `json:"waitlisted"`
+	Failed     int                  `json:"failed"`
+	Rows       []PlayerImportResult `json:"rows"`
+}
+
+// Import players to the invitation, e.g. the attendees of a company party. Each row is validated against the criteria
+// of the invitation and imported in a transaction of its own, so rows failing are reported without aborting the
+// import. Imported players are vouched for by the host, so they do not have to confirm their email. Rows with emails
+// already joined fail with ErrPlayerExists, and players not fitting on the invitation are put on the waitlist. Cards
+// are delivered by mail if asked for, regardless of the delivery method of the invitation. Only members allowed to
+// manage the game can import players.
+func (is *InvitationService) ImportPlayers(ctx context.Context, invId string, rows []PlayerImportRow, deliver bool) (*PlayerImportReport, error) {
+	if len(rows) == 0 {
+		return nil, ErrPlayerImportEmpty
+	}
+	if len(rows) > PlayerImportMaxRows {
+		return nil, ErrPlayerImportTooLarge
+	}
+
+	inv, err := is.invRepo.Get(ctx, invId)
+	if err != nil {
+		return nil, err
+	}
+	if err := is.authorize(ctx, inv, PermissionManageGame); err != nil {
+		return nil, err
+	}
+	if !inv.Active {
+		return nil, ErrInvitationInactive
+	}
+
+	report := &PlayerImportReport{Rows: make([]PlayerImportResult, 0, len(rows))}
+	for _, row := range rows {
+		p, err := is.importPlayer(ctx, inv, row, deliver)
+		res := PlayerImportResult{Line: row.Line, Email: NormalizeEmail(row.Email), Player: p, Err: err}
+		switch {
+		case err != nil:
+			report.Failed++
+		case p.Status == PlayerStatusWaitlisted:
+			report.Waitlisted++
+		default:
+			report.Imported++
+		}
+		report.Rows = append(report.Rows, res)
+	}
+
+	return report, nil
+}
+
+// Validate and import the player of the row, along with the cards asked for if there is room for them
+func (is *InvitationService) importPlayer(ctx context.Context, inv *Invitation, row PlayerImportRow, deliver bool) (*Player, error) {
+	if row.Err != nil {
+		return nil, row.Err
+	}
+	p := NewPlayer(inv.ID, row.Name, row.Email, row.Language)
+	if err := inv.ValidatePlayer(p); err != nil {
+		return nil, err
+	}
+	if err := inv.ValidateCardAmount(row.CardAmount); err != nil {
+		return nil, err
+	}
+
+	err := is.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := is.playerRepo.GetByEmail(ctx, inv.ID, p.Email)
+		if err == nil {
+			return ErrPlayerExists
+		}
+		if !errors.Is(err, ErrPlayerNotFound) {
+			return err
+		}
+
+		err = is.invRepo.Reserve(ctx, inv, row.CardAmount)
+		if errors.Is(err, ErrInvitationFull) {
+			position, err := is.invRepo.Waitlist(ctx, inv)
+			if err != nil {
+				return err
+			}
+			p.waitlist(position, row.CardAmount)
+			return is.playerRepo.Save(ctx, p)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Read the game within the transaction, as the cards generated by earlier rows count towards the allowance
+		g, err := is.gameRepo.Get(ctx, inv.GameID)
+		if err != nil {
+			return err
+		}
+		return is.roster.admit(ctx, inv, g, p, row.CardAmount, deliver)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,190 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitationService_ImportPlayers(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	inactive := MustMakeTestInvitation(t, testGame)
+	inactive.Active = false
+	rows := []bingo.PlayerImportRow{{Line: 2, Name: "Player Name", Email: "player@test.com", CardAmount: 1}}
+
+	cases := []struct {
+		caseName    string
+		actorId     string
+		inv         *bingo.Invitation
+		rows        []bingo.PlayerImportRow
+		expectGet   bool
+		expectedErr error
+	}{
+		{
+			caseName:    "no rows",
+			actorId:     testGame.HostId,
+			inv:         testInv,
+			rows:        []bingo.PlayerImportRow{},
+			expectedErr: bingo.ErrPlayerImportEmpty,
+		},
+		{
+			caseName:    "too many rows",
+			actorId:     testGame.HostId,
+			inv:         testInv,
+			rows:        make([]bingo.PlayerImportRow, bingo.PlayerImportMaxRows+1),
+			expectedErr: bingo.ErrPlayerImportTooLarge,
+		},
+		{
+			caseName:    "invitation inactive",
+			actorId:     testGame.HostId,
+			inv:         inactive,
+			rows:        rows,
+			expectGet:   true,
+			expectedErr: bingo.ErrInvitationInactive,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			inv:         testInv,
+			rows:        rows,
+			expectGet:   true,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+
+			if tc.expectGet {
+				mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *tc.inv))
+				mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			}
+
+			report, err := invSvc.ImportPlayers(NewActorContext(t, tc.actorId), tc.inv.ID, tc.rows, false)
+			require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+			require.Nil(t, report, "report must be nil when error is expected")
+		})
+	}
+}
+
+func TestInvitationService_ImportPlayersRows(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	errUnreadable := errors.New("row could not be read")
+	rows := []bingo.PlayerImportRow{
+		{Line: 2, Name: " First Player ", Email: "First@Test.com", CardAmount: 2, Language: bingo.LanguageDanish},
+		{Line: 3, Name: "", Email: "nameless@test.com", CardAmount: 1},
+		{Line: 4, Name: "Greedy Player", Email: "greedy@test.com", CardAmount: testInv.MaxCardAmount + 1},
+		{Line: 5, Name: "Known Player", Email: "known@test.com", CardAmount: 1},
+		{Line: 6, Name: "Unreadable Player", Email: "unreadable@test.com", Err: errUnreadable},
+		{Line: 7, Name: "Late Player", Email: "late@test.com", CardAmount: 1},
+	}
+
+	for _, deliver := range []bool{false, true} {
+		caseName := "without delivery"
+		if deliver {
+			caseName = "with delivery"
+		}
+		t.Run(caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+
+			// First player fits on the invitation and joins
+			mocks.playerRepo.ExpectGetByEmail(func(_ context.Context, _ string, email string) (*bingo.Player, error) {
+				require.Equal(t, "first@test.com", email, "player must be looked up by the normalized email")
+				return nil, bingo.ErrPlayerNotFound
+			})
+			mocks.invRepo.ExpectReserve(func(_ context.Context, _ *bingo.Invitation, cardAmount int) error {
+				require.Equal(t, 2, cardAmount, "room for the cards of the row must be reserved")
+				return nil
+			})
+			mocks.gameRepo.ExpectGet(func(_ context.Context, id string) (*bingo.Game, error) {
+				require.Equal(t, testGame.ID, id, "game must be read again within the transaction of the row")
+				return MustCopyGame(t, testGame), nil
+			})
+			mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+				p.ID = requiretest.UUIDv4(t)
+				return nil
+			})
+			mocks.cardRepo.ExpectSaveAll(func(_ context.Context, cards []bingo.Card) error { return nil })
+			mocks.gameRepo.ExpectAllocateCardNumbers(MakeCardNumbersAllocateHandler(t, *testGame))
+			var queued *bingo.OutboxMessage
+			if deliver {
+				mocks.outboxRepo.ExpectSave(func(_ context.Context, msg *bingo.OutboxMessage) error {
+					queued = msg
+					return nil
+				})
+			}
+
+			// Player already joined with the email
+			mocks.playerRepo.ExpectGetByEmail(func(_ context.Context, _ string, _ string) (*bingo.Player, error) {
+				return MustMakeTestPlayer(t, testInv), nil
+			})
+
+			// Last player does not fit and is waitlisted
+			mocks.playerRepo.ExpectGetByEmail(func(_ context.Context, _ string, _ string) (*bingo.Player, error) {
+				return nil, bingo.ErrPlayerNotFound
+			})
+			mocks.invRepo.ExpectReserve(func(_ context.Context, _ *bingo.Invitation, _ int) error { return bingo.ErrInvitationFull })
+			mocks.invRepo.ExpectWaitlist(func(_ context.Context, _ *bingo.Invitation) (int, error) { return 1, nil })
+			mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+				p.ID = requiretest.UUIDv4(t)
+				return nil
+			})
+
+			report, err := invSvc.ImportPlayers(NewActorContext(t, testGame.HostId), testInv.ID, rows, deliver)
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, 1, report.Imported, "rows joining must be counted as imported")
+			require.Equal(t, 1, report.Waitlisted, "rows waitlisted must be counted")
+			require.Equal(t, 4, report.Failed, "rows failing must be counted")
+			require.Len(t, report.Rows, len(rows), "every row must have a result")
+			for i, res := range report.Rows {
+				require.Equal(t, rows[i].Line, res.Line, "results must be in the order of the rows")
+			}
+
+			joined := report.Rows[0]
+			require.NoError(t, joined.Err, "row joining must not fail")
+			require.Equal(t, "first@test.com", joined.Email, "email of the result must be normalized")
+			require.Equal(t, bingo.PlayerStatusJoined, joined.Player.Status, "player must have joined without confirming")
+			require.Equal(t, "First Player", joined.Player.Name, "player name must be trimmed")
+			require.Equal(t, bingo.LanguageDanish, joined.Player.Language, "player must get mails in the language of the row")
+			require.Len(t, joined.Player.Cards, 2, "player must have the cards of the row")
+			if deliver {
+				require.Equal(t, bingo.DeliveryStatusPending, joined.Player.DeliveryStatus, "cards must await delivery when asked for")
+				require.Equal(t, joined.Player.ID, queued.PlayerID, "cards of the player must be queued for delivery")
+			} else {
+				require.Empty(t, joined.Player.DeliveryStatus, "cards must not be delivered unless asked for")
+			}
+
+			for _, i := range []int{1, 2} {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(report.Rows[i].Err, &valErr), "invalid row must fail with a validation error")
+				require.Nil(t, report.Rows[i].Player, "invalid row must not have a player")
+			}
+			require.ErrorIs(t, report.Rows[3].Err, bingo.ErrPlayerExists, "row of a player already joined must fail")
+			require.ErrorIs(t, report.Rows[4].Err, errUnreadable, "row that could not be read must fail with its error")
+
+			waitlisted := report.Rows[5]
+			require.NoError(t, waitlisted.Err, "row waitlisted must not fail")
+			require.Equal(t, bingo.PlayerStatusWaitlisted, waitlisted.Player.Status, "player must be on the waitlist")
+			require.Equal(t, 1, waitlisted.Player.RequestedCards, "player must wait for the cards of the row")
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidPlayerSession = errors.New("bingo: player session is invalid or expired")
+)
+
+const (
+	// Time the magic links players request are valid, and the sessions they start with them
+	PlayerSessionLifetime = 24 * time.Hour
+
+	// Time the download links of the mails delivering cards are valid. Players request a magic link once they expire
+	PlayerCardsLinkLifetime = 30 * 24 * time.Hour
+)
+
+// Signs and verifies the tokens of player sessions, which players get by magic links mailed to them.
+type PlayerSessionSigner interface {
+	Sign(claims PlayerSessionClaims) (string, error)
+	Verify(token string) (*PlayerSessionClaims, error)
+}
+
+type PlayerSessionClaims struct {
+	PlayerID string
+
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Mail a magic link to the player who joined the invitation with the email, starting a session of theirs when
+// followed. Nothing is mailed if no player joined with the email, and no error is returned either, so the emails of
+// players can not be probed for.
+func (ps *PlayerService) RequestMagicLink(ctx context.Context, invId string, email string) error {
+	inv, err := ps.invRepo.Get(ctx, invId)
+	if err != nil {
+		return err
+	}
+
+	p, err := ps.playerRepo.GetByEmail(ctx, inv.ID, NormalizeEmail(email))
+	if errors.Is(err, ErrPlayerNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if p.Status == PlayerStatusRemoved {
+		return nil
+	}
+
+	return ps.outboxRepo.Save(ctx, NewPlayerMagicLinkMessage(p))
+}
+
+// Authenticate the player by the token of their session. Removed players can no longer access their cards, and their
+// sessions are rejected.
+func (ps *PlayerService) Authenticate(ctx context.Context, token string) (*Player, error) {
+	claims, err := ps.sessions.Verify(token)
+	if err != nil {
+		return nil, ErrInvalidPlayerSession
+	}
+	if claims.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidPlayerSession
+	}
+
+	p, err := ps.playerRepo.Get(ctx, claims.PlayerID)
+	if errors.Is(err, ErrPlayerNotFound) {
+		return nil, ErrInvalidPlayerSession
+	} else if err != nil {
+		return nil, err
+	}
+	if p.Status == PlayerStatusRemoved {
+		return nil, ErrPlayerRemoved
+	}
+
+	return p, nil
+}
+
+// Get the player of the session with their cards, and the invitation and game they joined. The called numbers of the
+// game let the player follow it live.
+func (ps *PlayerService) Me(ctx context.Context) (*Player, error) {
+	sp := PlayerFromContext(ctx)
+	if sp == nil {
+		return nil, ErrForbidden
+	}
+
+	return ps.getWithInvitation(ctx, sp.ID)
+}
+
+// Deliver the cards of the player by mail again, e.g. when the player lost the first mail. Cards already awaiting
+// delivery are not queued again, so requesting it repeatedly sends one mail only. The player is read again within the
+// transaction, so players removed meanwhile are not restored by the save.
+func (ps *PlayerService) ResendCards(ctx context.Context, playerId string) (*Player, error) {
+	p, err := ps.getWithInvitation(ctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.authorizePlayer(ctx, p); err != nil {
+		return nil, err
+	}
+
+	var player *Player
+	err = ps.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := ps.playerRepo.Get(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		if err := current.requireCards(); err != nil {
+			return err
+		}
+		current.Invitation = p.Invitation
+		player = current
+		if current.DeliveryStatus == DeliveryStatusPending {
+			return nil
+		}
+
+		current.DeliveryStatus = DeliveryStatusPending
+		current.DeliveryError = ""
+		current.UpdatedAt = time.Now()
+		if err := ps.playerRepo.Save(ctx, current); err != nil {
+			return err
+		}
+
+		return ps.outboxRepo.Save(ctx, NewPlayerCardsMessage(current))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return player, nil
+}
+
+// Authorize access to the player, by the session of the player themselves or by members of the game allowed to view
+// players. The invitation and game of the player must be present.
+func (ps *PlayerService) authorizePlayer(ctx context.Context, p *Player) error {
+	if sp := PlayerFromContext(ctx); sp != nil {
+		if sp.ID != p.ID {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	return ps.authz.authorize(ctx, p.Invitation.Game, PermissionViewPlayers)
+}
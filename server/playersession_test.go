@@ -0,0 +1,392 @@
+package bingo_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayerService_RequestMagicLink(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testPlayer := MustMakeTestPlayer(t, testInv)
+	removed := MustMakeTestPlayer(t, testInv)
+	removed.Status = bingo.PlayerStatusRemoved
+
+	cases := []struct {
+		caseName      string
+		invId         string
+		email         string
+		player        *bingo.Player
+		expectLookup  bool
+		expectMessage bool
+		expectedErr   error
+	}{
+		{
+			caseName:      "success",
+			invId:         testInv.ID,
+			email:         " Player@Test.com",
+			player:        testPlayer,
+			expectLookup:  true,
+			expectMessage: true,
+		},
+		{
+			caseName:     "unknown email",
+			invId:        testInv.ID,
+			email:        "someone@test.com",
+			player:       testPlayer,
+			expectLookup: true,
+		},
+		{
+			caseName:     "player removed",
+			invId:        testInv.ID,
+			email:        removed.Email,
+			player:       removed,
+			expectLookup: true,
+		},
+		{
+			caseName:    "invitation not found",
+			invId:       requiretest.UUIDv4(t),
+			email:       testPlayer.Email,
+			expectedErr: bingo.ErrInvitationNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			if tc.expectLookup {
+				mocks.playerRepo.ExpectGetByEmail(func(_ context.Context, invId string, email string) (*bingo.Player, error) {
+					require.Equal(t, testInv.ID, invId, "player must be looked up by the invitation")
+					if email != tc.player.Email {
+						return nil, bingo.ErrPlayerNotFound
+					}
+					return MustCopyPlayer(t, tc.player), nil
+				})
+			}
+			if tc.expectMessage {
+				mocks.outboxRepo.ExpectSave(func(_ context.Context, msg *bingo.OutboxMessage) error {
+					require.Equal(t, bingo.MessageKindPlayerMagicLink, msg.Kind, "magic link must be queued")
+					require.Equal(t, tc.player.ID, msg.PlayerID, "magic link must be sent to the player")
+					return nil
+				})
+			}
+
+			err := playerSvc.RequestMagicLink(context.Background(), tc.invId, tc.email)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				return
+			}
+			require.NoError(t, err, "no error must reveal whether a player joined with the email")
+		})
+	}
+}
+
+func TestPlayerService_Authenticate(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testPlayer := MustMakeTestPlayer(t, testInv)
+	removed := MustMakeTestPlayer(t, testInv)
+	removed.Status = bingo.PlayerStatusRemoved
+
+	cases := []struct {
+		caseName    string
+		token       string
+		expectGet   bool
+		expectedErr error
+	}{
+		{
+			caseName:  "success",
+			token:     MustSignPlayerSession(t, testPlayer, time.Now().Add(time.Hour)),
+			expectGet: true,
+		},
+		{
+			caseName:    "malformed token",
+			token:       "malformed",
+			expectedErr: bingo.ErrInvalidPlayerSession,
+		},
+		{
+			caseName:    "expired token",
+			token:       MustSignPlayerSession(t, testPlayer, time.Now().Add(-time.Minute)),
+			expectedErr: bingo.ErrInvalidPlayerSession,
+		},
+		{
+			caseName:    "player gone",
+			token:       MustSignPlayerSession(t, MustMakeTestPlayer(t, testInv), time.Now().Add(time.Hour)),
+			expectGet:   true,
+			expectedErr: bingo.ErrInvalidPlayerSession,
+		},
+		{
+			caseName:    "player removed",
+			token:       MustSignPlayerSession(t, removed, time.Now().Add(time.Hour)),
+			expectGet:   true,
+			expectedErr: bingo.ErrPlayerRemoved,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.playerRepo.RequireExpectationsMet()
+
+			if tc.expectGet {
+				mocks.playerRepo.ExpectGet(func(_ context.Context, id string) (*bingo.Player, error) {
+					for _, p := range []*bingo.Player{testPlayer, removed} {
+						if p.ID == id {
+							return MustCopyPlayer(t, p), nil
+						}
+					}
+					return nil, bingo.ErrPlayerNotFound
+				})
+			}
+
+			p, err := playerSvc.Authenticate(context.Background(), tc.token)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, testPlayer.ID, p.ID, "player of the session must be returned")
+		})
+	}
+}
+
+func TestPlayerService_Me(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.Game = testGame
+	testPlayer := MustMakeTestPlayer(t, testInv)
+
+	t.Run("success", func(t *testing.T) {
+		playerSvc, mocks := MustCreatePlayerService(t)
+		defer mocks.playerRepo.RequireExpectationsMet()
+		defer mocks.invRepo.RequireExpectationsMet()
+
+		mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *testPlayer))
+		mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+
+		p, err := playerSvc.Me(bingo.NewContextWithPlayer(context.Background(), testPlayer))
+		require.NoError(t, err, "no error is expected")
+		require.Equal(t, testPlayer.ID, p.ID, "player of the session must be returned")
+		require.Equal(t, testGame.ID, p.Invitation.Game.ID, "game of player must be present for following it")
+	})
+
+	t.Run("forbidden no session", func(t *testing.T) {
+		playerSvc, _ := MustCreatePlayerService(t)
+
+		p, err := playerSvc.Me(NewActorContext(t, testGame.HostId))
+		require.ErrorIs(t, err, bingo.ErrForbidden, "error must be of expected error kind")
+		require.Nil(t, p, "player must be nil when error is expected")
+	})
+}
+
+func TestPlayerService_ResendCards(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.Game = testGame
+	testPlayer := MustMakeTestPlayer(t, testInv)
+	testPlayer.DeliveryStatus = bingo.DeliveryStatusFailed
+	testPlayer.DeliveryError = "mailbox full"
+	pending := MustMakeTestPlayer(t, testInv)
+	pending.DeliveryStatus = bingo.DeliveryStatusPending
+	waitlisted := MustMakeWaitlistedPlayer(t, testInv, 1, 2)
+	removed := *testPlayer
+	removed.Status = bingo.PlayerStatusRemoved
+
+	cases := []struct {
+		caseName     string
+		ctx          context.Context
+		player       *bingo.Player
+		current      *bingo.Player
+		expectResend bool
+		expectedErr  error
+	}{
+		{
+			caseName:     "success",
+			ctx:          bingo.NewContextWithPlayer(context.Background(), testPlayer),
+			player:       testPlayer,
+			current:      testPlayer,
+			expectResend: true,
+		},
+		{
+			caseName:     "success host",
+			ctx:          NewActorContext(t, testGame.HostId),
+			player:       testPlayer,
+			current:      testPlayer,
+			expectResend: true,
+		},
+		{
+			caseName: "already awaiting delivery",
+			ctx:      bingo.NewContextWithPlayer(context.Background(), pending),
+			player:   pending,
+			current:  pending,
+		},
+		{
+			caseName:    "player on the waitlist",
+			ctx:         bingo.NewContextWithPlayer(context.Background(), waitlisted),
+			player:      waitlisted,
+			current:     waitlisted,
+			expectedErr: bingo.ErrPlayerWaitlisted,
+		},
+		{
+			caseName:    "player removed meanwhile",
+			ctx:         NewActorContext(t, testGame.HostId),
+			player:      testPlayer,
+			current:     &removed,
+			expectedErr: bingo.ErrPlayerRemoved,
+		},
+		{
+			caseName:    "forbidden other player",
+			ctx:         bingo.NewContextWithPlayer(context.Background(), pending),
+			player:      testPlayer,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.player))
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			if tc.current != nil {
+				mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.current))
+			}
+			if tc.expectResend {
+				mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+					require.Equal(t, bingo.PlayerStatusJoined, p.Status, "player must be saved as read within the transaction")
+					require.Equal(t, bingo.DeliveryStatusPending, p.DeliveryStatus, "player must await delivery")
+					require.Empty(t, p.DeliveryError, "error of the last delivery must be cleared")
+					return nil
+				})
+				mocks.outboxRepo.ExpectSave(func(_ context.Context, msg *bingo.OutboxMessage) error {
+					require.Equal(t, bingo.MessageKindPlayerCards, msg.Kind, "cards must be queued")
+					require.Equal(t, tc.player.ID, msg.PlayerID, "cards must be sent to the player")
+					return nil
+				})
+			}
+
+			p, err := playerSvc.ResendCards(tc.ctx, tc.player.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.DeliveryStatusPending, p.DeliveryStatus, "player must await delivery")
+		})
+	}
+}
+
+func TestOutboxService_DeliverPlayerMagicLink(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	removed := MustMakeTestPlayer(t, testInv)
+	removed.Status = bingo.PlayerStatusRemoved
+
+	cases := []struct {
+		caseName   string
+		player     *bingo.Player
+		expectMail bool
+	}{
+		{
+			caseName:   "sent",
+			player:     MustMakeTestPlayer(t, testInv),
+			expectMail: true,
+		},
+		{
+			caseName:   "sent to player on the waitlist",
+			player:     MustMakeWaitlistedPlayer(t, testInv, 1, 2),
+			expectMail: true,
+		},
+		{
+			caseName: "removed since queued",
+			player:   removed,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			outboxSvc, mocks := MustCreateOutboxService(t)
+			defer mocks.outboxRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.templates.RequireExpectationsMet()
+			defer mocks.mailer.RequireExpectationsMet()
+
+			msg := bingo.NewPlayerMagicLinkMessage(tc.player)
+			msg.ID = requiretest.UUIDv4(t)
+
+			claimed := false
+			claimHandler := func(_ context.Context, _ time.Time, _ time.Duration) (*bingo.OutboxMessage, error) {
+				if claimed {
+					return nil, bingo.ErrOutboxEmpty
+				}
+				claimed = true
+				return msg, nil
+			}
+			mocks.outboxRepo.ExpectClaimDue(claimHandler)
+			mocks.outboxRepo.ExpectClaimDue(claimHandler)
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.player))
+			if tc.expectMail {
+				mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+				mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+				var data bingo.PlayerMagicLinkMailData
+				mocks.templates.ExpectRenderMail(func(tmpl bingo.MailTemplate, _ bingo.Language, d interface{}) (*bingo.Mail, error) {
+					require.Equal(t, bingo.MailTemplatePlayerMagicLink, tmpl, "magic link template must be rendered")
+					data = d.(bingo.PlayerMagicLinkMailData)
+					return &bingo.Mail{}, nil
+				})
+				mocks.mailer.ExpectSend(func(_ context.Context, m *bingo.Mail) error {
+					require.Equal(t, tc.player.Email, m.To, "link must be sent to the player")
+					require.True(t, strings.HasPrefix(data.Link, testDownloadLinkBase+"/player/session?token="), "link must point to the player session page")
+					require.WithinDuration(t, time.Now().Add(bingo.PlayerSessionLifetime), data.ExpiresAt, time.Minute, "link must be valid for the session lifetime")
+					return nil
+				})
+			}
+			var savedMsg *bingo.OutboxMessage
+			mocks.outboxRepo.ExpectSave(func(_ context.Context, m *bingo.OutboxMessage) error {
+				savedMsg = m
+				return nil
+			})
+
+			_, err := outboxSvc.DeliverDue(context.Background())
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.OutboxStatusSent, savedMsg.Status, "message must be done, whether or not the link was needed")
+		})
+	}
+}
+
+// Sign a token of a session of the player, as the fake signer of the services under test does
+func MustSignPlayerSession(tb testing.TB, p *bingo.Player, expiresAt time.Time) string {
+	tb.Helper()
+
+	token, err := mock.PlayerSessionSigner{}.Sign(bingo.PlayerSessionClaims{
+		PlayerID:  p.ID,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(tb, err, "token must be signed")
+
+	return token
+}
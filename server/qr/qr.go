@@ -0,0 +1,109 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+)
+
+// Modules of white space around codes, which scanners need to find them
+const quietZone = 4
+
+var (
+	ErrSizeTooSmall = errors.New("qr: size is too small to fit the code")
+)
+
+// QR code of some content, e.g. the join url of an invitation
+type Code struct {
+	// Dark modules by row and column, without the quiet zone
+	modules [][]bool
+}
+
+// Encode the content as a QR code. Medium error correction keeps codes scannable when printed on a crumpled poster.
+func Encode(content string) (*Code, error) {
+	bc, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+
+	n := bc.Bounds().Dx()
+	modules := make([][]bool, n)
+	for y := 0; y < n; y++ {
+		modules[y] = make([]bool, n)
+		for x := 0; x < n; x++ {
+			modules[y][x] = bc.At(x, y) == color.Black
+		}
+	}
+
+	return &Code{modules: modules}, nil
+}
+
+// Width of the code in modules, including the quiet zone
+func (c *Code) Width() int {
+	return len(c.modules) + quietZone*2
+}
+
+// Image of the code, with the quiet zone, at as many pixels per module as fit within the size. Modules are not
+// interpolated, so the image stays sharp however it is scaled afterwards.
+func (c *Code) Image(size int) (image.Image, error) {
+	scale := size / c.Width()
+	if scale < 1 {
+		return nil, ErrSizeTooSmall
+	}
+
+	px := c.Width() * scale
+	img := image.NewGray(image.Rect(0, 0, px, px))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y, row := range c.modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+quietZone)*scale+dx, (y+quietZone)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// Write the code as a png image of at most the size in pixels
+func (c *Code) WritePNG(w io.Writer, size int) error {
+	img, err := c.Image(size)
+	if err != nil {
+		return err
+	}
+
+	return png.Encode(w, img)
+}
+
+// Write the code as an svg image of the size in pixels. Vector images scale to any size, so the size is only a hint
+func (c *Code) WriteSVG(w io.Writer, size int) error {
+	// Draw every dark module as a unit square of one path, which keeps the file small
+	var path strings.Builder
+	for y, row := range c.modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+
+	width := c.Width()
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		size, size, width, width, width, width, path.String())
+
+	return err
+}
@@ -0,0 +1,54 @@
+package qr_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/nohns/bingo-box/server/qr"
+	"github.com/stretchr/testify/require"
+)
+
+const testContent = "https://bingobox.test/join/7K3M9QXD"
+
+func TestCode_WritePNG(t *testing.T) {
+	code, err := qr.Encode(testContent)
+	require.NoError(t, err, "content must be encoded")
+
+	var buf bytes.Buffer
+	require.NoError(t, code.WritePNG(&buf, 300), "no error is expected")
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err, "png must decode")
+	width := img.Bounds().Dx()
+	require.LessOrEqual(t, width, 300, "image must fit within the size")
+	require.Zero(t, width%code.Width(), "modules must be whole pixels")
+
+	scale := width / code.Width()
+	gray := func(x, y int) uint8 { return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y }
+	require.Equal(t, uint8(0xff), gray(0, 0), "quiet zone must be white")
+	require.Equal(t, uint8(0), gray(4*scale, 4*scale), "finder pattern must start after the quiet zone")
+}
+
+func TestCode_WritePNGTooSmall(t *testing.T) {
+	code, err := qr.Encode(testContent)
+	require.NoError(t, err, "content must be encoded")
+
+	err = code.WritePNG(&bytes.Buffer{}, code.Width()-1)
+	require.ErrorIs(t, err, qr.ErrSizeTooSmall, "error must be of expected error kind")
+}
+
+func TestCode_WriteSVG(t *testing.T) {
+	code, err := qr.Encode(testContent)
+	require.NoError(t, err, "content must be encoded")
+
+	var buf bytes.Buffer
+	require.NoError(t, code.WriteSVG(&buf, 300), "no error is expected")
+
+	svg := buf.String()
+	require.True(t, strings.HasPrefix(svg, "<svg"), "output must be an svg")
+	require.Contains(t, svg, `width="300"`, "svg must have the size")
+	require.Contains(t, svg, "M4 4h1v1h-1z", "finder pattern must start after the quiet zone")
+}
@@ -0,0 +1,14 @@
+package bingo
+
+// Repositories the domain services persist to. Services depending on several repositories take them by name from
+// this, so repositories can not be swapped by their position.
+type Repositories struct {
+	Users         UserRepository
+	Organizations OrganizationRepository
+	Games         GameRepository
+	Cards         CardRepository
+	Invitations   InvitationRepository
+	Players       PlayerRepository
+	Credits       CreditRepository
+	Outbox        OutboxRepository
+}
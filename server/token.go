@@ -0,0 +1,237 @@
+package bingo
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidAccessToken    = errors.New("bingo: access token is invalid or expired")
+	ErrInvalidRefreshToken   = errors.New("bingo: refresh token is invalid or expired")
+	ErrRefreshTokenReused    = errors.New("bingo: refresh token has already been used")
+	ErrRefreshTokenNotFound  = errors.New("bingo: refresh token was not found")
+	ErrTokenGenerationFailed = errors.New("bingo: could not generate random token")
+)
+
+const (
+	AccessTokenLifetime  = 15 * time.Minute
+	RefreshTokenLifetime = 30 * 24 * time.Hour
+)
+
+// Signs and verifies the short-lived access tokens handed out to clients.
+type AccessTokenSigner interface {
+	Sign(claims AccessTokenClaims) (string, error)
+	Verify(token string) (*AccessTokenClaims, error)
+}
+
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, t *RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+
+	// Revoke the token by its hash, unless already revoked. Returns ErrRefreshTokenReused if it was, so the token can
+	// only be rotated once, also when presented concurrently
+	Revoke(ctx context.Context, hash string, at time.Time) error
+	RevokeFamily(ctx context.Context, familyId string, at time.Time) error
+}
+
+type TokenService struct {
+	userRepo    UserRepository
+	refreshRepo RefreshTokenRepository
+	signer      AccessTokenSigner
+}
+
+// Issue a new access token and a refresh token starting a new token family for the user.
+func (ts *TokenService) Issue(ctx context.Context, u *User) (*AuthTokens, error) {
+	familyId, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.issue(ctx, u, familyId)
+}
+
+// Rotate the given refresh token. The used refresh token is revoked and a new pair of tokens in the same family
+// is returned. If a refresh token is used more than once, the whole family is revoked, as it might have been stolen.
+func (ts *TokenService) Refresh(ctx context.Context, refreshToken string) (*User, *AuthTokens, error) {
+	rt, err := ts.refreshRepo.GetByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, ErrRefreshTokenNotFound) {
+		return nil, nil, ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+
+	// A revoked token being presented means it has been used before. Revoke every token descending from the same login
+	if rt.Revoked() {
+		return nil, nil, ts.revokeReused(ctx, rt, now)
+	}
+	if rt.Expired(now) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// Mark token as used before handing out a new one. Only one of concurrent refreshes by the same token succeeds, as
+	// the others find it revoked and are treated as reuse
+	err = ts.refreshRepo.Revoke(ctx, rt.Hash, now)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return nil, nil, ts.revokeReused(ctx, rt, now)
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	u, err := ts.userRepo.Get(ctx, rt.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := ts.issue(ctx, u, rt.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u, tokens, nil
+}
+
+// Revoke the family of the given refresh token, so neither it or any token rotated from it can be used again.
+func (ts *TokenService) Revoke(ctx context.Context, refreshToken string) error {
+	rt, err := ts.refreshRepo.GetByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, ErrRefreshTokenNotFound) {
+		return ErrInvalidRefreshToken
+	} else if err != nil {
+		return err
+	}
+
+	return ts.refreshRepo.RevokeFamily(ctx, rt.FamilyID, time.Now())
+}
+
+// Revoke the family of the refresh token used more than once, as it might have been stolen. Returns
+// ErrRefreshTokenReused once the family is revoked.
+func (ts *TokenService) revokeReused(ctx context.Context, rt *RefreshToken, at time.Time) error {
+	if err := ts.refreshRepo.RevokeFamily(ctx, rt.FamilyID, at); err != nil {
+		return err
+	}
+
+	return ErrRefreshTokenReused
+}
+
+// Verify the access token and return the user it was issued to.
+func (ts *TokenService) Verify(ctx context.Context, accessToken string) (*User, error) {
+	claims, err := ts.signer.Verify(accessToken)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+	if claims.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	u, err := ts.userRepo.Get(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (ts *TokenService) issue(ctx context.Context, u *User, familyId string) (*AuthTokens, error) {
+	now := time.Now()
+
+	// Sign access token
+	accessExp := now.Add(AccessTokenLifetime)
+	accessToken, err := ts.signer.Sign(AccessTokenClaims{
+		UserID:    u.ID,
+		IssuedAt:  now,
+		ExpiresAt: accessExp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Create refresh token. Only the hash of it is persisted
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	rt := &RefreshToken{
+		FamilyID:  familyId,
+		UserID:    u.ID,
+		Hash:      hashToken(refreshToken),
+		ExpiresAt: now.Add(RefreshTokenLifetime),
+		CreatedAt: now,
+	}
+	if err := ts.refreshRepo.Save(ctx, rt); err != nil {
+		return nil, err
+	}
+
+	return &AuthTokens{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExp,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: rt.ExpiresAt,
+	}, nil
+}
+
+// Instantiate a new token service with dependencies
+func NewTokenService(userRepo UserRepository, refreshRepo RefreshTokenRepository, signer AccessTokenSigner) *TokenService {
+	return &TokenService{
+		userRepo:    userRepo,
+		refreshRepo: refreshRepo,
+		signer:      signer,
+	}
+}
+
+// Pair of tokens handed out to a client after authenticating
+type AuthTokens struct {
+	AccessToken           string    `json:"accessToken"`
+	AccessTokenExpiresAt  time.Time `json:"accessTokenExpiresAt"`
+	RefreshToken          string    `json:"refreshToken"`
+	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt"`
+}
+
+// Value object
+type AccessTokenClaims struct {
+	UserID    string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Entity. Tokens created from the same login share a family, so they can be revoked together.
+type RefreshToken struct {
+	ID       string
+	FamilyID string
+	UserID   string
+
+	// SHA-256 hash of the token given to the client
+	Hash string
+
+	ExpiresAt time.Time
+	RevokedAt time.Time
+	CreatedAt time.Time
+}
+
+func (rt *RefreshToken) Revoked() bool {
+	return !rt.RevokedAt.IsZero()
+}
+
+func (rt *RefreshToken) Expired(now time.Time) bool {
+	return !rt.ExpiresAt.After(now)
+}
+
+// Generate a random url safe token from n random bytes
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", ErrTokenGenerationFailed
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,252 @@
+package bingo_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenService_Issue(t *testing.T) {
+	tokenSvc, mocks := MustCreateTokenService(t)
+	defer mocks.refreshRepo.RequireExpectationsMet()
+
+	u := MustMakeTestUser(t)
+
+	var saved *bingo.RefreshToken
+	mocks.refreshRepo.ExpectSave(func(ctx context.Context, rt *bingo.RefreshToken) error {
+		saved = rt
+		return nil
+	})
+
+	tokens, err := tokenSvc.Issue(context.Background(), u)
+	require.NoError(t, err, "no error is expected")
+	require.NotEmpty(t, tokens.AccessToken, "access token must be set")
+	require.NotEmpty(t, tokens.RefreshToken, "refresh token must be set")
+	require.True(t, tokens.AccessTokenExpiresAt.Before(tokens.RefreshTokenExpiresAt), "access token must expire before refresh token")
+
+	require.NotNil(t, saved, "refresh token must be persisted")
+	require.Equal(t, u.ID, saved.UserID, "refresh token must belong to user")
+	require.NotEmpty(t, saved.FamilyID, "refresh token must start a family")
+	require.Equal(t, HashTestToken(tokens.RefreshToken), saved.Hash, "only the hash of the refresh token must be persisted")
+}
+
+func TestTokenService_Refresh(t *testing.T) {
+	u := MustMakeTestUser(t)
+	familyId := "family"
+	presented := "presented refresh token"
+
+	activeToken := func() *bingo.RefreshToken {
+		return &bingo.RefreshToken{
+			ID:        requiretest.UUIDv4(t),
+			FamilyID:  familyId,
+			UserID:    u.ID,
+			Hash:      HashTestToken(presented),
+			ExpiresAt: time.Now().Add(time.Hour),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	cases := []struct {
+		caseName       string
+		token          func() *bingo.RefreshToken
+		getErr         error
+		revokeErr      error
+		expectRevoke   bool
+		expectRotation bool
+		expectedErr    error
+	}{
+		{
+			caseName:       "success",
+			token:          activeToken,
+			expectRotation: true,
+		},
+		{
+			caseName:    "not found",
+			getErr:      bingo.ErrRefreshTokenNotFound,
+			expectedErr: bingo.ErrInvalidRefreshToken,
+		},
+		{
+			caseName: "expired",
+			token: func() *bingo.RefreshToken {
+				rt := activeToken()
+				rt.ExpiresAt = time.Now().Add(-time.Minute)
+				return rt
+			},
+			expectedErr: bingo.ErrInvalidRefreshToken,
+		},
+		{
+			caseName: "reused revokes family",
+			token: func() *bingo.RefreshToken {
+				rt := activeToken()
+				rt.RevokedAt = time.Now().Add(-time.Minute)
+				return rt
+			},
+			expectRevoke: true,
+			expectedErr:  bingo.ErrRefreshTokenReused,
+		},
+		{
+			caseName:       "reused concurrently revokes family",
+			token:          activeToken,
+			revokeErr:      bingo.ErrRefreshTokenReused,
+			expectRotation: true,
+			expectRevoke:   true,
+			expectedErr:    bingo.ErrRefreshTokenReused,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			tokenSvc, mocks := MustCreateTokenService(t)
+			defer mocks.refreshRepo.RequireExpectationsMet()
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			mocks.refreshRepo.ExpectGetByHash(func(ctx context.Context, hash string) (*bingo.RefreshToken, error) {
+				require.Equal(t, HashTestToken(presented), hash, "refresh token must be looked up by hash")
+				if tc.getErr != nil {
+					return nil, tc.getErr
+				}
+				return tc.token(), nil
+			})
+			if tc.expectRevoke {
+				mocks.refreshRepo.ExpectRevokeFamily(func(ctx context.Context, fid string, at time.Time) error {
+					require.Equal(t, familyId, fid, "family of reused token must be revoked")
+					return nil
+				})
+			}
+			if tc.expectRotation {
+				// Presented token is marked as used, unless used concurrently
+				mocks.refreshRepo.ExpectRevoke(func(ctx context.Context, hash string, at time.Time) error {
+					require.Equal(t, HashTestToken(presented), hash, "presented token must be revoked when rotated")
+					return tc.revokeErr
+				})
+			}
+			if tc.expectRotation && tc.revokeErr == nil {
+				// New token is saved in the same family
+				mocks.userRepo.ExpectGet(MakeSingleUserGetHandler(t, *u))
+				mocks.refreshRepo.ExpectSave(func(ctx context.Context, rt *bingo.RefreshToken) error {
+					require.False(t, rt.Revoked(), "rotated token must not be revoked")
+					require.Equal(t, familyId, rt.FamilyID, "rotated token must stay in family")
+					return nil
+				})
+			}
+
+			ru, tokens, err := tokenSvc.Refresh(context.Background(), presented)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, tokens, "tokens must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.Equal(t, u.ID, ru.ID, "tokens must be issued for the token owner")
+				require.NotEqual(t, presented, tokens.RefreshToken, "refresh token must be rotated")
+			}
+		})
+	}
+}
+
+func TestTokenService_Verify(t *testing.T) {
+	u := MustMakeTestUser(t)
+	signer := mock.AccessTokenSigner{}
+	validToken, err := signer.Sign(bingo.AccessTokenClaims{UserID: u.ID, ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	expiredToken, err := signer.Sign(bingo.AccessTokenClaims{UserID: u.ID, ExpiresAt: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+
+	cases := []struct {
+		caseName    string
+		token       string
+		expectGet   bool
+		expectedErr error
+	}{
+		{
+			caseName:  "success",
+			token:     validToken,
+			expectGet: true,
+		},
+		{
+			caseName:    "expired",
+			token:       expiredToken,
+			expectedErr: bingo.ErrInvalidAccessToken,
+		},
+		{
+			caseName:    "malformed",
+			token:       "malformed",
+			expectedErr: bingo.ErrInvalidAccessToken,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			tokenSvc, mocks := MustCreateTokenService(t)
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			if tc.expectGet {
+				mocks.userRepo.ExpectGet(MakeSingleUserGetHandler(t, *u))
+			}
+
+			vu, err := tokenSvc.Verify(context.Background(), tc.token)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, vu, "user must be nil when error is expected")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.Equal(t, u.ID, vu.ID, "user must be the one the token was issued to")
+			}
+		})
+	}
+}
+
+type tokenServiceMocks struct {
+	userRepo    *mock.UserRepository
+	refreshRepo *mock.RefreshTokenRepository
+}
+
+func MustCreateTokenService(tb testing.TB) (*bingo.TokenService, *tokenServiceMocks) {
+	tb.Helper()
+
+	userRepo := mock.NewUserRepository(tb)
+	refreshRepo := mock.NewRefreshTokenRepository(tb)
+
+	tokenSvc := bingo.NewTokenService(userRepo, refreshRepo, mock.AccessTokenSigner{})
+	mocks := &tokenServiceMocks{
+		userRepo:    userRepo,
+		refreshRepo: refreshRepo,
+	}
+
+	return tokenSvc, mocks
+}
+
+func HashTestToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func MakeSingleUserGetHandler(tb testing.TB, user bingo.User) mock.UserGetHandler {
+	tb.Helper()
+
+	return func(_ context.Context, id string) (*bingo.User, error) {
+		if id != user.ID {
+			return nil, bingo.ErrUserNotFound
+		}
+
+		return &user, nil
+	}
+}
+
+func MustMakeTestUser(tb testing.TB) *bingo.User {
+	tb.Helper()
+
+	return &bingo.User{
+		ID:        requiretest.UUIDv4(tb),
+		Name:      "test user",
+		Email:     "test@test.com",
+		UpdatedAt: time.Now(),
+		CreatedAt: time.Now().Add(-5 * time.Hour),
+	}
+}
@@ -0,0 +1,9 @@
+package bingo
+
+import "context"
+
+// Runs functions within a transaction, so the changes made through repositories using the given context are either
+// all persisted or none are.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -3,6 +3,8 @@ package bingo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -10,38 +12,182 @@ var (
 	ErrUserNotFound      = errors.New("bingo: user was not found")
 	ErrUserAlreadyExists = errors.New("bingo: user was already exists")
 	ErrPasswordMismatch  = errors.New("bingo: passwords are not matching")
+
+	ErrUserValidation = NewValErr("bingo: user validation failed")
+)
+
+const (
+	MinPasswordLength = 8
 )
 
 type UserRepository interface {
 	Get(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByIdentityID(ctx context.Context, identityId string) (*User, error)
 	Save(ctx context.Context, user *User) error
+
+	// Add amount to the game credits of the user atomically. Debits exceeding the credits are refused with
+	// ErrInsufficientCredits. Saving the user does not change the credits
+	AddCredits(ctx context.Context, id string, amount int) error
 }
 
 type Hasher interface {
 	Hash(passwd string) ([]byte, error)
 	Compare(hash []byte, passwd string) error
+
+	// Reports whether the hash was made with an outdated algorithm or weaker parameters than the hasher currently uses
+	NeedsRehash(hash []byte) bool
 }
 
 type UserService struct {
 	userRepo UserRepository
+	hasher   Hasher
 }
 
 // Authenticate user by email and password. If the credentials are valid, a user is returned and otherwise an error.
 func (us *UserService) Authenticate(ctx context.Context, email, passwd string) (*User, error) {
 	// Try to get user by their email
-	u, err := us.userRepo.GetByEmail(ctx, email)
+	u, err := us.userRepo.GetByEmail(ctx, NormalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+
+	// Users signed up through Kratos have no password here, so no password matches
+	if len(u.HashedPassword) == 0 {
+		return nil, ErrPasswordMismatch
+	}
+
+	// Make sure the password matches the stored hash. Hashes not in a format known by the hasher match no password
+	if err := us.hasher.Compare(u.HashedPassword, passwd); errors.Is(err, ErrPasswordMismatch) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPasswordMismatch, err)
+	}
+
+	// Upgrade outdated hashes while we have the plain password at hand
+	if us.hasher.NeedsRehash(u.HashedPassword) {
+		us.rehash(ctx, u, passwd)
+	}
+
+	return u, nil
+}
+
+// Rehash the password of the user with the current hasher settings and save it. Errors are ignored, as the user has
+// already been authenticated. The rehash will simply be tried again on the next login.
+func (us *UserService) rehash(ctx context.Context, u *User, passwd string) {
+	hash, err := us.hasher.Hash(passwd)
+	if err != nil {
+		return
+	}
+
+	prevHash := u.HashedPassword
+	u.HashedPassword = hash
+	if err := us.userRepo.Save(ctx, u); err != nil {
+		u.HashedPassword = prevHash
+	}
+}
+
+// Register a new user with a password. The email must not already be in use by another user.
+func (us *UserService) Register(ctx context.Context, name, email, passwd string) (*User, error) {
+	u := RegisterUser(name, email)
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(passwd); err != nil {
+		return nil, err
+	}
+
+	// Make sure no other user is registered with the email. Concurrent registrations are refused by the repository
+	_, err := us.userRepo.GetByEmail(ctx, u.Email)
+	if err == nil {
+		return nil, ErrUserAlreadyExists
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	// Only ever store the hash of the password
+	u.HashedPassword, err = us.hasher.Hash(passwd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := us.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Get the user belonging to an external identity. On first sight of the identity, it is linked to the user
+// registered with the same email, if the identity has verified the email, or a new user is created for it.
+func (us *UserService) ProvisionIdentity(ctx context.Context, id *Identity) (*User, error) {
+	u, linked, err := us.findOrRegisterIdentity(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if linked {
+		return u, nil
+	}
+	u.applyIdentity(id, time.Now())
+
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	if err := us.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Create or update the user of the identity, so name, email and terms of service acceptance mirror the identity. Used
+// when the identity provider notifies about registrations and identity updates.
+func (us *UserService) SyncIdentity(ctx context.Context, id *Identity) (*User, error) {
+	u, _, err := us.findOrRegisterIdentity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u.applyIdentity(id, time.Now())
+
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	if err := us.userRepo.Save(ctx, u); err != nil {
+		return nil, err
+	}
 
 	return u, nil
 }
 
-// Instantiate a new user service with a user repoistory.
-func NewUserService(userRepo UserRepository) *UserService {
+// Find the user linked to the identity. Otherwise link the user with the same email, or register a new one. Users are
+// only linked by email if the identity has verified it, so an identity can not take over an account by claiming its
+// email. Linked reports whether the user was already linked to the identity.
+func (us *UserService) findOrRegisterIdentity(ctx context.Context, id *Identity) (u *User, linked bool, err error) {
+	u, err = us.userRepo.GetByIdentityID(ctx, id.ID)
+	if err == nil {
+		return u, true, nil
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return nil, false, err
+	}
+
+	u, err = us.userRepo.GetByEmail(ctx, NormalizeEmail(id.Email))
+	if errors.Is(err, ErrUserNotFound) {
+		u = RegisterUser(id.Name(), id.Email)
+	} else if err != nil {
+		return nil, false, err
+	} else if !id.EmailVerified {
+		return nil, false, ErrIdentityEmailUnverified
+	}
+	u.IdentityID = id.ID
+
+	return u, false, nil
+}
+
+// Instantiate a new user service with a user repoistory and a password hasher.
+func NewUserService(userRepo UserRepository, hasher Hasher) *UserService {
 	return &UserService{
 		userRepo: userRepo,
+		hasher:   hasher,
 	}
 }
 
@@ -50,7 +196,24 @@ type User struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 
-	GameCredits int
+	// Balance of the credit ledger of the user. Cached, so debits can be guarded atomically, while the ledger is the
+	// source of truth of the balance shown
+	GameCredits int `json:"gameCredits"`
+
+	// Admins of the platform, e.g. allowed to grant credits. Only ever set directly in the database
+	Admin bool `json:"admin"`
+
+	// Organization the user is acting on behalf of. Empty when acting as a person
+	ActiveOrganizationID string `json:"activeOrganizationId"`
+
+	// Hash of the password. Never exposed outside of the domain
+	HashedPassword []byte `json:"-"`
+
+	// Id of the identity at the external identity provider, if the user is managed by one
+	IdentityID string `json:"-"`
+
+	// When the terms of service was accepted. Zero if not accepted
+	TOSAcceptedAt time.Time `json:"tosAcceptedAt"`
 
 	UpdatedAt time.Time `json:"updatedAt"`
 	CreatedAt time.Time `json:"createdAt"`
@@ -58,19 +221,52 @@ type User struct {
 
 // See if user object is valid
 func (u *User) Validate() error {
+	if u.Email == "" {
+		return ErrUserValidation.withFieldErr("Email", "empty", "email has to have a value")
+	}
+
 	return nil
 }
 
+// Mirror name, email and terms of service acceptance of the identity onto the user
+func (u *User) applyIdentity(id *Identity, now time.Time) {
+	if name := strings.TrimSpace(id.FirstName + " " + id.LastName); name != "" {
+		u.Name = name
+	}
+	if id.Email != "" {
+		u.Email = NormalizeEmail(id.Email)
+	}
+	if !id.TOSAccepted {
+		u.TOSAcceptedAt = time.Time{}
+	} else if u.TOSAcceptedAt.IsZero() {
+		u.TOSAcceptedAt = now
+	}
+	u.UpdatedAt = now
+}
+
 func (u *User) CreateGame(name string) *Game {
 	return CreateGame(u.ID, name)
 }
 
-// Register user by their information and return a user with a hash password.
+// Register user by their information. The password hash is set by the user service.
 func RegisterUser(name, email string) *User {
 	return &User{
-		Name:      name,
-		Email:     email,
+		Name:      strings.TrimSpace(name),
+		Email:     NormalizeEmail(email),
 		UpdatedAt: time.Now(),
 		CreatedAt: time.Now(),
 	}
 }
+
+func validatePassword(passwd string) error {
+	if len(passwd) < MinPasswordLength {
+		return ErrUserValidation.withFieldErr("Password", "min", "password must be at least %d characters long", MinPasswordLength)
+	}
+
+	return nil
+}
+
+// Normalize email for storage and comparison, so casing and surrounding whitespace does not matter.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,437 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/bcrypt"
+	"github.com/nohns/bingo-box/server/mock"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_Register(t *testing.T) {
+
+	var ErrUserRepo = errors.New("repo: error occurred")
+
+	existingUser := MustMakeTestUser(t)
+
+	notFoundGetByEmailHandler := func(_ context.Context, email string) (*bingo.User, error) {
+		return nil, bingo.ErrUserNotFound
+	}
+	successSaveHandler := func(_ context.Context, u *bingo.User) error {
+		u.ID = requiretest.UUIDv4(t)
+		return nil
+	}
+
+	cases := []struct {
+		caseName          string
+		name              string
+		email             string
+		passwd            string
+		getByEmailHandler mock.UserGetByEmailHandler
+		saveHandler       mock.UserSaveHandler
+		expectErr         bool
+		expectedErr       error
+		expectValErr      bool
+	}{
+		{
+			caseName:          "success",
+			name:              "new user",
+			email:             " New@Test.com ",
+			passwd:            "secret password",
+			getByEmailHandler: notFoundGetByEmailHandler,
+			saveHandler:       successSaveHandler,
+			expectErr:         false,
+		},
+		{
+			caseName:          "user already exists",
+			name:              "new user",
+			email:             existingUser.Email,
+			passwd:            "secret password",
+			getByEmailHandler: MakeSingleUserGetByEmailHandler(t, *existingUser),
+			expectErr:         true,
+			expectedErr:       bingo.ErrUserAlreadyExists,
+		},
+		{
+			caseName:     "password too short",
+			name:         "new user",
+			email:        "new@test.com",
+			passwd:       "short",
+			expectErr:    true,
+			expectValErr: true,
+		},
+		{
+			caseName:          "get by email error",
+			name:              "new user",
+			email:             "new@test.com",
+			passwd:            "secret password",
+			getByEmailHandler: func(_ context.Context, _ string) (*bingo.User, error) { return nil, ErrUserRepo },
+			expectErr:         true,
+			expectedErr:       ErrUserRepo,
+		},
+		{
+			caseName:          "save error",
+			name:              "new user",
+			email:             "new@test.com",
+			passwd:            "secret password",
+			getByEmailHandler: notFoundGetByEmailHandler,
+			saveHandler:       func(_ context.Context, _ *bingo.User) error { return ErrUserRepo },
+			expectErr:         true,
+			expectedErr:       ErrUserRepo,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			userSvc, mocks := MustCreateUserService(t)
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			if tc.getByEmailHandler != nil {
+				mocks.userRepo.ExpectGetByEmail(tc.getByEmailHandler)
+			}
+			if tc.saveHandler != nil {
+				mocks.userRepo.ExpectSave(tc.saveHandler)
+			}
+
+			u, err := userSvc.Register(context.Background(), tc.name, tc.email, tc.passwd)
+			if tc.expectErr {
+				require.Nil(t, u, "user must be nil when error is expected")
+				require.Error(t, err, "error must be set when error is expected")
+				if tc.expectValErr {
+					var valErr bingo.ValidationErr
+					require.ErrorAs(t, err, &valErr, "error must be a validation error")
+					return
+				}
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.NotEmpty(t, u.ID, "user id must be set")
+				require.Equal(t, bingo.NormalizeEmail(tc.email), u.Email, "email must be normalized")
+				require.Equal(t, mock.FakeHash(tc.passwd), u.HashedPassword, "password must be hashed by hasher")
+			}
+		})
+	}
+}
+
+func TestUserService_Authenticate(t *testing.T) {
+
+	passwd := "correct password"
+	u := MustMakeTestUser(t)
+	u.HashedPassword = mock.FakeHash(passwd)
+
+	cases := []struct {
+		caseName    string
+		email       string
+		passwd      string
+		expectErr   bool
+		expectedErr error
+	}{
+		{
+			caseName:  "success",
+			email:     u.Email,
+			passwd:    passwd,
+			expectErr: false,
+		},
+		{
+			caseName:  "success email casing",
+			email:     "TEST@test.com",
+			passwd:    passwd,
+			expectErr: false,
+		},
+		{
+			caseName:    "password mismatch",
+			email:       u.Email,
+			passwd:      "wrong password",
+			expectErr:   true,
+			expectedErr: bingo.ErrPasswordMismatch,
+		},
+		{
+			caseName:    "user not found",
+			email:       "unknown@test.com",
+			passwd:      passwd,
+			expectErr:   true,
+			expectedErr: bingo.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			userSvc, mocks := MustCreateUserService(t)
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			mocks.userRepo.ExpectGetByEmail(MakeSingleUserGetByEmailHandler(t, *u))
+
+			au, err := userSvc.Authenticate(context.Background(), tc.email, tc.passwd)
+			if tc.expectErr {
+				require.Nil(t, au, "user must be nil when error is expected")
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+			} else {
+				require.NoError(t, err, "no error is expected")
+				require.Equal(t, u.ID, au.ID, "authenticated user must be the one with the email")
+			}
+		})
+	}
+}
+
+// Users without a password in a format known by the hasher, e.g. signed up through Kratos, must be refused as any
+// other wrong password
+func TestUserService_Authenticate_UnknownHash(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		hash     []byte
+	}{
+		{
+			caseName: "kratos user without password",
+			hash:     nil,
+		},
+		{
+			caseName: "hash of unknown format",
+			hash:     []byte("not a hash"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			userRepo := mock.NewUserRepository(t)
+			defer userRepo.RequireExpectationsMet()
+			userSvc := bingo.NewUserService(userRepo, bcrypt.NewHasher())
+
+			u := MustMakeTestUser(t)
+			u.HashedPassword = tc.hash
+			u.IdentityID = requiretest.UUIDv4(t)
+			userRepo.ExpectGetByEmail(MakeSingleUserGetByEmailHandler(t, *u))
+
+			au, err := userSvc.Authenticate(context.Background(), u.Email, "")
+			require.ErrorIs(t, err, bingo.ErrPasswordMismatch, "error must be a password mismatch")
+			require.Nil(t, au, "user must be nil when error is expected")
+		})
+	}
+}
+
+func TestUserService_Authenticate_Rehash(t *testing.T) {
+
+	var ErrUserRepo = errors.New("repo: error occurred")
+
+	passwd := "correct password"
+
+	cases := []struct {
+		caseName     string
+		hash         []byte
+		saveHandler  mock.UserSaveHandler
+		expectedHash []byte
+	}{
+		{
+			caseName:     "current hash is kept",
+			hash:         mock.FakeHash(passwd),
+			saveHandler:  nil,
+			expectedHash: mock.FakeHash(passwd),
+		},
+		{
+			caseName:     "outdated hash is rehashed",
+			hash:         mock.FakeLegacyHash(passwd),
+			saveHandler:  func(_ context.Context, _ *bingo.User) error { return nil },
+			expectedHash: mock.FakeHash(passwd),
+		},
+		{
+			caseName:     "failed rehash save still authenticates",
+			hash:         mock.FakeLegacyHash(passwd),
+			saveHandler:  func(_ context.Context, _ *bingo.User) error { return ErrUserRepo },
+			expectedHash: mock.FakeLegacyHash(passwd),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			userSvc, mocks := MustCreateUserService(t)
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			u := MustMakeTestUser(t)
+			u.HashedPassword = tc.hash
+			mocks.userRepo.ExpectGetByEmail(MakeSingleUserGetByEmailHandler(t, *u))
+			if tc.saveHandler != nil {
+				mocks.userRepo.ExpectSave(tc.saveHandler)
+			}
+
+			au, err := userSvc.Authenticate(context.Background(), u.Email, passwd)
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, tc.expectedHash, au.HashedPassword, "unexpected password hash after authentication")
+		})
+	}
+}
+
+func TestUserService_ProvisionIdentity(t *testing.T) {
+
+	linkedUser := MustMakeTestUser(t)
+	linkedUser.IdentityID = "linked identity"
+	emailUser := MustMakeTestUser(t)
+	emailUser.Email = "existing@test.com"
+
+	notFoundGetByIdentityIDHandler := func(_ context.Context, _ string) (*bingo.User, error) {
+		return nil, bingo.ErrUserNotFound
+	}
+	successSaveHandler := func(_ context.Context, u *bingo.User) error {
+		if u.ID == "" {
+			u.ID = requiretest.UUIDv4(t)
+		}
+		return nil
+	}
+
+	cases := []struct {
+		caseName               string
+		identity               *bingo.Identity
+		getByIdentityIDHandler mock.UserGetByIdentityIDHandler
+		getByEmailHandler      mock.UserGetByEmailHandler
+		saveHandler            mock.UserSaveHandler
+		expectedUserID         string
+		expectedName           string
+		expectedErr            error
+	}{
+		{
+			caseName:               "already linked",
+			identity:               &bingo.Identity{ID: linkedUser.IdentityID, Email: linkedUser.Email},
+			getByIdentityIDHandler: func(_ context.Context, _ string) (*bingo.User, error) { return linkedUser, nil },
+			expectedUserID:         linkedUser.ID,
+			expectedName:           linkedUser.Name,
+		},
+		{
+			caseName:               "link existing user by email",
+			identity:               &bingo.Identity{ID: "new identity", Email: "Existing@test.com", EmailVerified: true},
+			getByIdentityIDHandler: notFoundGetByIdentityIDHandler,
+			getByEmailHandler:      MakeSingleUserGetByEmailHandler(t, *emailUser),
+			saveHandler:            successSaveHandler,
+			expectedUserID:         emailUser.ID,
+			expectedName:           emailUser.Name,
+		},
+		{
+			caseName:               "refuse linking by unverified email",
+			identity:               &bingo.Identity{ID: "new identity", Email: "Existing@test.com"},
+			getByIdentityIDHandler: notFoundGetByIdentityIDHandler,
+			getByEmailHandler:      MakeSingleUserGetByEmailHandler(t, *emailUser),
+			expectedErr:            bingo.ErrIdentityEmailUnverified,
+		},
+		{
+			caseName:               "create on first sight",
+			identity:               &bingo.Identity{ID: "new identity", Email: "new@test.com", FirstName: "New", LastName: "Person"},
+			getByIdentityIDHandler: notFoundGetByIdentityIDHandler,
+			getByEmailHandler:      MakeSingleUserGetByEmailHandler(t, *emailUser),
+			saveHandler:            successSaveHandler,
+			expectedName:           "New Person",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			userSvc, mocks := MustCreateUserService(t)
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			mocks.userRepo.ExpectGetByIdentityID(tc.getByIdentityIDHandler)
+			if tc.getByEmailHandler != nil {
+				mocks.userRepo.ExpectGetByEmail(tc.getByEmailHandler)
+			}
+			if tc.saveHandler != nil {
+				mocks.userRepo.ExpectSave(tc.saveHandler)
+			}
+
+			u, err := userSvc.ProvisionIdentity(context.Background(), tc.identity)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, u, "user must be nil when error is expected")
+				return
+			}
+			require.NoError(t, err, "no error is expected")
+			require.NotEmpty(t, u.ID, "user id must be set")
+			if tc.expectedUserID != "" {
+				require.Equal(t, tc.expectedUserID, u.ID, "identity must be mapped to existing user")
+			}
+			require.Equal(t, tc.identity.ID, u.IdentityID, "user must be linked to identity")
+			require.Equal(t, tc.expectedName, u.Name, "unexpected user name")
+		})
+	}
+}
+
+func TestUserService_SyncIdentity(t *testing.T) {
+
+	linkedUser := MustMakeTestUser(t)
+	linkedUser.IdentityID = "linked identity"
+	linkedUser.TOSAcceptedAt = time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		caseName            string
+		identity            *bingo.Identity
+		expectedName        string
+		expectedEmail       string
+		expectTOSAcceptedAt time.Time
+		expectTOSAccepted   bool
+	}{
+		{
+			caseName:            "update name and email",
+			identity:            &bingo.Identity{ID: linkedUser.IdentityID, Email: " Changed@Test.com", FirstName: "Changed", LastName: "Name", TOSAccepted: true},
+			expectedName:        "Changed Name",
+			expectedEmail:       "changed@test.com",
+			expectTOSAcceptedAt: linkedUser.TOSAcceptedAt,
+			expectTOSAccepted:   true,
+		},
+		{
+			caseName:      "keep name when identity has none",
+			identity:      &bingo.Identity{ID: linkedUser.IdentityID, Email: linkedUser.Email},
+			expectedName:  linkedUser.Name,
+			expectedEmail: linkedUser.Email,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			userSvc, mocks := MustCreateUserService(t)
+			defer mocks.userRepo.RequireExpectationsMet()
+
+			// Copy user, so cases do not affect each other
+			u := *linkedUser
+			mocks.userRepo.ExpectGetByIdentityID(func(_ context.Context, _ string) (*bingo.User, error) { return &u, nil })
+			mocks.userRepo.ExpectSave(func(_ context.Context, _ *bingo.User) error { return nil })
+
+			synced, err := userSvc.SyncIdentity(context.Background(), tc.identity)
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, linkedUser.ID, synced.ID, "identity must be mapped to linked user")
+			require.Equal(t, tc.expectedName, synced.Name, "unexpected user name")
+			require.Equal(t, tc.expectedEmail, synced.Email, "unexpected user email")
+			require.Equal(t, tc.expectTOSAccepted, !synced.TOSAcceptedAt.IsZero(), "unexpected terms of service acceptance")
+			if !tc.expectTOSAcceptedAt.IsZero() {
+				require.Equal(t, tc.expectTOSAcceptedAt, synced.TOSAcceptedAt, "first acceptance time must be kept")
+			}
+		})
+	}
+}
+
+type userServiceMocks struct {
+	userRepo *mock.UserRepository
+}
+
+func MustCreateUserService(tb testing.TB) (*bingo.UserService, *userServiceMocks) {
+	tb.Helper()
+
+	userRepo := mock.NewUserRepository(tb)
+
+	userSvc := bingo.NewUserService(userRepo, mock.Hasher{})
+	mocks := &userServiceMocks{
+		userRepo: userRepo,
+	}
+
+	return userSvc, mocks
+}
+
+func MakeSingleUserGetByEmailHandler(tb testing.TB, user bingo.User) mock.UserGetByEmailHandler {
+	tb.Helper()
+
+	return func(_ context.Context, email string) (*bingo.User, error) {
+		if email != user.Email {
+			return nil, bingo.ErrUserNotFound
+		}
+
+		return &user, nil
+	}
+}
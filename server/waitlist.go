@@ -0,0 +1,144 @@
+package bingo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Remove the player from the invitation they joined by. Their cards are revoked, so they can no longer win, and their
+// place and cards on the invitation are released for the players on the waitlist. Only members allowed to manage the
+// game can remove players.
+func (is *InvitationService) RemovePlayer(ctx context.Context, playerId string) (*Player, error) {
+	p, err := is.playerRepo.Get(ctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := is.invRepo.Get(ctx, p.InvitationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := is.authorize(ctx, inv, PermissionManageGame); err != nil {
+		return nil, err
+	}
+	if p.Status == PlayerStatusRemoved {
+		return nil, ErrPlayerRemoved
+	}
+
+	return is.roster.remove(ctx, inv, p.ID, NewCardRevocation(ctx, ""))
+}
+
+// Admits players to the invitations they join, and promotes players on the waitlist once there is room for them.
+// Shared by the services letting players join and leave games.
+type roster struct {
+	invRepo    InvitationRepository
+	playerRepo PlayerRepository
+	gameRepo   GameRepository
+	outboxRepo OutboxRepository
+	cards      cardGenerator
+	tx         Transactor
+}
+
+// Promote players on the waitlist of the invitation in line, for as long as there is room for the first one. Players
+// behind are not promoted before the first, even if they ask for fewer cards. Each player is promoted in a transaction
+// of their own, in which they and the game are read again, so concurrent promotions do not promote the same player
+// twice.
+func (r roster) promoteWaitlisted(ctx context.Context, inv *Invitation) error {
+	if inv.WaitlistCount == 0 {
+		return nil
+	}
+
+	waiting, err := r.playerRepo.Find(ctx, PlayerFilter{
+		GameID:       inv.GameID,
+		InvitationID: inv.ID,
+		Status:       PlayerStatusWaitlisted,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, w := range waiting {
+		err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			p, err := r.playerRepo.Get(ctx, w.ID)
+			if err != nil {
+				return err
+			}
+			if p.Status != PlayerStatusWaitlisted {
+				return nil
+			}
+			if err := r.invRepo.ReserveWaitlisted(ctx, inv, p.RequestedCards); err != nil {
+				return err
+			}
+			g, err := r.gameRepo.Get(ctx, inv.GameID)
+			if err != nil {
+				return err
+			}
+
+			return r.admit(ctx, inv, g, p, p.RequestedCards, inv.mailsCards())
+		})
+		if errors.Is(err, ErrInvitationFull) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Revoke the cards of the player for the reason given, remove them from the game and release their place and cards on
+// the invitation, before promoting the players on the waitlist. The player is read again within the transaction, so
+// their place is only released once if removed concurrently. Removing players already removed voids their cards only.
+func (r roster) remove(ctx context.Context, inv *Invitation, playerId string, rev CardRevocation) (*Player, error) {
+	var p *Player
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := r.playerRepo.Get(ctx, playerId)
+		if err != nil {
+			return err
+		}
+		if current.Status != PlayerStatusRemoved {
+			if err := r.invRepo.Release(ctx, inv, current); err != nil {
+				return err
+			}
+		}
+
+		if revoked := current.revokeCards(rev); revoked > 0 {
+			if err := r.cards.cardRepo.SaveAll(ctx, current.Cards); err != nil {
+				return err
+			}
+			if err := r.invRepo.ReleaseCards(ctx, inv, revoked); err != nil {
+				return err
+			}
+		}
+
+		current.remove()
+		current.UpdatedAt = time.Now()
+		if err := r.playerRepo.Save(ctx, current); err != nil {
+			return err
+		}
+		current.Invitation = inv
+		p = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.promoteWaitlisted(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func newRoster(repos Repositories, tx Transactor) roster {
+	return roster{
+		invRepo:    repos.Invitations,
+		playerRepo: repos.Players,
+		gameRepo:   repos.Games,
+		outboxRepo: repos.Outbox,
+		cards:      newCardGenerator(repos),
+		tx:         tx,
+	}
+}
@@ -0,0 +1,305 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitationService_RemovePlayer(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.MaxPlayers = 1
+	testInv.PlayerCount = 1
+	testInv.WaitlistCount = 2
+	joined := MustMakeTestPlayer(t, testInv)
+	for i := 0; i < 2; i++ {
+		joined.Cards = append(joined.Cards, bingo.Card{ID: requiretest.UUIDv4(t), GameID: testGame.ID, PlayerID: joined.ID, Number: i + 1})
+	}
+	removed := MustMakeTestPlayer(t, testInv)
+	removed.Status = bingo.PlayerStatusRemoved
+	first := MustMakeWaitlistedPlayer(t, testInv, 1, 2)
+	second := MustMakeWaitlistedPlayer(t, testInv, 2, 1)
+
+	cases := []struct {
+		caseName      string
+		actorId       string
+		player        *bingo.Player
+		expectRemove  bool
+		expectPromote bool
+		expectedErr   error
+	}{
+		{
+			caseName:      "joined player makes room for first in line",
+			actorId:       testGame.HostId,
+			player:        joined,
+			expectRemove:  true,
+			expectPromote: true,
+		},
+		{
+			caseName:     "waitlisted player leaves the line",
+			actorId:      testGame.HostId,
+			player:       second,
+			expectRemove: true,
+		},
+		{
+			caseName:    "already removed",
+			actorId:     testGame.HostId,
+			player:      removed,
+			expectedErr: bingo.ErrPlayerRemoved,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			player:      joined,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+
+			inv := MustCopyInvitation(t, testInv)
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.player))
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *inv))
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			if tc.expectRemove {
+				mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *tc.player))
+				mocks.invRepo.ExpectRelease(MakeInvitationReleaseHandler(t))
+			}
+			// Cards of removed players are revoked, so they can no longer win, and released on the invitation
+			if tc.expectRemove && len(tc.player.Cards) > 0 {
+				mocks.cardRepo.ExpectSaveAll(func(_ context.Context, cards []bingo.Card) error {
+					require.Len(t, cards, len(tc.player.Cards), "all cards of the player must be saved")
+					for _, c := range cards {
+						require.True(t, c.Void(), "cards of removed players must be void")
+					}
+					return nil
+				})
+				mocks.invRepo.ExpectReleaseCards(func(_ context.Context, _ *bingo.Invitation, cardAmount int) error {
+					require.Equal(t, len(tc.player.Cards), cardAmount, "revoked cards must be released on the invitation")
+					return nil
+				})
+			}
+			if tc.expectRemove {
+				mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+					require.Equal(t, bingo.PlayerStatusRemoved, p.Status, "player must be saved as removed")
+					return nil
+				})
+			}
+			// Removing players always attempts promoting the first in line, who only fits when a joined player left
+			var promoted []string
+			if tc.expectRemove {
+				mocks.playerRepo.ExpectFind(func(_ context.Context, filter bingo.PlayerFilter) ([]bingo.Player, error) {
+					require.Equal(t, bingo.PlayerStatusWaitlisted, filter.Status, "players on the waitlist must be found")
+					require.Equal(t, testInv.ID, filter.InvitationID, "players waiting for the invitation must be found")
+					return []bingo.Player{*first, *second}, nil
+				})
+				mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *first))
+			}
+			if tc.expectRemove && !tc.expectPromote {
+				mocks.invRepo.ExpectReserveWaitlisted(func(_ context.Context, _ *bingo.Invitation, _ int) error {
+					return bingo.ErrInvitationFull
+				})
+			}
+			if tc.expectPromote {
+				mocks.invRepo.ExpectReserveWaitlisted(func(_ context.Context, inv *bingo.Invitation, cardAmount int) error {
+					require.Equal(t, first.RequestedCards, cardAmount, "cards waited for must be reserved")
+					return nil
+				})
+				mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+				mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+					promoted = append(promoted, p.ID)
+					return nil
+				})
+				mocks.cardRepo.ExpectSaveAll(func(_ context.Context, cards []bingo.Card) error {
+					require.Len(t, cards, first.RequestedCards, "cards waited for must be generated")
+					return nil
+				})
+				mocks.gameRepo.ExpectAllocateCardNumbers(MakeCardNumbersAllocateHandler(t, *testGame))
+
+				// Second in line does not fit
+				mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *second))
+				mocks.invRepo.ExpectReserveWaitlisted(func(_ context.Context, _ *bingo.Invitation, _ int) error {
+					return bingo.ErrInvitationFull
+				})
+			}
+
+			p, err := invSvc.RemovePlayer(NewActorContext(t, tc.actorId), tc.player.ID)
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.PlayerStatusRemoved, p.Status, "player must be removed")
+			require.Zero(t, p.WaitlistPosition, "removed player must not be in line")
+			if tc.expectPromote {
+				require.Equal(t, []string{first.ID}, promoted, "only the first in line must be promoted")
+			} else {
+				require.Empty(t, promoted, "no players must be promoted without room")
+			}
+		})
+	}
+}
+
+func TestInvitationService_SetLimits(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.DeliveryMethod = bingo.InvitationDeliveryMethodMail
+	testInv.MaxPlayers = 1
+	testInv.PlayerCount = 1
+	testInv.WaitlistCount = 2
+	first := MustMakeWaitlistedPlayer(t, testInv, 1, 1)
+	second := MustMakeWaitlistedPlayer(t, testInv, 2, 3)
+
+	// First in line was promoted concurrently, which the promotion must not do again
+	promotedFirst := MustCopyPlayer(t, first)
+	promotedFirst.Status = bingo.PlayerStatusJoined
+
+	cases := []struct {
+		caseName     string
+		actorId      string
+		limits       bingo.InvitationLimits
+		firstInLine  *bingo.Player
+		expectValErr bool
+		expectedErr  error
+	}{
+		{
+			caseName:    "raised capacity promotes in line",
+			actorId:     testGame.HostId,
+			limits:      bingo.InvitationLimits{MaxPlayers: 3},
+			firstInLine: first,
+		},
+		{
+			caseName:    "skips players promoted concurrently",
+			actorId:     testGame.HostId,
+			limits:      bingo.InvitationLimits{MaxPlayers: 3},
+			firstInLine: promotedFirst,
+		},
+		{
+			caseName:     "negative max total cards",
+			actorId:      testGame.HostId,
+			limits:       bingo.InvitationLimits{MaxTotalCards: -1},
+			expectValErr: true,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			limits:      bingo.InvitationLimits{MaxPlayers: 3},
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			invSvc, mocks := MustCreateInvitationService(t)
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.gameRepo.RequireExpectationsMet()
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+			defer mocks.outboxRepo.RequireExpectationsMet()
+
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *MustCopyInvitation(t, testInv)))
+			mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *testGame))
+			var promoted []string
+			var queued []string
+			if tc.firstInLine != nil {
+				mocks.invRepo.ExpectSave(func(_ context.Context, inv *bingo.Invitation) error {
+					require.Equal(t, tc.limits.MaxPlayers, inv.MaxPlayers, "invitation must be saved with the limits")
+					return nil
+				})
+				mocks.playerRepo.ExpectFind(func(_ context.Context, _ bingo.PlayerFilter) ([]bingo.Player, error) {
+					return []bingo.Player{*first, *second}, nil
+				})
+
+				// Players are promoted in line, and get their cards through the outbox of mail invitations
+				for _, p := range []*bingo.Player{tc.firstInLine, second} {
+					mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *p))
+					if p.Status != bingo.PlayerStatusWaitlisted {
+						continue
+					}
+					mocks.invRepo.ExpectReserveWaitlisted(func(_ context.Context, inv *bingo.Invitation, _ int) error {
+						inv.WaitlistCount--
+						return nil
+					})
+					mocks.gameRepo.ExpectGet(MakeSingleGameGetHandler(t, *MustCopyGame(t, testGame)))
+					mocks.playerRepo.ExpectSave(func(_ context.Context, p *bingo.Player) error {
+						require.Equal(t, bingo.PlayerStatusJoined, p.Status, "promoted player must have joined")
+						require.Equal(t, bingo.DeliveryStatusPending, p.DeliveryStatus, "cards of promoted player must await delivery")
+						promoted = append(promoted, p.ID)
+						return nil
+					})
+					mocks.cardRepo.ExpectSaveAll(func(_ context.Context, _ []bingo.Card) error { return nil })
+					mocks.gameRepo.ExpectAllocateCardNumbers(MakeCardNumbersAllocateHandler(t, *testGame))
+					mocks.outboxRepo.ExpectSave(func(_ context.Context, msg *bingo.OutboxMessage) error {
+						queued = append(queued, msg.PlayerID)
+						return nil
+					})
+				}
+			}
+
+			inv, err := invSvc.SetLimits(NewActorContext(t, tc.actorId), testInv.ID, tc.limits)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, inv, "invitation must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			expected := []string{second.ID}
+			if tc.firstInLine.Status == bingo.PlayerStatusWaitlisted {
+				expected = []string{first.ID, second.ID}
+			}
+			require.Equal(t, expected, promoted, "players must be promoted in line")
+			require.Equal(t, expected, queued, "cards of promoted players must be queued for delivery")
+		})
+	}
+}
+
+func MakeInvitationReleaseHandler(tb testing.TB) func(context.Context, *bingo.Invitation, *bingo.Player) error {
+	tb.Helper()
+
+	return func(_ context.Context, inv *bingo.Invitation, p *bingo.Player) error {
+		if p.Status == bingo.PlayerStatusWaitlisted {
+			inv.WaitlistCount--
+		} else {
+			inv.PlayerCount--
+		}
+		return nil
+	}
+}
+
+func MustMakeWaitlistedPlayer(tb testing.TB, inv *bingo.Invitation, position, cardAmount int) *bingo.Player {
+	tb.Helper()
+
+	p := MustMakeTestPlayer(tb, inv)
+	p.Status = bingo.PlayerStatusWaitlisted
+	p.WaitlistPosition = position
+	p.RequestedCards = cardAmount
+	return p
+}
+
+func MustCopyPlayer(tb testing.TB, p *bingo.Player) *bingo.Player {
+	tb.Helper()
+
+	cp := *p
+	return &cp
+}
@@ -0,0 +1,52 @@
+package bingo
+
+import (
+	"context"
+)
+
+// Withdraw the player from the game by their own session, optionally giving a reason. Their cards are revoked, so
+// they can no longer win, and their place and cards on the invitation are released for the players on the waitlist.
+func (ps *PlayerService) Withdraw(ctx context.Context, playerId string, reason string) (*Player, error) {
+	p, err := ps.getWithInvitation(ctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+	if sp := PlayerFromContext(ctx); sp == nil || sp.ID != p.ID {
+		return nil, ErrForbidden
+	}
+	if p.Status == PlayerStatusRemoved {
+		return nil, ErrPlayerRemoved
+	}
+
+	return ps.revoke(ctx, p, NewCardRevocation(ctx, reason))
+}
+
+// Revoke the cards of the player for the reason given, and remove them from the game. The cards are void, so they can
+// no longer win, and the place and cards of the player on the invitation are released for the players on the
+// waitlist. Revoking the cards of players already removed voids their cards only. Only members allowed to manage the
+// game can revoke cards.
+func (ps *PlayerService) RevokeCards(ctx context.Context, playerId string, reason string) (*Player, error) {
+	p, err := ps.getWithInvitation(ctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.authz.authorize(ctx, p.Invitation.Game, PermissionManageGame); err != nil {
+		return nil, err
+	}
+
+	rev := NewCardRevocation(ctx, reason)
+	if rev.Reason == "" {
+		return nil, ErrCardRevocationValidation.withFieldErr("Reason", "empty", "reason has to have a value")
+	}
+
+	return ps.revoke(ctx, p, rev)
+}
+
+// Revoke the cards of the player with the invitation and game present, and remove them from the game.
+func (ps *PlayerService) revoke(ctx context.Context, p *Player, rev CardRevocation) (*Player, error) {
+	if err := rev.Validate(); err != nil {
+		return nil, err
+	}
+
+	return ps.roster.remove(ctx, p.Invitation, p.ID, rev)
+}
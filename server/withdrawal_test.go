@@ -0,0 +1,252 @@
+package bingo_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	bingo "github.com/nohns/bingo-box/server"
+	"github.com/nohns/bingo-box/server/requiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayerService_Withdraw(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.Game = testGame
+	testPlayer := MustMakePlayerWithCards(t, testGame, testInv, 2)
+	waitlisted := MustMakeWaitlistedPlayer(t, testInv, 1, 2)
+	removed := MustMakeTestPlayer(t, testInv)
+	removed.Status = bingo.PlayerStatusRemoved
+
+	cases := []struct {
+		caseName      string
+		ctx           context.Context
+		player        *bingo.Player
+		expectRevoked int
+		expectedErr   error
+	}{
+		{
+			caseName:      "success",
+			ctx:           bingo.NewContextWithPlayer(context.Background(), testPlayer),
+			player:        testPlayer,
+			expectRevoked: 2,
+		},
+		{
+			caseName: "success from the waitlist",
+			ctx:      bingo.NewContextWithPlayer(context.Background(), waitlisted),
+			player:   waitlisted,
+		},
+		{
+			caseName:    "player removed",
+			ctx:         bingo.NewContextWithPlayer(context.Background(), removed),
+			player:      removed,
+			expectedErr: bingo.ErrPlayerRemoved,
+		},
+		{
+			caseName:    "forbidden other player",
+			ctx:         bingo.NewContextWithPlayer(context.Background(), waitlisted),
+			player:      testPlayer,
+			expectedErr: bingo.ErrForbidden,
+		},
+		{
+			caseName:    "forbidden host",
+			ctx:         NewActorContext(t, testGame.HostId),
+			player:      testPlayer,
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *MustCopyPlayerWithCards(t, tc.player)))
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			if tc.expectedErr == nil {
+				ExpectPlayerRevoked(t, mocks, tc.player, tc.expectRevoked)
+			}
+
+			p, err := playerSvc.Withdraw(tc.ctx, tc.player.ID, " Can not make it ")
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.PlayerStatusRemoved, p.Status, "player must be removed")
+			for _, c := range p.Cards {
+				require.True(t, c.Void(), "cards of player must be void")
+				require.Empty(t, c.Revocation.RevokedBy, "cards of withdrawn players are revoked by no user")
+				require.Equal(t, "Can not make it", c.Revocation.Reason, "reason of the player must be recorded")
+			}
+		})
+	}
+}
+
+func TestPlayerService_RevokeCards(t *testing.T) {
+
+	testGame := MustMakeTestGame(t)
+	testInv := MustMakeTestInvitation(t, testGame)
+	testInv.Game = testGame
+	testPlayer := MustMakePlayerWithCards(t, testGame, testInv, 3)
+	removed := MustMakePlayerWithCards(t, testGame, testInv, 2)
+	removed.Status = bingo.PlayerStatusRemoved
+	partlyVoid := MustMakePlayerWithCards(t, testGame, testInv, 2)
+	partlyVoid.Cards[0].Revocation = &bingo.CardRevocation{Reason: "misprint"}
+
+	cases := []struct {
+		caseName      string
+		actorId       string
+		player        *bingo.Player
+		reason        string
+		expectRevoked int
+		expectValErr  bool
+		expectedErr   error
+	}{
+		{
+			caseName:      "success",
+			actorId:       testGame.HostId,
+			player:        testPlayer,
+			reason:        "paid with a fake note",
+			expectRevoked: 3,
+		},
+		{
+			caseName:      "success removed player",
+			actorId:       testGame.HostId,
+			player:        removed,
+			reason:        "paid with a fake note",
+			expectRevoked: 2,
+		},
+		{
+			caseName:      "keeps earlier revocations",
+			actorId:       testGame.HostId,
+			player:        partlyVoid,
+			reason:        "paid with a fake note",
+			expectRevoked: 1,
+		},
+		{
+			caseName:     "missing reason",
+			actorId:      testGame.HostId,
+			player:       testPlayer,
+			reason:       " ",
+			expectValErr: true,
+		},
+		{
+			caseName:     "too long reason",
+			actorId:      testGame.HostId,
+			player:       testPlayer,
+			reason:       strings.Repeat("a", bingo.CardRevocationReasonMaxLength+1),
+			expectValErr: true,
+		},
+		{
+			caseName:    "forbidden other host",
+			actorId:     requiretest.UUIDv4(t),
+			player:      testPlayer,
+			reason:      "paid with a fake note",
+			expectedErr: bingo.ErrForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			playerSvc, mocks := MustCreatePlayerService(t)
+			defer mocks.playerRepo.RequireExpectationsMet()
+			defer mocks.invRepo.RequireExpectationsMet()
+			defer mocks.cardRepo.RequireExpectationsMet()
+
+			mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(t, *MustCopyPlayerWithCards(t, tc.player)))
+			mocks.invRepo.ExpectGet(MakeSingleInvitationGetHandler(t, *testInv))
+			if tc.expectedErr == nil && !tc.expectValErr {
+				ExpectPlayerRevoked(t, mocks, tc.player, tc.expectRevoked)
+			}
+
+			p, err := playerSvc.RevokeCards(NewActorContext(t, tc.actorId), tc.player.ID, tc.reason)
+			if tc.expectValErr {
+				var valErr bingo.ValidationErr
+				require.True(t, errors.As(err, &valErr), "error must be a validation error")
+				return
+			}
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr, "error must be of expected error kind")
+				require.Nil(t, p, "player must be nil when error is expected")
+				return
+			}
+
+			require.NoError(t, err, "no error is expected")
+			require.Equal(t, bingo.PlayerStatusRemoved, p.Status, "player must be removed")
+			revokedByHost := 0
+			for _, c := range p.Cards {
+				require.True(t, c.Void(), "cards of player must be void")
+				if c.Revocation.RevokedBy == tc.actorId {
+					require.Equal(t, tc.reason, c.Revocation.Reason, "reason of the host must be recorded")
+					revokedByHost++
+				}
+			}
+			require.Equal(t, tc.expectRevoked, revokedByHost, "only cards not void already must be revoked")
+		})
+	}
+}
+
+// Expect the player to be read again, released from the invitation unless removed already, have the amount of cards
+// revoked, and be saved as removed
+func ExpectPlayerRevoked(tb testing.TB, mocks *playerServiceMocks, p *bingo.Player, revoked int) {
+	tb.Helper()
+
+	mocks.playerRepo.ExpectGet(MakeSinglePlayerGetHandler(tb, *MustCopyPlayerWithCards(tb, p)))
+	if p.Status != bingo.PlayerStatusRemoved {
+		mocks.invRepo.ExpectRelease(func(_ context.Context, _ *bingo.Invitation, released *bingo.Player) error {
+			require.Equal(tb, p.Status, released.Status, "player must be released by the status they had")
+			return nil
+		})
+	}
+	if revoked > 0 {
+		mocks.cardRepo.ExpectSaveAll(func(_ context.Context, cards []bingo.Card) error {
+			for _, c := range cards {
+				require.True(tb, c.Void(), "saved cards must be void")
+			}
+			return nil
+		})
+		mocks.invRepo.ExpectReleaseCards(func(_ context.Context, _ *bingo.Invitation, cardAmount int) error {
+			require.Equal(tb, revoked, cardAmount, "room of the revoked cards must be released")
+			return nil
+		})
+	}
+	mocks.playerRepo.ExpectSave(func(_ context.Context, saved *bingo.Player) error {
+		require.Equal(tb, bingo.PlayerStatusRemoved, saved.Status, "player must be saved as removed")
+		for _, c := range saved.Cards {
+			require.True(tb, c.Void(), "cards of saved player must be void")
+		}
+		return nil
+	})
+}
+
+func MustMakePlayerWithCards(tb testing.TB, g *bingo.Game, inv *bingo.Invitation, cardAmount int) *bingo.Player {
+	tb.Helper()
+
+	p := MustMakeTestPlayer(tb, inv)
+	for i := 0; i < cardAmount; i++ {
+		c := g.CreateRandomCard(rand.NewSource(int64(i)), i+1)
+		c.ID = requiretest.UUIDv4(tb)
+		c.PlayerID = p.ID
+		p.Cards = append(p.Cards, *c)
+	}
+	return p
+}
+
+// Copy the player along with their cards, so revoking the cards of the copy leaves the original as is
+func MustCopyPlayerWithCards(tb testing.TB, p *bingo.Player) *bingo.Player {
+	tb.Helper()
+
+	cp := MustCopyPlayer(tb, p)
+	cp.Cards = make([]bingo.Card, len(p.Cards))
+	copy(cp.Cards, p.Cards)
+	return cp
+}